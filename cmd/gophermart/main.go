@@ -40,6 +40,66 @@ func main() {
 				WriteTimeout:    cfg.HTTP.WriteTimeout,
 				IdleTimeout:     cfg.HTTP.IdleTimeout,
 				ShutdownTimeout: cfg.HTTP.ShutdownTimeout,
+				TrustedProxies:  cfg.HTTP.TrustedProxies,
+			},
+			Auth: app.Auth{
+				AccessTokenTTL:  cfg.Auth.AccessTokenTTL,
+				RefreshTokenTTL: cfg.Auth.RefreshTokenTTL,
+				KeysDir:         cfg.Auth.KeysDir,
+				PrivateKeyFile:  cfg.Auth.PrivateKeyFile,
+				SigningKeyID:    cfg.Auth.SigningKeyID,
+				RevocationCache: cfg.Auth.RevocationCache,
+				PasswordReset:   cfg.Auth.PasswordReset,
+				MFAChallenge:    cfg.Auth.MFAChallenge,
+				TOTPIssuer:      cfg.Auth.TOTPIssuer,
+				Cookie: app.Cookie{
+					Enabled:  cfg.Auth.CookieMode,
+					Domain:   cfg.Auth.CookieDomain,
+					Secure:   cfg.Auth.CookieSecure,
+					SameSite: cfg.Auth.CookieSameSite,
+				},
+			},
+			Password: app.Password{
+				Argon2Memory:      cfg.Password.Argon2Memory,
+				Argon2Iterations:  cfg.Password.Argon2Iterations,
+				Argon2Parallelism: cfg.Password.Argon2Parallelism,
+			},
+			Credentials: app.Credentials{
+				Login: app.CredentialField{
+					MinLength: cfg.Credentials.Login.MinLength,
+					MaxLength: cfg.Credentials.Login.MaxLength,
+					Allowed:   cfg.Credentials.Login.Allowed,
+					Symbols:   cfg.Credentials.Login.Symbols,
+					Required:  cfg.Credentials.Login.Required,
+				},
+				Password: app.CredentialField{
+					MinLength: cfg.Credentials.Password.MinLength,
+					MaxLength: cfg.Credentials.Password.MaxLength,
+					Allowed:   cfg.Credentials.Password.Allowed,
+					Symbols:   cfg.Credentials.Password.Symbols,
+					Required:  cfg.Credentials.Password.Required,
+				},
+				PasswordDenyList: cfg.Credentials.Password.DenyList,
+			},
+			Account: app.Account{
+				DeletedBalance: cfg.Account.DeletedBalance,
+			},
+			Idempotency: app.Idempotency{
+				TTL: cfg.Idempotency.TTL,
+			},
+			Points: app.Points{
+				ExpiryMonths: cfg.Points.ExpiryMonths,
+				ExpiringSoon: cfg.Points.ExpiringSoon,
+			},
+			LoginLimit: app.LoginLimit{
+				Store:          cfg.LoginLimit.Store,
+				FreeAttempts:   cfg.LoginLimit.FreeAttempts,
+				MaxFailures:    cfg.LoginLimit.MaxFailures,
+				IPFreeAttempts: cfg.LoginLimit.IPFreeAttempts,
+				IPMaxFailures:  cfg.LoginLimit.IPMaxFailures,
+				BaseDelay:      cfg.LoginLimit.BaseDelay,
+				MaxDelay:       cfg.LoginLimit.MaxDelay,
+				Lockout:        cfg.LoginLimit.Lockout,
 			},
 			Clients: app.Clients{
 				Accrual: app.AccrualSystem{
@@ -48,6 +108,10 @@ func main() {
 					RetryWaitTime: cfg.Clients.AccrualSystem.RetryWaitTime,
 					ReadTimeout:   cfg.Clients.AccrualSystem.ReadTimeout,
 				},
+				Notifier: app.Notifier{
+					Type: cfg.Notifier.Type,
+					File: cfg.Notifier.File,
+				},
 			},
 			Storages: app.Storages{
 				Postgres: app.PostgresStorage{
@@ -61,6 +125,19 @@ func main() {
 					ReadLimit:    cfg.Workers.UpdateOrders.ReadLimit,
 					WorkersLimit: cfg.Workers.UpdateOrders.WorkersLimit,
 				},
+				ReconcileBalances: app.WorkerReconcileBalances{
+					Interval: cfg.Workers.ReconcileBalances.Interval,
+					Timeout:  cfg.Workers.ReconcileBalances.Timeout,
+					Correct:  cfg.Workers.ReconcileBalances.Correct,
+				},
+				ExpirePoints: app.WorkerExpirePoints{
+					Interval: cfg.Workers.ExpirePoints.Interval,
+					Timeout:  cfg.Workers.ExpirePoints.Timeout,
+				},
+				PurgeIdempotency: app.WorkerPurgeIdempotency{
+					Interval: cfg.Workers.PurgeIdempotency.Interval,
+					Timeout:  cfg.Workers.PurgeIdempotency.Timeout,
+				},
 			},
 		},
 	).Run(ctx); err != nil {
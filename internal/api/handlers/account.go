@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/render"
+
+	"github.com/vladislav-kr/gophermart/internal/domain/models"
+	"github.com/vladislav-kr/gophermart/internal/domain/response"
+)
+
+// выгрузка всех данных пользователя одним JSON файлом
+func (h *Handlers) ExportUserData(w http.ResponseWriter, r *http.Request) error {
+	userID, _ := userIDFromContext(r.Context())
+
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second*10)
+	defer cancel()
+
+	export, err := h.service.ExportUserData(ctx, models.UserID(userID))
+	if err != nil {
+		render.Status(r, http.StatusInternalServerError)
+		render.JSON(w, r, response.Error("внутренняя ошибка сервера"))
+		return fmt.Errorf("export user data: %w", err)
+	}
+
+	w.Header().Set("Content-Disposition", `attachment; filename="gophermart-export.json"`)
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, export)
+	return nil
+}
+
+// удаление учетной записи, подтверждается паролем
+func (h *Handlers) DeleteAccount(w http.ResponseWriter, r *http.Request) error {
+	userID, _ := userIDFromContext(r.Context())
+
+	req := models.AccountDeletion{}
+	if err := render.DecodeJSON(r.Body, &req); err != nil {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, response.Error("неверный формат запроса"))
+		return fmt.Errorf("decode JSON: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second*4)
+	defer cancel()
+
+	if err := h.service.DeleteAccount(ctx, models.UserID(userID), req); err != nil {
+		var tooMany *models.TooManyAttemptsError
+		switch {
+		case errors.As(err, &tooMany):
+			setRetryAfter(w, tooMany.RetryAfter)
+			render.Status(r, http.StatusTooManyRequests)
+			render.JSON(w, r, response.Error("слишком много попыток"))
+		case errors.Is(err, models.ErrIncorrectPassword):
+			render.Status(r, http.StatusForbidden)
+			render.JSON(w, r, response.Error("неверный пароль"))
+		case errors.Is(err, models.ErrUserBlocked),
+			errors.Is(err, models.ErrUserDeleted):
+			render.Status(r, http.StatusForbidden)
+			render.JSON(w, r, response.Error("доступ запрещен"))
+		default:
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("внутренняя ошибка сервера"))
+		}
+		return fmt.Errorf("delete account: %w", err)
+	}
+	h.clearTokens(w)
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, response.OK())
+	return nil
+}
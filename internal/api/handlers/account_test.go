@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/vladislav-kr/gophermart/internal/api/handlers/mocks"
+	"github.com/vladislav-kr/gophermart/internal/domain/models"
+)
+
+func TestHandlers_ExportUserData(t *testing.T) {
+	srv := mocks.NewService(t)
+	handlers := NewHandlers(srv, nil)
+
+	userID := uuid.NewString()
+
+	srv.On("ExportUserData", mock.AnythingOfType("*context.timerCtx"), models.UserID(userID)).
+		Return(&models.UserExport{Profile: models.User{UserID: models.UserID(userID)}}, nil).Once()
+
+	rr := httptest.NewRecorder()
+	req, err := http.NewRequestWithContext(contextWithToken(t, userID), http.MethodGet, "/", nil)
+	require.NoError(t, err)
+
+	handlers.ExportUserData(rr, req)
+
+	result := rr.Result()
+	defer result.Body.Close()
+	assert.Equal(t, http.StatusOK, result.StatusCode)
+	assert.Contains(t, result.Header.Get("Content-Disposition"), "attachment")
+}
+
+func TestHandlers_DeleteAccount(t *testing.T) {
+	srv := mocks.NewService(t)
+	handlers := NewHandlers(srv, nil)
+
+	tests := []struct {
+		name           string
+		body           string
+		callMock       bool
+		err            error
+		expectedStatus int
+	}{
+		{
+			name:           "некорректный json",
+			body:           `{"password": "secret"`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "учетная запись удалена",
+			body:           `{"password": "secret"}`,
+			callMock:       true,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "неверный пароль",
+			body:           `{"password": "secret"}`,
+			callMock:       true,
+			err:            models.ErrIncorrectPassword,
+			expectedStatus: http.StatusForbidden,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			userID := uuid.NewString()
+
+			rr := httptest.NewRecorder()
+			req, err := http.NewRequestWithContext(
+				contextWithToken(t, userID),
+				http.MethodDelete,
+				"/",
+				strings.NewReader(tt.body),
+			)
+			require.NoError(t, err)
+
+			if tt.callMock {
+				srv.On("DeleteAccount",
+					mock.AnythingOfType("*context.timerCtx"),
+					models.UserID(userID),
+					models.AccountDeletion{Password: "secret"},
+				).Return(tt.err)
+			}
+
+			handlers.DeleteAccount(rr, req)
+
+			result := rr.Result()
+			defer result.Body.Close()
+			assert.Equal(t, tt.expectedStatus, result.StatusCode)
+		})
+	}
+}
@@ -0,0 +1,299 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+
+	"github.com/vladislav-kr/gophermart/internal/domain/models"
+	"github.com/vladislav-kr/gophermart/internal/domain/response"
+)
+
+// поиск пользователей по части логина
+func (h *Handlers) AdminSearchUsers(w http.ResponseWriter, r *http.Request) error {
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second*4)
+	defer cancel()
+	users, err := h.service.SearchUsers(ctx, r.URL.Query().Get("login"))
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrNoRecordsFound):
+			render.Status(r, http.StatusNoContent)
+			render.JSON(w, r, response.OK())
+			return nil
+		default:
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("внутренняя ошибка сервера"))
+			return fmt.Errorf("admin search users: %w", err)
+		}
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, users)
+	return nil
+}
+
+// данные пользователя
+func (h *Handlers) AdminUser(w http.ResponseWriter, r *http.Request) error {
+	userID, ok := userIDFromURL(w, r)
+	if !ok {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second*4)
+	defer cancel()
+	user, err := h.service.UserByID(ctx, userID)
+	if err != nil {
+		return adminError(w, r, err, "admin user")
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, user)
+	return nil
+}
+
+// заказы пользователя
+func (h *Handlers) AdminUserOrders(w http.ResponseWriter, r *http.Request) error {
+	userID, ok := userIDFromURL(w, r)
+	if !ok {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second*4)
+	defer cancel()
+	orders, err := h.service.OrdersByUserID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecordsFound) {
+			render.Status(r, http.StatusNoContent)
+			render.JSON(w, r, response.OK())
+			return nil
+		}
+		return adminError(w, r, err, "admin user orders")
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, orders)
+	return nil
+}
+
+// баланс пользователя, с параметром at - пересчет по журналу баллов на момент времени
+func (h *Handlers) AdminUserBalance(w http.ResponseWriter, r *http.Request) error {
+	userID, ok := userIDFromURL(w, r)
+	if !ok {
+		return nil
+	}
+
+	var at time.Time
+	if value := r.URL.Query().Get("at"); value != "" {
+		var err error
+		if at, err = time.Parse(time.RFC3339, value); err != nil {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Validation("неверные параметры запроса", &models.ValidationError{
+				Fields: []models.FieldError{{
+					Field:   "at",
+					Code:    models.CodeInvalidValue,
+					Message: "ожидается время RFC 3339",
+				}},
+			}))
+			return nil
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second*4)
+	defer cancel()
+
+	var (
+		balance *models.Balance
+		err     error
+	)
+	if at.IsZero() {
+		balance, err = h.service.UserBalance(ctx, userID)
+	} else {
+		balance, err = h.service.BalanceAt(ctx, userID, at)
+	}
+	if err != nil {
+		return adminError(w, r, err, "admin user balance")
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, balance)
+	return nil
+}
+
+// списания пользователя
+func (h *Handlers) AdminUserWithdrawals(w http.ResponseWriter, r *http.Request) error {
+	userID, ok := userIDFromURL(w, r)
+	if !ok {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second*4)
+	defer cancel()
+	withdrawals, err := h.service.WithdrawalsByUserID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecordsFound) {
+			render.Status(r, http.StatusNoContent)
+			render.JSON(w, r, response.OK())
+			return nil
+		}
+		return adminError(w, r, err, "admin user withdrawals")
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, withdrawals)
+	return nil
+}
+
+// блокировка пользователя
+func (h *Handlers) AdminBlockUser(w http.ResponseWriter, r *http.Request) error {
+	return h.setUserBlocked(w, r, true)
+}
+
+// разблокировка пользователя
+func (h *Handlers) AdminUnblockUser(w http.ResponseWriter, r *http.Request) error {
+	return h.setUserBlocked(w, r, false)
+}
+
+func (h *Handlers) setUserBlocked(w http.ResponseWriter, r *http.Request, blocked bool) error {
+	userID, ok := userIDFromURL(w, r)
+	if !ok {
+		return nil
+	}
+	adminID, _ := userIDFromContext(r.Context())
+
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second*4)
+	defer cancel()
+	if err := h.service.SetUserBlocked(ctx, models.UserID(adminID), userID, blocked); err != nil {
+		return adminError(w, r, err, "admin set user blocked")
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, response.OK())
+	return nil
+}
+
+// повторный расчёт заказа
+func (h *Handlers) AdminRecheckOrder(w http.ResponseWriter, r *http.Request) error {
+	adminID, _ := userIDFromContext(r.Context())
+
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second*4)
+	defer cancel()
+	err := h.service.RecheckOrder(ctx, models.UserID(adminID), models.OrderID(chi.URLParam(r, "number")))
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrIncorrectOrderNumber):
+			render.Status(r, http.StatusUnprocessableEntity)
+			render.JSON(w, r, response.Error("неверный номер заказа"))
+			return nil
+		case errors.Is(err, models.ErrInsufficientFunds):
+			render.Status(r, http.StatusConflict)
+			render.JSON(w, r, response.Error("начисленные за заказ баллы уже потрачены"))
+			return nil
+		default:
+			return adminError(w, r, err, "admin recheck order")
+		}
+	}
+
+	render.Status(r, http.StatusAccepted)
+	render.JSON(w, r, response.OK())
+	return nil
+}
+
+// ручная корректировка баланса пользователя
+func (h *Handlers) AdminAdjustBalance(w http.ResponseWriter, r *http.Request) error {
+	userID, ok := userIDFromURL(w, r)
+	if !ok {
+		return nil
+	}
+	adminID, _ := userIDFromContext(r.Context())
+
+	adjustment := models.BalanceAdjustment{}
+	if err := render.DecodeJSON(r.Body, &adjustment); err != nil {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, response.Error("неверный формат запроса"))
+		return fmt.Errorf("decode JSON: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second*4)
+	defer cancel()
+	balance, err := h.service.AdjustBalance(ctx, models.UserID(adminID), userID, adjustment)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrInvalidAdjustment):
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Error("неверные параметры корректировки"))
+			return nil
+		case errors.Is(err, models.ErrInsufficientFunds):
+			render.Status(r, http.StatusConflict)
+			render.JSON(w, r, response.Error("на счету недостаточно средств"))
+			return nil
+		default:
+			return adminError(w, r, err, "admin adjust balance")
+		}
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, balance)
+	return nil
+}
+
+// сверка проходит по всем балансам и дольше обычного запроса
+const reconcileTimeout = time.Second * 20
+
+// отчет о расхождениях балансов с заказами и списаниями без исправления
+func (h *Handlers) AdminBalanceDrifts(w http.ResponseWriter, r *http.Request) error {
+	return h.reconcileBalances(w, r, false)
+}
+
+// сверка балансов с исправлением расхождений аудируемой корректировкой
+func (h *Handlers) AdminReconcileBalances(w http.ResponseWriter, r *http.Request) error {
+	return h.reconcileBalances(w, r, true)
+}
+
+func (h *Handlers) reconcileBalances(w http.ResponseWriter, r *http.Request, correct bool) error {
+	adminID, _ := userIDFromContext(r.Context())
+
+	ctx, cancel := context.WithTimeout(r.Context(), reconcileTimeout)
+	defer cancel()
+	report, err := h.service.ReconcileBalances(ctx, models.UserID(adminID), correct)
+	if err != nil {
+		return adminError(w, r, err, "admin reconcile balances")
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, report)
+	return nil
+}
+
+// userID из пути запроса, при неверном значении отвечает 400
+func userIDFromURL(w http.ResponseWriter, r *http.Request) (models.UserID, bool) {
+	userID := models.UserID(chi.URLParam(r, "userID"))
+	if !userID.Validate() {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, response.Error("неверный идентификатор пользователя"))
+		return "", false
+	}
+	return userID, true
+}
+
+// общие ответы административных обработчиков
+func adminError(w http.ResponseWriter, r *http.Request, err error, op string) error {
+	switch {
+	case errors.Is(err, models.ErrUserIDMandatory):
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, response.Error("неверный идентификатор пользователя"))
+		return nil
+	case errors.Is(err, models.ErrNoRecordsFound):
+		render.Status(r, http.StatusNotFound)
+		render.JSON(w, r, response.Error("не найдено"))
+		return nil
+	default:
+		render.Status(r, http.StatusInternalServerError)
+		render.JSON(w, r, response.Error("внутренняя ошибка сервера"))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+}
@@ -0,0 +1,473 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/vladislav-kr/gophermart/internal/api/handlers/mocks"
+	"github.com/vladislav-kr/gophermart/internal/domain/models"
+	"github.com/vladislav-kr/gophermart/internal/domain/money"
+)
+
+// контекст с токеном администратора и параметром пути
+func contextWithURLParam(t *testing.T, adminID, key, value string) context.Context {
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add(key, value)
+	return context.WithValue(contextWithToken(t, adminID), chi.RouteCtxKey, rctx)
+}
+
+func TestHandlers_AdminUser(t *testing.T) {
+	srv := mocks.NewService(t)
+	handlers := NewHandlers(srv, nil)
+
+	adminID := uuid.NewString()
+
+	tests := []struct {
+		name           string
+		userID         string
+		callMock       bool
+		user           *models.User
+		err            error
+		expectedStatus int
+	}{
+		{
+			name:     "пользователь найден",
+			userID:   uuid.NewString(),
+			callMock: true,
+			user: &models.User{
+				Login: "user",
+				Role:  models.RoleUser,
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "неверный идентификатор пользователя",
+			userID:         "user",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "пользователь не найден",
+			userID:         uuid.NewString(),
+			callMock:       true,
+			err:            models.ErrNoRecordsFound,
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "внутренняя ошибка сервера",
+			userID:         uuid.NewString(),
+			callMock:       true,
+			err:            fmt.Errorf("failed to connect to the database"),
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			rr := httptest.NewRecorder()
+			req, err := http.NewRequestWithContext(
+				contextWithURLParam(t, adminID, "userID", tt.userID),
+				http.MethodGet,
+				"/",
+				nil,
+			)
+			require.NoError(t, err)
+
+			if tt.callMock {
+				srv.On("UserByID", mock.AnythingOfType("*context.timerCtx"), models.UserID(tt.userID)).
+					Return(tt.user, tt.err)
+			}
+
+			handlers.AdminUser(rr, req)
+
+			result := rr.Result()
+			defer result.Body.Close()
+			assert.Equal(t, tt.expectedStatus, result.StatusCode)
+		})
+	}
+}
+
+func TestHandlers_AdminBlockUser(t *testing.T) {
+	srv := mocks.NewService(t)
+	handlers := NewHandlers(srv, nil)
+
+	adminID := uuid.NewString()
+
+	tests := []struct {
+		name           string
+		userID         string
+		callMock       bool
+		err            error
+		expectedStatus int
+	}{
+		{
+			name:           "пользователь заблокирован",
+			userID:         uuid.NewString(),
+			callMock:       true,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "неверный идентификатор пользователя",
+			userID:         "user",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "пользователь не найден",
+			userID:         uuid.NewString(),
+			callMock:       true,
+			err:            models.ErrNoRecordsFound,
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			rr := httptest.NewRecorder()
+			req, err := http.NewRequestWithContext(
+				contextWithURLParam(t, adminID, "userID", tt.userID),
+				http.MethodPost,
+				"/",
+				nil,
+			)
+			require.NoError(t, err)
+
+			if tt.callMock {
+				srv.On("SetUserBlocked",
+					mock.AnythingOfType("*context.timerCtx"),
+					models.UserID(adminID),
+					models.UserID(tt.userID),
+					true,
+				).Return(tt.err)
+			}
+
+			handlers.AdminBlockUser(rr, req)
+
+			result := rr.Result()
+			defer result.Body.Close()
+			assert.Equal(t, tt.expectedStatus, result.StatusCode)
+		})
+	}
+}
+
+func TestHandlers_AdminRecheckOrder(t *testing.T) {
+	srv := mocks.NewService(t)
+	handlers := NewHandlers(srv, nil)
+
+	adminID := uuid.NewString()
+
+	tests := []struct {
+		name           string
+		orderID        string
+		err            error
+		expectedStatus int
+	}{
+		{
+			name:           "заказ отправлен на повторный расчёт",
+			orderID:        "2377225624",
+			expectedStatus: http.StatusAccepted,
+		},
+		{
+			name:           "неверный номер заказа",
+			orderID:        "2377225625",
+			err:            models.ErrIncorrectOrderNumber,
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:           "заказ не найден",
+			orderID:        "12345678903",
+			err:            models.ErrNoRecordsFound,
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "начисление за заказ уже потрачено",
+			orderID:        "4561261212345467",
+			err:            models.ErrInsufficientFunds,
+			expectedStatus: http.StatusConflict,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			rr := httptest.NewRecorder()
+			req, err := http.NewRequestWithContext(
+				contextWithURLParam(t, adminID, "number", tt.orderID),
+				http.MethodPost,
+				"/",
+				nil,
+			)
+			require.NoError(t, err)
+
+			srv.On("RecheckOrder",
+				mock.AnythingOfType("*context.timerCtx"),
+				models.UserID(adminID),
+				models.OrderID(tt.orderID),
+			).Return(tt.err)
+
+			handlers.AdminRecheckOrder(rr, req)
+
+			result := rr.Result()
+			defer result.Body.Close()
+			assert.Equal(t, tt.expectedStatus, result.StatusCode)
+		})
+	}
+}
+
+func TestHandlers_AdminAdjustBalance(t *testing.T) {
+	srv := mocks.NewService(t)
+	handlers := NewHandlers(srv, nil)
+
+	adminID := uuid.NewString()
+
+	tests := []struct {
+		name           string
+		userID         string
+		body           string
+		callMock       bool
+		adjustment     models.BalanceAdjustment
+		err            error
+		expectedStatus int
+	}{
+		{
+			name:     "баланс скорректирован",
+			userID:   uuid.NewString(),
+			body:     `{"amount": 100, "reason": "compensation", "comment": "задержка доставки"}`,
+			callMock: true,
+			adjustment: models.BalanceAdjustment{
+				Amount:  money.FromInt(100),
+				Reason:  models.ReasonCompensation,
+				Comment: "задержка доставки",
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "неверный формат запроса",
+			userID:         uuid.NewString(),
+			body:           `{"amount": "100"`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:     "неверные параметры корректировки",
+			userID:   uuid.NewString(),
+			body:     `{"amount": 100, "reason": "gift"}`,
+			callMock: true,
+			adjustment: models.BalanceAdjustment{
+				Amount: money.FromInt(100),
+				Reason: "gift",
+			},
+			err:            models.ErrInvalidAdjustment,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:     "на счету недостаточно средств",
+			userID:   uuid.NewString(),
+			body:     `{"amount": -100, "reason": "correction", "comment": "ошибка начисления"}`,
+			callMock: true,
+			adjustment: models.BalanceAdjustment{
+				Amount:  money.FromInt(-100),
+				Reason:  models.ReasonCorrection,
+				Comment: "ошибка начисления",
+			},
+			err:            models.ErrInsufficientFunds,
+			expectedStatus: http.StatusConflict,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			rr := httptest.NewRecorder()
+			req, err := http.NewRequestWithContext(
+				contextWithURLParam(t, adminID, "userID", tt.userID),
+				http.MethodPost,
+				"/",
+				strings.NewReader(tt.body),
+			)
+			require.NoError(t, err)
+
+			if tt.callMock {
+				srv.On("AdjustBalance",
+					mock.AnythingOfType("*context.timerCtx"),
+					models.UserID(adminID),
+					models.UserID(tt.userID),
+					tt.adjustment,
+				).Return(&models.Balance{}, tt.err)
+			}
+
+			handlers.AdminAdjustBalance(rr, req)
+
+			result := rr.Result()
+			defer result.Body.Close()
+			assert.Equal(t, tt.expectedStatus, result.StatusCode)
+		})
+	}
+}
+
+func TestHandlers_AdminUserBalance(t *testing.T) {
+	srv := mocks.NewService(t)
+	handlers := NewHandlers(srv, nil)
+
+	adminID := uuid.NewString()
+
+	tests := []struct {
+		name           string
+		userID         string
+		query          string
+		method         string
+		at             time.Time
+		err            error
+		expectedStatus int
+	}{
+		{
+			name:           "текущий баланс",
+			userID:         uuid.NewString(),
+			method:         "UserBalance",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "баланс на момент времени",
+			userID:         uuid.NewString(),
+			query:          "?at=2024-04-01T12:00:00Z",
+			method:         "BalanceAt",
+			at:             time.Date(2024, 4, 1, 12, 0, 0, 0, time.UTC),
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "неверный формат времени",
+			userID:         uuid.NewString(),
+			query:          "?at=2024-04-01",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "пользователь не найден",
+			userID:         uuid.NewString(),
+			query:          "?at=2024-04-01T12:00:00Z",
+			method:         "BalanceAt",
+			at:             time.Date(2024, 4, 1, 12, 0, 0, 0, time.UTC),
+			err:            models.ErrNoRecordsFound,
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			rr := httptest.NewRecorder()
+			req, err := http.NewRequestWithContext(
+				contextWithURLParam(t, adminID, "userID", tt.userID),
+				http.MethodGet,
+				"/"+tt.query,
+				nil,
+			)
+			require.NoError(t, err)
+
+			var balance *models.Balance
+			if tt.err == nil {
+				balance = &models.Balance{Current: money.FromInt(100)}
+			}
+
+			switch tt.method {
+			case "UserBalance":
+				srv.On("UserBalance",
+					mock.AnythingOfType("*context.timerCtx"),
+					models.UserID(tt.userID),
+				).Return(balance, tt.err)
+			case "BalanceAt":
+				srv.On("BalanceAt",
+					mock.AnythingOfType("*context.timerCtx"),
+					models.UserID(tt.userID),
+					mock.MatchedBy(tt.at.Equal),
+				).Return(balance, tt.err)
+			}
+
+			handlers.AdminUserBalance(rr, req)
+
+			result := rr.Result()
+			defer result.Body.Close()
+			assert.Equal(t, tt.expectedStatus, result.StatusCode)
+		})
+	}
+}
+
+func TestHandlers_AdminReconcileBalances(t *testing.T) {
+	adminID := uuid.NewString()
+
+	tests := []struct {
+		name           string
+		correct        bool
+		report         *models.ReconciliationReport
+		err            error
+		expectedStatus int
+	}{
+		{
+			name:    "отчет о расхождениях",
+			correct: false,
+			report: &models.ReconciliationReport{
+				Drifted: 1,
+				Users:   []models.BalanceDrift{{UserID: models.UserID(uuid.NewString())}},
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "сверка с исправлением",
+			correct:        true,
+			report:         &models.ReconciliationReport{Users: []models.BalanceDrift{}},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "ошибка сверки",
+			correct:        true,
+			err:            models.ErrInternal,
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			srv := mocks.NewService(t)
+			handlers := NewHandlers(srv, nil)
+
+			rr := httptest.NewRecorder()
+			req, err := http.NewRequestWithContext(
+				contextWithToken(t, adminID),
+				http.MethodPost,
+				"/",
+				nil,
+			)
+			require.NoError(t, err)
+
+			srv.On("ReconcileBalances",
+				mock.AnythingOfType("*context.timerCtx"),
+				models.UserID(adminID),
+				tt.correct,
+			).Return(tt.report, tt.err)
+
+			if tt.correct {
+				handlers.AdminReconcileBalances(rr, req)
+			} else {
+				handlers.AdminBalanceDrifts(rr, req)
+			}
+
+			result := rr.Result()
+			defer result.Body.Close()
+			assert.Equal(t, tt.expectedStatus, result.StatusCode)
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+
+	"github.com/vladislav-kr/gophermart/internal/domain/models"
+	"github.com/vladislav-kr/gophermart/internal/domain/response"
+)
+
+// выпуск API ключа, значение ключа возвращается только в этом ответе
+func (h *Handlers) CreateAPIKey(w http.ResponseWriter, r *http.Request) error {
+	userID, _ := userIDFromContext(r.Context())
+
+	req := models.APIKeyRequest{}
+	if err := render.DecodeJSON(r.Body, &req); err != nil {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, response.Error("неверный формат запроса"))
+		return fmt.Errorf("decode JSON: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second*4)
+	defer cancel()
+
+	key, err := h.service.CreateAPIKey(ctx, models.UserID(userID), req)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrInvalidAPIKeyRequest):
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Error(err.Error()))
+		default:
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("внутренняя ошибка сервера"))
+		}
+		return fmt.Errorf("create api key: %w", err)
+	}
+
+	render.Status(r, http.StatusCreated)
+	render.JSON(w, r, key)
+	return nil
+}
+
+// список API ключей пользователя
+func (h *Handlers) APIKeys(w http.ResponseWriter, r *http.Request) error {
+	userID, _ := userIDFromContext(r.Context())
+
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second*4)
+	defer cancel()
+
+	keys, err := h.service.APIKeys(ctx, models.UserID(userID))
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrNoRecordsFound):
+			render.Status(r, http.StatusNoContent)
+			render.JSON(w, r, response.OK())
+			return nil
+		default:
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("внутренняя ошибка сервера"))
+			return fmt.Errorf("api keys: %w", err)
+		}
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, keys)
+	return nil
+}
+
+// отзыв API ключа, действует сразу
+func (h *Handlers) RevokeAPIKey(w http.ResponseWriter, r *http.Request) error {
+	userID, _ := userIDFromContext(r.Context())
+
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second*4)
+	defer cancel()
+
+	if err := h.service.RevokeAPIKey(ctx, models.UserID(userID), chi.URLParam(r, "keyID")); err != nil {
+		switch {
+		case errors.Is(err, models.ErrNoRecordsFound):
+			render.Status(r, http.StatusNotFound)
+			render.JSON(w, r, response.Error("ключ не найден"))
+		default:
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("внутренняя ошибка сервера"))
+		}
+		return fmt.Errorf("revoke api key: %w", err)
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, response.OK())
+	return nil
+}
@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/vladislav-kr/gophermart/internal/api/handlers/mocks"
+	"github.com/vladislav-kr/gophermart/internal/domain/models"
+)
+
+func TestHandlers_CreateAPIKey(t *testing.T) {
+	srv := mocks.NewService(t)
+	handlers := NewHandlers(srv, nil)
+
+	tests := []struct {
+		name           string
+		body           string
+		callMock       bool
+		req            models.APIKeyRequest
+		err            error
+		expectedStatus int
+	}{
+		{
+			name:           "некорректный json",
+			body:           `{"name": "POS"`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:     "ключ выпущен",
+			body:     `{"name": "POS", "scopes": ["orders:upload"]}`,
+			callMock: true,
+			req: models.APIKeyRequest{
+				Name:   "POS",
+				Scopes: []models.APIKeyScope{models.ScopeOrdersUpload},
+			},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:     "неизвестная операция",
+			body:     `{"name": "POS", "scopes": ["orders:read"]}`,
+			callMock: true,
+			req: models.APIKeyRequest{
+				Name:   "POS",
+				Scopes: []models.APIKeyScope{"orders:read"},
+			},
+			err:            fmt.Errorf("unknown scope: %w", models.ErrInvalidAPIKeyRequest),
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			userID := models.UserID(uuid.NewString())
+
+			rr := httptest.NewRecorder()
+			req, err := http.NewRequestWithContext(
+				contextWithToken(t, string(userID)),
+				http.MethodPost,
+				"/",
+				strings.NewReader(tt.body),
+			)
+			require.NoError(t, err)
+
+			if tt.callMock {
+				var key *models.NewAPIKey
+				if tt.err == nil {
+					key = &models.NewAPIKey{Key: "gmk_secret"}
+				}
+				srv.On("CreateAPIKey", mock.AnythingOfType("*context.timerCtx"), userID, tt.req).
+					Return(key, tt.err)
+			}
+
+			handlers.CreateAPIKey(rr, req)
+
+			result := rr.Result()
+			defer result.Body.Close()
+			assert.Equal(t, tt.expectedStatus, result.StatusCode)
+		})
+	}
+}
+
+func TestHandlers_RevokeAPIKey(t *testing.T) {
+	srv := mocks.NewService(t)
+	handlers := NewHandlers(srv, nil)
+
+	tests := []struct {
+		name           string
+		err            error
+		expectedStatus int
+	}{
+		{
+			name:           "ключ отозван",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "ключ не найден",
+			err:            models.ErrNoRecordsFound,
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			userID := uuid.NewString()
+			keyID := uuid.NewString()
+
+			rr := httptest.NewRecorder()
+			req, err := http.NewRequestWithContext(
+				contextWithURLParam(t, userID, "keyID", keyID),
+				http.MethodDelete,
+				"/",
+				nil,
+			)
+			require.NoError(t, err)
+
+			srv.On("RevokeAPIKey", mock.AnythingOfType("*context.timerCtx"), models.UserID(userID), keyID).
+				Return(tt.err)
+
+			handlers.RevokeAPIKey(rr, req)
+
+			result := rr.Result()
+			defer result.Body.Close()
+			assert.Equal(t, tt.expectedStatus, result.StatusCode)
+		})
+	}
+}
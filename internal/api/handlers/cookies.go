@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/render"
+
+	"github.com/vladislav-kr/gophermart/internal/domain/models"
+	"github.com/vladislav-kr/gophermart/internal/domain/response"
+)
+
+const (
+	// access токен, имя ожидает jwtauth.TokenFromCookie
+	AccessTokenCookie = "jwt"
+	// refresh токен, отправляется только на /api/user
+	RefreshTokenCookie = "refresh_token"
+	// токен защиты от CSRF, доступен скрипту для передачи в CSRFHeader
+	CSRFCookie = "csrf_token"
+	CSRFHeader = "X-CSRF-Token"
+
+	refreshTokenCookiePath = "/api/user"
+)
+
+// CookieConfig режим браузерных клиентов: токены передаются в HttpOnly cookie
+// и недоступны скриптам страницы
+type CookieConfig struct {
+	Domain   string
+	Secure   bool
+	SameSite http.SameSite
+	// время жизни refresh cookie, совпадает со временем жизни refresh токена
+	RefreshTTL time.Duration
+}
+
+type Option func(*Handlers)
+
+// WithCookies включает выдачу токенов в cookie
+func WithCookies(cfg CookieConfig) Option {
+	return func(h *Handlers) {
+		h.cookies = &cfg
+	}
+}
+
+// writeTokens отдает пару токенов в заголовке и теле ответа,
+// в режиме cookie - только в cookie вместе с токеном CSRF
+func (h *Handlers) writeTokens(w http.ResponseWriter, r *http.Request, tokens *models.Tokens) error {
+	if h.cookies == nil {
+		w.Header().Set("Authorization", fmt.Sprintf("Bearer %s", tokens.AccessToken))
+		render.Status(r, http.StatusOK)
+		render.JSON(w, r, tokens)
+		return nil
+	}
+
+	csrfToken, err := newCSRFToken()
+	if err != nil {
+		render.Status(r, http.StatusInternalServerError)
+		render.JSON(w, r, response.Error("внутренняя ошибка сервера"))
+		return fmt.Errorf("csrf token generation: %w", err)
+	}
+
+	accessMaxAge := int(tokens.ExpiresIn)
+	http.SetCookie(w, h.cookie(AccessTokenCookie, tokens.AccessToken, "/", accessMaxAge, true))
+	http.SetCookie(w, h.cookie(RefreshTokenCookie, tokens.RefreshToken, refreshTokenCookiePath,
+		int(h.cookies.RefreshTTL.Seconds()), true))
+	http.SetCookie(w, h.cookie(CSRFCookie, csrfToken, "/", int(h.cookies.RefreshTTL.Seconds()), false))
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, models.Tokens{ExpiresIn: tokens.ExpiresIn})
+	return nil
+}
+
+// clearTokens удаляет cookie с токенами после выхода
+func (h *Handlers) clearTokens(w http.ResponseWriter) {
+	if h.cookies == nil {
+		return
+	}
+	http.SetCookie(w, h.cookie(AccessTokenCookie, "", "/", -1, true))
+	http.SetCookie(w, h.cookie(RefreshTokenCookie, "", refreshTokenCookiePath, -1, true))
+	http.SetCookie(w, h.cookie(CSRFCookie, "", "/", -1, false))
+}
+
+// refreshTokenFromRequest refresh токен из тела запроса, в режиме cookie - из cookie
+func (h *Handlers) refreshTokenFromRequest(r *http.Request, fromBody string) string {
+	if fromBody != "" || h.cookies == nil {
+		return fromBody
+	}
+	if cookie, err := r.Cookie(RefreshTokenCookie); err == nil {
+		return cookie.Value
+	}
+	return ""
+}
+
+func (h *Handlers) cookie(name, value, path string, maxAge int, httpOnly bool) *http.Cookie {
+	return &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     path,
+		Domain:   h.cookies.Domain,
+		MaxAge:   maxAge,
+		Secure:   h.cookies.Secure,
+		HttpOnly: httpOnly,
+		SameSite: h.cookies.SameSite,
+	}
+}
+
+func newCSRFToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
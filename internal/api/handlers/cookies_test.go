@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/vladislav-kr/gophermart/internal/api/handlers/mocks"
+	"github.com/vladislav-kr/gophermart/internal/domain/models"
+)
+
+func cookieHandlers(srv *mocks.Service) *Handlers {
+	return NewHandlers(srv, nil, WithCookies(CookieConfig{
+		Secure:     true,
+		SameSite:   http.SameSiteStrictMode,
+		RefreshTTL: time.Hour,
+	}))
+}
+
+func cookiesByName(result *http.Response) map[string]*http.Cookie {
+	cookies := make(map[string]*http.Cookie)
+	for _, c := range result.Cookies() {
+		cookies[c.Name] = c
+	}
+	return cookies
+}
+
+func TestHandlers_LoginCookieMode(t *testing.T) {
+	srv := mocks.NewService(t)
+	handlers := cookieHandlers(srv)
+
+	cred := models.Credentials{Login: "user", Password: "password"}
+	srv.On("Login", mock.AnythingOfType("*context.timerCtx"), cred, mock.AnythingOfType("models.Client")).
+		Return(&models.Tokens{
+			AccessToken:  "access-token",
+			RefreshToken: "refresh-token",
+			ExpiresIn:    900,
+		}, nil).Once()
+
+	rr := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodPost, "/", strings.NewReader(`{"login": "user", "password": "password"}`))
+	require.NoError(t, err)
+
+	handlers.Login(rr, req)
+
+	result := rr.Result()
+	defer result.Body.Close()
+	require.Equal(t, http.StatusOK, result.StatusCode)
+
+	// токены недоступны скриптам: нет заголовка и тела, только HttpOnly cookie
+	assert.Empty(t, result.Header.Get("Authorization"))
+	body := models.Tokens{}
+	require.NoError(t, json.NewDecoder(result.Body).Decode(&body))
+	assert.Empty(t, body.AccessToken)
+	assert.Empty(t, body.RefreshToken)
+	assert.Equal(t, int64(900), body.ExpiresIn)
+
+	cookies := cookiesByName(result)
+	require.Contains(t, cookies, AccessTokenCookie)
+	require.Contains(t, cookies, RefreshTokenCookie)
+	require.Contains(t, cookies, CSRFCookie)
+
+	assert.Equal(t, "access-token", cookies[AccessTokenCookie].Value)
+	assert.True(t, cookies[AccessTokenCookie].HttpOnly)
+	assert.True(t, cookies[AccessTokenCookie].Secure)
+	assert.Equal(t, http.SameSiteStrictMode, cookies[AccessTokenCookie].SameSite)
+
+	assert.Equal(t, "refresh-token", cookies[RefreshTokenCookie].Value)
+	assert.True(t, cookies[RefreshTokenCookie].HttpOnly)
+	assert.Equal(t, refreshTokenCookiePath, cookies[RefreshTokenCookie].Path)
+
+	// токен CSRF читается скриптом и передается в заголовке
+	assert.NotEmpty(t, cookies[CSRFCookie].Value)
+	assert.False(t, cookies[CSRFCookie].HttpOnly)
+}
+
+func TestHandlers_RefreshTokenCookieMode(t *testing.T) {
+	srv := mocks.NewService(t)
+	handlers := cookieHandlers(srv)
+
+	srv.On("Refresh", mock.AnythingOfType("*context.timerCtx"), "refresh-token-1", mock.AnythingOfType("models.Client")).
+		Return(&models.Tokens{AccessToken: "access-token", RefreshToken: "refresh-token-2"}, nil).Once()
+
+	// refresh токен берется из cookie, тело запроса не обязательно
+	rr := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodPost, "/", http.NoBody)
+	require.NoError(t, err)
+	req.AddCookie(&http.Cookie{Name: RefreshTokenCookie, Value: "refresh-token-1"})
+
+	handlers.RefreshToken(rr, req)
+
+	result := rr.Result()
+	defer result.Body.Close()
+	require.Equal(t, http.StatusOK, result.StatusCode)
+	assert.Equal(t, "refresh-token-2", cookiesByName(result)[RefreshTokenCookie].Value)
+}
+
+func TestHandlers_LogoutCookieMode(t *testing.T) {
+	srv := mocks.NewService(t)
+	handlers := cookieHandlers(srv)
+
+	srv.On("Logout", mock.AnythingOfType("*context.timerCtx"), mock.Anything, "refresh-token").
+		Return(nil).Once()
+
+	rr := httptest.NewRecorder()
+	req, err := http.NewRequestWithContext(
+		contextWithToken(t, "1cf50925-d72d-488b-94e5-426acce77f3c"),
+		http.MethodPost,
+		"/",
+		http.NoBody,
+	)
+	require.NoError(t, err)
+	req.AddCookie(&http.Cookie{Name: RefreshTokenCookie, Value: "refresh-token"})
+
+	handlers.Logout(rr, req)
+
+	result := rr.Result()
+	defer result.Body.Close()
+	require.Equal(t, http.StatusOK, result.StatusCode)
+
+	cookies := cookiesByName(result)
+	for _, name := range []string{AccessTokenCookie, RefreshTokenCookie, CSRFCookie} {
+		require.Contains(t, cookies, name)
+		assert.Empty(t, cookies[name].Value)
+		assert.Negative(t, cookies[name].MaxAge)
+	}
+}
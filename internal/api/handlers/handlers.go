@@ -6,13 +6,18 @@ import (
 	"fmt"
 	"io"
 	"log/slog"
+	"math"
+	"net"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/go-chi/httplog/v2"
 	"github.com/go-chi/render"
+	"github.com/lestrrat-go/jwx/v2/jwk"
 
 	"github.com/vladislav-kr/gophermart/internal/domain/models"
+	"github.com/vladislav-kr/gophermart/internal/domain/money"
 	"github.com/vladislav-kr/gophermart/internal/domain/response"
 	"github.com/vladislav-kr/gophermart/internal/logger"
 	"github.com/vladislav-kr/gophermart/internal/metrics"
@@ -37,13 +42,41 @@ func (h Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 //go:generate mockery --name service --exported
 type service interface {
-	Login(ctx context.Context, cred models.Credentials) (string, error)
-	Register(ctx context.Context, cred models.Credentials) (string, error)
+	Login(ctx context.Context, cred models.Credentials, client models.Client) (*models.Tokens, error)
+	LoginMFA(ctx context.Context, req models.MFALogin, client models.Client) (*models.Tokens, error)
+	Register(ctx context.Context, cred models.Credentials, client models.Client) (*models.Tokens, error)
+	Refresh(ctx context.Context, refreshToken string, client models.Client) (*models.Tokens, error)
+	ChangePassword(ctx context.Context, userID models.UserID, change models.PasswordChange, client models.Client) (*models.Tokens, error)
+	RequestPasswordReset(ctx context.Context, req models.PasswordResetRequest) error
+	ResetPassword(ctx context.Context, reset models.PasswordReset) error
+	EnrollTOTP(ctx context.Context, userID models.UserID) (*models.TOTPEnrollment, error)
+	ConfirmTOTP(ctx context.Context, userID models.UserID, code models.TOTPCode) (*models.RecoveryCodes, error)
+	PublicKeys() jwk.Set
+	Logout(ctx context.Context, claims jwt.Claims, refreshToken string) error
+	LogoutAll(ctx context.Context, userID models.UserID) error
+	Sessions(ctx context.Context, claims jwt.Claims) ([]models.Session, error)
+	RevokeSession(ctx context.Context, userID models.UserID, sessionID string) error
+	ExportUserData(ctx context.Context, userID models.UserID) (*models.UserExport, error)
+	DeleteAccount(ctx context.Context, userID models.UserID, req models.AccountDeletion) error
 	Order(ctx context.Context, orderID models.OrderID, userID models.UserID) error
+	UploadOrders(ctx context.Context, userID models.UserID, orderIDs []models.OrderID) (*models.BulkUpload, error)
 	OrdersByUserID(ctx context.Context, userID models.UserID) ([]models.Order, error)
+	OrdersPageByUserID(ctx context.Context, userID models.UserID, query models.OrdersQuery) (*models.OrdersPage, error)
+	OrderByUserID(ctx context.Context, userID models.UserID, orderID models.OrderID) (*models.OrderDetails, error)
 	UserBalance(ctx context.Context, userID models.UserID) (*models.Balance, error)
+	BalanceAt(ctx context.Context, userID models.UserID, at time.Time) (*models.Balance, error)
 	WithdrawalsByUserID(ctx context.Context, userID models.UserID) ([]models.WithdrawalsBonuses, error)
 	Withdraw(ctx context.Context, userID models.UserID, withdraw models.WithdrawBonuses) error
+	BalanceHistory(ctx context.Context, userID models.UserID) ([]models.BalanceEntry, error)
+	SearchUsers(ctx context.Context, login string) ([]models.User, error)
+	UserByID(ctx context.Context, userID models.UserID) (*models.User, error)
+	SetUserBlocked(ctx context.Context, adminID, userID models.UserID, blocked bool) error
+	RecheckOrder(ctx context.Context, adminID models.UserID, orderID models.OrderID) error
+	AdjustBalance(ctx context.Context, adminID, userID models.UserID, adjustment models.BalanceAdjustment) (*models.Balance, error)
+	ReconcileBalances(ctx context.Context, adminID models.UserID, correct bool) (*models.ReconciliationReport, error)
+	CreateAPIKey(ctx context.Context, userID models.UserID, req models.APIKeyRequest) (*models.NewAPIKey, error)
+	APIKeys(ctx context.Context, userID models.UserID) ([]models.APIKey, error)
+	RevokeAPIKey(ctx context.Context, userID models.UserID, keyID string) error
 }
 
 //go:generate mockery --name pinger --exported
@@ -55,16 +88,22 @@ type Handlers struct {
 	log     *slog.Logger
 	service service
 	pinger  pinger
+	// nil - токены только в заголовке и теле ответа
+	cookies *CookieConfig
 }
 
-func NewHandlers(s service, p pinger) *Handlers {
-	return &Handlers{
+func NewHandlers(s service, p pinger, opts ...Option) *Handlers {
+	h := &Handlers{
 		log: logger.Logger().With(
 			slog.String("comopnetn", "handlers"),
 		),
 		service: s,
 		pinger:  p,
 	}
+	for _, fn := range opts {
+		fn(h)
+	}
+	return h
 }
 
 // аутентификация пользователя
@@ -80,12 +119,29 @@ func (h *Handlers) Login(w http.ResponseWriter, r *http.Request) error {
 	ctx, cancel := context.WithTimeout(r.Context(), time.Second*4)
 	defer cancel()
 
-	token, err := h.service.Login(ctx, cred)
+	tokens, err := h.service.Login(ctx, cred, clientFromRequest(r))
 	if err != nil {
+		var tooMany *models.TooManyAttemptsError
+		var mfa *models.MFARequiredError
 		switch {
+		case errors.As(err, &mfa):
+			// пароль верный, токены выдаются после второго шага
+			render.Status(r, http.StatusAccepted)
+			render.JSON(w, r, mfa.Challenge)
+			return nil
+		case errors.As(err, &tooMany):
+			setRetryAfter(w, tooMany.RetryAfter)
+			render.Status(r, http.StatusTooManyRequests)
+			render.JSON(w, r, response.Error("слишком много попыток входа"))
 		case errors.Is(err, models.ErrIncorrectCredentials):
 			render.Status(r, http.StatusUnauthorized)
 			render.JSON(w, r, response.Error("неверная пара логин/пароль"))
+		case errors.Is(err, models.ErrUserBlocked):
+			render.Status(r, http.StatusForbidden)
+			render.JSON(w, r, response.Error("пользователь заблокирован"))
+		case errors.Is(err, models.ErrUserDeleted):
+			render.Status(r, http.StatusForbidden)
+			render.JSON(w, r, response.Error("учетная запись удалена"))
 		default:
 			render.Status(r, http.StatusInternalServerError)
 			render.JSON(w, r, response.Error("внутренняя ошибка сервера"))
@@ -93,11 +149,7 @@ func (h *Handlers) Login(w http.ResponseWriter, r *http.Request) error {
 		return fmt.Errorf("register user: %w", err)
 	}
 
-	w.Header().Set("Authorization", fmt.Sprintf("Bearer %s", token))
-	render.Status(r, http.StatusOK)
-	render.JSON(w, r, response.OK())
-
-	return nil
+	return h.writeTokens(w, r, tokens)
 }
 
 // регистрация пользователя
@@ -113,9 +165,13 @@ func (h *Handlers) Register(w http.ResponseWriter, r *http.Request) error {
 	ctx, cancel := context.WithTimeout(r.Context(), time.Second*4)
 	defer cancel()
 
-	token, err := h.service.Register(ctx, cred)
+	tokens, err := h.service.Register(ctx, cred, clientFromRequest(r))
 	if err != nil {
+		var invalid *models.ValidationError
 		switch {
+		case errors.As(err, &invalid):
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Validation("учетные данные не соответствуют требованиям", invalid))
 		case errors.Is(err, models.ErrLoginAlreadyExists):
 			render.Status(r, http.StatusConflict)
 			render.JSON(w, r, response.Error("логин уже занят"))
@@ -126,7 +182,83 @@ func (h *Handlers) Register(w http.ResponseWriter, r *http.Request) error {
 		return fmt.Errorf("register user: %w", err)
 	}
 
-	w.Header().Set("Authorization", fmt.Sprintf("Bearer %s", token))
+	return h.writeTokens(w, r, tokens)
+}
+
+// обновление пары токенов по refresh токену, в режиме cookie тело запроса можно не передавать
+func (h *Handlers) RefreshToken(w http.ResponseWriter, r *http.Request) error {
+	req := models.RefreshTokenRequest{}
+
+	if err := render.DecodeJSON(r.Body, &req); err != nil && !(h.cookies != nil && errors.Is(err, io.EOF)) {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, response.Error("неверный формат запроса"))
+		return fmt.Errorf("decoding the request body into JSON: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second*4)
+	defer cancel()
+
+	tokens, err := h.service.Refresh(ctx, h.refreshTokenFromRequest(r, req.RefreshToken), clientFromRequest(r))
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrInvalidRefreshToken),
+			errors.Is(err, models.ErrRefreshTokenReused):
+			render.Status(r, http.StatusUnauthorized)
+			render.JSON(w, r, response.Error("недействительный refresh токен"))
+		case errors.Is(err, models.ErrUserBlocked),
+			errors.Is(err, models.ErrUserDeleted):
+			render.Status(r, http.StatusForbidden)
+			render.JSON(w, r, response.Error("доступ запрещен"))
+		default:
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("внутренняя ошибка сервера"))
+		}
+		return fmt.Errorf("refresh token: %w", err)
+	}
+
+	return h.writeTokens(w, r, tokens)
+}
+
+// выход, отзыв текущего токена
+func (h *Handlers) Logout(w http.ResponseWriter, r *http.Request) error {
+	claims, _ := jwt.ClaimsFromContext(r.Context())
+
+	req := models.LogoutRequest{}
+	if err := render.DecodeJSON(r.Body, &req); err != nil && !errors.Is(err, io.EOF) {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, response.Error("неверный формат запроса"))
+		return fmt.Errorf("decoding the request body into JSON: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second*4)
+	defer cancel()
+
+	if err := h.service.Logout(ctx, claims, h.refreshTokenFromRequest(r, req.RefreshToken)); err != nil {
+		render.Status(r, http.StatusInternalServerError)
+		render.JSON(w, r, response.Error("внутренняя ошибка сервера"))
+		return fmt.Errorf("logout: %w", err)
+	}
+	h.clearTokens(w)
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, response.OK())
+	return nil
+}
+
+// выход со всех устройств, отзыв всех токенов пользователя
+func (h *Handlers) LogoutAll(w http.ResponseWriter, r *http.Request) error {
+	userID, _ := userIDFromContext(r.Context())
+
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second*4)
+	defer cancel()
+
+	if err := h.service.LogoutAll(ctx, models.UserID(userID)); err != nil {
+		render.Status(r, http.StatusInternalServerError)
+		render.JSON(w, r, response.Error("внутренняя ошибка сервера"))
+		return fmt.Errorf("logout all: %w", err)
+	}
+	h.clearTokens(w)
+
 	render.Status(r, http.StatusOK)
 	render.JSON(w, r, response.OK())
 	return nil
@@ -176,8 +308,13 @@ func (h *Handlers) SaveOrder(w http.ResponseWriter, r *http.Request) error {
 }
 
 // получение списка загруженных пользователем номеров заказов,
-// статусов их обработки и информации о начислениях
+// статусов их обработки и информации о начислениях.
+// С параметрами выборки ответ отдается постранично.
 func (h *Handlers) ListOrdersByUser(w http.ResponseWriter, r *http.Request) error {
+	if ordersPageRequested(r) {
+		return h.ListOrdersPage(w, r)
+	}
+
 	userID, _ := userIDFromContext(r.Context())
 
 	ctx, cancel := context.WithTimeout(r.Context(), time.Second*4)
@@ -228,8 +365,15 @@ func (h *Handlers) WithdrawBonuses(w http.ResponseWriter, r *http.Request) error
 
 	err := render.DecodeJSON(r.Body, &withdraw)
 	if err != nil {
-		render.Status(r, http.StatusBadRequest)
-		render.JSON(w, r, response.Error("внутренняя ошибка сервера"))
+		switch {
+		// сумма с лишними знаками после запятой или вне диапазона
+		case errors.Is(err, money.ErrScale), errors.Is(err, money.ErrRange), errors.Is(err, money.ErrValue):
+			render.Status(r, http.StatusUnprocessableEntity)
+			render.JSON(w, r, response.Error("неверная сумма списания"))
+		default:
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Error("внутренняя ошибка сервера"))
+		}
 		return fmt.Errorf("decode JSON: %w", err)
 	}
 
@@ -237,13 +381,26 @@ func (h *Handlers) WithdrawBonuses(w http.ResponseWriter, r *http.Request) error
 	defer cancel()
 
 	if err := h.service.Withdraw(ctx, models.UserID(userID), withdraw); err != nil {
+		var exists *models.WithdrawalExistsError
 		switch {
+		case errors.As(err, &exists):
+			render.Status(r, http.StatusConflict)
+			render.JSON(w, r, struct {
+				response.Response
+				Withdrawal models.WithdrawalsBonuses `json:"withdrawal"`
+			}{
+				Response:   response.Error("списание по этому номеру заказа уже выполнено"),
+				Withdrawal: exists.Withdrawal,
+			})
 		case errors.Is(err, models.ErrInsufficientFunds):
 			render.Status(r, http.StatusPaymentRequired)
 			render.JSON(w, r, response.Error("на счету недостаточно средств"))
 		case errors.Is(err, models.ErrIncorrectOrderNumber):
 			render.Status(r, http.StatusUnprocessableEntity)
 			render.JSON(w, r, response.Error("неверный номер заказа"))
+		case errors.Is(err, models.ErrInvalidSum):
+			render.Status(r, http.StatusUnprocessableEntity)
+			render.JSON(w, r, response.Error("неверная сумма списания"))
 		default:
 			render.Status(r, http.StatusInternalServerError)
 			render.JSON(w, r, response.Error("внутренняя ошибка сервера"))
@@ -282,6 +439,40 @@ func (h *Handlers) HistoryWithdrawals(w http.ResponseWriter, r *http.Request) er
 	return nil
 }
 
+// история начислений, списаний и корректировок баланса
+func (h *Handlers) BalanceHistory(w http.ResponseWriter, r *http.Request) error {
+	userID, _ := userIDFromContext(r.Context())
+
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second*4)
+	defer cancel()
+
+	entries, err := h.service.BalanceHistory(ctx, models.UserID(userID))
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrNoRecordsFound):
+			render.Status(r, http.StatusNoContent)
+			render.JSON(w, r, response.OK())
+			return nil
+		default:
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("внутренняя ошибка сервера"))
+			return fmt.Errorf("balance history: %w", err)
+		}
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, entries)
+	return nil
+}
+
+// открытые ключи проверки токенов в формате JWKS
+func (h *Handlers) JWKS(w http.ResponseWriter, r *http.Request) error {
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, h.service.PublicKeys())
+	return nil
+}
+
 // сервер запустился
 func (h *Handlers) Live(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
@@ -305,6 +496,23 @@ func (h *Handlers) Ready(w http.ResponseWriter, r *http.Request) error {
 	return nil
 }
 
+// Retry-After в целых секундах с округлением вверх
+func setRetryAfter(w http.ResponseWriter, d time.Duration) {
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(d.Seconds()))))
+}
+
+// IP и User-Agent клиента, адрес за доверенным прокси уже подменен middleware RealIP
+func clientFromRequest(r *http.Request) models.Client {
+	ip := r.RemoteAddr
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		ip = host
+	}
+	return models.Client{
+		IP:        ip,
+		UserAgent: r.UserAgent(),
+	}
+}
+
 // userID из токена JWT
 func userIDFromContext(ctx context.Context) (string, bool) {
 	userID, ok := jwt.ClaimJWTFromContext[string](ctx, jwt.UserID)
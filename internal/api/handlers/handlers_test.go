@@ -15,12 +15,15 @@ import (
 	"github.com/go-chi/httplog/v2"
 	"github.com/go-chi/jwtauth/v5"
 	"github.com/google/uuid"
+	"github.com/lestrrat-go/jwx/v2/jwk"
 	"github.com/lestrrat-go/jwx/v2/jwt"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
-	"github.com/vladislav-kr/gophermart/internal/domain/models"
 	"github.com/vladislav-kr/gophermart/internal/api/handlers/mocks"
+	"github.com/vladislav-kr/gophermart/internal/domain/models"
+	"github.com/vladislav-kr/gophermart/internal/domain/money"
+	servicejwt "github.com/vladislav-kr/gophermart/internal/service/jwt"
 )
 
 func contextWithToken(t *testing.T, userID string) context.Context {
@@ -45,7 +48,7 @@ func TestHandlers_Login(t *testing.T) {
 	type mockParam struct {
 		callMock bool
 		cred     models.Credentials
-		token    string
+		tokens   *models.Tokens
 		err      error
 	}
 	type args struct {
@@ -86,8 +89,11 @@ func TestHandlers_Login(t *testing.T) {
 						Login:    "admin",
 						Password: "SuperPassword1234@#!",
 					},
-					token: "secret-token",
-					err:   nil,
+					tokens: &models.Tokens{
+						AccessToken:  "secret-token",
+						RefreshToken: "refresh-token",
+					},
+					err: nil,
 				},
 			},
 			expectedStatus: http.StatusOK,
@@ -103,12 +109,63 @@ func TestHandlers_Login(t *testing.T) {
 						Login:    "admin",
 						Password: "WWW_SuperPassword1234@#!",
 					},
-					token: "",
-					err:   models.ErrIncorrectCredentials,
+					tokens: nil,
+					err:    models.ErrIncorrectCredentials,
 				},
 			},
 			expectedStatus: http.StatusUnauthorized,
 		},
+		{
+			name: "пользователь заблокирован",
+			args: args{
+				body:     `{"login": "blocked","password": "SuperPassword1234@#!"}`,
+				handlers: handlers,
+				mock: mockParam{
+					callMock: true,
+					cred: models.Credentials{
+						Login:    "blocked",
+						Password: "SuperPassword1234@#!",
+					},
+					tokens: nil,
+					err:    models.ErrUserBlocked,
+				},
+			},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name: "слишком много попыток входа",
+			args: args{
+				body:     `{"login": "locked","password": "SuperPassword1234@#!"}`,
+				handlers: handlers,
+				mock: mockParam{
+					callMock: true,
+					cred: models.Credentials{
+						Login:    "locked",
+						Password: "SuperPassword1234@#!",
+					},
+					tokens: nil,
+					err:    &models.TooManyAttemptsError{RetryAfter: time.Millisecond * 1500},
+				},
+			},
+			expectedStatus: http.StatusTooManyRequests,
+		},
+		{
+			name: "требуется второй фактор",
+			args: args{
+				body:     `{"login": "mfa","password": "SuperPassword1234@#!"}`,
+				handlers: handlers,
+				mock: mockParam{
+					callMock: true,
+					cred: models.Credentials{
+						Login:    "mfa",
+						Password: "SuperPassword1234@#!",
+					},
+					tokens: nil,
+					err:    models.NewMFARequiredError("challenge-token", time.Minute*5),
+				},
+			},
+			expectedStatus: http.StatusAccepted,
+		},
 		{
 			name: "внутренняя ошибка сервера",
 			args: args{
@@ -120,8 +177,8 @@ func TestHandlers_Login(t *testing.T) {
 						Login:    "3admin",
 						Password: "3WWW_SuperPassword1234@#!",
 					},
-					token: "",
-					err:   fmt.Errorf("failed to connect to the database"),
+					tokens: nil,
+					err:    fmt.Errorf("failed to connect to the database"),
 				},
 			},
 			expectedStatus: http.StatusInternalServerError,
@@ -143,8 +200,11 @@ func TestHandlers_Login(t *testing.T) {
 			require.NoError(t, err)
 
 			if tt.args.mock.callMock {
-				srv.On("Login", mock.AnythingOfType("*context.timerCtx"), tt.args.mock.cred).
-					Return(tt.args.mock.token, tt.args.mock.err)
+				srv.On("Login",
+					mock.AnythingOfType("*context.timerCtx"),
+					tt.args.mock.cred,
+					mock.AnythingOfType("models.Client"),
+				).Return(tt.args.mock.tokens, tt.args.mock.err)
 			}
 
 			tt.args.handlers.Login(rr, req)
@@ -153,6 +213,10 @@ func TestHandlers_Login(t *testing.T) {
 			defer result.Body.Close()
 			assert.Equal(t, tt.expectedStatus, result.StatusCode)
 
+			if result.StatusCode == http.StatusTooManyRequests {
+				assert.Equal(t, "2", result.Header.Get("Retry-After"))
+			}
+
 			if result.StatusCode == http.StatusOK {
 				assert.NotEmpty(t, result.Header.Get("Authorization"))
 			}
@@ -167,7 +231,7 @@ func TestHandlers_Register(t *testing.T) {
 	type mockParam struct {
 		callMock bool
 		cred     models.Credentials
-		token    string
+		tokens   *models.Tokens
 		err      error
 	}
 	type args struct {
@@ -207,8 +271,11 @@ func TestHandlers_Register(t *testing.T) {
 						Login:    "admin",
 						Password: "SuperPassword1234@#!",
 					},
-					token: "secret-token",
-					err:   nil,
+					tokens: &models.Tokens{
+						AccessToken:  "secret-token",
+						RefreshToken: "refresh-token",
+					},
+					err: nil,
 				},
 			},
 			expectedStatus: http.StatusOK,
@@ -224,12 +291,32 @@ func TestHandlers_Register(t *testing.T) {
 						Login:    "admin2",
 						Password: "SuperPassword1234@#!",
 					},
-					token: "",
-					err:   models.ErrLoginAlreadyExists,
+					tokens: nil,
+					err:    models.ErrLoginAlreadyExists,
 				},
 			},
 			expectedStatus: http.StatusConflict,
 		},
+		{
+			name: "учетные данные не соответствуют требованиям",
+			args: args{
+				body:     `{"login": "ad","password": "1"}`,
+				handlers: handlers,
+				mock: mockParam{
+					callMock: true,
+					cred: models.Credentials{
+						Login:    "ad",
+						Password: "1",
+					},
+					tokens: nil,
+					err: &models.ValidationError{Fields: []models.FieldError{
+						{Field: "login", Code: models.CodeTooShort},
+						{Field: "password", Code: models.CodeTooShort},
+					}},
+				},
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
 		{
 			name: "внутренняя ошибка сервера",
 			args: args{
@@ -241,8 +328,8 @@ func TestHandlers_Register(t *testing.T) {
 						Login:    "3admin",
 						Password: "3WWW_SuperPassword1234@#!",
 					},
-					token: "",
-					err:   fmt.Errorf("failed to connect to the database"),
+					tokens: nil,
+					err:    fmt.Errorf("failed to connect to the database"),
 				},
 			},
 			expectedStatus: http.StatusInternalServerError,
@@ -262,8 +349,8 @@ func TestHandlers_Register(t *testing.T) {
 			require.NoError(t, err)
 
 			if tt.args.mock.callMock {
-				srv.On("Register", mock.AnythingOfType("*context.timerCtx"), tt.args.mock.cred).
-					Return(tt.args.mock.token, tt.args.mock.err)
+				srv.On("Register", mock.AnythingOfType("*context.timerCtx"), tt.args.mock.cred, mock.AnythingOfType("models.Client")).
+					Return(tt.args.mock.tokens, tt.args.mock.err)
 			}
 
 			tt.args.handlers.Register(rr, req)
@@ -279,6 +366,240 @@ func TestHandlers_Register(t *testing.T) {
 	}
 }
 
+func TestHandlers_RefreshToken(t *testing.T) {
+	srv := mocks.NewService(t)
+	handlers := NewHandlers(srv, nil)
+
+	type mockParam struct {
+		callMock     bool
+		refreshToken string
+		tokens       *models.Tokens
+		err          error
+	}
+	type args struct {
+		body     string
+		handlers *Handlers
+		mock     mockParam
+	}
+	tests := []struct {
+		name           string
+		args           args
+		expectedStatus int
+	}{
+		{
+			name: "ошибка парсинга json(некорректный json)",
+			args: args{
+				body:     `{"refresh_token": "token"`,
+				handlers: handlers,
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "токены успешно обновлены",
+			args: args{
+				body:     `{"refresh_token": "refresh-token-1"}`,
+				handlers: handlers,
+				mock: mockParam{
+					callMock:     true,
+					refreshToken: "refresh-token-1",
+					tokens: &models.Tokens{
+						AccessToken:  "secret-token",
+						RefreshToken: "refresh-token-2",
+					},
+				},
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "недействительный refresh токен",
+			args: args{
+				body:     `{"refresh_token": "refresh-token-3"}`,
+				handlers: handlers,
+				mock: mockParam{
+					callMock:     true,
+					refreshToken: "refresh-token-3",
+					err:          models.ErrInvalidRefreshToken,
+				},
+			},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name: "повторное использование refresh токена",
+			args: args{
+				body:     `{"refresh_token": "refresh-token-4"}`,
+				handlers: handlers,
+				mock: mockParam{
+					callMock:     true,
+					refreshToken: "refresh-token-4",
+					err:          models.ErrRefreshTokenReused,
+				},
+			},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name: "внутренняя ошибка сервера",
+			args: args{
+				body:     `{"refresh_token": "refresh-token-5"}`,
+				handlers: handlers,
+				mock: mockParam{
+					callMock:     true,
+					refreshToken: "refresh-token-5",
+					err:          fmt.Errorf("failed to connect to the database"),
+				},
+			},
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			rr := httptest.NewRecorder()
+			req, err := http.NewRequest(
+				http.MethodPost,
+				"/",
+				strings.NewReader(tt.args.body),
+			)
+			require.NoError(t, err)
+
+			if tt.args.mock.callMock {
+				srv.On("Refresh", mock.AnythingOfType("*context.timerCtx"), tt.args.mock.refreshToken, mock.AnythingOfType("models.Client")).
+					Return(tt.args.mock.tokens, tt.args.mock.err)
+			}
+
+			tt.args.handlers.RefreshToken(rr, req)
+
+			result := rr.Result()
+			defer result.Body.Close()
+			assert.Equal(t, tt.expectedStatus, result.StatusCode)
+
+			if result.StatusCode == http.StatusOK {
+				assert.Equal(t, "Bearer "+tt.args.mock.tokens.AccessToken, result.Header.Get("Authorization"))
+			}
+		})
+	}
+}
+
+func TestHandlers_Logout(t *testing.T) {
+	srv := mocks.NewService(t)
+	handlers := NewHandlers(srv, nil)
+
+	userID := uuid.NewString()
+
+	tests := []struct {
+		name           string
+		body           string
+		callMock       bool
+		refreshToken   string
+		err            error
+		expectedStatus int
+	}{
+		{
+			name:           "некорректный json",
+			body:           `{"refresh_token": `,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "выход без refresh токена",
+			body:           "",
+			callMock:       true,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "выход с отзывом refresh токена",
+			body:           `{"refresh_token": "refresh-token-1"}`,
+			callMock:       true,
+			refreshToken:   "refresh-token-1",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "внутренняя ошибка сервера",
+			body:           `{"refresh_token": "refresh-token-2"}`,
+			callMock:       true,
+			refreshToken:   "refresh-token-2",
+			err:            fmt.Errorf("failed to connect to the database"),
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			rr := httptest.NewRecorder()
+			req, err := http.NewRequestWithContext(
+				contextWithToken(t, userID),
+				http.MethodPost,
+				"/",
+				strings.NewReader(tt.body),
+			)
+			require.NoError(t, err)
+
+			if tt.callMock {
+				srv.On("Logout",
+					mock.AnythingOfType("*context.timerCtx"),
+					mock.MatchedBy(func(c servicejwt.Claims) bool { return c.UserID == userID }),
+					tt.refreshToken,
+				).Return(tt.err)
+			}
+
+			handlers.Logout(rr, req)
+
+			result := rr.Result()
+			defer result.Body.Close()
+			assert.Equal(t, tt.expectedStatus, result.StatusCode)
+		})
+	}
+}
+
+func TestHandlers_LogoutAll(t *testing.T) {
+	srv := mocks.NewService(t)
+	handlers := NewHandlers(srv, nil)
+
+	tests := []struct {
+		name           string
+		userID         models.UserID
+		err            error
+		expectedStatus int
+	}{
+		{
+			name:           "все токены отозваны",
+			userID:         models.UserID(uuid.NewString()),
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "внутренняя ошибка сервера",
+			userID:         models.UserID(uuid.NewString()),
+			err:            fmt.Errorf("failed to connect to the database"),
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			rr := httptest.NewRecorder()
+			req, err := http.NewRequestWithContext(
+				contextWithToken(t, string(tt.userID)),
+				http.MethodPost,
+				"/",
+				nil,
+			)
+			require.NoError(t, err)
+
+			srv.On("LogoutAll", mock.AnythingOfType("*context.timerCtx"), tt.userID).Return(tt.err)
+
+			handlers.LogoutAll(rr, req)
+
+			result := rr.Result()
+			defer result.Body.Close()
+			assert.Equal(t, tt.expectedStatus, result.StatusCode)
+		})
+	}
+}
+
 func TestHandlers_SaveOrder(t *testing.T) {
 	srv := mocks.NewService(t)
 	handlers := NewHandlers(srv, nil)
@@ -508,7 +829,7 @@ func TestHandlers_ListOrdersByUser(t *testing.T) {
 						{
 							OrderID:    "2377225624",
 							Status:     "PROCESSED",
-							Accrual:    500.5,
+							Accrual:    money.MustParse("500.5"),
 							UploadedAt: timeTest,
 						},
 					},
@@ -611,8 +932,8 @@ func TestHandlers_BalanceByUser(t *testing.T) {
 					callMock: true,
 					userID:   "5172509d-14b2-4ed0-9dc5-8c8838218426",
 					balance: &models.Balance{
-						Current:   100.43,
-						Withdrawn: 394,
+						Current:   money.MustParse("100.43"),
+						Withdrawn: money.FromInt(394),
 					},
 					err: nil,
 				},
@@ -620,6 +941,29 @@ func TestHandlers_BalanceByUser(t *testing.T) {
 			expectedBody:   `{"current": 100.43,"withdrawn": 394}`,
 			expectedStatus: http.StatusOK,
 		},
+		{
+			name: "баланс с баллами, которые скоро сгорят",
+			args: args{
+				ctx:      contextWithToken(t, "6172509d-14b2-4ed0-9dc5-8c8838218426"),
+				handlers: handlers,
+				mock: mockParam{
+					callMock: true,
+					userID:   "6172509d-14b2-4ed0-9dc5-8c8838218426",
+					balance: &models.Balance{
+						Current:   money.FromInt(150),
+						Withdrawn: money.FromInt(0),
+						ExpiringSoon: []models.ExpiringPoints{
+							{
+								Amount:    money.MustParse("50.5"),
+								ExpiresAt: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+							},
+						},
+					},
+				},
+			},
+			expectedBody:   `{"current": 150,"withdrawn": 0,"expiring_soon": [{"amount": 50.5,"expires_at": "2024-05-01T12:00:00Z"}]}`,
+			expectedStatus: http.StatusOK,
+		},
 	}
 	for _, tt := range tests {
 		tt := tt
@@ -690,7 +1034,7 @@ func TestHandlers_WithdrawBonuses(t *testing.T) {
 					callMock: true,
 					withdraw: models.WithdrawBonuses{
 						Order: "23772256241",
-						Sum:   300,
+						Sum:   money.FromInt(300),
 					},
 					err: models.ErrUserIDMandatory,
 				},
@@ -709,6 +1053,36 @@ func TestHandlers_WithdrawBonuses(t *testing.T) {
 			},
 			expectedStatus: http.StatusBadRequest,
 		},
+		{
+			name: "сумма с лишними знаками после запятой",
+			args: args{
+				ctx:      contextWithToken(t, "9f059c1c-da6d-4245-9102-d4734a8433db"),
+				handlers: handlers,
+				body:     `{"order":"2377225624","sum":0.0001}`,
+				mock: mockParam{
+					callMock: false,
+				},
+			},
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name: "отрицательная сумма списания",
+			args: args{
+				ctx:      contextWithToken(t, "9f059c1c-da6d-4245-9102-d4734a8433db"),
+				handlers: handlers,
+				body:     `{"order":"2377225624","sum":-5}`,
+				mock: mockParam{
+					callMock: true,
+					withdraw: models.WithdrawBonuses{
+						Order: "2377225624",
+						Sum:   money.FromInt(-5),
+					},
+					userID: "9f059c1c-da6d-4245-9102-d4734a8433db",
+					err:    models.ErrInvalidSum,
+				},
+			},
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
 		{
 			name: "неверный номер заказа",
 			args: args{
@@ -719,7 +1093,7 @@ func TestHandlers_WithdrawBonuses(t *testing.T) {
 					callMock: true,
 					withdraw: models.WithdrawBonuses{
 						Order: "2377225623",
-						Sum:   500,
+						Sum:   money.FromInt(500),
 					},
 					userID: "9f059c1c-da6d-4245-9102-d4734a8433db",
 					err:    models.ErrIncorrectOrderNumber,
@@ -737,7 +1111,7 @@ func TestHandlers_WithdrawBonuses(t *testing.T) {
 					callMock: true,
 					withdraw: models.WithdrawBonuses{
 						Order: "2377225645",
-						Sum:   700,
+						Sum:   money.FromInt(700),
 					},
 					userID: "5172509d-14b2-4ed0-9dc5-8c8838218426",
 					err:    fmt.Errorf("failed to connect to the database"),
@@ -755,7 +1129,7 @@ func TestHandlers_WithdrawBonuses(t *testing.T) {
 					callMock: true,
 					withdraw: models.WithdrawBonuses{
 						Order: "2377225624",
-						Sum:   200,
+						Sum:   money.FromInt(200),
 					},
 					userID: "dd55ca8f-d25f-4242-8d63-06783b69926d",
 					err:    nil,
@@ -773,7 +1147,7 @@ func TestHandlers_WithdrawBonuses(t *testing.T) {
 					callMock: true,
 					withdraw: models.WithdrawBonuses{
 						Order: "2377225624",
-						Sum:   300,
+						Sum:   money.FromInt(300),
 					},
 					userID: "9ac768ed-c871-42e2-9137-20efc6b6b035",
 					err:    models.ErrInsufficientFunds,
@@ -781,6 +1155,26 @@ func TestHandlers_WithdrawBonuses(t *testing.T) {
 			},
 			expectedStatus: http.StatusPaymentRequired,
 		},
+		{
+			name: "повторное списание по номеру заказа",
+			args: args{
+				ctx:      contextWithToken(t, "3b1f5a2c-8f0e-4c6d-9a57-2d6e1c4b7f90"),
+				handlers: handlers,
+				body:     `{"order":"2377225624","sum":300}`,
+				mock: mockParam{
+					callMock: true,
+					withdraw: models.WithdrawBonuses{
+						Order: "2377225624",
+						Sum:   money.FromInt(300),
+					},
+					userID: "3b1f5a2c-8f0e-4c6d-9a57-2d6e1c4b7f90",
+					err: &models.WithdrawalExistsError{
+						Withdrawal: models.WithdrawalsBonuses{Order: "2377225624", Sum: money.FromInt(300)},
+					},
+				},
+			},
+			expectedStatus: http.StatusConflict,
+		},
 	}
 	for _, tt := range tests {
 		tt := tt
@@ -886,7 +1280,7 @@ func TestHandlers_HistoryWithdrawals(t *testing.T) {
 					withdrawals: []models.WithdrawalsBonuses{
 						{
 							Order:       "2377225624",
-							Sum:         300,
+							Sum:         money.FromInt(300),
 							ProcessedAt: timeTest,
 						},
 					},
@@ -1006,6 +1400,36 @@ func TestHandlers_Live(t *testing.T) {
 	assert.Equal(t, http.StatusOK, result.StatusCode)
 }
 
+func TestHandlers_JWKS(t *testing.T) {
+	srv := mocks.NewService(t)
+	handlers := NewHandlers(srv, nil)
+
+	keys, err := servicejwt.GenerateKeySet()
+	require.NoError(t, err)
+	srv.On("PublicKeys").Return(keys.PublicKeys())
+
+	rr := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodGet, "/", nil)
+	require.NoError(t, err)
+
+	handlers.JWKS(rr, req)
+
+	result := rr.Result()
+	defer result.Body.Close()
+
+	assert.Equal(t, http.StatusOK, result.StatusCode)
+
+	set, err := jwk.ParseReader(result.Body)
+	require.NoError(t, err)
+	assert.Equal(t, 1, set.Len())
+
+	key, ok := set.Key(0)
+	require.True(t, ok)
+	assert.NotEmpty(t, key.KeyID())
+	_, isPrivate := key.(jwk.RSAPrivateKey)
+	assert.False(t, isPrivate)
+}
+
 func Test_userIDFromContext(t *testing.T) {
 	type args struct {
 		ctx context.Context
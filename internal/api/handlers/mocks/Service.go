@@ -1,13 +1,19 @@
-// Code generated by mockery v2.37.0. DO NOT EDIT.
+// Code generated by mockery v2.53.7. DO NOT EDIT.
 
 package mocks
 
 import (
 	context "context"
 
+	jwk "github.com/lestrrat-go/jwx/v2/jwk"
+
+	jwt "github.com/vladislav-kr/gophermart/internal/service/jwt"
+
 	mock "github.com/stretchr/testify/mock"
 
 	models "github.com/vladislav-kr/gophermart/internal/domain/models"
+
+	time "time"
 )
 
 // Service is an autogenerated mock type for the service type
@@ -15,23 +21,209 @@ type Service struct {
 	mock.Mock
 }
 
-// Login provides a mock function with given fields: ctx, cred
-func (_m *Service) Login(ctx context.Context, cred models.Credentials) (string, error) {
-	ret := _m.Called(ctx, cred)
+// APIKeys provides a mock function with given fields: ctx, userID
+func (_m *Service) APIKeys(ctx context.Context, userID models.UserID) ([]models.APIKey, error) {
+	ret := _m.Called(ctx, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for APIKeys")
+	}
+
+	var r0 []models.APIKey
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.UserID) ([]models.APIKey, error)); ok {
+		return rf(ctx, userID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, models.UserID) []models.APIKey); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.APIKey)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, models.UserID) error); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// AdjustBalance provides a mock function with given fields: ctx, adminID, userID, adjustment
+func (_m *Service) AdjustBalance(ctx context.Context, adminID models.UserID, userID models.UserID, adjustment models.BalanceAdjustment) (*models.Balance, error) {
+	ret := _m.Called(ctx, adminID, userID, adjustment)
+
+	if len(ret) == 0 {
+		panic("no return value specified for AdjustBalance")
+	}
+
+	var r0 *models.Balance
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.UserID, models.UserID, models.BalanceAdjustment) (*models.Balance, error)); ok {
+		return rf(ctx, adminID, userID, adjustment)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, models.UserID, models.UserID, models.BalanceAdjustment) *models.Balance); ok {
+		r0 = rf(ctx, adminID, userID, adjustment)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Balance)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, models.UserID, models.UserID, models.BalanceAdjustment) error); ok {
+		r1 = rf(ctx, adminID, userID, adjustment)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// BalanceAt provides a mock function with given fields: ctx, userID, at
+func (_m *Service) BalanceAt(ctx context.Context, userID models.UserID, at time.Time) (*models.Balance, error) {
+	ret := _m.Called(ctx, userID, at)
+
+	if len(ret) == 0 {
+		panic("no return value specified for BalanceAt")
+	}
+
+	var r0 *models.Balance
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.UserID, time.Time) (*models.Balance, error)); ok {
+		return rf(ctx, userID, at)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, models.UserID, time.Time) *models.Balance); ok {
+		r0 = rf(ctx, userID, at)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Balance)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, models.UserID, time.Time) error); ok {
+		r1 = rf(ctx, userID, at)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// BalanceHistory provides a mock function with given fields: ctx, userID
+func (_m *Service) BalanceHistory(ctx context.Context, userID models.UserID) ([]models.BalanceEntry, error) {
+	ret := _m.Called(ctx, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for BalanceHistory")
+	}
+
+	var r0 []models.BalanceEntry
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.UserID) ([]models.BalanceEntry, error)); ok {
+		return rf(ctx, userID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, models.UserID) []models.BalanceEntry); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.BalanceEntry)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, models.UserID) error); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ChangePassword provides a mock function with given fields: ctx, userID, change, client
+func (_m *Service) ChangePassword(ctx context.Context, userID models.UserID, change models.PasswordChange, client models.Client) (*models.Tokens, error) {
+	ret := _m.Called(ctx, userID, change, client)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ChangePassword")
+	}
+
+	var r0 *models.Tokens
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.UserID, models.PasswordChange, models.Client) (*models.Tokens, error)); ok {
+		return rf(ctx, userID, change, client)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, models.UserID, models.PasswordChange, models.Client) *models.Tokens); ok {
+		r0 = rf(ctx, userID, change, client)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Tokens)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, models.UserID, models.PasswordChange, models.Client) error); ok {
+		r1 = rf(ctx, userID, change, client)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ConfirmTOTP provides a mock function with given fields: ctx, userID, code
+func (_m *Service) ConfirmTOTP(ctx context.Context, userID models.UserID, code models.TOTPCode) (*models.RecoveryCodes, error) {
+	ret := _m.Called(ctx, userID, code)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ConfirmTOTP")
+	}
+
+	var r0 *models.RecoveryCodes
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.UserID, models.TOTPCode) (*models.RecoveryCodes, error)); ok {
+		return rf(ctx, userID, code)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, models.UserID, models.TOTPCode) *models.RecoveryCodes); ok {
+		r0 = rf(ctx, userID, code)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.RecoveryCodes)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, models.UserID, models.TOTPCode) error); ok {
+		r1 = rf(ctx, userID, code)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateAPIKey provides a mock function with given fields: ctx, userID, req
+func (_m *Service) CreateAPIKey(ctx context.Context, userID models.UserID, req models.APIKeyRequest) (*models.NewAPIKey, error) {
+	ret := _m.Called(ctx, userID, req)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateAPIKey")
+	}
 
-	var r0 string
+	var r0 *models.NewAPIKey
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, models.Credentials) (string, error)); ok {
-		return rf(ctx, cred)
+	if rf, ok := ret.Get(0).(func(context.Context, models.UserID, models.APIKeyRequest) (*models.NewAPIKey, error)); ok {
+		return rf(ctx, userID, req)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, models.Credentials) string); ok {
-		r0 = rf(ctx, cred)
+	if rf, ok := ret.Get(0).(func(context.Context, models.UserID, models.APIKeyRequest) *models.NewAPIKey); ok {
+		r0 = rf(ctx, userID, req)
 	} else {
-		r0 = ret.Get(0).(string)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.NewAPIKey)
+		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, models.Credentials) error); ok {
-		r1 = rf(ctx, cred)
+	if rf, ok := ret.Get(1).(func(context.Context, models.UserID, models.APIKeyRequest) error); ok {
+		r1 = rf(ctx, userID, req)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -39,10 +231,188 @@ func (_m *Service) Login(ctx context.Context, cred models.Credentials) (string,
 	return r0, r1
 }
 
+// DeleteAccount provides a mock function with given fields: ctx, userID, req
+func (_m *Service) DeleteAccount(ctx context.Context, userID models.UserID, req models.AccountDeletion) error {
+	ret := _m.Called(ctx, userID, req)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteAccount")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.UserID, models.AccountDeletion) error); ok {
+		r0 = rf(ctx, userID, req)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// EnrollTOTP provides a mock function with given fields: ctx, userID
+func (_m *Service) EnrollTOTP(ctx context.Context, userID models.UserID) (*models.TOTPEnrollment, error) {
+	ret := _m.Called(ctx, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for EnrollTOTP")
+	}
+
+	var r0 *models.TOTPEnrollment
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.UserID) (*models.TOTPEnrollment, error)); ok {
+		return rf(ctx, userID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, models.UserID) *models.TOTPEnrollment); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.TOTPEnrollment)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, models.UserID) error); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ExportUserData provides a mock function with given fields: ctx, userID
+func (_m *Service) ExportUserData(ctx context.Context, userID models.UserID) (*models.UserExport, error) {
+	ret := _m.Called(ctx, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ExportUserData")
+	}
+
+	var r0 *models.UserExport
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.UserID) (*models.UserExport, error)); ok {
+		return rf(ctx, userID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, models.UserID) *models.UserExport); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.UserExport)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, models.UserID) error); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Login provides a mock function with given fields: ctx, cred, client
+func (_m *Service) Login(ctx context.Context, cred models.Credentials, client models.Client) (*models.Tokens, error) {
+	ret := _m.Called(ctx, cred, client)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Login")
+	}
+
+	var r0 *models.Tokens
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.Credentials, models.Client) (*models.Tokens, error)); ok {
+		return rf(ctx, cred, client)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, models.Credentials, models.Client) *models.Tokens); ok {
+		r0 = rf(ctx, cred, client)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Tokens)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, models.Credentials, models.Client) error); ok {
+		r1 = rf(ctx, cred, client)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// LoginMFA provides a mock function with given fields: ctx, req, client
+func (_m *Service) LoginMFA(ctx context.Context, req models.MFALogin, client models.Client) (*models.Tokens, error) {
+	ret := _m.Called(ctx, req, client)
+
+	if len(ret) == 0 {
+		panic("no return value specified for LoginMFA")
+	}
+
+	var r0 *models.Tokens
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.MFALogin, models.Client) (*models.Tokens, error)); ok {
+		return rf(ctx, req, client)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, models.MFALogin, models.Client) *models.Tokens); ok {
+		r0 = rf(ctx, req, client)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Tokens)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, models.MFALogin, models.Client) error); ok {
+		r1 = rf(ctx, req, client)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Logout provides a mock function with given fields: ctx, claims, refreshToken
+func (_m *Service) Logout(ctx context.Context, claims jwt.Claims, refreshToken string) error {
+	ret := _m.Called(ctx, claims, refreshToken)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Logout")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, jwt.Claims, string) error); ok {
+		r0 = rf(ctx, claims, refreshToken)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// LogoutAll provides a mock function with given fields: ctx, userID
+func (_m *Service) LogoutAll(ctx context.Context, userID models.UserID) error {
+	ret := _m.Called(ctx, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for LogoutAll")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.UserID) error); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // Order provides a mock function with given fields: ctx, orderID, userID
 func (_m *Service) Order(ctx context.Context, orderID models.OrderID, userID models.UserID) error {
 	ret := _m.Called(ctx, orderID, userID)
 
+	if len(ret) == 0 {
+		panic("no return value specified for Order")
+	}
+
 	var r0 error
 	if rf, ok := ret.Get(0).(func(context.Context, models.OrderID, models.UserID) error); ok {
 		r0 = rf(ctx, orderID, userID)
@@ -53,10 +423,44 @@ func (_m *Service) Order(ctx context.Context, orderID models.OrderID, userID mod
 	return r0
 }
 
+// OrderByUserID provides a mock function with given fields: ctx, userID, orderID
+func (_m *Service) OrderByUserID(ctx context.Context, userID models.UserID, orderID models.OrderID) (*models.OrderDetails, error) {
+	ret := _m.Called(ctx, userID, orderID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for OrderByUserID")
+	}
+
+	var r0 *models.OrderDetails
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.UserID, models.OrderID) (*models.OrderDetails, error)); ok {
+		return rf(ctx, userID, orderID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, models.UserID, models.OrderID) *models.OrderDetails); ok {
+		r0 = rf(ctx, userID, orderID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.OrderDetails)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, models.UserID, models.OrderID) error); ok {
+		r1 = rf(ctx, userID, orderID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // OrdersByUserID provides a mock function with given fields: ctx, userID
 func (_m *Service) OrdersByUserID(ctx context.Context, userID models.UserID) ([]models.Order, error) {
 	ret := _m.Called(ctx, userID)
 
+	if len(ret) == 0 {
+		panic("no return value specified for OrdersByUserID")
+	}
+
 	var r0 []models.Order
 	var r1 error
 	if rf, ok := ret.Get(0).(func(context.Context, models.UserID) ([]models.Order, error)); ok {
@@ -79,23 +483,337 @@ func (_m *Service) OrdersByUserID(ctx context.Context, userID models.UserID) ([]
 	return r0, r1
 }
 
-// Register provides a mock function with given fields: ctx, cred
-func (_m *Service) Register(ctx context.Context, cred models.Credentials) (string, error) {
-	ret := _m.Called(ctx, cred)
+// OrdersPageByUserID provides a mock function with given fields: ctx, userID, query
+func (_m *Service) OrdersPageByUserID(ctx context.Context, userID models.UserID, query models.OrdersQuery) (*models.OrdersPage, error) {
+	ret := _m.Called(ctx, userID, query)
+
+	if len(ret) == 0 {
+		panic("no return value specified for OrdersPageByUserID")
+	}
+
+	var r0 *models.OrdersPage
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.UserID, models.OrdersQuery) (*models.OrdersPage, error)); ok {
+		return rf(ctx, userID, query)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, models.UserID, models.OrdersQuery) *models.OrdersPage); ok {
+		r0 = rf(ctx, userID, query)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.OrdersPage)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, models.UserID, models.OrdersQuery) error); ok {
+		r1 = rf(ctx, userID, query)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PublicKeys provides a mock function with no fields
+func (_m *Service) PublicKeys() jwk.Set {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for PublicKeys")
+	}
+
+	var r0 jwk.Set
+	if rf, ok := ret.Get(0).(func() jwk.Set); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(jwk.Set)
+		}
+	}
+
+	return r0
+}
+
+// RecheckOrder provides a mock function with given fields: ctx, adminID, orderID
+func (_m *Service) RecheckOrder(ctx context.Context, adminID models.UserID, orderID models.OrderID) error {
+	ret := _m.Called(ctx, adminID, orderID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RecheckOrder")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.UserID, models.OrderID) error); ok {
+		r0 = rf(ctx, adminID, orderID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ReconcileBalances provides a mock function with given fields: ctx, adminID, correct
+func (_m *Service) ReconcileBalances(ctx context.Context, adminID models.UserID, correct bool) (*models.ReconciliationReport, error) {
+	ret := _m.Called(ctx, adminID, correct)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ReconcileBalances")
+	}
+
+	var r0 *models.ReconciliationReport
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.UserID, bool) (*models.ReconciliationReport, error)); ok {
+		return rf(ctx, adminID, correct)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, models.UserID, bool) *models.ReconciliationReport); ok {
+		r0 = rf(ctx, adminID, correct)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.ReconciliationReport)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, models.UserID, bool) error); ok {
+		r1 = rf(ctx, adminID, correct)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Refresh provides a mock function with given fields: ctx, refreshToken, client
+func (_m *Service) Refresh(ctx context.Context, refreshToken string, client models.Client) (*models.Tokens, error) {
+	ret := _m.Called(ctx, refreshToken, client)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Refresh")
+	}
+
+	var r0 *models.Tokens
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, models.Client) (*models.Tokens, error)); ok {
+		return rf(ctx, refreshToken, client)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, models.Client) *models.Tokens); ok {
+		r0 = rf(ctx, refreshToken, client)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Tokens)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, models.Client) error); ok {
+		r1 = rf(ctx, refreshToken, client)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Register provides a mock function with given fields: ctx, cred, client
+func (_m *Service) Register(ctx context.Context, cred models.Credentials, client models.Client) (*models.Tokens, error) {
+	ret := _m.Called(ctx, cred, client)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Register")
+	}
+
+	var r0 *models.Tokens
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.Credentials, models.Client) (*models.Tokens, error)); ok {
+		return rf(ctx, cred, client)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, models.Credentials, models.Client) *models.Tokens); ok {
+		r0 = rf(ctx, cred, client)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Tokens)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, models.Credentials, models.Client) error); ok {
+		r1 = rf(ctx, cred, client)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RequestPasswordReset provides a mock function with given fields: ctx, req
+func (_m *Service) RequestPasswordReset(ctx context.Context, req models.PasswordResetRequest) error {
+	ret := _m.Called(ctx, req)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RequestPasswordReset")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.PasswordResetRequest) error); ok {
+		r0 = rf(ctx, req)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ResetPassword provides a mock function with given fields: ctx, reset
+func (_m *Service) ResetPassword(ctx context.Context, reset models.PasswordReset) error {
+	ret := _m.Called(ctx, reset)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ResetPassword")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.PasswordReset) error); ok {
+		r0 = rf(ctx, reset)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RevokeAPIKey provides a mock function with given fields: ctx, userID, keyID
+func (_m *Service) RevokeAPIKey(ctx context.Context, userID models.UserID, keyID string) error {
+	ret := _m.Called(ctx, userID, keyID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RevokeAPIKey")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.UserID, string) error); ok {
+		r0 = rf(ctx, userID, keyID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RevokeSession provides a mock function with given fields: ctx, userID, sessionID
+func (_m *Service) RevokeSession(ctx context.Context, userID models.UserID, sessionID string) error {
+	ret := _m.Called(ctx, userID, sessionID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RevokeSession")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.UserID, string) error); ok {
+		r0 = rf(ctx, userID, sessionID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SearchUsers provides a mock function with given fields: ctx, login
+func (_m *Service) SearchUsers(ctx context.Context, login string) ([]models.User, error) {
+	ret := _m.Called(ctx, login)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SearchUsers")
+	}
+
+	var r0 []models.User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) ([]models.User, error)); ok {
+		return rf(ctx, login)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) []models.User); ok {
+		r0 = rf(ctx, login)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.User)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, login)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Sessions provides a mock function with given fields: ctx, claims
+func (_m *Service) Sessions(ctx context.Context, claims jwt.Claims) ([]models.Session, error) {
+	ret := _m.Called(ctx, claims)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Sessions")
+	}
 
-	var r0 string
+	var r0 []models.Session
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, models.Credentials) (string, error)); ok {
-		return rf(ctx, cred)
+	if rf, ok := ret.Get(0).(func(context.Context, jwt.Claims) ([]models.Session, error)); ok {
+		return rf(ctx, claims)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, models.Credentials) string); ok {
-		r0 = rf(ctx, cred)
+	if rf, ok := ret.Get(0).(func(context.Context, jwt.Claims) []models.Session); ok {
+		r0 = rf(ctx, claims)
 	} else {
-		r0 = ret.Get(0).(string)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.Session)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, jwt.Claims) error); ok {
+		r1 = rf(ctx, claims)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SetUserBlocked provides a mock function with given fields: ctx, adminID, userID, blocked
+func (_m *Service) SetUserBlocked(ctx context.Context, adminID models.UserID, userID models.UserID, blocked bool) error {
+	ret := _m.Called(ctx, adminID, userID, blocked)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetUserBlocked")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.UserID, models.UserID, bool) error); ok {
+		r0 = rf(ctx, adminID, userID, blocked)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UploadOrders provides a mock function with given fields: ctx, userID, orderIDs
+func (_m *Service) UploadOrders(ctx context.Context, userID models.UserID, orderIDs []models.OrderID) (*models.BulkUpload, error) {
+	ret := _m.Called(ctx, userID, orderIDs)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UploadOrders")
+	}
+
+	var r0 *models.BulkUpload
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.UserID, []models.OrderID) (*models.BulkUpload, error)); ok {
+		return rf(ctx, userID, orderIDs)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, models.UserID, []models.OrderID) *models.BulkUpload); ok {
+		r0 = rf(ctx, userID, orderIDs)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.BulkUpload)
+		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, models.Credentials) error); ok {
-		r1 = rf(ctx, cred)
+	if rf, ok := ret.Get(1).(func(context.Context, models.UserID, []models.OrderID) error); ok {
+		r1 = rf(ctx, userID, orderIDs)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -107,6 +825,10 @@ func (_m *Service) Register(ctx context.Context, cred models.Credentials) (strin
 func (_m *Service) UserBalance(ctx context.Context, userID models.UserID) (*models.Balance, error) {
 	ret := _m.Called(ctx, userID)
 
+	if len(ret) == 0 {
+		panic("no return value specified for UserBalance")
+	}
+
 	var r0 *models.Balance
 	var r1 error
 	if rf, ok := ret.Get(0).(func(context.Context, models.UserID) (*models.Balance, error)); ok {
@@ -129,10 +851,44 @@ func (_m *Service) UserBalance(ctx context.Context, userID models.UserID) (*mode
 	return r0, r1
 }
 
+// UserByID provides a mock function with given fields: ctx, userID
+func (_m *Service) UserByID(ctx context.Context, userID models.UserID) (*models.User, error) {
+	ret := _m.Called(ctx, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UserByID")
+	}
+
+	var r0 *models.User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.UserID) (*models.User, error)); ok {
+		return rf(ctx, userID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, models.UserID) *models.User); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.User)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, models.UserID) error); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Withdraw provides a mock function with given fields: ctx, userID, withdraw
 func (_m *Service) Withdraw(ctx context.Context, userID models.UserID, withdraw models.WithdrawBonuses) error {
 	ret := _m.Called(ctx, userID, withdraw)
 
+	if len(ret) == 0 {
+		panic("no return value specified for Withdraw")
+	}
+
 	var r0 error
 	if rf, ok := ret.Get(0).(func(context.Context, models.UserID, models.WithdrawBonuses) error); ok {
 		r0 = rf(ctx, userID, withdraw)
@@ -147,6 +903,10 @@ func (_m *Service) Withdraw(ctx context.Context, userID models.UserID, withdraw
 func (_m *Service) WithdrawalsByUserID(ctx context.Context, userID models.UserID) ([]models.WithdrawalsBonuses, error) {
 	ret := _m.Called(ctx, userID)
 
+	if len(ret) == 0 {
+		panic("no return value specified for WithdrawalsByUserID")
+	}
+
 	var r0 []models.WithdrawalsBonuses
 	var r1 error
 	if rf, ok := ret.Get(0).(func(context.Context, models.UserID) ([]models.WithdrawalsBonuses, error)); ok {
@@ -0,0 +1,296 @@
+package handlers
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+
+	"github.com/vladislav-kr/gophermart/internal/domain/models"
+	"github.com/vladislav-kr/gophermart/internal/domain/response"
+)
+
+// параметры постраничной выборки заказов
+const (
+	ordersParamStatus = "status"
+	ordersParamFrom   = "from"
+	ordersParamTo     = "to"
+	ordersParamSort   = "sort"
+	ordersParamLimit  = "limit"
+	ordersParamCursor = "cursor"
+)
+
+// формат даты без времени для границ интервала
+const dateLayout = "2006-01-02"
+
+// наибольший размер тела пакетной загрузки заказов
+const maxBulkBodyBytes = 1 << 20
+
+var errUnsupportedMediaType = errors.New("unsupported media type")
+
+// ordersPageRequested клиент передал хотя бы один параметр выборки,
+// без них список отдается целиком в прежнем формате
+func ordersPageRequested(r *http.Request) bool {
+	query := r.URL.Query()
+	for _, param := range []string{
+		ordersParamStatus,
+		ordersParamFrom,
+		ordersParamTo,
+		ordersParamSort,
+		ordersParamLimit,
+		ordersParamCursor,
+	} {
+		if query.Has(param) {
+			return true
+		}
+	}
+	return false
+}
+
+// страница заказов пользователя, ссылка на следующую передается
+// в поле next_cursor и в заголовке Link
+func (h *Handlers) ListOrdersPage(w http.ResponseWriter, r *http.Request) error {
+	userID, _ := userIDFromContext(r.Context())
+
+	query, err := parseOrdersQuery(r.URL.Query())
+	if err == nil {
+		ctx, cancel := context.WithTimeout(r.Context(), time.Second*4)
+		defer cancel()
+
+		var page *models.OrdersPage
+		page, err = h.service.OrdersPageByUserID(ctx, models.UserID(userID), query)
+		if err == nil {
+			if page.NextCursor != "" {
+				w.Header().Set("Link", nextPageLink(r.URL, page.NextCursor))
+			}
+			render.Status(r, http.StatusOK)
+			render.JSON(w, r, page)
+			return nil
+		}
+	}
+
+	var invalid *models.ValidationError
+	switch {
+	case errors.As(err, &invalid):
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, response.Validation("неверные параметры выборки", invalid))
+	default:
+		render.Status(r, http.StatusInternalServerError)
+		render.JSON(w, r, response.Error("внутренняя ошибка сервера"))
+	}
+	return fmt.Errorf("list orders page: %w", err)
+}
+
+// parseOrdersQuery разбор параметров запроса, вернет *models.ValidationError
+// с ошибками по каждому неразобранному параметру
+func parseOrdersQuery(values url.Values) (models.OrdersQuery, error) {
+	query := models.OrdersQuery{Cursor: values.Get(ordersParamCursor)}
+	fields := make([]models.FieldError, 0)
+
+	invalid := func(field, msg string) {
+		fields = append(fields, models.FieldError{
+			Field:   field,
+			Code:    models.CodeInvalidValue,
+			Message: msg,
+		})
+	}
+
+	// статусы через запятую или повтором параметра
+	for _, value := range values[ordersParamStatus] {
+		for _, status := range strings.Split(value, ",") {
+			if status = strings.ToUpper(strings.TrimSpace(status)); status != "" {
+				query.Statuses = append(query.Statuses, status)
+			}
+		}
+	}
+
+	var err error
+	if value := values.Get(ordersParamFrom); value != "" {
+		if query.From, err = parseBound(value, false); err != nil {
+			invalid(ordersParamFrom, "ожидается дата 2006-01-02 или время RFC 3339")
+		}
+	}
+	if value := values.Get(ordersParamTo); value != "" {
+		if query.To, err = parseBound(value, true); err != nil {
+			invalid(ordersParamTo, "ожидается дата 2006-01-02 или время RFC 3339")
+		}
+	}
+
+	switch values.Get(ordersParamSort) {
+	case "", "asc":
+	case "desc":
+		query.Desc = true
+	default:
+		invalid(ordersParamSort, "допустимо asc или desc")
+	}
+
+	if value := values.Get(ordersParamLimit); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit <= 0 {
+			invalid(ordersParamLimit, "ожидается положительное число")
+		}
+		query.Limit = limit
+	}
+
+	if len(fields) > 0 {
+		return query, &models.ValidationError{Fields: fields}
+	}
+	return query, nil
+}
+
+// parseBound граница интервала. Дата в конце интервала включает весь день.
+func parseBound(value string, end bool) (time.Time, error) {
+	if t, err := time.Parse(dateLayout, value); err == nil {
+		if end {
+			t = t.AddDate(0, 0, 1)
+		}
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+// nextPageLink ссылка на следующую страницу с теми же параметрами
+func nextPageLink(u *url.URL, cursor string) string {
+	query := u.Query()
+	query.Set(ordersParamCursor, cursor)
+	next := url.URL{Path: u.Path, RawQuery: query.Encode()}
+	return fmt.Sprintf(`<%s>; rel="next"`, next.String())
+}
+
+// заказ пользователя с историей смены статусов
+func (h *Handlers) OrderByUser(w http.ResponseWriter, r *http.Request) error {
+	userID, _ := userIDFromContext(r.Context())
+
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second*4)
+	defer cancel()
+
+	order, err := h.service.OrderByUserID(ctx, models.UserID(userID), models.OrderID(chi.URLParam(r, "number")))
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrIncorrectOrderNumber):
+			render.Status(r, http.StatusUnprocessableEntity)
+			render.JSON(w, r, response.Error("неверный формат номера заказа"))
+		case errors.Is(err, models.ErrNoRecordsFound):
+			render.Status(r, http.StatusNotFound)
+			render.JSON(w, r, response.Error("заказ не найден"))
+		default:
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("внутренняя ошибка сервера"))
+		}
+		return fmt.Errorf("order by user: %w", err)
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, order)
+	return nil
+}
+
+// пакетная загрузка номеров заказов JSON массивом или CSV файлом,
+// результат по каждому номеру соответствует загрузке одного заказа
+func (h *Handlers) SaveOrders(w http.ResponseWriter, r *http.Request) error {
+	userID, _ := userIDFromContext(r.Context())
+
+	defer r.Body.Close()
+	orderIDs, err := readOrderIDs(http.MaxBytesReader(w, r.Body, maxBulkBodyBytes), r.Header.Get("Content-Type"))
+	if err != nil {
+		switch {
+		case errors.Is(err, errUnsupportedMediaType):
+			render.Status(r, http.StatusUnsupportedMediaType)
+			render.JSON(w, r, response.Error("ожидается application/json или text/csv"))
+		default:
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Error("неверный формат запроса"))
+		}
+		return fmt.Errorf("read order numbers: %w", err)
+	}
+	if len(orderIDs) == 0 {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, response.Error("нет номеров заказов"))
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second*8)
+	defer cancel()
+
+	upload, err := h.service.UploadOrders(ctx, models.UserID(userID), orderIDs)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrTooManyOrders):
+			render.Status(r, http.StatusRequestEntityTooLarge)
+			render.JSON(w, r, response.Error(fmt.Sprintf("не более %d номеров за запрос", models.MaxBulkOrders)))
+		default:
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("внутренняя ошибка сервера"))
+		}
+		return fmt.Errorf("upload orders: %w", err)
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, upload)
+	return nil
+}
+
+// readOrderIDs номера заказов из тела запроса.
+// В JSON номера передаются строками или числами, в CSV берется первая колонка,
+// строка заголовка без цифр пропускается.
+func readOrderIDs(body io.Reader, contentType string) ([]models.OrderID, error) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, errUnsupportedMediaType
+	}
+
+	orderIDs := make([]models.OrderID, 0)
+
+	switch mediaType {
+	case "application/json":
+		items := make([]json.RawMessage, 0)
+		if err := json.NewDecoder(body).Decode(&items); err != nil {
+			return nil, err
+		}
+		for _, item := range items {
+			// строка может содержать что угодно, проверку Луна не пройдет
+			var number string
+			if err := json.Unmarshal(item, &number); err != nil {
+				number = string(item)
+			}
+			orderIDs = append(orderIDs, models.OrderID(strings.TrimSpace(number)))
+		}
+
+	case "text/csv":
+		reader := csv.NewReader(body)
+		reader.FieldsPerRecord = -1
+		reader.TrimLeadingSpace = true
+
+		for line := 0; ; line++ {
+			record, err := reader.Read()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				return nil, err
+			}
+
+			number := strings.TrimSpace(record[0])
+			if number == "" || line == 0 && !strings.ContainsAny(number, "0123456789") {
+				continue
+			}
+			orderIDs = append(orderIDs, models.OrderID(number))
+		}
+
+	default:
+		return nil, errUnsupportedMediaType
+	}
+
+	return orderIDs, nil
+}
@@ -0,0 +1,308 @@
+package handlers
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/vladislav-kr/gophermart/internal/api/handlers/mocks"
+	"github.com/vladislav-kr/gophermart/internal/domain/models"
+	"github.com/vladislav-kr/gophermart/internal/domain/money"
+)
+
+func TestHandlers_ListOrdersPage(t *testing.T) {
+	srv := mocks.NewService(t)
+	handlers := NewHandlers(srv, nil)
+
+	from := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name           string
+		target         string
+		callMock       bool
+		query          models.OrdersQuery
+		page           *models.OrdersPage
+		err            error
+		expectedStatus int
+		expectedLink   string
+	}{
+		{
+			name:     "страница со ссылкой на следующую",
+			target:   "/api/user/orders?status=new,processing&from=2024-03-01&to=2024-03-31&sort=desc&limit=1",
+			callMock: true,
+			query: models.OrdersQuery{
+				Statuses: []string{models.StatusNew, models.StatusProcessing},
+				From:     from,
+				To:       from.AddDate(0, 0, 31),
+				Desc:     true,
+				Limit:    1,
+			},
+			page: &models.OrdersPage{
+				Orders:     []models.Order{{OrderID: "2377225624", Status: models.StatusNew}},
+				NextCursor: "next",
+			},
+			expectedStatus: http.StatusOK,
+			expectedLink:   `</api/user/orders?cursor=next&from=2024-03-01&limit=1&sort=desc&status=new%2Cprocessing&to=2024-03-31>; rel="next"`,
+		},
+		{
+			name:           "последняя страница",
+			target:         "/api/user/orders?cursor=last",
+			callMock:       true,
+			query:          models.OrdersQuery{Cursor: "last"},
+			page:           &models.OrdersPage{Orders: []models.Order{}},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "неверные параметры",
+			target:         "/api/user/orders?from=вчера&sort=up&limit=-1",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:     "недействительный курсор",
+			target:   "/api/user/orders?cursor=garbage",
+			callMock: true,
+			query:    models.OrdersQuery{Cursor: "garbage"},
+			err: &models.ValidationError{Fields: []models.FieldError{
+				{Field: "cursor", Code: models.CodeInvalidValue},
+			}},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "ошибка сервиса",
+			target:         "/api/user/orders?limit=10",
+			callMock:       true,
+			query:          models.OrdersQuery{Limit: 10},
+			err:            fmt.Errorf("db error: %w", models.ErrInternal),
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			userID := uuid.NewString()
+
+			rr := httptest.NewRecorder()
+			req, err := http.NewRequestWithContext(
+				contextWithToken(t, userID),
+				http.MethodGet,
+				tt.target,
+				nil,
+			)
+			require.NoError(t, err)
+
+			if tt.callMock {
+				srv.On("OrdersPageByUserID",
+					mock.AnythingOfType("*context.timerCtx"),
+					models.UserID(userID),
+					tt.query,
+				).Return(tt.page, tt.err)
+			}
+
+			handlers.ListOrdersByUser(rr, req)
+
+			result := rr.Result()
+			defer result.Body.Close()
+			assert.Equal(t, tt.expectedStatus, result.StatusCode)
+			assert.Equal(t, tt.expectedLink, result.Header.Get("Link"))
+		})
+	}
+}
+
+func TestHandlers_OrderByUser(t *testing.T) {
+	srv := mocks.NewService(t)
+	handlers := NewHandlers(srv, nil)
+
+	uploaded := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name           string
+		order          *models.OrderDetails
+		err            error
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name: "заказ с историей",
+			order: &models.OrderDetails{
+				Order: models.Order{
+					OrderID:    "2377225624",
+					Status:     models.StatusProcessed,
+					UploadedAt: uploaded,
+					Accrual:    money.FromInt(500),
+				},
+				History: []models.OrderEvent{
+					{Status: models.StatusNew, ChangedAt: uploaded},
+					{Status: models.StatusProcessed, Accrual: money.FromInt(500), ChangedAt: uploaded.Add(time.Hour)},
+				},
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody: `{"number":"2377225624","status":"PROCESSED","uploaded_at":"2024-03-01T10:00:00Z","accrual":500,` +
+				`"history":[{"status":"NEW","accrual":0,"changed_at":"2024-03-01T10:00:00Z"},` +
+				`{"status":"PROCESSED","accrual":500,"changed_at":"2024-03-01T11:00:00Z"}]}`,
+		},
+		{
+			name:           "неверный номер заказа",
+			err:            models.ErrIncorrectOrderNumber,
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:           "заказ не найден",
+			err:            models.ErrNoRecordsFound,
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "ошибка сервиса",
+			err:            fmt.Errorf("db error: %w", models.ErrInternal),
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			userID := uuid.NewString()
+
+			rr := httptest.NewRecorder()
+			req, err := http.NewRequestWithContext(
+				contextWithURLParam(t, userID, "number", "2377225624"),
+				http.MethodGet,
+				"/",
+				nil,
+			)
+			require.NoError(t, err)
+
+			srv.On("OrderByUserID",
+				mock.AnythingOfType("*context.timerCtx"),
+				models.UserID(userID),
+				models.OrderID("2377225624"),
+			).Return(tt.order, tt.err)
+
+			handlers.OrderByUser(rr, req)
+
+			result := rr.Result()
+			defer result.Body.Close()
+			assert.Equal(t, tt.expectedStatus, result.StatusCode)
+
+			if tt.expectedBody != "" {
+				body, err := io.ReadAll(result.Body)
+				require.NoError(t, err)
+				assert.JSONEq(t, tt.expectedBody, string(body))
+			}
+		})
+	}
+}
+
+func TestHandlers_SaveOrders(t *testing.T) {
+	srv := mocks.NewService(t)
+	handlers := NewHandlers(srv, nil)
+
+	tests := []struct {
+		name           string
+		contentType    string
+		body           string
+		callMock       bool
+		orders         []models.OrderID
+		err            error
+		expectedStatus int
+	}{
+		{
+			name:           "JSON массив строк и чисел",
+			contentType:    "application/json",
+			body:           `["2377225624", 12345678903, "0012345"]`,
+			callMock:       true,
+			orders:         []models.OrderID{"2377225624", "12345678903", "0012345"},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "CSV с заголовком",
+			contentType:    "text/csv; charset=utf-8",
+			body:           "number,comment\n2377225624,чек\n\n12345678903\n",
+			callMock:       true,
+			orders:         []models.OrderID{"2377225624", "12345678903"},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "слишком много номеров",
+			contentType:    "application/json",
+			body:           `["2377225624"]`,
+			callMock:       true,
+			orders:         []models.OrderID{"2377225624"},
+			err:            models.ErrTooManyOrders,
+			expectedStatus: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:           "ошибка сервиса",
+			contentType:    "application/json",
+			body:           `["2377225624"]`,
+			callMock:       true,
+			orders:         []models.OrderID{"2377225624"},
+			err:            fmt.Errorf("db error: %w", models.ErrInternal),
+			expectedStatus: http.StatusInternalServerError,
+		},
+		{
+			name:           "неверный JSON",
+			contentType:    "application/json",
+			body:           `{"orders":[]}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "пустой список",
+			contentType:    "application/json",
+			body:           `[]`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "неподдерживаемый формат",
+			contentType:    "text/plain",
+			body:           "2377225624",
+			expectedStatus: http.StatusUnsupportedMediaType,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			userID := uuid.NewString()
+
+			rr := httptest.NewRecorder()
+			req, err := http.NewRequestWithContext(
+				contextWithToken(t, userID),
+				http.MethodPost,
+				"/",
+				strings.NewReader(tt.body),
+			)
+			require.NoError(t, err)
+			req.Header.Set("Content-Type", tt.contentType)
+
+			if tt.callMock {
+				upload := &models.BulkUpload{}
+				if tt.err != nil {
+					upload = nil
+				}
+				srv.On("UploadOrders",
+					mock.AnythingOfType("*context.timerCtx"),
+					models.UserID(userID),
+					tt.orders,
+				).Return(upload, tt.err)
+			}
+
+			handlers.SaveOrders(rr, req)
+
+			result := rr.Result()
+			defer result.Body.Close()
+			assert.Equal(t, tt.expectedStatus, result.StatusCode)
+		})
+	}
+}
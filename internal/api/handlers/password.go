@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/render"
+
+	"github.com/vladislav-kr/gophermart/internal/domain/models"
+	"github.com/vladislav-kr/gophermart/internal/domain/response"
+)
+
+// смена пароля аутентифицированным пользователем
+func (h *Handlers) ChangePassword(w http.ResponseWriter, r *http.Request) error {
+	userID, _ := userIDFromContext(r.Context())
+
+	change := models.PasswordChange{}
+	if err := render.DecodeJSON(r.Body, &change); err != nil {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, response.Error("неверный формат запроса"))
+		return fmt.Errorf("decode JSON: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second*4)
+	defer cancel()
+
+	tokens, err := h.service.ChangePassword(ctx, models.UserID(userID), change, clientFromRequest(r))
+	if err != nil {
+		var tooMany *models.TooManyAttemptsError
+		var invalid *models.ValidationError
+		switch {
+		case errors.As(err, &tooMany):
+			setRetryAfter(w, tooMany.RetryAfter)
+			render.Status(r, http.StatusTooManyRequests)
+			render.JSON(w, r, response.Error("слишком много попыток"))
+		case errors.As(err, &invalid):
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Validation("пароль не соответствует требованиям", invalid))
+		case errors.Is(err, models.ErrIncorrectPassword):
+			render.Status(r, http.StatusForbidden)
+			render.JSON(w, r, response.Error("неверный текущий пароль"))
+		case errors.Is(err, models.ErrUserBlocked),
+			errors.Is(err, models.ErrUserDeleted):
+			render.Status(r, http.StatusForbidden)
+			render.JSON(w, r, response.Error("доступ запрещен"))
+		default:
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("внутренняя ошибка сервера"))
+		}
+		return fmt.Errorf("change password: %w", err)
+	}
+
+	return h.writeTokens(w, r, tokens)
+}
+
+// запрос токена сброса пароля, ответ не зависит от наличия пользователя
+func (h *Handlers) RequestPasswordReset(w http.ResponseWriter, r *http.Request) error {
+	req := models.PasswordResetRequest{}
+	if err := render.DecodeJSON(r.Body, &req); err != nil {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, response.Error("неверный формат запроса"))
+		return fmt.Errorf("decode JSON: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second*4)
+	defer cancel()
+
+	if err := h.service.RequestPasswordReset(ctx, req); err != nil {
+		render.Status(r, http.StatusInternalServerError)
+		render.JSON(w, r, response.Error("внутренняя ошибка сервера"))
+		return fmt.Errorf("request password reset: %w", err)
+	}
+
+	render.Status(r, http.StatusAccepted)
+	render.JSON(w, r, response.OK())
+	return nil
+}
+
+// установка нового пароля по токену сброса
+func (h *Handlers) ResetPassword(w http.ResponseWriter, r *http.Request) error {
+	reset := models.PasswordReset{}
+	if err := render.DecodeJSON(r.Body, &reset); err != nil {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, response.Error("неверный формат запроса"))
+		return fmt.Errorf("decode JSON: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second*4)
+	defer cancel()
+
+	if err := h.service.ResetPassword(ctx, reset); err != nil {
+		var invalid *models.ValidationError
+		switch {
+		case errors.As(err, &invalid):
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Validation("пароль не соответствует требованиям", invalid))
+		case errors.Is(err, models.ErrInvalidResetToken):
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Error("токен сброса пароля недействителен"))
+		default:
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("внутренняя ошибка сервера"))
+		}
+		return fmt.Errorf("reset password: %w", err)
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, response.OK())
+	return nil
+}
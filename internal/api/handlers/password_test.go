@@ -0,0 +1,194 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/vladislav-kr/gophermart/internal/api/handlers/mocks"
+	"github.com/vladislav-kr/gophermart/internal/domain/models"
+)
+
+func TestHandlers_ChangePassword(t *testing.T) {
+	srv := mocks.NewService(t)
+	handlers := NewHandlers(srv, nil)
+
+	tests := []struct {
+		name           string
+		body           string
+		callMock       bool
+		change         models.PasswordChange
+		err            error
+		expectedStatus int
+	}{
+		{
+			name:     "пароль изменен",
+			body:     `{"old_password": "oldPassword1", "new_password": "newPassword1"}`,
+			callMock: true,
+			change: models.PasswordChange{
+				OldPassword: "oldPassword1",
+				NewPassword: "newPassword1",
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "неверный формат запроса",
+			body:           `{"old_password": `,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:     "неверный текущий пароль",
+			body:     `{"old_password": "wrongPassword1", "new_password": "newPassword1"}`,
+			callMock: true,
+			change: models.PasswordChange{
+				OldPassword: "wrongPassword1",
+				NewPassword: "newPassword1",
+			},
+			err:            models.ErrIncorrectPassword,
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:     "пароль не соответствует требованиям",
+			body:     `{"old_password": "oldPassword1", "new_password": "1"}`,
+			callMock: true,
+			change: models.PasswordChange{
+				OldPassword: "oldPassword1",
+				NewPassword: "1",
+			},
+			err:            &models.ValidationError{Fields: []models.FieldError{{Field: "new_password", Code: models.CodeTooShort}}},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			userID := models.UserID(uuid.NewString())
+
+			rr := httptest.NewRecorder()
+			req, err := http.NewRequestWithContext(
+				contextWithToken(t, string(userID)),
+				http.MethodPost,
+				"/",
+				strings.NewReader(tt.body),
+			)
+			require.NoError(t, err)
+
+			if tt.callMock {
+				var tokens *models.Tokens
+				if tt.err == nil {
+					tokens = &models.Tokens{AccessToken: "access-token", RefreshToken: "refresh-token"}
+				}
+				srv.On("ChangePassword", mock.AnythingOfType("*context.timerCtx"), userID, tt.change, mock.AnythingOfType("models.Client")).
+					Return(tokens, tt.err)
+			}
+
+			handlers.ChangePassword(rr, req)
+
+			result := rr.Result()
+			defer result.Body.Close()
+			assert.Equal(t, tt.expectedStatus, result.StatusCode)
+		})
+	}
+}
+
+func TestHandlers_RequestPasswordReset(t *testing.T) {
+	srv := mocks.NewService(t)
+	handlers := NewHandlers(srv, nil)
+
+	tests := []struct {
+		name           string
+		login          string
+		err            error
+		expectedStatus int
+	}{
+		{
+			name:           "запрос принят",
+			login:          "mylogin",
+			expectedStatus: http.StatusAccepted,
+		},
+		{
+			name:           "внутренняя ошибка сервера",
+			login:          "mylogin2",
+			err:            fmt.Errorf("failed to connect to the database"),
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			rr := httptest.NewRecorder()
+			req, err := http.NewRequest(
+				http.MethodPost,
+				"/",
+				strings.NewReader(fmt.Sprintf(`{"login": %q}`, tt.login)),
+			)
+			require.NoError(t, err)
+
+			srv.On("RequestPasswordReset", mock.AnythingOfType("*context.timerCtx"),
+				models.PasswordResetRequest{Login: tt.login}).Return(tt.err)
+
+			handlers.RequestPasswordReset(rr, req)
+
+			result := rr.Result()
+			defer result.Body.Close()
+			assert.Equal(t, tt.expectedStatus, result.StatusCode)
+		})
+	}
+}
+
+func TestHandlers_ResetPassword(t *testing.T) {
+	srv := mocks.NewService(t)
+	handlers := NewHandlers(srv, nil)
+
+	tests := []struct {
+		name           string
+		token          string
+		err            error
+		expectedStatus int
+	}{
+		{
+			name:           "пароль установлен",
+			token:          "reset-token",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "токен недействителен",
+			token:          "used-token",
+			err:            models.ErrInvalidResetToken,
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			rr := httptest.NewRecorder()
+			req, err := http.NewRequest(
+				http.MethodPost,
+				"/",
+				strings.NewReader(fmt.Sprintf(`{"token": %q, "new_password": "newPassword1"}`, tt.token)),
+			)
+			require.NoError(t, err)
+
+			srv.On("ResetPassword", mock.AnythingOfType("*context.timerCtx"),
+				models.PasswordReset{Token: tt.token, NewPassword: "newPassword1"}).Return(tt.err)
+
+			handlers.ResetPassword(rr, req)
+
+			result := rr.Result()
+			defer result.Body.Close()
+			assert.Equal(t, tt.expectedStatus, result.StatusCode)
+		})
+	}
+}
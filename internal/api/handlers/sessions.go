@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+
+	"github.com/vladislav-kr/gophermart/internal/domain/models"
+	"github.com/vladislav-kr/gophermart/internal/domain/response"
+	"github.com/vladislav-kr/gophermart/internal/service/jwt"
+)
+
+// список устройств, на которых выполнен вход
+func (h *Handlers) Sessions(w http.ResponseWriter, r *http.Request) error {
+	claims, _ := jwt.ClaimsFromContext(r.Context())
+
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second*4)
+	defer cancel()
+
+	sessions, err := h.service.Sessions(ctx, claims)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrNoRecordsFound):
+			render.Status(r, http.StatusNoContent)
+			render.JSON(w, r, response.OK())
+			return nil
+		default:
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("внутренняя ошибка сервера"))
+			return fmt.Errorf("sessions: %w", err)
+		}
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, sessions)
+	return nil
+}
+
+// завершение сессии, ее токены отзываются
+func (h *Handlers) RevokeSession(w http.ResponseWriter, r *http.Request) error {
+	userID, _ := userIDFromContext(r.Context())
+
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second*4)
+	defer cancel()
+
+	if err := h.service.RevokeSession(ctx, models.UserID(userID), chi.URLParam(r, "sessionID")); err != nil {
+		switch {
+		case errors.Is(err, models.ErrNoRecordsFound):
+			render.Status(r, http.StatusNotFound)
+			render.JSON(w, r, response.Error("сессия не найдена"))
+		default:
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("внутренняя ошибка сервера"))
+		}
+		return fmt.Errorf("revoke session: %w", err)
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, response.OK())
+	return nil
+}
@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/vladislav-kr/gophermart/internal/api/handlers/mocks"
+	"github.com/vladislav-kr/gophermart/internal/domain/models"
+	"github.com/vladislav-kr/gophermart/internal/service/jwt"
+)
+
+func TestHandlers_Sessions(t *testing.T) {
+	srv := mocks.NewService(t)
+	handlers := NewHandlers(srv, nil)
+
+	tests := []struct {
+		name           string
+		sessions       []models.Session
+		err            error
+		expectedStatus int
+	}{
+		{
+			name: "список сессий",
+			sessions: []models.Session{
+				{SessionID: uuid.NewString(), IP: "10.0.0.1", UserAgent: "Mozilla/5.0", Current: true},
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "нет сессий",
+			err:            models.ErrNoRecordsFound,
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			name:           "ошибка сервиса",
+			err:            fmt.Errorf("db error: %w", models.ErrInternal),
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			userID := uuid.NewString()
+
+			rr := httptest.NewRecorder()
+			req, err := http.NewRequestWithContext(
+				contextWithToken(t, userID),
+				http.MethodGet,
+				"/",
+				nil,
+			)
+			require.NoError(t, err)
+
+			srv.On("Sessions",
+				mock.AnythingOfType("*context.timerCtx"),
+				mock.MatchedBy(func(claims jwt.Claims) bool {
+					return claims.UserID == userID
+				}),
+			).Return(tt.sessions, tt.err)
+
+			handlers.Sessions(rr, req)
+
+			result := rr.Result()
+			defer result.Body.Close()
+			assert.Equal(t, tt.expectedStatus, result.StatusCode)
+		})
+	}
+}
+
+func TestHandlers_RevokeSession(t *testing.T) {
+	srv := mocks.NewService(t)
+	handlers := NewHandlers(srv, nil)
+
+	tests := []struct {
+		name           string
+		err            error
+		expectedStatus int
+	}{
+		{
+			name:           "сессия завершена",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "сессия не найдена",
+			err:            models.ErrNoRecordsFound,
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			userID := uuid.NewString()
+			sessionID := uuid.NewString()
+
+			rr := httptest.NewRecorder()
+			req, err := http.NewRequestWithContext(
+				contextWithURLParam(t, userID, "sessionID", sessionID),
+				http.MethodDelete,
+				"/",
+				nil,
+			)
+			require.NoError(t, err)
+
+			srv.On("RevokeSession", mock.AnythingOfType("*context.timerCtx"), models.UserID(userID), sessionID).
+				Return(tt.err)
+
+			handlers.RevokeSession(rr, req)
+
+			result := rr.Result()
+			defer result.Body.Close()
+			assert.Equal(t, tt.expectedStatus, result.StatusCode)
+		})
+	}
+}
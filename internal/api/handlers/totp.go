@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/render"
+
+	"github.com/vladislav-kr/gophermart/internal/domain/models"
+	"github.com/vladislav-kr/gophermart/internal/domain/response"
+)
+
+// второй шаг входа: токен после проверки пароля и код TOTP или код восстановления
+func (h *Handlers) LoginMFA(w http.ResponseWriter, r *http.Request) error {
+	req := models.MFALogin{}
+	if err := render.DecodeJSON(r.Body, &req); err != nil {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, response.Error("неверный формат запроса"))
+		return fmt.Errorf("decode JSON: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second*4)
+	defer cancel()
+
+	tokens, err := h.service.LoginMFA(ctx, req, clientFromRequest(r))
+	if err != nil {
+		var tooMany *models.TooManyAttemptsError
+		switch {
+		case errors.As(err, &tooMany):
+			setRetryAfter(w, tooMany.RetryAfter)
+			render.Status(r, http.StatusTooManyRequests)
+			render.JSON(w, r, response.Error("слишком много попыток входа"))
+		case errors.Is(err, models.ErrInvalidMFAChallenge):
+			render.Status(r, http.StatusUnauthorized)
+			render.JSON(w, r, response.Error("токен второго шага недействителен, войдите заново"))
+		case errors.Is(err, models.ErrInvalidTOTPCode):
+			render.Status(r, http.StatusUnauthorized)
+			render.JSON(w, r, response.Error("неверный код"))
+		case errors.Is(err, models.ErrUserBlocked),
+			errors.Is(err, models.ErrUserDeleted):
+			render.Status(r, http.StatusForbidden)
+			render.JSON(w, r, response.Error("доступ запрещен"))
+		default:
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("внутренняя ошибка сервера"))
+		}
+		return fmt.Errorf("login mfa: %w", err)
+	}
+
+	return h.writeTokens(w, r, tokens)
+}
+
+// начало подключения 2FA, секрет действует после подтверждения
+func (h *Handlers) EnrollTOTP(w http.ResponseWriter, r *http.Request) error {
+	userID, _ := userIDFromContext(r.Context())
+
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second*4)
+	defer cancel()
+
+	enrollment, err := h.service.EnrollTOTP(ctx, models.UserID(userID))
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrTOTPAlreadyEnabled):
+			render.Status(r, http.StatusConflict)
+			render.JSON(w, r, response.Error("двухфакторная аутентификация уже включена"))
+		default:
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("внутренняя ошибка сервера"))
+		}
+		return fmt.Errorf("enroll totp: %w", err)
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, enrollment)
+	return nil
+}
+
+// подтверждение 2FA первым кодом, в ответе коды восстановления
+func (h *Handlers) ConfirmTOTP(w http.ResponseWriter, r *http.Request) error {
+	userID, _ := userIDFromContext(r.Context())
+
+	code := models.TOTPCode{}
+	if err := render.DecodeJSON(r.Body, &code); err != nil {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, response.Error("неверный формат запроса"))
+		return fmt.Errorf("decode JSON: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second*4)
+	defer cancel()
+
+	codes, err := h.service.ConfirmTOTP(ctx, models.UserID(userID), code)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrInvalidTOTPCode):
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Error("неверный код"))
+		case errors.Is(err, models.ErrTOTPNotEnrolled):
+			render.Status(r, http.StatusNotFound)
+			render.JSON(w, r, response.Error("подключение 2FA не начато"))
+		case errors.Is(err, models.ErrTOTPAlreadyEnabled):
+			render.Status(r, http.StatusConflict)
+			render.JSON(w, r, response.Error("двухфакторная аутентификация уже включена"))
+		default:
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("внутренняя ошибка сервера"))
+		}
+		return fmt.Errorf("confirm totp: %w", err)
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, codes)
+	return nil
+}
@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/vladislav-kr/gophermart/internal/api/handlers/mocks"
+	"github.com/vladislav-kr/gophermart/internal/domain/models"
+)
+
+func TestHandlers_LoginMFA(t *testing.T) {
+	srv := mocks.NewService(t)
+	handlers := NewHandlers(srv, nil)
+
+	tests := []struct {
+		name           string
+		body           string
+		callMock       bool
+		req            models.MFALogin
+		tokens         *models.Tokens
+		err            error
+		expectedStatus int
+	}{
+		{
+			name:           "некорректный json",
+			body:           `{"challenge_token": "token"`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:     "вход выполнен",
+			body:     `{"challenge_token": "token-1", "code": "123456"}`,
+			callMock: true,
+			req:      models.MFALogin{ChallengeToken: "token-1", Code: "123456"},
+			tokens: &models.Tokens{
+				AccessToken:  "access-token",
+				RefreshToken: "refresh-token",
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "неверный код",
+			body:           `{"challenge_token": "token-2", "code": "000000"}`,
+			callMock:       true,
+			req:            models.MFALogin{ChallengeToken: "token-2", Code: "000000"},
+			err:            models.ErrInvalidTOTPCode,
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "токен второго шага недействителен",
+			body:           `{"challenge_token": "token-3", "code": "123456"}`,
+			callMock:       true,
+			req:            models.MFALogin{ChallengeToken: "token-3", Code: "123456"},
+			err:            models.ErrInvalidMFAChallenge,
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "слишком много попыток",
+			body:           `{"challenge_token": "token-4", "code": "123456"}`,
+			callMock:       true,
+			req:            models.MFALogin{ChallengeToken: "token-4", Code: "123456"},
+			err:            &models.TooManyAttemptsError{RetryAfter: time.Second},
+			expectedStatus: http.StatusTooManyRequests,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			rr := httptest.NewRecorder()
+			req, err := http.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+			require.NoError(t, err)
+
+			if tt.callMock {
+				srv.On("LoginMFA", mock.AnythingOfType("*context.timerCtx"), tt.req, mock.AnythingOfType("models.Client")).
+					Return(tt.tokens, tt.err)
+			}
+
+			handlers.LoginMFA(rr, req)
+
+			result := rr.Result()
+			defer result.Body.Close()
+			assert.Equal(t, tt.expectedStatus, result.StatusCode)
+		})
+	}
+}
+
+func TestHandlers_ConfirmTOTP(t *testing.T) {
+	srv := mocks.NewService(t)
+	handlers := NewHandlers(srv, nil)
+
+	tests := []struct {
+		name           string
+		code           string
+		err            error
+		expectedStatus int
+	}{
+		{
+			name:           "2FA включена",
+			code:           "123456",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "неверный код",
+			code:           "000000",
+			err:            models.ErrInvalidTOTPCode,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "подключение не начато",
+			code:           "111111",
+			err:            models.ErrTOTPNotEnrolled,
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "уже включена",
+			code:           "222222",
+			err:            models.ErrTOTPAlreadyEnabled,
+			expectedStatus: http.StatusConflict,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			userID := "1cf50925-d72d-488b-94e5-426acce77f3c"
+
+			rr := httptest.NewRecorder()
+			req, err := http.NewRequestWithContext(
+				contextWithToken(t, userID),
+				http.MethodPost,
+				"/",
+				strings.NewReader(`{"code": "`+tt.code+`"}`),
+			)
+			require.NoError(t, err)
+
+			var codes *models.RecoveryCodes
+			if tt.err == nil {
+				codes = &models.RecoveryCodes{Codes: []string{"abcd-efgh"}}
+			}
+			srv.On("ConfirmTOTP", mock.AnythingOfType("*context.timerCtx"), models.UserID(userID), models.TOTPCode{Code: tt.code}).
+				Return(codes, tt.err)
+
+			handlers.ConfirmTOTP(rr, req)
+
+			result := rr.Result()
+			defer result.Body.Close()
+			assert.Equal(t, tt.expectedStatus, result.StatusCode)
+		})
+	}
+}
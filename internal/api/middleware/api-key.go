@@ -0,0 +1,84 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+
+	"github.com/go-chi/jwtauth/v5"
+	"github.com/go-chi/render"
+	"github.com/vladislav-kr/gophermart/internal/domain/models"
+	"github.com/vladislav-kr/gophermart/internal/domain/response"
+	"github.com/vladislav-kr/gophermart/internal/service/jwt"
+)
+
+// APIKeyHeader заголовок с API ключом партнера или интеграции
+const APIKeyHeader = "X-API-Key"
+
+type APIKeyAuthenticator interface {
+	AuthenticateAPIKey(ctx context.Context, key string, scope models.APIKeyScope, client models.Client) (*models.APIKey, error)
+}
+
+// APIKey аутентификация по API ключу параллельно Verifier.
+// Запрос с заголовком X-API-Key проверяется ключом с правом scope,
+// в контекст кладется токен владельца ключа с ролью user.
+// Запрос без заголовка проходит через цепочку jwtChain.
+func APIKey(a APIKeyAuthenticator, scope models.APIKeyScope, jwtChain ...func(http.Handler) http.Handler) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		withJWT := next
+		for i := len(jwtChain) - 1; i >= 0; i-- {
+			withJWT = jwtChain[i](withJWT)
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(APIKeyHeader)
+			if key == "" {
+				withJWT.ServeHTTP(w, r)
+				return
+			}
+
+			apiKey, err := a.AuthenticateAPIKey(r.Context(), key, scope, clientFromRequest(r))
+			if err != nil {
+				switch {
+				case errors.Is(err, models.ErrInvalidAPIKey):
+					render.Status(r, http.StatusUnauthorized)
+					render.JSON(w, r, response.Error("недействительный API ключ"))
+				case errors.Is(err, models.ErrAPIKeyScope):
+					render.Status(r, http.StatusForbidden)
+					render.JSON(w, r, response.Error("недостаточно прав"))
+				case errors.Is(err, models.ErrUserBlocked),
+					errors.Is(err, models.ErrUserDeleted):
+					render.Status(r, http.StatusForbidden)
+					render.JSON(w, r, response.Error("доступ запрещен"))
+				default:
+					render.Status(r, http.StatusInternalServerError)
+					render.JSON(w, r, response.Error("внутренняя ошибка сервера"))
+				}
+				return
+			}
+
+			token, err := jwt.NewAPIKeyToken(string(apiKey.UserID), apiKey.KeyID)
+			if err != nil {
+				render.Status(r, http.StatusInternalServerError)
+				render.JSON(w, r, response.Error("внутренняя ошибка сервера"))
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(
+				jwtauth.NewContext(r.Context(), token, nil),
+			))
+		})
+	}
+}
+
+func clientFromRequest(r *http.Request) models.Client {
+	ip := r.RemoteAddr
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		ip = host
+	}
+	return models.Client{
+		IP:        ip,
+		UserAgent: r.UserAgent(),
+	}
+}
@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+
+	"github.com/go-chi/render"
+	"github.com/vladislav-kr/gophermart/internal/domain/response"
+)
+
+// CSRF защита double-submit для запросов, аутентифицированных cookie:
+// изменяющий запрос должен передать в заголовке header значение cookie cookieName.
+// Запросы с заголовком Authorization и без cookie authCookies не проверяются,
+// их браузер не отправит от имени пользователя.
+func CSRF(cookieName, header string, authCookies ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if safeMethod(r.Method) || r.Header.Get("Authorization") != "" || !hasCookie(r, authCookies) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			cookie, err := r.Cookie(cookieName)
+			token := r.Header.Get(header)
+			if err != nil || cookie.Value == "" || token == "" ||
+				subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(token)) != 1 {
+				render.Status(r, http.StatusForbidden)
+				render.JSON(w, r, response.Error("недействительный CSRF токен"))
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func safeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	return false
+}
+
+func hasCookie(r *http.Request, names []string) bool {
+	for _, name := range names {
+		if _, err := r.Cookie(name); err == nil {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,104 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCSRF(t *testing.T) {
+	const (
+		csrfCookie = "csrf_token"
+		csrfHeader = "X-CSRF-Token"
+		authCookie = "access_token"
+	)
+
+	tests := []struct {
+		name          string
+		method        string
+		authCookie    bool
+		authorization string
+		csrfCookie    string
+		csrfHeader    string
+		wantStatus    int
+	}{
+		{
+			name:       "cookie без заголовка",
+			method:     http.MethodPost,
+			authCookie: true,
+			csrfCookie: "token",
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "cookie с другим значением заголовка",
+			method:     http.MethodPost,
+			authCookie: true,
+			csrfCookie: "token",
+			csrfHeader: "other",
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "cookie без csrf cookie",
+			method:     http.MethodDelete,
+			authCookie: true,
+			csrfHeader: "token",
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "заголовок совпадает с cookie",
+			method:     http.MethodPost,
+			authCookie: true,
+			csrfCookie: "token",
+			csrfHeader: "token",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:          "аутентификация заголовком Authorization",
+			method:        http.MethodPost,
+			authCookie:    true,
+			authorization: "Bearer token",
+			wantStatus:    http.StatusOK,
+		},
+		{
+			name:       "безопасный метод",
+			method:     http.MethodGet,
+			authCookie: true,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "запрос без cookie аутентификации",
+			method:     http.MethodPost,
+			wantStatus: http.StatusOK,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(tt.method, "/api/user/orders", nil)
+			if tt.authCookie {
+				req.AddCookie(&http.Cookie{Name: authCookie, Value: "jwt"})
+			}
+			if tt.csrfCookie != "" {
+				req.AddCookie(&http.Cookie{Name: csrfCookie, Value: tt.csrfCookie})
+			}
+			if tt.csrfHeader != "" {
+				req.Header.Set(csrfHeader, tt.csrfHeader)
+			}
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			rr := httptest.NewRecorder()
+
+			CSRF(csrfCookie, csrfHeader, authCookie)(next).ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.wantStatus, rr.Code)
+		})
+	}
+}
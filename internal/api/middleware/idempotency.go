@@ -0,0 +1,140 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/httplog/v2"
+	"github.com/go-chi/render"
+	"github.com/vladislav-kr/gophermart/internal/domain/models"
+	"github.com/vladislav-kr/gophermart/internal/domain/response"
+	"github.com/vladislav-kr/gophermart/internal/service/jwt"
+)
+
+const (
+	// IdempotencyKeyHeader ключ, по которому повтор запроса получает первый ответ
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotentReplayedHeader признак повторно отданного ответа
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+)
+
+// наибольшее тело запроса, которое читается для отпечатка
+const maxIdempotentBodyBytes = 1 << 20
+
+//go:generate mockery --name IdempotencyStore
+type IdempotencyStore interface {
+	BeginIdempotent(ctx context.Context, req models.IdempotentRequest) (*models.IdempotentResponse, error)
+	CompleteIdempotent(ctx context.Context, req models.IdempotentRequest, resp models.IdempotentResponse) error
+	ReleaseIdempotent(ctx context.Context, req models.IdempotentRequest) error
+}
+
+// Idempotency повторяет первый ответ на запрос с тем же заголовком Idempotency-Key
+// от того же пользователя. Повтор ключа с другим телом отклоняется с 422,
+// пока первый запрос выполняется - с 409. Ответы 5xx не сохраняются.
+// Подключается после аутентификации, запросы без заголовка не затрагивает.
+func Idempotency(store IdempotencyStore) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(IdempotencyKeyHeader)
+			claims, ok := jwt.ClaimsFromContext(r.Context())
+			if key == "" || safeMethod(r.Method) || !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			body, err := io.ReadAll(io.LimitReader(r.Body, maxIdempotentBodyBytes+1))
+			_ = r.Body.Close()
+			if err != nil || len(body) > maxIdempotentBodyBytes {
+				render.Status(r, http.StatusRequestEntityTooLarge)
+				render.JSON(w, r, response.Error("слишком большой запрос"))
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			req := models.IdempotentRequest{
+				UserID: models.UserID(claims.UserID),
+				Key:    key,
+				Hash:   requestHash(r, body),
+			}
+
+			saved, err := store.BeginIdempotent(r.Context(), req)
+			if err != nil {
+				switch {
+				case errors.Is(err, models.ErrInvalidIdempotencyKey):
+					render.Status(r, http.StatusBadRequest)
+					render.JSON(w, r, response.Error("недопустимый ключ идемпотентности"))
+				case errors.Is(err, models.ErrIdempotencyKeyReused):
+					render.Status(r, http.StatusUnprocessableEntity)
+					render.JSON(w, r, response.Error("ключ идемпотентности использован для другого запроса"))
+				case errors.Is(err, models.ErrIdempotencyInProgress):
+					render.Status(r, http.StatusConflict)
+					render.JSON(w, r, response.Error("запрос с этим ключом еще выполняется"))
+				default:
+					render.Status(r, http.StatusInternalServerError)
+					render.JSON(w, r, response.Error("внутренняя ошибка сервера"))
+				}
+				return
+			}
+
+			if saved != nil {
+				if saved.ContentType != "" {
+					w.Header().Set("Content-Type", saved.ContentType)
+				}
+				w.Header().Set(IdempotentReplayedHeader, "true")
+				w.WriteHeader(saved.StatusCode)
+				_, _ = w.Write(saved.Body)
+				return
+			}
+
+			// ответ сохраняется и после отмены запроса клиентом
+			ctx := context.WithoutCancel(r.Context())
+			completed := false
+			defer func() {
+				if !completed {
+					if err := store.ReleaseIdempotent(ctx, req); err != nil {
+						httplog.LogEntrySetField(r.Context(), "idempotency_error", slog.AnyValue(err))
+					}
+				}
+			}()
+
+			buf := &bytes.Buffer{}
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			ww.Tee(buf)
+
+			next.ServeHTTP(ww, r)
+
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+			if status >= http.StatusInternalServerError {
+				return
+			}
+
+			if err := store.CompleteIdempotent(ctx, req, models.IdempotentResponse{
+				StatusCode:  status,
+				ContentType: ww.Header().Get("Content-Type"),
+				Body:        buf.Bytes(),
+			}); err != nil {
+				httplog.LogEntrySetField(r.Context(), "idempotency_error", slog.AnyValue(err))
+				return
+			}
+			completed = true
+		})
+	}
+}
+
+// requestHash отпечаток запроса, по которому повтор отличается от нового запроса
+func requestHash(r *http.Request, body []byte) []byte {
+	h := sha256.New()
+	_, _ = io.WriteString(h, r.Method+" "+r.URL.RequestURI()+"\n")
+	_, _ = io.WriteString(h, r.Header.Get("Content-Type")+"\n")
+	_, _ = h.Write(body)
+	return h.Sum(nil)
+}
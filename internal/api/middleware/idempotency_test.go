@@ -0,0 +1,157 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/jwtauth/v5"
+	"github.com/lestrrat-go/jwx/v2/jwt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/vladislav-kr/gophermart/internal/api/middleware/mocks"
+	"github.com/vladislav-kr/gophermart/internal/domain/models"
+	servicejwt "github.com/vladislav-kr/gophermart/internal/service/jwt"
+)
+
+const testUserID = "5172509d-14b2-4ed0-9dc5-8c8838218426"
+
+func contextWithToken(t *testing.T, userID string) context.Context {
+	token, err := jwt.NewBuilder().
+		Issuer("gophermart").
+		Expiration(time.Now().Add(time.Minute*3)).
+		Claim(servicejwt.UserID, userID).
+		Build()
+	require.NoError(t, err)
+
+	return context.WithValue(context.Background(), jwtauth.TokenCtxKey, token)
+}
+
+func TestIdempotency(t *testing.T) {
+	matchRequest := mock.MatchedBy(func(req models.IdempotentRequest) bool {
+		return req.UserID == testUserID && req.Key == "retry-1" && len(req.Hash) > 0
+	})
+
+	tests := []struct {
+		name string
+		// ответ обработчика, 0 - обработчик не должен вызываться
+		handlerStatus int
+		saved         *models.IdempotentResponse
+		errBegin      error
+		wantComplete  bool
+		wantRelease   bool
+		wantStatus    int
+		wantBody      string
+		wantReplayed  bool
+	}{
+		{
+			name:          "первый запрос выполняется и сохраняется",
+			handlerStatus: http.StatusOK,
+			wantComplete:  true,
+			wantStatus:    http.StatusOK,
+			wantBody:      `{"status":"OK"}`,
+		},
+		{
+			name: "повтор получает сохраненный ответ",
+			saved: &models.IdempotentResponse{
+				StatusCode:  http.StatusAccepted,
+				ContentType: "application/json",
+				Body:        []byte(`{"status":"saved"}`),
+			},
+			wantStatus:   http.StatusAccepted,
+			wantBody:     `{"status":"saved"}`,
+			wantReplayed: true,
+		},
+		{
+			name:       "первый запрос еще выполняется",
+			errBegin:   models.ErrIdempotencyInProgress,
+			wantStatus: http.StatusConflict,
+		},
+		{
+			name:       "ключ с другим телом запроса",
+			errBegin:   models.ErrIdempotencyKeyReused,
+			wantStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:          "ответ 5xx освобождает ключ",
+			handlerStatus: http.StatusInternalServerError,
+			wantRelease:   true,
+			wantStatus:    http.StatusInternalServerError,
+			wantBody:      `{"status":"OK"}`,
+		},
+		{
+			name:          "ответ 4xx сохраняется",
+			handlerStatus: http.StatusUnprocessableEntity,
+			wantComplete:  true,
+			wantStatus:    http.StatusUnprocessableEntity,
+			wantBody:      `{"status":"OK"}`,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			store := mocks.NewIdempotencyStore(t)
+
+			store.On("BeginIdempotent", mock.Anything, matchRequest).Return(tt.saved, tt.errBegin).Once()
+			if tt.wantComplete {
+				store.On("CompleteIdempotent", mock.Anything, matchRequest,
+					models.IdempotentResponse{
+						StatusCode:  tt.handlerStatus,
+						ContentType: "application/json",
+						Body:        []byte(`{"status":"OK"}`),
+					},
+				).Return(nil).Once()
+			}
+			if tt.wantRelease {
+				store.On("ReleaseIdempotent", mock.Anything, matchRequest).Return(nil).Once()
+			}
+
+			called := false
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				called = true
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(tt.handlerStatus)
+				_, _ = w.Write([]byte(`{"status":"OK"}`))
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/api/user/balance/withdraw",
+				strings.NewReader(`{"order":"2377225624","sum":751}`)).
+				WithContext(contextWithToken(t, testUserID))
+			req.Header.Set(IdempotencyKeyHeader, "retry-1")
+			rr := httptest.NewRecorder()
+
+			Idempotency(store)(next).ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.handlerStatus != 0, called)
+			assert.Equal(t, tt.wantStatus, rr.Code)
+			if tt.wantBody != "" {
+				assert.JSONEq(t, tt.wantBody, rr.Body.String())
+			}
+			assert.Equal(t, tt.wantReplayed, rr.Header().Get(IdempotentReplayedHeader) == "true")
+		})
+	}
+}
+
+func TestIdempotency_withoutKey(t *testing.T) {
+	store := mocks.NewIdempotencyStore(t)
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	for _, method := range []string{http.MethodPost, http.MethodGet} {
+		req := httptest.NewRequest(method, "/", nil).WithContext(contextWithToken(t, testUserID))
+		if method == http.MethodGet {
+			req.Header.Set(IdempotencyKeyHeader, "retry-1")
+		}
+		rr := httptest.NewRecorder()
+
+		// запрос без ключа и безопасный метод хранилище не затрагивают
+		Idempotency(store)(next).ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusOK, rr.Code, method)
+	}
+}
@@ -0,0 +1,96 @@
+// Code generated by mockery v2.53.7. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+
+	models "github.com/vladislav-kr/gophermart/internal/domain/models"
+)
+
+// IdempotencyStore is an autogenerated mock type for the IdempotencyStore type
+type IdempotencyStore struct {
+	mock.Mock
+}
+
+// BeginIdempotent provides a mock function with given fields: ctx, req
+func (_m *IdempotencyStore) BeginIdempotent(ctx context.Context, req models.IdempotentRequest) (*models.IdempotentResponse, error) {
+	ret := _m.Called(ctx, req)
+
+	if len(ret) == 0 {
+		panic("no return value specified for BeginIdempotent")
+	}
+
+	var r0 *models.IdempotentResponse
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.IdempotentRequest) (*models.IdempotentResponse, error)); ok {
+		return rf(ctx, req)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, models.IdempotentRequest) *models.IdempotentResponse); ok {
+		r0 = rf(ctx, req)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.IdempotentResponse)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, models.IdempotentRequest) error); ok {
+		r1 = rf(ctx, req)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CompleteIdempotent provides a mock function with given fields: ctx, req, resp
+func (_m *IdempotencyStore) CompleteIdempotent(ctx context.Context, req models.IdempotentRequest, resp models.IdempotentResponse) error {
+	ret := _m.Called(ctx, req, resp)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CompleteIdempotent")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.IdempotentRequest, models.IdempotentResponse) error); ok {
+		r0 = rf(ctx, req, resp)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ReleaseIdempotent provides a mock function with given fields: ctx, req
+func (_m *IdempotencyStore) ReleaseIdempotent(ctx context.Context, req models.IdempotentRequest) error {
+	ret := _m.Called(ctx, req)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ReleaseIdempotent")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.IdempotentRequest) error); ok {
+		r0 = rf(ctx, req)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewIdempotencyStore creates a new instance of IdempotencyStore. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewIdempotencyStore(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *IdempotencyStore {
+	mock := &IdempotencyStore{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
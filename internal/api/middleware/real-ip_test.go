@@ -0,0 +1,99 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRealIP(t *testing.T) {
+	tests := []struct {
+		name       string
+		trusted    []string
+		remoteAddr string
+		xff        string
+		xRealIP    string
+		wantAddr   string
+	}{
+		{
+			name:       "без доверенных прокси заголовки игнорируются",
+			remoteAddr: "203.0.113.7:52000",
+			xff:        "198.51.100.1",
+			xRealIP:    "198.51.100.2",
+			wantAddr:   "203.0.113.7:52000",
+		},
+		{
+			name:       "заголовки от недоверенного адреса игнорируются",
+			trusted:    []string{"10.0.0.0/8"},
+			remoteAddr: "203.0.113.7:52000",
+			xff:        "198.51.100.1",
+			wantAddr:   "203.0.113.7:52000",
+		},
+		{
+			name:       "адрес клиента от доверенного прокси",
+			trusted:    []string{"10.0.0.0/8"},
+			remoteAddr: "10.0.0.5:52000",
+			xff:        "198.51.100.1",
+			wantAddr:   "198.51.100.1",
+		},
+		{
+			name:       "подделанное начало цепочки пропускается",
+			trusted:    []string{"10.0.0.0/8"},
+			remoteAddr: "10.0.0.5:52000",
+			xff:        "1.2.3.4, 198.51.100.1, 10.0.0.9",
+			wantAddr:   "198.51.100.1",
+		},
+		{
+			name:       "X-Real-IP от доверенного прокси",
+			trusted:    []string{"10.0.0.5"},
+			remoteAddr: "10.0.0.5:52000",
+			xRealIP:    "198.51.100.2",
+			wantAddr:   "198.51.100.2",
+		},
+		{
+			name:       "некорректный адрес в цепочке",
+			trusted:    []string{"10.0.0.0/8"},
+			remoteAddr: "10.0.0.5:52000",
+			xff:        "unknown",
+			wantAddr:   "10.0.0.5:52000",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			trusted, err := ParseTrustedProxies(tt.trusted)
+			require.NoError(t, err)
+
+			var gotAddr string
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotAddr = r.RemoteAddr
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/api/user/login", nil)
+			req.RemoteAddr = tt.remoteAddr
+			if tt.xff != "" {
+				req.Header.Set("X-Forwarded-For", tt.xff)
+			}
+			if tt.xRealIP != "" {
+				req.Header.Set("X-Real-IP", tt.xRealIP)
+			}
+
+			RealIP(trusted)(next).ServeHTTP(httptest.NewRecorder(), req)
+
+			assert.Equal(t, tt.wantAddr, gotAddr)
+		})
+	}
+}
+
+func TestParseTrustedProxies(t *testing.T) {
+	trusted, err := ParseTrustedProxies([]string{"10.0.0.0/8", " 192.168.1.1 ", "::1", ""})
+	require.NoError(t, err)
+	assert.Len(t, trusted, 3)
+
+	_, err = ParseTrustedProxies([]string{"proxy.local"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,33 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/go-chi/render"
+	"github.com/vladislav-kr/gophermart/internal/domain/models"
+	"github.com/vladislav-kr/gophermart/internal/domain/response"
+	"github.com/vladislav-kr/gophermart/internal/service/jwt"
+)
+
+// RequirePermission пропускает запрос, если роль из токена имеет разрешение perm,
+// подключается после ValidateToken
+func RequirePermission(perm models.Permission) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims, ok := jwt.ClaimsFromContext(r.Context())
+			if !ok {
+				render.Status(r, http.StatusUnauthorized)
+				render.JSON(w, r, response.Error("пользователь не аутентифицирован"))
+				return
+			}
+
+			if !models.Role(claims.Role).Can(perm) {
+				render.Status(r, http.StatusForbidden)
+				render.JSON(w, r, response.Error("недостаточно прав"))
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
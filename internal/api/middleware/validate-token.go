@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/render"
+	"github.com/vladislav-kr/gophermart/internal/domain/models"
+	"github.com/vladislav-kr/gophermart/internal/domain/response"
+	"github.com/vladislav-kr/gophermart/internal/service/jwt"
+)
+
+type TokenValidator interface {
+	ValidateToken(ctx context.Context, claims jwt.Claims) error
+}
+
+// ValidateToken отклоняет отозванные токены и токены заблокированных пользователей,
+// подключается после jwtauth.Authenticator
+func ValidateToken(v TokenValidator) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims, ok := jwt.ClaimsFromContext(r.Context())
+			if !ok {
+				render.Status(r, http.StatusUnauthorized)
+				render.JSON(w, r, response.Error("пользователь не аутентифицирован"))
+				return
+			}
+
+			if err := v.ValidateToken(r.Context(), claims); err != nil {
+				switch {
+				case errors.Is(err, models.ErrTokenRevoked):
+					render.Status(r, http.StatusUnauthorized)
+					render.JSON(w, r, response.Error("токен отозван"))
+				case errors.Is(err, models.ErrUserBlocked),
+					errors.Is(err, models.ErrUserDeleted):
+					render.Status(r, http.StatusForbidden)
+					render.JSON(w, r, response.Error("доступ запрещен"))
+				default:
+					render.Status(r, http.StatusInternalServerError)
+					render.JSON(w, r, response.Error("внутренняя ошибка сервера"))
+				}
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
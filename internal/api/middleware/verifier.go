@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/go-chi/jwtauth/v5"
+	"github.com/lestrrat-go/jwx/v2/jwt"
+)
+
+type tokenVerifier interface {
+	Verify(token string) (jwt.Token, error)
+}
+
+// Verifier аналог jwtauth.Verifier, проверяющий подпись набором открытых ключей.
+// Результат проверки кладется в контекст для jwtauth.Authenticator.
+func Verifier(v tokenVerifier, findTokenFns ...func(r *http.Request) string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var tokenString string
+			for _, fn := range findTokenFns {
+				if tokenString = fn(r); tokenString != "" {
+					break
+				}
+			}
+
+			if tokenString == "" {
+				next.ServeHTTP(w, r.WithContext(
+					jwtauth.NewContext(r.Context(), nil, jwtauth.ErrNoTokenFound),
+				))
+				return
+			}
+
+			token, err := v.Verify(tokenString)
+			if err != nil {
+				err = jwtauth.ErrorReason(err)
+			}
+
+			next.ServeHTTP(w, r.WithContext(
+				jwtauth.NewContext(r.Context(), token, err),
+			))
+		})
+	}
+}
@@ -1,7 +1,7 @@
 package router
 
 import (
-	"crypto/rsa"
+	"net"
 	"net/http"
 
 	"github.com/go-chi/chi/v5"
@@ -11,14 +11,36 @@ import (
 	"github.com/lestrrat-go/jwx/v2/jwa"
 	"github.com/vladislav-kr/gophermart/internal/api/handlers"
 	apiMiddleware "github.com/vladislav-kr/gophermart/internal/api/middleware"
+	"github.com/vladislav-kr/gophermart/internal/domain/models"
 	"github.com/vladislav-kr/gophermart/internal/logger"
+	"github.com/vladislav-kr/gophermart/internal/service/jwt"
 )
 
 // NewRouter конфигурирует главный роутер
-func NewRouter(h *handlers.Handlers, publicKey *rsa.PublicKey) *chi.Mux {
+func NewRouter(
+	h *handlers.Handlers,
+	keys *jwt.KeySet,
+	validator apiMiddleware.TokenValidator,
+	apiKeys apiMiddleware.APIKeyAuthenticator,
+	idempotency apiMiddleware.IdempotencyStore,
+	trustedProxies []*net.IPNet,
+) *chi.Mux {
 	log := logger.HTTPLogger()
 
-	auth := jwtauth.New(jwa.RS256.String(), publicKey, nil)
+	// подпись проверяется в apiMiddleware.Verifier набором ключей,
+	// jwtauth используется только для валидации claims
+	auth := jwtauth.New(jwa.RS256.String(), nil, nil)
+
+	// проверяется только для запросов с токенами в cookie
+	csrf := apiMiddleware.CSRF(
+		handlers.CSRFCookie,
+		handlers.CSRFHeader,
+		handlers.AccessTokenCookie,
+		handlers.RefreshTokenCookie,
+	)
+
+	// повтор запроса с заголовком Idempotency-Key получает первый ответ
+	idempotent := apiMiddleware.Idempotency(idempotency)
 
 	router := chi.NewRouter()
 
@@ -26,7 +48,7 @@ func NewRouter(h *handlers.Handlers, publicKey *rsa.PublicKey) *chi.Mux {
 		r.Use(
 			middleware.Recoverer,
 			middleware.RequestID,
-			middleware.RealIP,
+			apiMiddleware.RealIP(trustedProxies),
 			httplog.RequestLogger(log),
 			apiMiddleware.Recoverer,
 			apiMiddleware.RequestIncMertics,
@@ -37,32 +59,129 @@ func NewRouter(h *handlers.Handlers, publicKey *rsa.PublicKey) *chi.Mux {
 
 			// аутентификация пользователя
 			r.Method(http.MethodPost, "/api/user/login", handlers.Handler(h.Login))
+
+			// второй шаг входа для пользователей с 2FA
+			r.Method(http.MethodPost, "/api/user/login/2fa", handlers.Handler(h.LoginMFA))
+
+			// обновление пары токенов
+			r.With(csrf).Method(http.MethodPost, "/api/user/token/refresh", handlers.Handler(h.RefreshToken))
+
+			// запрос токена сброса пароля
+			r.Method(http.MethodPost, "/api/user/password/reset/request", handlers.Handler(h.RequestPasswordReset))
+
+			// установка нового пароля по токену сброса
+			r.Method(http.MethodPost, "/api/user/password/reset", handlers.Handler(h.ResetPassword))
 		})
 
 		r.Group(func(r chi.Router) {
-			r.Use(
-				middleware.Compress(5),
-				jwtauth.Verifier(auth),
+			r.Use(middleware.Compress(5))
+
+			authJWT := []func(http.Handler) http.Handler{
+				apiMiddleware.Verifier(keys, jwtauth.TokenFromHeader, jwtauth.TokenFromCookie),
 				jwtauth.Authenticator(auth),
-			)
+				apiMiddleware.ValidateToken(validator),
+				csrf,
+			}
+
+			//загрузка номера заказа для расчёта пользователем
+			//или партнером по API ключу от имени пользователя
+			r.With(apiMiddleware.APIKey(apiKeys, models.ScopeOrdersUpload, authJWT...), idempotent).
+				Method(http.MethodPost, "/api/user/orders", handlers.Handler(h.SaveOrder))
+
+			//пакетная загрузка номеров заказов JSON массивом или CSV файлом
+			r.With(apiMiddleware.APIKey(apiKeys, models.ScopeOrdersUpload, authJWT...), idempotent).
+				Method(http.MethodPost, "/api/user/orders/bulk", handlers.Handler(h.SaveOrders))
+
+			r.Group(func(r chi.Router) {
+				r.Use(authJWT...)
+
+				//выход, отзыв текущего токена
+				r.Method(http.MethodPost, "/api/user/logout", handlers.Handler(h.Logout))
+
+				//выход со всех устройств
+				r.Method(http.MethodPost, "/api/user/logout/all", handlers.Handler(h.LogoutAll))
+
+				//устройства, на которых выполнен вход, и завершение отдельной сессии
+				r.Method(http.MethodGet, "/api/user/sessions", handlers.Handler(h.Sessions))
+				r.Method(http.MethodDelete, "/api/user/sessions/{sessionID}", handlers.Handler(h.RevokeSession))
+
+				//выгрузка данных пользователя и удаление учетной записи
+				r.Method(http.MethodGet, "/api/user/export", handlers.Handler(h.ExportUserData))
+				r.Method(http.MethodDelete, "/api/user", handlers.Handler(h.DeleteAccount))
 
-			//загрузка пользователем номера заказа для расчёта
-			r.Method(http.MethodPost, "/api/user/orders", handlers.Handler(h.SaveOrder))
+				//смена пароля, отзывает все выданные токены
+				r.Method(http.MethodPost, "/api/user/password", handlers.Handler(h.ChangePassword))
 
-			//получение списка загруженных пользователем номеров заказов,
-			//статусов их обработки и информации о начислениях
-			r.Method(http.MethodGet, "/api/user/orders", handlers.Handler(h.ListOrdersByUser))
+				//подключение двухфакторной аутентификации TOTP
+				r.Method(http.MethodPost, "/api/user/2fa/totp", handlers.Handler(h.EnrollTOTP))
+				r.Method(http.MethodPost, "/api/user/2fa/totp/confirm", handlers.Handler(h.ConfirmTOTP))
 
-			//получение текущего баланса счёта баллов лояльности пользователя
-			r.Method(http.MethodGet, "/api/user/balance", handlers.Handler(h.BalanceByUser))
+				//API ключи партнеров и интеграций, действующие от имени пользователя
+				r.Method(http.MethodPost, "/api/user/api-keys", handlers.Handler(h.CreateAPIKey))
+				r.Method(http.MethodGet, "/api/user/api-keys", handlers.Handler(h.APIKeys))
+				r.Method(http.MethodDelete, "/api/user/api-keys/{keyID}", handlers.Handler(h.RevokeAPIKey))
 
-			//запрос на списание баллов с накопительного счёта в счёт оплаты нового заказа
-			r.Method(http.MethodPost, "/api/user/balance/withdraw", handlers.Handler(h.WithdrawBonuses))
+				//получение списка загруженных пользователем номеров заказов,
+				//статусов их обработки и информации о начислениях
+				r.Method(http.MethodGet, "/api/user/orders", handlers.Handler(h.ListOrdersByUser))
 
-			//получение информации о выводе средств с накопительного счёта пользователем
-			r.Method(http.MethodGet, "/api/user/withdrawals", handlers.Handler(h.HistoryWithdrawals))
+				//заказ с историей смены статусов
+				r.Method(http.MethodGet, "/api/user/orders/{number}", handlers.Handler(h.OrderByUser))
+
+				//получение текущего баланса счёта баллов лояльности пользователя
+				r.Method(http.MethodGet, "/api/user/balance", handlers.Handler(h.BalanceByUser))
+
+				//запрос на списание баллов с накопительного счёта в счёт оплаты нового заказа
+				r.With(idempotent).
+					Method(http.MethodPost, "/api/user/balance/withdraw", handlers.Handler(h.WithdrawBonuses))
+
+				//история начислений, списаний и корректировок баланса
+				r.Method(http.MethodGet, "/api/user/balance/history", handlers.Handler(h.BalanceHistory))
+
+				//получение информации о выводе средств с накопительного счёта пользователем
+				r.Method(http.MethodGet, "/api/user/withdrawals", handlers.Handler(h.HistoryWithdrawals))
+
+				//административные операции, доступ по разрешениям роли
+				r.Route("/api/admin", func(r chi.Router) {
+					r.Group(func(r chi.Router) {
+						r.Use(apiMiddleware.RequirePermission(models.PermissionUsersRead))
+
+						//поиск пользователей по логину
+						r.Method(http.MethodGet, "/users", handlers.Handler(h.AdminSearchUsers))
+						r.Method(http.MethodGet, "/users/{userID}", handlers.Handler(h.AdminUser))
+						r.Method(http.MethodGet, "/users/{userID}/orders", handlers.Handler(h.AdminUserOrders))
+						r.Method(http.MethodGet, "/users/{userID}/balance", handlers.Handler(h.AdminUserBalance))
+						r.Method(http.MethodGet, "/users/{userID}/withdrawals", handlers.Handler(h.AdminUserWithdrawals))
+
+						//расхождения балансов с заказами и списаниями
+						r.Method(http.MethodGet, "/balances/drift", handlers.Handler(h.AdminBalanceDrifts))
+					})
+
+					r.Group(func(r chi.Router) {
+						r.Use(apiMiddleware.RequirePermission(models.PermissionUsersBlock))
+
+						r.Method(http.MethodPost, "/users/{userID}/block", handlers.Handler(h.AdminBlockUser))
+						r.Method(http.MethodPost, "/users/{userID}/unblock", handlers.Handler(h.AdminUnblockUser))
+					})
+
+					//ручная корректировка баланса
+					r.With(apiMiddleware.RequirePermission(models.PermissionBalanceAdjust)).
+						Method(http.MethodPost, "/users/{userID}/balance/adjustments", handlers.Handler(h.AdminAdjustBalance))
+
+					//сверка балансов с исправлением расхождений
+					r.With(apiMiddleware.RequirePermission(models.PermissionBalanceAdjust)).
+						Method(http.MethodPost, "/balances/reconcile", handlers.Handler(h.AdminReconcileBalances))
+
+					//повторный расчёт заказа в системе начислений
+					r.With(apiMiddleware.RequirePermission(models.PermissionOrdersRecheck)).
+						Method(http.MethodPost, "/orders/{number}/recheck", handlers.Handler(h.AdminRecheckOrder))
+				})
+			})
 		})
 
+		//открытые ключи проверки токенов
+		r.Method(http.MethodGet, "/.well-known/jwks.json", handlers.Handler(h.JWKS))
+
 		//готов принимать запросы
 		r.Method(http.MethodGet, "/ready", handlers.Handler(h.Ready))
 	})
@@ -2,28 +2,35 @@ package app
 
 import (
 	"context"
-	"crypto/rand"
-	"crypto/rsa"
 	"fmt"
 	"io"
 	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/vladislav-kr/gophermart/internal/api/handlers"
 	httpserver "github.com/vladislav-kr/gophermart/internal/api/http-server"
+	apiMiddleware "github.com/vladislav-kr/gophermart/internal/api/middleware"
 	"github.com/vladislav-kr/gophermart/internal/api/router"
 	accrualsystem "github.com/vladislav-kr/gophermart/internal/clients/accrual-system"
+	"github.com/vladislav-kr/gophermart/internal/clients/notifier"
+	"github.com/vladislav-kr/gophermart/internal/domain/models"
 	"github.com/vladislav-kr/gophermart/internal/logger"
 	"github.com/vladislav-kr/gophermart/internal/service"
+	expirepoints "github.com/vladislav-kr/gophermart/internal/service/expire-points"
+	"github.com/vladislav-kr/gophermart/internal/service/jwt"
+	loginlimiter "github.com/vladislav-kr/gophermart/internal/service/login-limiter"
 	passwordgenerator "github.com/vladislav-kr/gophermart/internal/service/password-generator"
+	purgeidempotency "github.com/vladislav-kr/gophermart/internal/service/purge-idempotency"
+	reconcilebalances "github.com/vladislav-kr/gophermart/internal/service/reconcile-balances"
 	retrieveupdates "github.com/vladislav-kr/gophermart/internal/service/retrieve-updates"
+	"github.com/vladislav-kr/gophermart/internal/service/totp"
 	"github.com/vladislav-kr/gophermart/internal/storage/postgres"
 
-	"golang.org/x/crypto/bcrypt"
 	"golang.org/x/sync/errgroup"
 )
 
@@ -33,6 +40,8 @@ type HTTP struct {
 	WriteTimeout    time.Duration
 	IdleTimeout     time.Duration
 	ShutdownTimeout time.Duration
+	// доверенные прокси, передающие адрес клиента в заголовках
+	TrustedProxies []string
 }
 
 type AccrualSystem struct {
@@ -42,8 +51,16 @@ type AccrualSystem struct {
 	ReadTimeout   time.Duration
 }
 
+// Notifier доставка уведомлений пользователям
+type Notifier struct {
+	// log или file
+	Type string
+	File string
+}
+
 type Clients struct {
-	Accrual AccrualSystem
+	Accrual  AccrualSystem
+	Notifier Notifier
 }
 
 type WorkerUpdateOrdes struct {
@@ -53,8 +70,33 @@ type WorkerUpdateOrdes struct {
 	WorkersLimit uint8
 }
 
+// WorkerReconcileBalances периодическая сверка балансов
+type WorkerReconcileBalances struct {
+	// 0 - сверка отключена
+	Interval time.Duration
+	Timeout  time.Duration
+	Correct  bool
+}
+
+// WorkerExpirePoints периодическое сгорание баллов
+type WorkerExpirePoints struct {
+	// 0 - сгорание отключено
+	Interval time.Duration
+	Timeout  time.Duration
+}
+
+// WorkerPurgeIdempotency периодическое удаление истекших ключей идемпотентности
+type WorkerPurgeIdempotency struct {
+	// 0 - очистка отключена
+	Interval time.Duration
+	Timeout  time.Duration
+}
+
 type Workers struct {
-	UpdateOrders WorkerUpdateOrdes
+	UpdateOrders      WorkerUpdateOrdes
+	ReconcileBalances WorkerReconcileBalances
+	ExpirePoints      WorkerExpirePoints
+	PurgeIdempotency  WorkerPurgeIdempotency
 }
 
 type PostgresStorage struct {
@@ -65,11 +107,96 @@ type Storages struct {
 	Postgres PostgresStorage
 }
 
+type Auth struct {
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+	KeysDir         string
+	PrivateKeyFile  string
+	SigningKeyID    string
+	RevocationCache time.Duration
+	PasswordReset   time.Duration
+	MFAChallenge    time.Duration
+	TOTPIssuer      string
+	Cookie          Cookie
+}
+
+// Cookie выдача токенов браузерным клиентам в HttpOnly cookie
+type Cookie struct {
+	Enabled bool
+	Domain  string
+	Secure  bool
+	// strict, lax или none
+	SameSite string
+}
+
+// Password параметры argon2id для новых хешей паролей,
+// при их изменении хеши обновляются при входе
+type Password struct {
+	Argon2Memory      uint32
+	Argon2Iterations  uint32
+	Argon2Parallelism uint8
+}
+
+// CredentialField требования к логину или паролю, классы символов
+// lower, upper, digit, symbol, space, unicode
+type CredentialField struct {
+	MinLength int
+	MaxLength int
+	Allowed   []string
+	Symbols   string
+	Required  []string
+}
+
+type Credentials struct {
+	Login    CredentialField
+	Password CredentialField
+	// файл запрещенных паролей, по одному в строке
+	PasswordDenyList string
+}
+
+// Account удаление учетных записей
+type Account struct {
+	// forfeit или freeze
+	DeletedBalance string
+}
+
+// Idempotency хранение ответов на запросы с заголовком Idempotency-Key
+type Idempotency struct {
+	TTL time.Duration
+}
+
+// Points срок действия начисленных баллов
+type Points struct {
+	// срок сгорания в месяцах, 0 - баллы не сгорают
+	ExpiryMonths int
+	// окно баллов, которые скоро сгорят, в ответе баланса
+	ExpiringSoon time.Duration
+}
+
+type LoginLimit struct {
+	// memory или postgres
+	Store          string
+	FreeAttempts   int
+	MaxFailures    int
+	IPFreeAttempts int
+	IPMaxFailures  int
+	BaseDelay      time.Duration
+	MaxDelay       time.Duration
+	Lockout        time.Duration
+}
+
 type Option struct {
-	HTTP     HTTP
-	Clients  Clients
-	Storages Storages
-	Workers  Workers
+	HTTP        HTTP
+	Auth        Auth
+	Password    Password
+	Credentials Credentials
+	Account     Account
+	Idempotency Idempotency
+	Points      Points
+	LoginLimit  LoginLimit
+	Clients     Clients
+	Storages    Storages
+	Workers     Workers
 }
 
 type App struct {
@@ -97,16 +224,39 @@ func (a *App) Run(ctx context.Context) error {
 	)
 	defer sigCancel()
 
-	storage, err := postgres.New(ctx, postgres.Config{URI: a.opt.Storages.Postgres.URI})
+	policy, err := a.credentialPolicy()
+	if err != nil {
+		return err
+	}
+
+	handlerOpts, err := a.handlerOptions()
+	if err != nil {
+		return err
+	}
+
+	deletedBalance := models.DeletedBalancePolicy(a.opt.Account.DeletedBalance)
+	if !deletedBalance.Validate() {
+		return fmt.Errorf("unknown deleted balance policy %q", a.opt.Account.DeletedBalance)
+	}
+
+	storage, err := postgres.New(ctx, postgres.Config{
+		URI:                a.opt.Storages.Postgres.URI,
+		PointsExpiryMonths: a.opt.Points.ExpiryMonths,
+	})
 	if err != nil {
 		return err
 	}
 
 	a.closers = append(a.closers, storage)
 
-	key, err := rsa.GenerateKey(rand.Reader, 2<<10)
+	keys, err := a.keySet()
+	if err != nil {
+		return err
+	}
+
+	trustedProxies, err := apiMiddleware.ParseTrustedProxies(a.opt.HTTP.TrustedProxies)
 	if err != nil {
-		return fmt.Errorf("generate RSA private key: %w", err)
+		return err
 	}
 
 	accrual := accrualsystem.New(
@@ -116,7 +266,13 @@ func (a *App) Run(ctx context.Context) error {
 			a.opt.Clients.Accrual.RetryWaitTime,
 		),
 	)
-	passGen := passwordgenerator.New(bcrypt.DefaultCost)
+	passGen := passwordgenerator.New(
+		passwordgenerator.WithArgon2Params(passwordgenerator.Argon2Params{
+			Memory:      a.opt.Password.Argon2Memory,
+			Iterations:  a.opt.Password.Argon2Iterations,
+			Parallelism: a.opt.Password.Argon2Parallelism,
+		}),
+	)
 
 	updater := retrieveupdates.New(
 		accrual,
@@ -140,14 +296,51 @@ func (a *App) Run(ctx context.Context) error {
 		}
 	}()
 
+	srvc := service.NewService(passGen, storage, accrual, keys,
+		service.WithTokenTTL(
+			a.opt.Auth.AccessTokenTTL,
+			a.opt.Auth.RefreshTokenTTL,
+		),
+		service.WithRevocationCacheTTL(a.opt.Auth.RevocationCache),
+		service.WithLoginLimiter(a.loginLimiter(storage)),
+		service.WithNotifier(a.notifier()),
+		service.WithPasswordResetTTL(a.opt.Auth.PasswordReset),
+		service.WithCredentialPolicy(policy),
+		service.WithTOTP(totp.New(totp.WithIssuer(a.opt.Auth.TOTPIssuer))),
+		service.WithMFAChallengeTTL(a.opt.Auth.MFAChallenge),
+		service.WithDeletedBalancePolicy(deletedBalance),
+		service.WithIdempotencyTTL(a.opt.Idempotency.TTL),
+		service.WithExpiringSoon(a.opt.Points.ExpiringSoon),
+	)
+
+	go reconcilebalances.New(
+		srvc,
+		a.opt.Workers.ReconcileBalances.Interval,
+		a.opt.Workers.ReconcileBalances.Timeout,
+		a.opt.Workers.ReconcileBalances.Correct,
+	).Run(ctx)
+
+	go expirepoints.New(
+		srvc,
+		a.opt.Workers.ExpirePoints.Interval,
+		a.opt.Workers.ExpirePoints.Timeout,
+	).Run(ctx)
+
+	go purgeidempotency.New(
+		srvc,
+		a.opt.Workers.PurgeIdempotency.Interval,
+		a.opt.Workers.PurgeIdempotency.Timeout,
+	).Run(ctx)
+
 	srv := &http.Server{
 		Addr: a.opt.HTTP.Host,
 		Handler: router.NewRouter(
-			handlers.NewHandlers(
-				service.NewService(passGen, storage, accrual, key),
-				storage,
-			),
-			&key.PublicKey,
+			handlers.NewHandlers(srvc, storage, handlerOpts...),
+			keys,
+			srvc,
+			srvc,
+			srvc,
+			trustedProxies,
 		),
 		ReadTimeout:  a.opt.HTTP.ReadTimeout,
 		WriteTimeout: a.opt.HTTP.WriteTimeout,
@@ -188,3 +381,146 @@ func (a *App) Run(ctx context.Context) error {
 	return errGr.Wait()
 
 }
+
+// keySet ключи подписи токенов из файлов,
+// если ключи не заданы - генерируется временный ключ
+func (a *App) keySet() (*jwt.KeySet, error) {
+	if a.opt.Auth.KeysDir == "" && a.opt.Auth.PrivateKeyFile == "" {
+		logger.Logger().Warn("JWT keys are not configured, an ephemeral key is generated")
+		keys, err := jwt.GenerateKeySet()
+		if err != nil {
+			return nil, fmt.Errorf("generate key set: %w", err)
+		}
+		return keys, nil
+	}
+
+	keys, err := jwt.LoadKeySet(
+		a.opt.Auth.KeysDir,
+		a.opt.Auth.PrivateKeyFile,
+		a.opt.Auth.SigningKeyID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("load key set: %w", err)
+	}
+	return keys, nil
+}
+
+// loginLimiter счётчики в Postgres общие для всех реплик,
+// в памяти - только для одной
+func (a *App) loginLimiter(storage loginlimiter.Store) service.LoginLimiter {
+	opt := a.opt.LoginLimit
+	cfg := loginlimiter.Config{
+		Login:     loginlimiter.Policy{FreeAttempts: opt.FreeAttempts, MaxFailures: opt.MaxFailures},
+		IP:        loginlimiter.Policy{FreeAttempts: opt.IPFreeAttempts, MaxFailures: opt.IPMaxFailures},
+		BaseDelay: opt.BaseDelay,
+		MaxDelay:  opt.MaxDelay,
+		Lockout:   opt.Lockout,
+	}
+
+	if opt.Store == "memory" {
+		return loginlimiter.New(loginlimiter.NewMemoryStore(), cfg)
+	}
+	return loginlimiter.New(storage, cfg)
+}
+
+func (a *App) notifier() service.Notifier {
+	if a.opt.Clients.Notifier.Type == "file" {
+		return notifier.NewFile(a.opt.Clients.Notifier.File)
+	}
+	return notifier.NewLog()
+}
+
+// credentialPolicy требования к учетным данным,
+// неизвестный класс символов - ошибка конфигурации
+func (a *App) credentialPolicy() (models.CredentialPolicy, error) {
+	opt := a.opt.Credentials
+
+	login, err := fieldPolicy(opt.Login)
+	if err != nil {
+		return models.CredentialPolicy{}, fmt.Errorf("login policy: %w", err)
+	}
+	password, err := fieldPolicy(opt.Password)
+	if err != nil {
+		return models.CredentialPolicy{}, fmt.Errorf("password policy: %w", err)
+	}
+
+	if opt.PasswordDenyList != "" {
+		data, err := os.ReadFile(opt.PasswordDenyList)
+		if err != nil {
+			return models.CredentialPolicy{}, fmt.Errorf("read password deny list: %w", err)
+		}
+		password.DenyList = models.NewDenyList(strings.Split(string(data), "\n"))
+	}
+
+	return models.CredentialPolicy{Login: login, Password: password}, nil
+}
+
+func fieldPolicy(f CredentialField) (models.FieldPolicy, error) {
+	allowed, err := charClasses(f.Allowed)
+	if err != nil {
+		return models.FieldPolicy{}, err
+	}
+	required, err := charClasses(f.Required)
+	if err != nil {
+		return models.FieldPolicy{}, err
+	}
+	if f.MaxLength > 0 && f.MinLength > f.MaxLength {
+		return models.FieldPolicy{}, fmt.Errorf("min length %d is greater than max length %d", f.MinLength, f.MaxLength)
+	}
+
+	return models.FieldPolicy{
+		MinLength: f.MinLength,
+		MaxLength: f.MaxLength,
+		Allowed:   allowed,
+		Symbols:   f.Symbols,
+		Required:  required,
+	}, nil
+}
+
+func charClasses(names []string) ([]models.CharClass, error) {
+	classes := make([]models.CharClass, 0, len(names))
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		class := models.CharClass(strings.ToLower(name))
+		if !class.Validate() {
+			return nil, fmt.Errorf("unknown character class %q", name)
+		}
+		classes = append(classes, class)
+	}
+	return classes, nil
+}
+
+// handlerOptions режим cookie, SameSite=None без Secure браузеры отвергают
+func (a *App) handlerOptions() ([]handlers.Option, error) {
+	opt := a.opt.Auth.Cookie
+	if !opt.Enabled {
+		return nil, nil
+	}
+
+	var sameSite http.SameSite
+	switch strings.ToLower(opt.SameSite) {
+	case "", "strict":
+		sameSite = http.SameSiteStrictMode
+	case "lax":
+		sameSite = http.SameSiteLaxMode
+	case "none":
+		if !opt.Secure {
+			return nil, fmt.Errorf("cookie SameSite=None requires Secure")
+		}
+		sameSite = http.SameSiteNoneMode
+	default:
+		return nil, fmt.Errorf("unknown cookie SameSite %q", opt.SameSite)
+	}
+
+	return []handlers.Option{
+		handlers.WithCookies(handlers.CookieConfig{
+			Domain:     opt.Domain,
+			Secure:     opt.Secure,
+			SameSite:   sameSite,
+			RefreshTTL: a.opt.Auth.RefreshTokenTTL,
+		}),
+	}, nil
+}
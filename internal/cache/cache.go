@@ -0,0 +1,77 @@
+package cache
+
+import (
+	"sync"
+	"time"
+)
+
+type item[V any] struct {
+	value     V
+	expiresAt time.Time
+}
+
+// Cache потокобезопасный кеш с ограниченным временем жизни записей
+type Cache[K comparable, V any] struct {
+	mu        sync.RWMutex
+	ttl       time.Duration
+	items     map[K]item[V]
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+func New[K comparable, V any](ttl time.Duration) *Cache[K, V] {
+	return &Cache[K, V]{
+		ttl:   ttl,
+		items: make(map[K]item[V]),
+		now:   time.Now,
+	}
+}
+
+func (c *Cache[K, V]) Get(key K) (V, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	it, ok := c.items[key]
+	if !ok || c.now().After(it.expiresAt) {
+		var zero V
+		return zero, false
+	}
+	return it.value, true
+}
+
+func (c *Cache[K, V]) Set(key K, value V) {
+	c.SetWithTTL(key, value, c.ttl)
+}
+
+func (c *Cache[K, V]) SetWithTTL(key K, value V, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	c.items[key] = item[V]{
+		value:     value,
+		expiresAt: now.Add(ttl),
+	}
+
+	// устаревшие записи удаляются не чаще раза за ttl
+	if now.Sub(c.lastSweep) > c.ttl {
+		for k, it := range c.items {
+			if now.After(it.expiresAt) {
+				delete(c.items, k)
+			}
+		}
+		c.lastSweep = now
+	}
+}
+
+func (c *Cache[K, V]) Delete(key K) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.items, key)
+}
+
+func (c *Cache[K, V]) Len() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return len(c.items)
+}
@@ -0,0 +1,41 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCache(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	c := New[string, int](time.Minute)
+	c.now = func() time.Time { return now }
+
+	c.Set("a", 1)
+	c.SetWithTTL("b", 2, time.Minute*5)
+
+	v, ok := c.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, 1, v)
+
+	_, ok = c.Get("unknown")
+	assert.False(t, ok)
+
+	// запись "a" устарела, "b" еще действительна
+	now = now.Add(time.Minute * 2)
+	_, ok = c.Get("a")
+	assert.False(t, ok)
+	v, ok = c.Get("b")
+	assert.True(t, ok)
+	assert.Equal(t, 2, v)
+
+	// при записи устаревшие значения удаляются
+	c.Set("c", 3)
+	assert.Equal(t, 2, c.Len())
+
+	c.Delete("b")
+	_, ok = c.Get("b")
+	assert.False(t, ok)
+}
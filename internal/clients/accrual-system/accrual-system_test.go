@@ -10,7 +10,9 @@ import (
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/render"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/vladislav-kr/gophermart/internal/clients"
+	"github.com/vladislav-kr/gophermart/internal/domain/money"
 )
 
 func TestWithOpton(t *testing.T) {
@@ -43,7 +45,7 @@ func Test_accrualSystem_Order(t *testing.T) {
 			wantOrder: &clients.OrderAccrual{
 				Order:   "order_1",
 				Status:  "PROCESSED",
-				Accural: 500,
+				Accural: money.FromInt(500),
 			},
 		},
 		{
@@ -122,3 +124,51 @@ func Test_accrualSystem_Order(t *testing.T) {
 		})
 	}
 }
+
+func Test_accrualSystem_OrderRoundsAccrual(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        string
+		wantAccrual money.Amount
+	}{
+		{
+			name:        "начисление с четырьмя знаками после запятой",
+			body:        `{"order":"order_5","status":"PROCESSED","accrual":0.0005}`,
+			wantAccrual: money.MustParse("0.001"),
+		},
+		{
+			name:        "начисление округляется до тысячных",
+			body:        `{"order":"order_5","status":"PROCESSED","accrual":729.98749}`,
+			wantAccrual: money.MustParse("729.987"),
+		},
+		{
+			name: "начисления нет",
+			body: `{"order":"order_5","status":"PROCESSING"}`,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			router := chi.NewRouter()
+			router.Get("/api/orders/{id}",
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					w.Header().Set("Content-Type", "application/json")
+					w.WriteHeader(http.StatusOK)
+					_, _ = w.Write([]byte(tt.body))
+				}))
+
+			ts := httptest.NewServer(router)
+			defer ts.Close()
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+			defer cancel()
+
+			order, _, err := New(ts.URL).Order(ctx, "order_5")
+			require.NoError(t, err)
+			assert.Equal(t, "order_5", order.Order)
+			assert.Equal(t, tt.wantAccrual, order.Accural)
+		})
+	}
+}
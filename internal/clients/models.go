@@ -1,7 +1,49 @@
 package clients
 
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/vladislav-kr/gophermart/internal/domain/money"
+)
+
 type OrderAccrual struct {
-	Order   string  `json:"order"`
-	Status  string  `json:"status"`
-	Accural float64 `json:"accrual,omitempty"`
+	Order   string       `json:"order"`
+	Status  string       `json:"status"`
+	Accural money.Amount `json:"accrual,omitempty"`
+}
+
+// UnmarshalJSON начисление системы расчёта с лишними знаками после запятой
+// округляется, как прежде при записи в NUMERIC(15, 3), а не отклоняется,
+// иначе такой заказ никогда не обновился бы
+func (o *OrderAccrual) UnmarshalJSON(data []byte) error {
+	type orderAccrual OrderAccrual
+	aux := struct {
+		*orderAccrual
+		Accrual *json.Number `json:"accrual,omitempty"`
+	}{
+		orderAccrual: (*orderAccrual)(o),
+	}
+
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	if aux.Accrual != nil {
+		accrual, err := money.ParseRounded(aux.Accrual.String())
+		if err != nil {
+			return fmt.Errorf("accrual: %w", err)
+		}
+		o.Accural = accrual
+	}
+
+	return nil
+}
+
+// PasswordResetMessage уведомление со ссылкой сброса пароля
+type PasswordResetMessage struct {
+	Login     string    `json:"login"`
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
 }
@@ -0,0 +1,76 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+
+	"github.com/vladislav-kr/gophermart/internal/clients"
+	"github.com/vladislav-kr/gophermart/internal/logger"
+)
+
+// logNotifier пишет уведомления в лог, только для локальной разработки
+type logNotifier struct {
+	log *slog.Logger
+}
+
+func NewLog() *logNotifier {
+	return &logNotifier{
+		log: logger.Logger().With(slog.String("component", "notifier")),
+	}
+}
+
+func (n *logNotifier) PasswordReset(_ context.Context, msg clients.PasswordResetMessage) error {
+	n.log.Info("password reset",
+		slog.String("login", msg.Login),
+		slog.String("token", msg.Token),
+		slog.Time("expires_at", msg.ExpiresAt),
+	)
+	return nil
+}
+
+// fileNotifier дописывает уведомления в файл построчно в JSON,
+// только для локальной разработки и тестов
+type fileNotifier struct {
+	mu   sync.Mutex
+	path string
+}
+
+func NewFile(path string) *fileNotifier {
+	return &fileNotifier{
+		path: path,
+	}
+}
+
+type fileMessage struct {
+	Type string `json:"type"`
+	clients.PasswordResetMessage
+}
+
+func (n *fileNotifier) PasswordReset(_ context.Context, msg clients.PasswordResetMessage) error {
+	line, err := json.Marshal(fileMessage{
+		Type:                 "password_reset",
+		PasswordResetMessage: msg,
+	})
+	if err != nil {
+		return fmt.Errorf("marshal message: %w", err)
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	f, err := os.OpenFile(n.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("open %s: %w", n.path, err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("write %s: %w", n.path, err)
+	}
+
+	return nil
+}
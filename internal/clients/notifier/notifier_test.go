@@ -0,0 +1,47 @@
+package notifier
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vladislav-kr/gophermart/internal/clients"
+)
+
+func Test_fileNotifier_PasswordReset(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notifications.jsonl")
+	n := NewFile(path)
+
+	expiresAt := time.Date(2024, 3, 17, 12, 0, 0, 0, time.UTC)
+	for _, token := range []string{"token-1", "token-2"} {
+		require.NoError(t, n.PasswordReset(context.Background(), clients.PasswordResetMessage{
+			Login:     "user",
+			Token:     token,
+			ExpiresAt: expiresAt,
+		}))
+	}
+
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	var messages []fileMessage
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var msg fileMessage
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &msg))
+		messages = append(messages, msg)
+	}
+	require.NoError(t, scanner.Err())
+
+	require.Len(t, messages, 2)
+	assert.Equal(t, "password_reset", messages[0].Type)
+	assert.Equal(t, "token-2", messages[1].Token)
+	assert.True(t, expiresAt.Equal(messages[1].ExpiresAt))
+}
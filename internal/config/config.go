@@ -18,6 +18,64 @@ type Config struct {
 		ReadTimeout     time.Duration `env:"HTTP_READ_TIMEOUT" env-default:"30s" env-description:"таймаут на чтение"`
 		WriteTimeout    time.Duration `env:"HTTP_WRITE_TIMEOUT" env-default:"30s" env-description:"таймаут на запись"`
 		IdleTimeout     time.Duration `env:"HTTP_IDLE_TIMEOUT" env-default:"90s" env-description:"таймаут простоя подключения"`
+		TrustedProxies  []string      `env:"HTTP_TRUSTED_PROXIES" env-description:"CIDR или IP доверенных обратных прокси, только от них учитываются X-Forwarded-For и X-Real-IP; пусто - адрес клиента берется из подключения"`
+	}
+	Auth struct {
+		AccessTokenTTL  time.Duration `env:"AUTH_ACCESS_TOKEN_TTL" env-default:"15m" env-description:"время жизни access токена"`
+		RefreshTokenTTL time.Duration `env:"AUTH_REFRESH_TOKEN_TTL" env-default:"720h" env-description:"время жизни refresh токена"`
+		KeysDir         string        `env:"JWT_KEYS_DIR" env-description:"каталог с ключами RSA в формате PEM, kid - имя файла"`
+		PrivateKeyFile  string        `env:"JWT_PRIVATE_KEY_FILE" env-description:"файл закрытого ключа RSA в формате PEM"`
+		SigningKeyID    string        `env:"JWT_SIGNING_KEY_ID" env-description:"kid ключа подписи, по умолчанию последний по имени закрытый ключ"`
+		RevocationCache time.Duration `env:"AUTH_REVOCATION_CACHE_TTL" env-default:"30s" env-description:"время жизни кеша отозванных токенов"`
+		PasswordReset   time.Duration `env:"AUTH_PASSWORD_RESET_TTL" env-default:"1h" env-description:"время жизни токена сброса пароля"`
+		MFAChallenge    time.Duration `env:"AUTH_MFA_CHALLENGE_TTL" env-default:"5m" env-description:"время на ввод второго фактора после проверки пароля"`
+		TOTPIssuer      string        `env:"AUTH_TOTP_ISSUER" env-default:"Gophermart" env-description:"название сервиса в приложении-аутентификаторе"`
+		CookieMode      bool          `env:"AUTH_COOKIE_MODE" env-default:"false" env-description:"выдача токенов в HttpOnly cookie для браузерных клиентов"`
+		CookieDomain    string        `env:"AUTH_COOKIE_DOMAIN" env-description:"домен cookie, по умолчанию домен запроса"`
+		CookieSecure    bool          `env:"AUTH_COOKIE_SECURE" env-default:"true" env-description:"cookie только по HTTPS"`
+		CookieSameSite  string        `env:"AUTH_COOKIE_SAME_SITE" env-default:"strict" env-description:"атрибут SameSite cookie: strict, lax, none"`
+	}
+	Password struct {
+		Argon2Memory      uint32 `env:"PASSWORD_ARGON2_MEMORY" env-default:"65536" env-description:"память argon2id, KiB"`
+		Argon2Iterations  uint32 `env:"PASSWORD_ARGON2_ITERATIONS" env-default:"3" env-description:"количество проходов argon2id"`
+		Argon2Parallelism uint8  `env:"PASSWORD_ARGON2_PARALLELISM" env-default:"2" env-description:"количество потоков argon2id"`
+	}
+	Credentials struct {
+		Login struct {
+			MinLength int      `env:"CREDENTIALS_LOGIN_MIN_LENGTH" env-default:"4" env-description:"минимальная длина логина"`
+			MaxLength int      `env:"CREDENTIALS_LOGIN_MAX_LENGTH" env-default:"30" env-description:"максимальная длина логина"`
+			Allowed   []string `env:"CREDENTIALS_LOGIN_ALLOWED" env-default:"lower,upper,digit,symbol" env-description:"допустимые классы символов логина: lower, upper, digit, symbol, space, unicode"`
+			Symbols   string   `env:"CREDENTIALS_LOGIN_SYMBOLS" env-default:"-_." env-description:"допустимые символы класса symbol в логине, пусто - любые"`
+			Required  []string `env:"CREDENTIALS_LOGIN_REQUIRED" env-description:"обязательные классы символов логина"`
+		}
+		Password struct {
+			MinLength int      `env:"CREDENTIALS_PASSWORD_MIN_LENGTH" env-default:"8" env-description:"минимальная длина пароля"`
+			MaxLength int      `env:"CREDENTIALS_PASSWORD_MAX_LENGTH" env-default:"128" env-description:"максимальная длина пароля"`
+			Allowed   []string `env:"CREDENTIALS_PASSWORD_ALLOWED" env-description:"допустимые классы символов пароля, пусто - любые печатные"`
+			Symbols   string   `env:"CREDENTIALS_PASSWORD_SYMBOLS" env-description:"допустимые символы класса symbol в пароле, пусто - любые"`
+			Required  []string `env:"CREDENTIALS_PASSWORD_REQUIRED" env-description:"обязательные классы символов пароля"`
+			DenyList  string   `env:"CREDENTIALS_PASSWORD_DENY_LIST" env-description:"файл запрещенных паролей, по одному в строке"`
+		}
+	}
+	Account struct {
+		DeletedBalance string `env:"ACCOUNT_DELETED_BALANCE" env-default:"freeze" env-description:"остаток баланса удаленной учетной записи: forfeit - списать, freeze - заморозить"`
+	}
+	Idempotency struct {
+		TTL time.Duration `env:"IDEMPOTENCY_TTL" env-default:"24h" env-description:"время хранения ответов на запросы с заголовком Idempotency-Key"`
+	}
+	Points struct {
+		ExpiryMonths int           `env:"POINTS_EXPIRY_MONTHS" env-default:"0" env-description:"срок сгорания начисленных баллов в месяцах, 0 - не сгорают"`
+		ExpiringSoon time.Duration `env:"POINTS_EXPIRING_SOON" env-default:"720h" env-description:"окно баллов, которые скоро сгорят, в ответе баланса"`
+	}
+	LoginLimit struct {
+		Store          string        `env:"LOGIN_LIMIT_STORE" env-default:"postgres" env-description:"хранилище счётчиков попыток входа: memory, postgres"`
+		FreeAttempts   int           `env:"LOGIN_LIMIT_FREE_ATTEMPTS" env-default:"3" env-description:"неудачные попытки на логин без задержки"`
+		MaxFailures    int           `env:"LOGIN_LIMIT_MAX_FAILURES" env-default:"10" env-description:"неудачные попытки на логин до блокировки"`
+		IPFreeAttempts int           `env:"LOGIN_LIMIT_IP_FREE_ATTEMPTS" env-default:"20" env-description:"неудачные попытки с IP без задержки"`
+		IPMaxFailures  int           `env:"LOGIN_LIMIT_IP_MAX_FAILURES" env-default:"100" env-description:"неудачные попытки с IP до блокировки"`
+		BaseDelay      time.Duration `env:"LOGIN_LIMIT_BASE_DELAY" env-default:"1s" env-description:"начальная задержка, удваивается с каждой попыткой"`
+		MaxDelay       time.Duration `env:"LOGIN_LIMIT_MAX_DELAY" env-default:"1m" env-description:"максимальная задержка"`
+		Lockout        time.Duration `env:"LOGIN_LIMIT_LOCKOUT" env-default:"15m" env-description:"время блокировки"`
 	}
 	Storage struct {
 		Postgres struct {
@@ -32,6 +90,10 @@ type Config struct {
 			ReadTimeout   time.Duration `env:"ACCRUAL_READ_TIMEOUT" env-default:"4s" env-description:"таймаут на чтение"`
 		}
 	}
+	Notifier struct {
+		Type string `env:"NOTIFIER_TYPE" env-default:"log" env-description:"доставка уведомлений: log, file"`
+		File string `env:"NOTIFIER_FILE" env-default:"notifications.jsonl" env-description:"файл уведомлений для NOTIFIER_TYPE=file"`
+	}
 	Workers struct {
 		UpdateOrders struct {
 			ReadTimeout  time.Duration `env:"WORKERS_UPDATE_ORDERS_READ_TIMEOUT" env-default:"4s" env-description:"таймаут на чтение"`
@@ -39,6 +101,19 @@ type Config struct {
 			ReadLimit    uint32        `env:"WORKERS_UPDATE_ORDERS_READ_LIMIT" env-default:"10" env-description:"лимит чтения заказов для обновления"`
 			WorkersLimit uint8         `env:"WORKERS_UPDATE_ORDERS_WORKERS_LIMIT" env-default:"3" env-description:"количество одновременно работающих воркеров"`
 		}
+		ReconcileBalances struct {
+			Interval time.Duration `env:"WORKERS_RECONCILE_BALANCES_INTERVAL" env-default:"1h" env-description:"период сверки балансов с заказами и списаниями, 0 - отключена"`
+			Timeout  time.Duration `env:"WORKERS_RECONCILE_BALANCES_TIMEOUT" env-default:"1m" env-description:"таймаут одной сверки"`
+			Correct  bool          `env:"WORKERS_RECONCILE_BALANCES_CORRECT" env-default:"false" env-description:"исправлять расхождения корректировкой баланса"`
+		}
+		ExpirePoints struct {
+			Interval time.Duration `env:"WORKERS_EXPIRE_POINTS_INTERVAL" env-default:"1h" env-description:"период сгорания баллов с истекшим сроком, 0 - отключено"`
+			Timeout  time.Duration `env:"WORKERS_EXPIRE_POINTS_TIMEOUT" env-default:"1m" env-description:"таймаут одного запуска сгорания"`
+		}
+		PurgeIdempotency struct {
+			Interval time.Duration `env:"WORKERS_PURGE_IDEMPOTENCY_INTERVAL" env-default:"1h" env-description:"период удаления истекших ключей идемпотентности, 0 - отключено"`
+			Timeout  time.Duration `env:"WORKERS_PURGE_IDEMPOTENCY_TIMEOUT" env-default:"1m" env-description:"таймаут одного запуска очистки"`
+		}
 	}
 }
 
@@ -0,0 +1,39 @@
+package models
+
+import "time"
+
+// UserExport данные, хранящиеся о пользователе, по запросу субъекта данных
+type UserExport struct {
+	ExportedAt       time.Time            `json:"exported_at"`
+	Profile          User                 `json:"profile"`
+	TwoFactorEnabled bool                 `json:"two_factor_enabled"`
+	Sessions         []Session            `json:"sessions"`
+	APIKeys          []APIKey             `json:"api_keys"`
+	Orders           []Order              `json:"orders"`
+	Balance          Balance              `json:"balance"`
+	Withdrawals      []WithdrawalsBonuses `json:"withdrawals"`
+	BalanceHistory   []BalanceEntry       `json:"balance_history"`
+}
+
+// AccountDeletion удаление учетной записи подтверждается паролем
+type AccountDeletion struct {
+	Password string `json:"password"`
+}
+
+// DeletedBalancePolicy остаток баллов при удалении учетной записи
+type DeletedBalancePolicy string
+
+const (
+	// остаток списывается записью в журнале корректировок
+	DeletedBalanceForfeit DeletedBalancePolicy = "forfeit"
+	// остаток сохраняется без доступа и может быть восстановлен поддержкой
+	DeletedBalanceFreeze DeletedBalancePolicy = "freeze"
+)
+
+func (p DeletedBalancePolicy) Validate() bool {
+	switch p {
+	case DeletedBalanceForfeit, DeletedBalanceFreeze:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,86 @@
+package models
+
+import (
+	"fmt"
+	"time"
+	"unicode/utf8"
+)
+
+// APIKeyScope операция, разрешенная API ключу
+type APIKeyScope string
+
+const (
+	// загрузка номеров заказов от имени пользователя
+	ScopeOrdersUpload APIKeyScope = "orders:upload"
+)
+
+func (s APIKeyScope) Validate() bool {
+	switch s {
+	case ScopeOrdersUpload:
+		return true
+	}
+	return false
+}
+
+// APIKey долгоживущий ключ партнера или интеграции, действует от имени пользователя
+type APIKey struct {
+	KeyID  string `json:"id"`
+	UserID UserID `json:"-"`
+	Name   string `json:"name"`
+	// начало ключа для узнавания в списке, сам ключ не хранится
+	Prefix     string        `json:"prefix"`
+	Scopes     []APIKeyScope `json:"scopes"`
+	CreatedAt  time.Time     `json:"created_at"`
+	ExpiresAt  *time.Time    `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time    `json:"last_used_at,omitempty"`
+	LastUsedIP string        `json:"last_used_ip,omitempty"`
+	UseCount   int64         `json:"use_count"`
+	RevokedAt  *time.Time    `json:"revoked_at,omitempty"`
+}
+
+// Allows ключ выдан на операцию scope
+func (k APIKey) Allows(scope APIKeyScope) bool {
+	for _, s := range k.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// Usable ключ не отозван и не истек
+func (k APIKey) Usable(now time.Time) bool {
+	return k.RevokedAt == nil && (k.ExpiresAt == nil || k.ExpiresAt.After(now))
+}
+
+// APIKeyRequest создание API ключа
+type APIKeyRequest struct {
+	Name      string        `json:"name"`
+	Scopes    []APIKeyScope `json:"scopes"`
+	ExpiresAt *time.Time    `json:"expires_at,omitempty"`
+}
+
+func (r APIKeyRequest) Validate(now time.Time) error {
+	switch {
+	case r.Name == "":
+		return fmt.Errorf("name is required: %w", ErrInvalidAPIKeyRequest)
+	case utf8.RuneCountInString(r.Name) > 100:
+		return fmt.Errorf("name is too long: %w", ErrInvalidAPIKeyRequest)
+	case len(r.Scopes) == 0:
+		return fmt.Errorf("scopes are required: %w", ErrInvalidAPIKeyRequest)
+	case r.ExpiresAt != nil && !r.ExpiresAt.After(now):
+		return fmt.Errorf("expires_at must be in the future: %w", ErrInvalidAPIKeyRequest)
+	}
+	for _, s := range r.Scopes {
+		if !s.Validate() {
+			return fmt.Errorf("unknown scope %q: %w", s, ErrInvalidAPIKeyRequest)
+		}
+	}
+	return nil
+}
+
+// NewAPIKey созданный ключ, значение Key возвращается только один раз
+type NewAPIKey struct {
+	APIKey
+	Key string `json:"key"`
+}
@@ -1,6 +1,120 @@
 package models
 
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/vladislav-kr/gophermart/internal/domain/money"
+)
+
 type Balance struct {
-	Current   float64 `json:"current"`
-	Withdrawn float64 `json:"withdrawn"`
+	Current   money.Amount `json:"current"`
+	Withdrawn money.Amount `json:"withdrawn"`
+	// баллы, которые скоро сгорят, по дате сгорания
+	ExpiringSoon []ExpiringPoints `json:"expiring_soon,omitempty"`
+}
+
+// ExpiringPoints остаток партий баллов с общим сроком сгорания
+type ExpiringPoints struct {
+	Amount    money.Amount `json:"amount"`
+	ExpiresAt time.Time    `json:"expires_at"`
+}
+
+// PointsExpiry результат сгорания баллов с истекшим сроком
+type PointsExpiry struct {
+	ExpiredAt time.Time `json:"expired_at"`
+	// пользователи, у которых сгорели баллы
+	Users  int          `json:"users"`
+	Amount money.Amount `json:"amount"`
+}
+
+// AdjustmentReason код причины ручной корректировки баланса
+type AdjustmentReason string
+
+const (
+	ReasonCompensation AdjustmentReason = "compensation" // компенсация клиенту
+	ReasonCorrection   AdjustmentReason = "correction"   // исправление ошибки начисления или списания
+	ReasonPromotion    AdjustmentReason = "promotion"    // начисление по акции
+
+	// списание остатка при удалении учетной записи, только системой
+	ReasonAccountDeletion AdjustmentReason = "account_deletion"
+	// исправление расхождения при сверке баланса, только системой
+	ReasonReconciliation AdjustmentReason = "reconciliation"
+)
+
+func (r AdjustmentReason) Validate() bool {
+	switch r {
+	case ReasonCompensation, ReasonCorrection, ReasonPromotion:
+		return true
+	}
+	return false
+}
+
+// максимальная длина комментария к корректировке
+const maxAdjustmentComment = 1000
+
+// BalanceAdjustment ручная корректировка баланса,
+// положительная сумма начисляет баллы, отрицательная списывает
+type BalanceAdjustment struct {
+	Amount  money.Amount     `json:"amount"`
+	Reason  AdjustmentReason `json:"reason"`
+	Comment string           `json:"comment"`
+}
+
+func (a BalanceAdjustment) Validate() error {
+	comment := strings.TrimSpace(a.Comment)
+	switch {
+	case a.Amount == 0:
+		return fmt.Errorf("amount is zero: %w", ErrInvalidAdjustment)
+	case !a.Reason.Validate():
+		return fmt.Errorf("unknown reason %q: %w", a.Reason, ErrInvalidAdjustment)
+	case comment == "":
+		return fmt.Errorf("comment is empty: %w", ErrInvalidAdjustment)
+	case utf8.RuneCountInString(comment) > maxAdjustmentComment:
+		return fmt.Errorf("comment is too long: %w", ErrInvalidAdjustment)
+	}
+	return nil
+}
+
+// типы записей истории баланса
+const (
+	EntryAccrual    string = "accrual"    // начисление за заказ
+	EntryWithdrawal string = "withdrawal" // списание в счёт оплаты заказа
+	EntryAdjustment string = "adjustment" // ручная корректировка
+	EntryReversal   string = "reversal"   // сторно ранее записанной проводки
+	EntryExpiry     string = "expiry"     // сгорание баллов с истекшим сроком
+)
+
+// BalanceEntry запись истории баланса, сумма списаний отрицательная
+type BalanceEntry struct {
+	Type        string           `json:"type"`
+	Order       OrderID          `json:"order,omitempty"`
+	Reason      AdjustmentReason `json:"reason,omitempty"`
+	Sum         money.Amount     `json:"sum"`
+	ProcessedAt time.Time        `json:"processed_at"`
+}
+
+// BalanceDrift расхождение баланса пользователя с заказами, списаниями и корректировками
+type BalanceDrift struct {
+	UserID            UserID       `json:"user_id"`
+	Current           money.Amount `json:"current"`
+	Withdrawn         money.Amount `json:"withdrawn"`
+	ExpectedCurrent   money.Amount `json:"expected_current"`
+	ExpectedWithdrawn money.Amount `json:"expected_withdrawn"`
+	// текущий остаток исправлен корректировкой
+	Corrected bool `json:"corrected"`
+}
+
+// ReconciliationReport результат сверки балансов
+type ReconciliationReport struct {
+	CheckedAt time.Time `json:"checked_at"`
+	// пользователи с расхождением
+	Drifted int `json:"drifted"`
+	// исправленные корректировкой
+	Corrected int `json:"corrected"`
+	// сумма расхождений текущего остатка по модулю
+	DriftAmount money.Amount   `json:"drift_amount"`
+	Users       []BalanceDrift `json:"users"`
 }
@@ -0,0 +1,7 @@
+package models
+
+// Client сведения о клиенте, выполняющем запрос
+type Client struct {
+	IP        string
+	UserAgent string
+}
@@ -2,22 +2,207 @@ package models
 
 import (
 	"fmt"
-	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
 )
 
-var (
-	regLogin *regexp.Regexp = regexp.MustCompile(`^[a-zA-Z0-9-_\.]{4,30}$`)
-	regPass  *regexp.Regexp = regexp.MustCompile(`^[a-zA-Z0-9-_\.]{7,32}$`)
-)
+// максимальная длина пароля при входе, защищает хеширование от огромных строк
+const maxLoginPasswordBytes = 1024
 
 type Credentials struct {
 	Login    string `json:"login"`
 	Password string `json:"password"`
 }
 
+// Validate проверка при входе. Политика не применяется,
+// чтобы пользователи со старыми паролями могли войти после ее изменения.
 func (c Credentials) Validate() error {
-	if ok := regLogin.MatchString(c.Login) && regPass.MatchString(c.Password); !ok {
+	if c.Login == "" || c.Password == "" || len(c.Password) > maxLoginPasswordBytes {
 		return fmt.Errorf("invalid login or password")
 	}
 	return nil
 }
+
+// CharClass класс символов
+type CharClass string
+
+const (
+	CharLower   CharClass = "lower"   // строчные латинские буквы
+	CharUpper   CharClass = "upper"   // заглавные латинские буквы
+	CharDigit   CharClass = "digit"   // цифры 0-9
+	CharSymbol  CharClass = "symbol"  // знаки препинания и символы ASCII
+	CharSpace   CharClass = "space"   // пробел
+	CharUnicode CharClass = "unicode" // прочие печатные символы, например кириллица
+)
+
+func (c CharClass) Validate() bool {
+	switch c {
+	case CharLower, CharUpper, CharDigit, CharSymbol, CharSpace, CharUnicode:
+		return true
+	}
+	return false
+}
+
+func charClass(r rune) (CharClass, bool) {
+	switch {
+	case r >= 'a' && r <= 'z':
+		return CharLower, true
+	case r >= 'A' && r <= 'Z':
+		return CharUpper, true
+	case r >= '0' && r <= '9':
+		return CharDigit, true
+	case r == ' ':
+		return CharSpace, true
+	case r < utf8.RuneSelf && (unicode.IsPunct(r) || unicode.IsSymbol(r)):
+		return CharSymbol, true
+	case r >= utf8.RuneSelf && unicode.IsPrint(r):
+		return CharUnicode, true
+	}
+	return "", false
+}
+
+// FieldPolicy требования к логину или паролю
+type FieldPolicy struct {
+	// длина в символах
+	MinLength int
+	MaxLength int
+	// допустимые классы, пусто - любые печатные символы
+	Allowed []CharClass
+	// допустимые символы класса symbol, пусто - любые
+	Symbols string
+	// классы, символ каждого из которых обязателен
+	Required []CharClass
+	// запрещенные значения без учета регистра
+	DenyList map[string]struct{}
+}
+
+// NewDenyList список запрещенных значений, пустые строки пропускаются
+func NewDenyList(values []string) map[string]struct{} {
+	list := make(map[string]struct{}, len(values))
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			list[strings.ToLower(v)] = struct{}{}
+		}
+	}
+	return list
+}
+
+// CredentialPolicy требования к учетным данным при регистрации и смене пароля
+type CredentialPolicy struct {
+	Login    FieldPolicy
+	Password FieldPolicy
+}
+
+// DefaultCredentialPolicy логин как раньше, пароль допускает фразы на любом языке
+func DefaultCredentialPolicy() CredentialPolicy {
+	return CredentialPolicy{
+		Login: FieldPolicy{
+			MinLength: 4,
+			MaxLength: 30,
+			Allowed:   []CharClass{CharLower, CharUpper, CharDigit, CharSymbol},
+			Symbols:   "-_.",
+		},
+		Password: FieldPolicy{
+			MinLength: 8,
+			MaxLength: 128,
+		},
+	}
+}
+
+// Validate вернет *ValidationError с ошибками по каждому полю
+func (p CredentialPolicy) Validate(c Credentials) error {
+	fields := append(
+		p.Login.check("login", c.Login),
+		p.Password.check("password", c.Password)...,
+	)
+	if len(fields) > 0 {
+		return &ValidationError{Fields: fields}
+	}
+	return nil
+}
+
+// ValidatePassword проверка нового пароля в поле field
+func (p CredentialPolicy) ValidatePassword(field, password string) error {
+	if fields := p.Password.check(field, password); len(fields) > 0 {
+		return &ValidationError{Fields: fields}
+	}
+	return nil
+}
+
+func (p FieldPolicy) check(field, value string) []FieldError {
+	if value == "" {
+		return []FieldError{{Field: field, Code: CodeRequired, Message: "обязательное поле"}}
+	}
+
+	errs := make([]FieldError, 0)
+
+	length := utf8.RuneCountInString(value)
+	if p.MinLength > 0 && length < p.MinLength {
+		errs = append(errs, FieldError{
+			Field:   field,
+			Code:    CodeTooShort,
+			Message: fmt.Sprintf("минимальная длина %d", p.MinLength),
+		})
+	}
+	if p.MaxLength > 0 && length > p.MaxLength {
+		errs = append(errs, FieldError{
+			Field:   field,
+			Code:    CodeTooLong,
+			Message: fmt.Sprintf("максимальная длина %d", p.MaxLength),
+		})
+	}
+
+	present := make(map[CharClass]bool)
+	invalid := false
+	for _, r := range value {
+		class, ok := charClass(r)
+		if !ok || !p.allowed(class, r) {
+			invalid = true
+			continue
+		}
+		present[class] = true
+	}
+	if invalid {
+		errs = append(errs, FieldError{
+			Field:   field,
+			Code:    CodeInvalidChars,
+			Message: "недопустимые символы",
+		})
+	}
+
+	for _, class := range p.Required {
+		if !present[class] {
+			errs = append(errs, FieldError{
+				Field:   field,
+				Code:    CodeMissingClass,
+				Message: fmt.Sprintf("требуется символ класса %s", class),
+			})
+		}
+	}
+
+	if _, ok := p.DenyList[strings.ToLower(value)]; ok {
+		errs = append(errs, FieldError{
+			Field:   field,
+			Code:    CodeCommon,
+			Message: "слишком распространенное значение",
+		})
+	}
+
+	return errs
+}
+
+func (p FieldPolicy) allowed(class CharClass, r rune) bool {
+	if class == CharSymbol && p.Symbols != "" && !strings.ContainsRune(p.Symbols, r) {
+		return false
+	}
+	if len(p.Allowed) == 0 {
+		return true
+	}
+	for _, c := range p.Allowed {
+		if c == class {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,110 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCredentialPolicy_Validate(t *testing.T) {
+	strict := DefaultCredentialPolicy()
+	strict.Password.Required = []CharClass{CharUpper, CharDigit}
+	strict.Password.DenyList = NewDenyList([]string{"Password123", " ", "qwerty12345"})
+
+	tests := []struct {
+		name   string
+		policy CredentialPolicy
+		cred   Credentials
+		codes  map[string][]string
+	}{
+		{
+			name:   "корректные учетные данные",
+			policy: DefaultCredentialPolicy(),
+			cred:   Credentials{Login: "my.login_1", Password: "Secret_pass"},
+		},
+		{
+			name:   "пароль-фраза на кириллице с пробелами",
+			policy: DefaultCredentialPolicy(),
+			cred:   Credentials{Login: "mylogin", Password: "мой секретный пароль!"},
+		},
+		{
+			name:   "пустые поля",
+			policy: DefaultCredentialPolicy(),
+			cred:   Credentials{},
+			codes: map[string][]string{
+				"login":    {CodeRequired},
+				"password": {CodeRequired},
+			},
+		},
+		{
+			name:   "короткие логин и пароль",
+			policy: DefaultCredentialPolicy(),
+			cred:   Credentials{Login: "abc", Password: "1234567"},
+			codes: map[string][]string{
+				"login":    {CodeTooShort},
+				"password": {CodeTooShort},
+			},
+		},
+		{
+			name:   "недопустимые символы в логине",
+			policy: DefaultCredentialPolicy(),
+			cred:   Credentials{Login: "логин@mail", Password: "12345678"},
+			codes: map[string][]string{
+				"login": {CodeInvalidChars},
+			},
+		},
+		{
+			name:   "длинный пароль",
+			policy: DefaultCredentialPolicy(),
+			cred:   Credentials{Login: "mylogin", Password: string(make([]byte, 129))},
+			codes: map[string][]string{
+				"password": {CodeTooLong, CodeInvalidChars},
+			},
+		},
+		{
+			name:   "нет обязательных классов",
+			policy: strict,
+			cred:   Credentials{Login: "mylogin", Password: "secret_pass"},
+			codes: map[string][]string{
+				"password": {CodeMissingClass, CodeMissingClass},
+			},
+		},
+		{
+			name:   "пароль из списка запрещенных",
+			policy: strict,
+			cred:   Credentials{Login: "mylogin", Password: "PASSWORD123"},
+			codes: map[string][]string{
+				"password": {CodeCommon},
+			},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := tt.policy.Validate(tt.cred)
+			if len(tt.codes) == 0 {
+				require.NoError(t, err)
+				return
+			}
+
+			require.ErrorIs(t, err, ErrValidation)
+			var invalid *ValidationError
+			require.ErrorAs(t, err, &invalid)
+
+			codes := make(map[string][]string)
+			for _, f := range invalid.Fields {
+				assert.NotEmpty(t, f.Message)
+				codes[f.Field] = append(codes[f.Field], f.Code)
+			}
+			assert.Equal(t, tt.codes, codes)
+		})
+	}
+}
+
+func TestNewDenyList(t *testing.T) {
+	list := NewDenyList([]string{"Qwerty", "", "  123456  "})
+	assert.Equal(t, map[string]struct{}{"qwerty": {}, "123456": {}}, list)
+}
@@ -1,18 +1,116 @@
 package models
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
 
 var (
 	ErrLoginAlreadyExists   = errors.New("login already exists")
 	ErrIncorrectCredentials = errors.New("incorrect credentials")
 	ErrIncorrectOrderNumber = errors.New("incorrect order number")
+	ErrTooManyOrders        = errors.New("too many orders in bulk upload")
 
 	ErrInternal                   = errors.New("internal Error")
 	ErrAlreadyUploadedUser        = errors.New("already uploaded by user")
 	ErrAlreadyUploadedAnotherUser = errors.New("already uploaded by another user")
 	ErrNoRecordsFound             = errors.New("no records found")
 	ErrInsufficientFunds          = errors.New("insufficient funds")
+	ErrWithdrawalExists           = errors.New("withdrawal for order already exists")
+	ErrInvalidSum                 = errors.New("invalid withdrawal sum")
+	ErrInvalidAdjustment          = errors.New("invalid balance adjustment")
+
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	ErrRefreshTokenReused  = errors.New("refresh token reused")
+	ErrTokenRevoked        = errors.New("token revoked")
+
+	ErrUserBlocked = errors.New("user is blocked")
+	ErrUserDeleted = errors.New("user is deleted")
+
+	ErrTooManyAttempts = errors.New("too many login attempts")
+
+	ErrIncorrectPassword = errors.New("incorrect password")
+	ErrInvalidResetToken = errors.New("invalid password reset token")
+
+	ErrValidation = errors.New("validation failed")
+
+	ErrMFARequired         = errors.New("second factor required")
+	ErrTOTPAlreadyEnabled  = errors.New("totp already enabled")
+	ErrTOTPNotEnrolled     = errors.New("totp enrollment not started")
+	ErrInvalidTOTPCode     = errors.New("invalid totp code")
+	ErrInvalidMFAChallenge = errors.New("invalid mfa challenge")
+
+	ErrInvalidAPIKey        = errors.New("invalid api key")
+	ErrAPIKeyScope          = errors.New("api key scope does not allow the operation")
+	ErrInvalidAPIKeyRequest = errors.New("invalid api key request")
+
+	ErrInvalidIdempotencyKey = errors.New("invalid idempotency key")
+	ErrIdempotencyKeyReused  = errors.New("idempotency key reused with another request")
+	ErrIdempotencyInProgress = errors.New("request with idempotency key in progress")
 
 	ErrUserIDMandatory           = errors.New("userID is a mandatory parameter")
 	ErrMismatchedHashAndPassword = errors.New("hashedPassword is not the hash of the given password")
 )
+
+// TooManyAttemptsError попытка входа запрещена до истечения RetryAfter
+type TooManyAttemptsError struct {
+	RetryAfter time.Duration
+}
+
+func (e *TooManyAttemptsError) Error() string {
+	return fmt.Sprintf("too many login attempts, retry after %s", e.RetryAfter)
+}
+
+func (e *TooManyAttemptsError) Unwrap() error {
+	return ErrTooManyAttempts
+}
+
+// WithdrawalExistsError списание по номеру заказа уже выполнено
+type WithdrawalExistsError struct {
+	Withdrawal WithdrawalsBonuses
+}
+
+func (e *WithdrawalExistsError) Error() string {
+	return fmt.Sprintf("withdrawal for order %s already exists", e.Withdrawal.Order)
+}
+
+func (e *WithdrawalExistsError) Unwrap() error {
+	return ErrWithdrawalExists
+}
+
+// коды ошибок полей, стабильны для клиентов API
+const (
+	CodeRequired     = "required"
+	CodeTooShort     = "too_short"
+	CodeTooLong      = "too_long"
+	CodeInvalidChars = "invalid_chars"
+	CodeMissingClass = "missing_class"
+	CodeCommon       = "common_value"
+	CodeInvalidValue = "invalid_value"
+)
+
+// FieldError нарушение требования к полю
+type FieldError struct {
+	Field   string
+	Code    string
+	Message string
+}
+
+// ValidationError все нарушения требований к входным данным
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	parts := make([]string, 0, len(e.Fields))
+	for _, f := range e.Fields {
+		parts = append(parts, f.Field+": "+f.Code)
+	}
+	return fmt.Sprintf("validation failed: %s", strings.Join(parts, ", "))
+}
+
+func (e *ValidationError) Unwrap() error {
+	return ErrValidation
+}
@@ -0,0 +1,26 @@
+package models
+
+// MaxIdempotencyKeyLength наибольшая длина заголовка Idempotency-Key
+const MaxIdempotencyKeyLength = 255
+
+// IdempotentRequest запрос с ключом идемпотентности.
+// Hash отпечаток метода, пути и тела запроса.
+type IdempotentRequest struct {
+	UserID UserID
+	Key    string
+	Hash   []byte
+}
+
+func (r IdempotentRequest) Validate() error {
+	if r.Key == "" || len(r.Key) > MaxIdempotencyKeyLength {
+		return ErrInvalidIdempotencyKey
+	}
+	return nil
+}
+
+// IdempotentResponse сохраненный ответ, повторяется на запросы с тем же ключом
+type IdempotentResponse struct {
+	StatusCode  int
+	ContentType string
+	Body        []byte
+}
@@ -1,8 +1,13 @@
 package models
 
 import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"strconv"
 	"time"
+
+	"github.com/vladislav-kr/gophermart/internal/domain/money"
 )
 
 type OrderID string
@@ -34,10 +39,49 @@ func (o OrderID) Validate() bool {
 }
 
 type Order struct {
-	OrderID    OrderID   `json:"number"`
-	Status     string    `json:"status"`
-	UploadedAt time.Time `json:"uploaded_at"`
-	Accrual    float64   `json:"accrual,omitempty"`
+	OrderID    OrderID      `json:"number"`
+	Status     string       `json:"status"`
+	UploadedAt time.Time    `json:"uploaded_at"`
+	Accrual    money.Amount `json:"accrual,omitempty"`
+}
+
+// OrderEvent смена статуса заказа с начислением,
+// полученным от системы расчета на этот момент
+type OrderEvent struct {
+	Status    string       `json:"status"`
+	Accrual   money.Amount `json:"accrual"`
+	ChangedAt time.Time    `json:"changed_at"`
+}
+
+// OrderDetails заказ с историей статусов в хронологическом порядке
+type OrderDetails struct {
+	Order
+	History []OrderEvent `json:"history"`
+}
+
+// MaxBulkOrders наибольшее число номеров в одной пакетной загрузке
+const MaxBulkOrders = 1000
+
+// OrderUploadStatus результат загрузки номера, соответствует ответам
+// загрузки одного заказа: 202, 200, 409 и 422
+type OrderUploadStatus string
+
+const (
+	UploadAccepted        OrderUploadStatus = "accepted"                 // новый номер принят в обработку
+	UploadAlreadyUploaded OrderUploadStatus = "already_uploaded"         // номер уже загружен этим пользователем
+	UploadAnotherUser     OrderUploadStatus = "uploaded_by_another_user" // номер загружен другим пользователем
+	UploadInvalidNumber   OrderUploadStatus = "invalid_number"           // номер не проходит проверку Луна
+)
+
+type OrderUploadResult struct {
+	Number OrderID           `json:"number"`
+	Result OrderUploadStatus `json:"result"`
+}
+
+// BulkUpload результаты в порядке номеров запроса
+type BulkUpload struct {
+	Accepted int                 `json:"accepted"`
+	Results  []OrderUploadResult `json:"results"`
 }
 
 type UpdateOrderID struct {
@@ -50,3 +94,108 @@ const (
 	StatusProcessing string = "PROCESSING" // расчёт начисления в процессе;
 	StatusProcessed  string = "PROCESSED"  // расчёт начисления окончен;
 )
+
+// StatusValid известный статус заказа
+func StatusValid(status string) bool {
+	switch status {
+	case StatusNew, StatusInvalid, StatusProcessing, StatusProcessed:
+		return true
+	}
+	return false
+}
+
+// ограничения размера страницы списка заказов
+const (
+	DefaultOrdersLimit = 50
+	MaxOrdersLimit     = 500
+)
+
+// OrdersQuery параметры постраничной выборки заказов.
+// Заказы загружены в интервале [From, To), нулевая граница не ограничивает.
+type OrdersQuery struct {
+	Statuses []string
+	From     time.Time
+	To       time.Time
+	Desc     bool
+	// 0 - DefaultOrdersLimit
+	Limit  int
+	Cursor string
+}
+
+// Validate вернет *ValidationError с ошибками по каждому параметру
+func (q OrdersQuery) Validate() error {
+	fields := make([]FieldError, 0)
+
+	for _, status := range q.Statuses {
+		if !StatusValid(status) {
+			fields = append(fields, FieldError{
+				Field:   "status",
+				Code:    CodeInvalidValue,
+				Message: "неизвестный статус " + status,
+			})
+			break
+		}
+	}
+	if !q.From.IsZero() && !q.To.IsZero() && !q.From.Before(q.To) {
+		fields = append(fields, FieldError{
+			Field:   "to",
+			Code:    CodeInvalidValue,
+			Message: "окончание интервала должно быть позже начала",
+		})
+	}
+	if q.Limit < 0 || q.Limit > MaxOrdersLimit {
+		fields = append(fields, FieldError{
+			Field:   "limit",
+			Code:    CodeInvalidValue,
+			Message: "допустимо от 1 до " + strconv.Itoa(MaxOrdersLimit),
+		})
+	}
+
+	if len(fields) > 0 {
+		return &ValidationError{Fields: fields}
+	}
+	return nil
+}
+
+// PageLimit размер страницы с учетом значения по умолчанию
+func (q OrdersQuery) PageLimit() int {
+	if q.Limit == 0 {
+		return DefaultOrdersLimit
+	}
+	return q.Limit
+}
+
+// OrdersPage страница заказов, NextCursor пуст на последней странице
+type OrdersPage struct {
+	Orders     []Order `json:"orders"`
+	NextCursor string  `json:"next_cursor,omitempty"`
+}
+
+var errInvalidCursor = errors.New("invalid cursor")
+
+// OrderCursor последний заказ выданной страницы.
+// Клиенту передается закодированным и не разбирается им.
+type OrderCursor struct {
+	UploadedAt time.Time `json:"t"`
+	OrderID    OrderID   `json:"o"`
+	// курсор действителен только для того же направления сортировки
+	Desc bool `json:"d,omitempty"`
+}
+
+func (c OrderCursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func DecodeOrderCursor(cursor string) (OrderCursor, error) {
+	c := OrderCursor{}
+
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return c, errInvalidCursor
+	}
+	if err := json.Unmarshal(data, &c); err != nil || c.OrderID == "" || c.UploadedAt.IsZero() {
+		return c, errInvalidCursor
+	}
+	return c, nil
+}
@@ -0,0 +1,82 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrderCursor(t *testing.T) {
+	cursor := OrderCursor{
+		UploadedAt: time.Date(2024, 3, 1, 10, 0, 0, 123000, time.UTC),
+		OrderID:    "2377225624",
+		Desc:       true,
+	}
+
+	decoded, err := DecodeOrderCursor(cursor.Encode())
+	require.NoError(t, err)
+	assert.True(t, cursor.UploadedAt.Equal(decoded.UploadedAt))
+	assert.Equal(t, cursor.OrderID, decoded.OrderID)
+	assert.True(t, decoded.Desc)
+
+	for _, invalid := range []string{"", "не base64", "e30"} {
+		_, err := DecodeOrderCursor(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestOrdersQuery_Validate(t *testing.T) {
+	from := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		query  OrdersQuery
+		fields []string
+	}{
+		{
+			name: "параметры по умолчанию",
+		},
+		{
+			name: "все параметры",
+			query: OrdersQuery{
+				Statuses: []string{StatusNew, StatusProcessed},
+				From:     from,
+				To:       from.AddDate(0, 1, 0),
+				Desc:     true,
+				Limit:    MaxOrdersLimit,
+			},
+		},
+		{
+			name:   "неизвестный статус",
+			query:  OrdersQuery{Statuses: []string{StatusNew, "DONE"}},
+			fields: []string{"status"},
+		},
+		{
+			name:   "пустой интервал и большой лимит",
+			query:  OrdersQuery{From: from, To: from, Limit: MaxOrdersLimit + 1},
+			fields: []string{"to", "limit"},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := tt.query.Validate()
+			if len(tt.fields) == 0 {
+				assert.NoError(t, err)
+				return
+			}
+
+			var invalid *ValidationError
+			require.ErrorAs(t, err, &invalid)
+			fields := make([]string, 0, len(invalid.Fields))
+			for _, f := range invalid.Fields {
+				fields = append(fields, f.Field)
+			}
+			assert.Equal(t, tt.fields, fields)
+		})
+	}
+}
@@ -0,0 +1,29 @@
+package models
+
+// PasswordChange смена пароля аутентифицированным пользователем
+type PasswordChange struct {
+	OldPassword string `json:"old_password"`
+	NewPassword string `json:"new_password"`
+}
+
+func (p PasswordChange) Validate(policy CredentialPolicy) error {
+	return policy.ValidatePassword("new_password", p.NewPassword)
+}
+
+// PasswordResetRequest запрос токена сброса пароля
+type PasswordResetRequest struct {
+	Login string `json:"login"`
+}
+
+// PasswordReset установка нового пароля по токену сброса
+type PasswordReset struct {
+	Token       string `json:"token"`
+	NewPassword string `json:"new_password"`
+}
+
+func (p PasswordReset) Validate(policy CredentialPolicy) error {
+	if p.Token == "" {
+		return ErrInvalidResetToken
+	}
+	return policy.ValidatePassword("new_password", p.NewPassword)
+}
@@ -0,0 +1,55 @@
+package models
+
+// Role роль пользователя, определяет набор разрешений
+type Role string
+
+const (
+	RoleUser    Role = "user"    // покупатель
+	RoleSupport Role = "support" // поддержка, только чтение
+	RoleFinance Role = "finance" // финансы, чтение и корректировка балансов
+	RoleAdmin   Role = "admin"   // администратор, все разрешения
+)
+
+// Permission разрешение на административное действие
+type Permission string
+
+const (
+	PermissionUsersRead     Permission = "users:read"     // просмотр пользователей, заказов, балансов
+	PermissionUsersBlock    Permission = "users:block"    // блокировка и разблокировка пользователей
+	PermissionOrdersRecheck Permission = "orders:recheck" // повторный расчёт заказа
+	PermissionBalanceAdjust Permission = "balance:adjust" // корректировка баланса
+)
+
+var rolePermissions = map[Role][]Permission{
+	RoleSupport: {
+		PermissionUsersRead,
+	},
+	RoleFinance: {
+		PermissionUsersRead,
+		PermissionBalanceAdjust,
+	},
+	RoleAdmin: {
+		PermissionUsersRead,
+		PermissionUsersBlock,
+		PermissionOrdersRecheck,
+		PermissionBalanceAdjust,
+	},
+}
+
+func (r Role) Validate() bool {
+	switch r {
+	case RoleUser, RoleSupport, RoleFinance, RoleAdmin:
+		return true
+	}
+	return false
+}
+
+// Can проверяет наличие разрешения у роли
+func (r Role) Can(p Permission) bool {
+	for _, perm := range rolePermissions[r] {
+		if perm == p {
+			return true
+		}
+	}
+	return false
+}
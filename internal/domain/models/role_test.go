@@ -0,0 +1,28 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRole_Can(t *testing.T) {
+	tests := []struct {
+		role Role
+		perm Permission
+		want bool
+	}{
+		{role: RoleUser, perm: PermissionUsersRead, want: false},
+		{role: RoleSupport, perm: PermissionUsersRead, want: true},
+		{role: RoleSupport, perm: PermissionUsersBlock, want: false},
+		{role: RoleSupport, perm: PermissionBalanceAdjust, want: false},
+		{role: RoleFinance, perm: PermissionBalanceAdjust, want: true},
+		{role: RoleFinance, perm: PermissionOrdersRecheck, want: false},
+		{role: RoleAdmin, perm: PermissionUsersBlock, want: true},
+		{role: RoleAdmin, perm: PermissionOrdersRecheck, want: true},
+		{role: Role("unknown"), perm: PermissionUsersRead, want: false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, tt.role.Can(tt.perm), "%s %s", tt.role, tt.perm)
+	}
+}
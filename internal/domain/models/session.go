@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// Session вход пользователя с устройства, живет пока действуют его refresh токены
+type Session struct {
+	SessionID  string    `json:"id"`
+	IP         string    `json:"ip"`
+	UserAgent  string    `json:"user_agent"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	// сессия, из которой пришел запрос
+	Current bool `json:"current"`
+}
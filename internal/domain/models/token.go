@@ -0,0 +1,20 @@
+package models
+
+// Tokens пара токенов, выдаваемая при аутентификации
+type Tokens struct {
+	// в режиме cookie токены в теле ответа не передаются
+	AccessToken  string `json:"access_token,omitempty"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	// время жизни access токена в секундах
+	ExpiresIn int64 `json:"expires_in"`
+}
+
+// RefreshTokenRequest запрос на обновление пары токенов
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// LogoutRequest запрос на выход, refresh токен отзывается вместе с access токеном
+type LogoutRequest struct {
+	RefreshToken string `json:"refresh_token,omitempty"`
+}
@@ -0,0 +1,58 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// TOTPEnrollment секрет для приложения-аутентификатора, действует после подтверждения
+type TOTPEnrollment struct {
+	Secret string `json:"secret"`
+	// otpauth URI для QR-кода
+	URI string `json:"uri"`
+}
+
+// TOTPCode код из приложения-аутентификатора
+type TOTPCode struct {
+	Code string `json:"code"`
+}
+
+// RecoveryCodes одноразовые коды восстановления, показываются один раз
+type RecoveryCodes struct {
+	Codes []string `json:"recovery_codes"`
+}
+
+// MFAChallenge выдается после проверки пароля пользователю с включенной 2FA
+type MFAChallenge struct {
+	ChallengeToken string `json:"challenge_token"`
+	// время жизни токена в секундах
+	ExpiresIn int64 `json:"expires_in"`
+}
+
+// MFALogin второй шаг входа: код TOTP или код восстановления
+type MFALogin struct {
+	ChallengeToken string `json:"challenge_token"`
+	Code           string `json:"code"`
+}
+
+// MFARequiredError пароль верный, для выдачи токенов нужен второй фактор
+type MFARequiredError struct {
+	Challenge MFAChallenge
+}
+
+func NewMFARequiredError(token string, ttl time.Duration) *MFARequiredError {
+	return &MFARequiredError{
+		Challenge: MFAChallenge{
+			ChallengeToken: token,
+			ExpiresIn:      int64(ttl.Seconds()),
+		},
+	}
+}
+
+func (e *MFARequiredError) Error() string {
+	return fmt.Sprintf("second factor required, challenge expires in %ds", e.Challenge.ExpiresIn)
+}
+
+func (e *MFARequiredError) Unwrap() error {
+	return ErrMFARequired
+}
@@ -8,3 +8,22 @@ func (u UserID) Validate() bool {
 	_, err := uuid.Parse(string(u))
 	return err == nil
 }
+
+type User struct {
+	UserID    UserID `json:"user_id"`
+	Login     string `json:"login"`
+	IsBlocked bool   `json:"is_blocked"`
+	IsDeleted bool   `json:"is_deleted"`
+	Role      Role   `json:"role"`
+}
+
+// Active вернет ошибку, если пользователю запрещен доступ
+func (u User) Active() error {
+	switch {
+	case u.IsDeleted:
+		return ErrUserDeleted
+	case u.IsBlocked:
+		return ErrUserBlocked
+	}
+	return nil
+}
@@ -1,14 +1,27 @@
 package models
 
-import "time"
+import (
+	"fmt"
+	"time"
+
+	"github.com/vladislav-kr/gophermart/internal/domain/money"
+)
 
 type WithdrawalsBonuses struct {
-	Order       OrderID   `json:"order"`
-	Sum         float64   `json:"sum"`
-	ProcessedAt time.Time `json:"processed_at"`
+	Order       OrderID      `json:"order"`
+	Sum         money.Amount `json:"sum"`
+	ProcessedAt time.Time    `json:"processed_at"`
 }
 
 type WithdrawBonuses struct {
-	Order OrderID `json:"order"`
-	Sum   float64 `json:"sum"`
+	Order OrderID      `json:"order"`
+	Sum   money.Amount `json:"sum"`
+}
+
+// Validate сумма списания должна быть положительной
+func (w WithdrawBonuses) Validate() error {
+	if !w.Sum.IsPositive() {
+		return fmt.Errorf("sum %s: %w", w.Sum, ErrInvalidSum)
+	}
+	return nil
 }
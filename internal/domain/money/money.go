@@ -0,0 +1,223 @@
+package money
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Scale количество знаков после запятой, как у NUMERIC(15, 3) в базе
+const Scale = 3
+
+// unit одна целая единица в тысячных долях
+const unit = 1000
+
+// maxAmount наибольшая сумма, помещающаяся в NUMERIC(15, 3)
+const maxAmount Amount = 999_999_999_999_999
+
+// maxInput наибольшая длина десятичной записи. Запись любой допустимой суммы
+// короче, а длинная запись или большой порядок вроде 1e1000000 стоили бы
+// разбора огромного числа.
+const (
+	maxInput          = 40
+	maxExponentDigits = 2
+)
+
+var (
+	ErrScale = errors.New("more than 3 decimal places")
+	ErrRange = errors.New("amount out of range")
+	ErrValue = errors.New("invalid amount")
+)
+
+// Amount сумма баллов с фиксированной точкой в тысячных долях.
+// В JSON и в базе передается десятичным числом без потери точности.
+type Amount int64
+
+// FromInt сумма из целого числа баллов
+func FromInt(units int64) Amount {
+	return Amount(units * unit)
+}
+
+// Parse разбор десятичной записи, например 751.5 или 1e2.
+// Вернет ErrScale при дробной части длиннее Scale знаков.
+func Parse(s string) (Amount, error) {
+	r, err := parseRat(s)
+	if err != nil {
+		return 0, err
+	}
+	return fromRat(r, s)
+}
+
+// MustParse Parse для констант, паникует при ошибке
+func MustParse(s string) Amount {
+	a, err := Parse(s)
+	if err != nil {
+		panic(err)
+	}
+	return a
+}
+
+// ParseRounded Parse с округлением до Scale знаков, половина - от нуля,
+// как при записи в NUMERIC(15, 3). Для сумм из внешних систем,
+// пользовательский ввод разбирается строго через Parse.
+func ParseRounded(s string) (Amount, error) {
+	r, err := parseRat(s)
+	if err != nil {
+		return 0, err
+	}
+	r.Mul(r, big.NewRat(unit, 1))
+
+	v, rem := new(big.Int).QuoRem(r.Num(), r.Denom(), new(big.Int))
+	if rem.Abs(rem).Lsh(rem, 1).Cmp(r.Denom()) >= 0 {
+		v.Add(v, big.NewInt(int64(r.Sign())))
+	}
+	return fromBig(v, s)
+}
+
+// parseRat разбор записи с ограничением длины и порядка до big.Rat
+func parseRat(s string) (*big.Rat, error) {
+	t := strings.TrimSpace(s)
+	if len(t) > maxInput {
+		return nil, fmt.Errorf("%q: %w", truncate(s), ErrRange)
+	}
+	if i := strings.IndexAny(t, "eE"); i >= 0 {
+		if exp := strings.TrimLeft(t[i+1:], "+-"); len(exp) > maxExponentDigits {
+			return nil, fmt.Errorf("%q: %w", truncate(s), ErrRange)
+		}
+	}
+	r, ok := new(big.Rat).SetString(t)
+	if !ok {
+		return nil, fmt.Errorf("%q: %w", truncate(s), ErrValue)
+	}
+	return r, nil
+}
+
+// fromRat сумма из числа баллов, input - исходная запись для текста ошибки
+func fromRat(r *big.Rat, input string) (Amount, error) {
+	r.Mul(r, big.NewRat(unit, 1))
+	if !r.IsInt() {
+		return 0, fmt.Errorf("%q: %w", truncate(input), ErrScale)
+	}
+	return fromBig(r.Num(), input)
+}
+
+// fromBig сумма из целого числа тысячных долей
+func fromBig(v *big.Int, input string) (Amount, error) {
+	if !v.IsInt64() || Amount(v.Int64()) > maxAmount || Amount(v.Int64()) < -maxAmount {
+		return 0, fmt.Errorf("%q: %w", truncate(input), ErrRange)
+	}
+	return Amount(v.Int64()), nil
+}
+
+// truncate начало ввода для текста ошибки, ввод может быть сколь угодно длинным
+func truncate(s string) string {
+	if len(s) > maxInput {
+		return s[:maxInput] + "..."
+	}
+	return s
+}
+
+func (a Amount) IsZero() bool {
+	return a == 0
+}
+
+func (a Amount) IsPositive() bool {
+	return a > 0
+}
+
+func (a Amount) IsNegative() bool {
+	return a < 0
+}
+
+func (a Amount) Neg() Amount {
+	return -a
+}
+
+func (a Amount) Abs() Amount {
+	if a < 0 {
+		return -a
+	}
+	return a
+}
+
+// Float64 приближенное значение для метрик и логов, в расчетах не используется
+func (a Amount) Float64() float64 {
+	return float64(a) / unit
+}
+
+// String десятичная запись без лишних нулей: 500, 751.5, -0.001
+func (a Amount) String() string {
+	sign := ""
+	v := int64(a)
+	if v < 0 {
+		sign = "-"
+		v = -v
+	}
+	s := fmt.Sprintf("%s%d", sign, v/unit)
+	if frac := v % unit; frac != 0 {
+		s += strings.TrimRight(fmt.Sprintf(".%03d", frac), "0")
+	}
+	return s
+}
+
+func (a Amount) MarshalJSON() ([]byte, error) {
+	return []byte(a.String()), nil
+}
+
+// UnmarshalJSON принимает только число, строка или null - ошибка
+func (a *Amount) UnmarshalJSON(data []byte) error {
+	s := string(data)
+	if s == "null" || strings.HasPrefix(s, `"`) {
+		return fmt.Errorf("%q: %w", truncate(s), ErrValue)
+	}
+	v, err := Parse(s)
+	if err != nil {
+		return err
+	}
+	*a = v
+	return nil
+}
+
+// ScanNumeric чтение NUMERIC из pgx, NULL - ошибка
+func (a *Amount) ScanNumeric(n pgtype.Numeric) error {
+	if !n.Valid {
+		return fmt.Errorf("cannot scan NULL: %w", ErrValue)
+	}
+	if n.NaN || n.InfinityModifier != pgtype.Finite {
+		return fmt.Errorf("cannot scan %v: %w", n.InfinityModifier, ErrValue)
+	}
+
+	r := new(big.Rat).SetInt(n.Int)
+	exp := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(abs(n.Exp))), nil)
+	if n.Exp >= 0 {
+		r.Mul(r, new(big.Rat).SetInt(exp))
+	} else {
+		r.Quo(r, new(big.Rat).SetInt(exp))
+	}
+
+	v, err := fromRat(r, fmt.Sprintf("%se%d", n.Int, n.Exp))
+	if err != nil {
+		return err
+	}
+	*a = v
+	return nil
+}
+
+// NumericValue запись в NUMERIC через pgx
+func (a Amount) NumericValue() (pgtype.Numeric, error) {
+	return pgtype.Numeric{
+		Int:   big.NewInt(int64(a)),
+		Exp:   -Scale,
+		Valid: true,
+	}, nil
+}
+
+func abs(v int32) int32 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
@@ -0,0 +1,181 @@
+package money
+
+import (
+	"encoding/json"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    Amount
+		wantErr error
+	}{
+		{name: "целое число", value: "500", want: FromInt(500)},
+		{name: "дробная часть", value: "751.5", want: 751500},
+		{name: "три знака после запятой", value: "0.001", want: 1},
+		{name: "отрицательная сумма", value: "-30.25", want: -30250},
+		{name: "экспонента", value: "1e2", want: FromInt(100)},
+		{name: "лишние нули", value: "2.5000", want: 2500},
+		{name: "четыре знака после запятой", value: "0.0001", wantErr: ErrScale},
+		{name: "вне диапазона NUMERIC(15, 3)", value: "1000000000000", wantErr: ErrRange},
+		{name: "большой порядок", value: "1e1000000", wantErr: ErrRange},
+		{name: "порядок вне диапазона", value: "1e99", wantErr: ErrRange},
+		{name: "длинная запись", value: "1" + strings.Repeat("0", 100000), wantErr: ErrRange},
+		{name: "не число", value: "сто", wantErr: ErrValue},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := Parse(tt.value)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestParseRounded(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    Amount
+		wantErr error
+	}{
+		{name: "без округления", value: "751.5", want: 751500},
+		{name: "половина округляется от нуля", value: "0.0005", want: 1},
+		{name: "меньше половины", value: "0.0004", want: 0},
+		{name: "отрицательная сумма", value: "-729.98765", want: -729988},
+		{name: "вне диапазона NUMERIC(15, 3)", value: "1000000000000", wantErr: ErrRange},
+		{name: "не число", value: "сто", wantErr: ErrValue},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := ParseRounded(tt.value)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+// текст ошибки не растет вместе с вводом
+func TestParse_errorText(t *testing.T) {
+	for _, value := range []string{
+		"1e1000000",
+		"1" + strings.Repeat("0", 100000),
+		"0." + strings.Repeat("1", 100000),
+		`"` + strings.Repeat("1", 100000) + `"`,
+	} {
+		_, err := Parse(value)
+		require.Error(t, err)
+		assert.Less(t, len(err.Error()), 100)
+
+		_, err = ParseRounded(value)
+		require.Error(t, err)
+		assert.Less(t, len(err.Error()), 100)
+
+		var a Amount
+		err = json.Unmarshal([]byte(value), &a)
+		require.Error(t, err)
+		assert.Less(t, len(err.Error()), 100)
+	}
+}
+
+func TestAmount_String(t *testing.T) {
+	assert.Equal(t, "500", FromInt(500).String())
+	assert.Equal(t, "751.5", MustParse("751.5").String())
+	assert.Equal(t, "-0.001", Amount(-1).String())
+	assert.Equal(t, "0", Amount(0).String())
+	// сумма без ошибки округления float64
+	assert.Equal(t, "0.3", (MustParse("0.1") + MustParse("0.2")).String())
+}
+
+func TestAmount_JSON(t *testing.T) {
+	type balance struct {
+		Current   Amount `json:"current"`
+		Withdrawn Amount `json:"withdrawn,omitempty"`
+	}
+
+	data, err := json.Marshal(balance{Current: MustParse("100.43")})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"current":100.43}`, string(data))
+
+	var decoded balance
+	require.NoError(t, json.Unmarshal([]byte(`{"current":500.5,"withdrawn":42}`), &decoded))
+	assert.Equal(t, balance{Current: 500500, Withdrawn: FromInt(42)}, decoded)
+
+	for _, invalid := range []string{`{"current":"500"}`, `{"current":null}`, `{"current":0.0005}`} {
+		assert.Error(t, json.Unmarshal([]byte(invalid), &decoded), invalid)
+	}
+}
+
+func TestAmount_Numeric(t *testing.T) {
+	tests := []struct {
+		name    string
+		numeric pgtype.Numeric
+		want    Amount
+		wantErr error
+	}{
+		{
+			name:    "NUMERIC(15, 3)",
+			numeric: pgtype.Numeric{Int: big.NewInt(751500), Exp: -3, Valid: true},
+			want:    MustParse("751.5"),
+		},
+		{
+			name:    "положительная экспонента",
+			numeric: pgtype.Numeric{Int: big.NewInt(5), Exp: 2, Valid: true},
+			want:    FromInt(500),
+		},
+		{
+			name:    "лишние знаки после запятой",
+			numeric: pgtype.Numeric{Int: big.NewInt(1), Exp: -4, Valid: true},
+			wantErr: ErrScale,
+		},
+		{
+			name:    "NULL",
+			numeric: pgtype.Numeric{},
+			wantErr: ErrValue,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var got Amount
+			err := got.ScanNumeric(tt.numeric)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+
+			// запись и повторное чтение не меняют сумму
+			numeric, err := got.NumericValue()
+			require.NoError(t, err)
+			var again Amount
+			require.NoError(t, again.ScanNumeric(numeric))
+			assert.Equal(t, got, again)
+		})
+	}
+}
@@ -1,8 +1,18 @@
 package response
 
+import "github.com/vladislav-kr/gophermart/internal/domain/models"
+
 type Response struct {
-	Status string `json:"status"`
-	Error  string `json:"error,omitempty"`
+	Status string       `json:"status"`
+	Error  string       `json:"error,omitempty"`
+	Fields []FieldError `json:"fields,omitempty"`
+}
+
+// FieldError нарушение требования к полю запроса
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
 }
 
 const (
@@ -21,4 +31,18 @@ func Error(msg string) Response {
 		Status: StatusError,
 		Error:  msg,
 	}
-}
\ No newline at end of file
+}
+
+// Validation ошибка с перечнем нарушений по полям
+func Validation(msg string, err *models.ValidationError) Response {
+	resp := Error(msg)
+	resp.Fields = make([]FieldError, 0, len(err.Fields))
+	for _, f := range err.Fields {
+		resp.Fields = append(resp.Fields, FieldError{
+			Field:   f.Field,
+			Code:    f.Code,
+			Message: f.Message,
+		})
+	}
+	return resp
+}
@@ -16,6 +16,10 @@ type metrics struct {
 	panicTotal         prometheus.Counter
 	requestCount       *prometheus.CounterVec
 	statusCount        *prometheus.CounterVec
+	driftUsers         prometheus.Gauge
+	driftAmount        prometheus.Gauge
+	driftCorrections   prometheus.Counter
+	pointsExpired      prometheus.Counter
 }
 
 var m *metrics
@@ -48,6 +52,26 @@ func init() {
 		[]string{"status"},
 	)
 
+	m.driftUsers = promauto.With(m.prometheusRegistry).NewGauge(prometheus.GaugeOpts{
+		Name: "balance_reconciliation_drifted_users",
+		Help: "Number of users whose balance drifted from orders and withdrawals at the last reconciliation.",
+	})
+
+	m.driftAmount = promauto.With(m.prometheusRegistry).NewGauge(prometheus.GaugeOpts{
+		Name: "balance_reconciliation_drift_amount",
+		Help: "Absolute sum of current balance drift at the last reconciliation.",
+	})
+
+	m.driftCorrections = promauto.With(m.prometheusRegistry).NewCounter(prometheus.CounterOpts{
+		Name: "balance_reconciliation_corrections_total",
+		Help: "Total number of balances corrected by reconciliation adjustments.",
+	})
+
+	m.pointsExpired = promauto.With(m.prometheusRegistry).NewCounter(prometheus.CounterOpts{
+		Name: "points_expired_total",
+		Help: "Total amount of points expired after their lots reached the expiry date.",
+	})
+
 	m.prometheusHandler = promhttp.HandlerFor(
 		m.prometheusRegistry,
 		promhttp.HandlerOpts{
@@ -70,6 +94,20 @@ func (m *metrics) RequestStatusInc(method, uri string, status int) {
 	m.requestCount.WithLabelValues(method, uri, strconv.Itoa(status)).Inc()
 }
 
+// BalanceDrift результат последней сверки балансов
+func (m *metrics) BalanceDrift(users int, amount float64) {
+	m.driftUsers.Set(float64(users))
+	m.driftAmount.Set(amount)
+}
+
+func (m *metrics) BalanceCorrectionsAdd(n int) {
+	m.driftCorrections.Add(float64(n))
+}
+
+func (m *metrics) PointsExpiredAdd(amount float64) {
+	m.pointsExpired.Add(amount)
+}
+
 func (m *metrics) Handler() http.Handler {
 	return m.prometheusHandler
 }
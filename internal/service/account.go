@@ -0,0 +1,136 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/vladislav-kr/gophermart/internal/domain/models"
+	"github.com/vladislav-kr/gophermart/internal/logger"
+	"github.com/vladislav-kr/gophermart/internal/service/jwt"
+	"github.com/vladislav-kr/gophermart/internal/storage"
+)
+
+// ExportUserData профиль, входы, заказы и движение баллов пользователя.
+// Пустые разделы выгружаются пустыми списками.
+func (s *service) ExportUserData(ctx context.Context, userID models.UserID) (*models.UserExport, error) {
+	user, err := s.UserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	export := &models.UserExport{
+		ExportedAt:     time.Now().UTC(),
+		Profile:        *user,
+		Sessions:       []models.Session{},
+		APIKeys:        []models.APIKey{},
+		Orders:         []models.Order{},
+		Withdrawals:    []models.WithdrawalsBonuses{},
+		BalanceHistory: []models.BalanceEntry{},
+	}
+
+	totp, err := s.storage.TOTP(ctx, string(userID))
+	switch {
+	case err == nil:
+		export.TwoFactorEnabled = totp.ConfirmedAt != nil
+	case !errors.Is(err, storage.ErrNoRecordsFound):
+		return nil, fmt.Errorf("totp %v: %w", err, models.ErrInternal)
+	}
+
+	if sessions, err := s.Sessions(ctx, jwt.Claims{UserID: string(userID)}); err == nil {
+		export.Sessions = sessions
+	} else if !errors.Is(err, models.ErrNoRecordsFound) {
+		return nil, err
+	}
+
+	if keys, err := s.APIKeys(ctx, userID); err == nil {
+		export.APIKeys = keys
+	} else if !errors.Is(err, models.ErrNoRecordsFound) {
+		return nil, err
+	}
+
+	if orders, err := s.OrdersByUserID(ctx, userID); err == nil {
+		export.Orders = orders
+	} else if !errors.Is(err, models.ErrNoRecordsFound) {
+		return nil, err
+	}
+
+	if balance, err := s.UserBalance(ctx, userID); err == nil {
+		export.Balance = *balance
+	} else if !errors.Is(err, models.ErrNoRecordsFound) {
+		return nil, err
+	}
+
+	if withdrawals, err := s.WithdrawalsByUserID(ctx, userID); err == nil {
+		export.Withdrawals = withdrawals
+	} else if !errors.Is(err, models.ErrNoRecordsFound) {
+		return nil, err
+	}
+
+	if history, err := s.BalanceHistory(ctx, userID); err == nil {
+		export.BalanceHistory = history
+	} else if !errors.Is(err, models.ErrNoRecordsFound) {
+		return nil, err
+	}
+
+	return export, nil
+}
+
+// DeleteAccount удаляет учетную запись после проверки пароля.
+// Остаток баланса списывается или замораживается по политике сервиса.
+func (s *service) DeleteAccount(ctx context.Context, userID models.UserID, req models.AccountDeletion) error {
+	if !userID.Validate() {
+		return models.ErrUserIDMandatory
+	}
+
+	user, err := s.storage.UserByID(ctx, string(userID))
+	if err != nil {
+		return fmt.Errorf("user by id %v: %w", err, models.ErrInternal)
+	}
+
+	if err := userFromStorage(user).Active(); err != nil {
+		return err
+	}
+
+	// подбор пароля по украденному токену ограничивается как вход
+	if err := s.limiter.Allow(ctx, user.Login, ""); err != nil {
+		if errors.Is(err, models.ErrTooManyAttempts) {
+			return err
+		}
+		s.log.Error("login limiter allow", logger.Error(err))
+	}
+
+	if err := s.generator.CompareHashAndPassword(
+		user.Password,
+		[]byte(req.Password),
+	); err != nil {
+		switch {
+		case errors.Is(err, models.ErrMismatchedHashAndPassword):
+			s.loginFailed(ctx, user.Login, "")
+			return models.ErrIncorrectPassword
+		default:
+			return fmt.Errorf("compare hash and password %v: %w", err, models.ErrInternal)
+		}
+	}
+
+	forfeited, err := s.storage.DeleteUser(ctx, user.UserID, s.deletedBalance == models.DeletedBalanceForfeit)
+	if err != nil {
+		switch {
+		case errors.Is(err, storage.ErrNoRecordsFound):
+			return models.ErrUserDeleted
+		default:
+			return fmt.Errorf("delete user %v: %w", err, models.ErrInternal)
+		}
+	}
+	s.authStates.Delete(user.UserID)
+
+	s.log.Info("account deleted",
+		slog.String("user_id", user.UserID),
+		slog.String("balance_policy", string(s.deletedBalance)),
+		slog.String("forfeited", forfeited.String()),
+	)
+
+	return nil
+}
@@ -0,0 +1,117 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/vladislav-kr/gophermart/internal/domain/models"
+	"github.com/vladislav-kr/gophermart/internal/domain/money"
+	"github.com/vladislav-kr/gophermart/internal/service/mocks"
+	"github.com/vladislav-kr/gophermart/internal/storage"
+)
+
+func Test_service_ExportUserData(t *testing.T) {
+	const userID = "1cf50925-d72d-488b-94e5-426acce77f3c"
+
+	stor := mocks.NewStorage(t)
+	srv := NewService(nil, stor, nil, nil)
+
+	confirmedAt := time.Now()
+	stor.On("UserByID", mock.Anything, userID).
+		Return(&storage.User{UserID: userID, Login: "mylogin", Role: "user"}, nil).Once()
+	stor.On("TOTP", mock.Anything, userID).
+		Return(&storage.TOTP{UserID: userID, ConfirmedAt: &confirmedAt}, nil).Once()
+	stor.On("Sessions", mock.Anything, userID).Return(nil, storage.ErrNoRecordsFound).Once()
+	stor.On("APIKeys", mock.Anything, userID).Return(nil, storage.ErrNoRecordsFound).Once()
+	stor.On("Orders", mock.Anything, userID).Return([]storage.Order{
+		{OrderID: "12345678903", UserID: userID, Status: "PROCESSED", Accrual: money.FromInt(500)},
+	}, nil).Once()
+	stor.On("UserBalance", mock.Anything, userID).
+		Return(&storage.Balance{Current: money.FromInt(400), Withdrawn: money.FromInt(100)}, nil).Once()
+	stor.On("Withdrawals", mock.Anything, userID).Return([]storage.WithdrawalsBonuses{
+		{Order: "2377225624", Sum: money.FromInt(100)},
+	}, nil).Once()
+	stor.On("BalanceHistory", mock.Anything, userID).Return(nil, storage.ErrNoRecordsFound).Once()
+
+	export, err := srv.ExportUserData(context.Background(), userID)
+	require.NoError(t, err)
+
+	assert.Equal(t, "mylogin", export.Profile.Login)
+	assert.True(t, export.TwoFactorEnabled)
+	assert.Len(t, export.Orders, 1)
+	assert.Len(t, export.Withdrawals, 1)
+	assert.Equal(t, money.FromInt(400), export.Balance.Current)
+
+	// пустые разделы выгружаются пустыми списками, а не null
+	assert.NotNil(t, export.Sessions)
+	assert.NotNil(t, export.APIKeys)
+	assert.NotNil(t, export.BalanceHistory)
+}
+
+func Test_service_DeleteAccount(t *testing.T) {
+	const userID = "1cf50925-d72d-488b-94e5-426acce77f3c"
+
+	user := &storage.User{
+		UserID:   userID,
+		Login:    "mylogin",
+		Password: []byte("hash"),
+		Role:     "user",
+	}
+
+	tests := []struct {
+		name    string
+		policy  models.DeletedBalancePolicy
+		errGen  error
+		wantErr error
+	}{
+		{
+			name:   "учетная запись удалена, баланс заморожен",
+			policy: models.DeletedBalanceFreeze,
+		},
+		{
+			name:   "учетная запись удалена, баланс списан",
+			policy: models.DeletedBalanceForfeit,
+		},
+		{
+			name:    "неверный пароль",
+			policy:  models.DeletedBalanceFreeze,
+			errGen:  models.ErrMismatchedHashAndPassword,
+			wantErr: models.ErrIncorrectPassword,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			stor := mocks.NewStorage(t)
+			gen := mocks.NewPasswordGenerator(t)
+			limiter := mocks.NewLoginLimiter(t)
+			srv := NewService(gen, stor, nil, nil,
+				WithLoginLimiter(limiter),
+				WithDeletedBalancePolicy(tt.policy),
+			)
+
+			stor.On("UserByID", mock.Anything, userID).Return(user, nil).Once()
+			limiter.On("Allow", mock.Anything, user.Login, "").Return(nil).Once()
+			gen.On("CompareHashAndPassword", user.Password, []byte("password")).Return(tt.errGen).Once()
+			if tt.errGen != nil {
+				limiter.On("Failure", mock.Anything, user.Login, "").Return(nil).Once()
+			} else {
+				stor.On("DeleteUser", mock.Anything, userID, tt.policy == models.DeletedBalanceForfeit).
+					Return(money.FromInt(150), nil).Once()
+			}
+
+			err := srv.DeleteAccount(context.Background(), userID, models.AccountDeletion{Password: "password"})
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
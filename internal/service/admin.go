@@ -0,0 +1,154 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/vladislav-kr/gophermart/internal/domain/models"
+	"github.com/vladislav-kr/gophermart/internal/storage"
+)
+
+// максимальное количество пользователей в результате поиска
+const searchUsersLimit = 50
+
+// SearchUsers поиск пользователей по части логина
+func (s *service) SearchUsers(ctx context.Context, login string) ([]models.User, error) {
+	dbUsers, err := s.storage.SearchUsers(ctx, login, searchUsersLimit)
+	if err != nil {
+		switch {
+		case errors.Is(err, storage.ErrNoRecordsFound):
+			return nil, models.ErrNoRecordsFound
+		default:
+			return nil, fmt.Errorf("search users %v: %w", err, models.ErrInternal)
+		}
+	}
+
+	users := make([]models.User, 0, len(dbUsers))
+	for i := range dbUsers {
+		users = append(users, userFromStorage(&dbUsers[i]))
+	}
+
+	return users, nil
+}
+
+func (s *service) UserByID(ctx context.Context, userID models.UserID) (*models.User, error) {
+	if !userID.Validate() {
+		return nil, models.ErrUserIDMandatory
+	}
+
+	dbUser, err := s.storage.UserByID(ctx, string(userID))
+	if err != nil {
+		switch {
+		case errors.Is(err, storage.ErrNoRecordsFound):
+			return nil, models.ErrNoRecordsFound
+		default:
+			return nil, fmt.Errorf("user by id %v: %w", err, models.ErrInternal)
+		}
+	}
+
+	user := userFromStorage(dbUser)
+	return &user, nil
+}
+
+// SetUserBlocked блокирует или разблокирует пользователя.
+// Кеш состояния сбрасывается, чтобы блокировка действовала сразу.
+func (s *service) SetUserBlocked(ctx context.Context, adminID, userID models.UserID, blocked bool) error {
+	if !userID.Validate() {
+		return models.ErrUserIDMandatory
+	}
+
+	if err := s.storage.SetUserBlocked(ctx, string(userID), blocked); err != nil {
+		switch {
+		case errors.Is(err, storage.ErrNoRecordsFound):
+			return models.ErrNoRecordsFound
+		default:
+			return fmt.Errorf("set user blocked %v: %w", err, models.ErrInternal)
+		}
+	}
+	s.authStates.Delete(string(userID))
+
+	s.log.Info("admin action",
+		slog.String("action", "set_user_blocked"),
+		slog.String("admin_id", string(adminID)),
+		slog.String("user_id", string(userID)),
+		slog.Bool("blocked", blocked),
+	)
+
+	return nil
+}
+
+// RecheckOrder отправляет заказ на повторный расчёт в системе начислений,
+// начисление за обработанный заказ сторнируется
+func (s *service) RecheckOrder(ctx context.Context, adminID models.UserID, orderID models.OrderID) error {
+	if !orderID.Validate() {
+		return models.ErrIncorrectOrderNumber
+	}
+
+	if err := s.storage.RecheckOrder(ctx, string(orderID)); err != nil {
+		switch {
+		case errors.Is(err, storage.ErrNoRecordsFound):
+			return models.ErrNoRecordsFound
+		case errors.Is(err, storage.ErrConstraints):
+			return models.ErrInsufficientFunds
+		default:
+			return fmt.Errorf("recheck order %v: %w", err, models.ErrInternal)
+		}
+	}
+
+	s.log.Info("admin action",
+		slog.String("action", "recheck_order"),
+		slog.String("admin_id", string(adminID)),
+		slog.String("order_id", string(orderID)),
+	)
+
+	return nil
+}
+
+// AdjustBalance ручная корректировка баланса пользователя
+func (s *service) AdjustBalance(
+	ctx context.Context,
+	adminID, userID models.UserID,
+	adjustment models.BalanceAdjustment,
+) (*models.Balance, error) {
+	if !userID.Validate() || !adminID.Validate() {
+		return nil, models.ErrUserIDMandatory
+	}
+
+	if err := adjustment.Validate(); err != nil {
+		return nil, err
+	}
+
+	balance, err := s.storage.AdjustBalance(ctx, storage.BalanceAdjustment{
+		UserID:  string(userID),
+		AdminID: string(adminID),
+		Amount:  adjustment.Amount,
+		Reason:  string(adjustment.Reason),
+		Comment: strings.TrimSpace(adjustment.Comment),
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, storage.ErrNoRecordsFound):
+			return nil, models.ErrNoRecordsFound
+		case errors.Is(err, storage.ErrConstraints):
+			return nil, models.ErrInsufficientFunds
+		default:
+			return nil, fmt.Errorf("adjust balance %v: %w", err, models.ErrInternal)
+		}
+	}
+
+	s.log.Info("admin action",
+		slog.String("action", "adjust_balance"),
+		slog.String("admin_id", string(adminID)),
+		slog.String("user_id", string(userID)),
+		slog.String("amount", adjustment.Amount.String()),
+		slog.String("reason", string(adjustment.Reason)),
+	)
+
+	return &models.Balance{
+		Current:   balance.Current,
+		Withdrawn: balance.Withdrawn,
+	}, nil
+}
@@ -0,0 +1,418 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/vladislav-kr/gophermart/internal/domain/models"
+	"github.com/vladislav-kr/gophermart/internal/domain/money"
+	"github.com/vladislav-kr/gophermart/internal/service/mocks"
+	"github.com/vladislav-kr/gophermart/internal/storage"
+)
+
+func Test_service_SearchUsers(t *testing.T) {
+	tests := []struct {
+		name      string
+		dbUsers   []storage.User
+		errDB     error
+		wantUsers []models.User
+		wantErr   error
+	}{
+		{
+			name: "пользователи найдены",
+			dbUsers: []storage.User{
+				{UserID: "1cf50925-d72d-488b-94e5-426acce77f3c", Login: "user1", Role: "user"},
+				{UserID: "2cf50925-d72d-488b-94e5-426acce77f3c", Login: "user2", Role: "support", IsBlocked: true},
+			},
+			wantUsers: []models.User{
+				{UserID: "1cf50925-d72d-488b-94e5-426acce77f3c", Login: "user1", Role: models.RoleUser},
+				{UserID: "2cf50925-d72d-488b-94e5-426acce77f3c", Login: "user2", Role: models.RoleSupport, IsBlocked: true},
+			},
+		},
+		{
+			name:    "пользователи не найдены",
+			errDB:   storage.ErrNoRecordsFound,
+			wantErr: models.ErrNoRecordsFound,
+		},
+		{
+			name:    "ошибка хранилища",
+			errDB:   fmt.Errorf("db error"),
+			wantErr: models.ErrInternal,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			stor := mocks.NewStorage(t)
+			srv := NewService(nil, stor, nil, nil)
+
+			stor.On("SearchUsers",
+				mock.AnythingOfType("*context.timerCtx"),
+				"user",
+				uint32(searchUsersLimit),
+			).Return(tt.dbUsers, tt.errDB).Once()
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second*4)
+			defer cancel()
+
+			users, err := srv.SearchUsers(ctx, "user")
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantUsers, users)
+		})
+	}
+}
+
+func Test_service_SetUserBlocked(t *testing.T) {
+	const userID = "1cf50925-d72d-488b-94e5-426acce77f3c"
+
+	tests := []struct {
+		name     string
+		userID   models.UserID
+		callMock bool
+		errDB    error
+		wantErr  error
+	}{
+		{
+			name:     "пользователь заблокирован",
+			userID:   userID,
+			callMock: true,
+		},
+		{
+			name:    "неверный идентификатор пользователя",
+			userID:  "user",
+			wantErr: models.ErrUserIDMandatory,
+		},
+		{
+			name:     "пользователь не найден",
+			userID:   userID,
+			callMock: true,
+			errDB:    storage.ErrNoRecordsFound,
+			wantErr:  models.ErrNoRecordsFound,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			stor := mocks.NewStorage(t)
+			srv := NewService(nil, stor, nil, nil)
+
+			if tt.callMock {
+				stor.On("SetUserBlocked",
+					mock.AnythingOfType("*context.timerCtx"),
+					string(tt.userID),
+					true,
+				).Return(tt.errDB).Once()
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second*4)
+			defer cancel()
+
+			// состояние из кеша не должно пережить блокировку
+			srv.authStates.Set(string(tt.userID), authState{})
+
+			err := srv.SetUserBlocked(ctx, "admin", tt.userID, true)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+
+			_, ok := srv.authStates.Get(string(tt.userID))
+			assert.False(t, ok)
+		})
+	}
+}
+
+func Test_service_RecheckOrder(t *testing.T) {
+	tests := []struct {
+		name     string
+		orderID  models.OrderID
+		callMock bool
+		errDB    error
+		wantErr  error
+	}{
+		{
+			name:     "заказ отправлен на повторный расчёт",
+			orderID:  "2377225624",
+			callMock: true,
+		},
+		{
+			name:    "неверный номер заказа",
+			orderID: "2377225625",
+			wantErr: models.ErrIncorrectOrderNumber,
+		},
+		{
+			name:     "заказ не найден",
+			orderID:  "2377225624",
+			callMock: true,
+			errDB:    storage.ErrNoRecordsFound,
+			wantErr:  models.ErrNoRecordsFound,
+		},
+		{
+			name:     "начисление за заказ уже потрачено",
+			orderID:  "2377225624",
+			callMock: true,
+			errDB:    storage.ErrConstraints,
+			wantErr:  models.ErrInsufficientFunds,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			stor := mocks.NewStorage(t)
+			srv := NewService(nil, stor, nil, nil)
+
+			if tt.callMock {
+				stor.On("RecheckOrder",
+					mock.AnythingOfType("*context.timerCtx"),
+					string(tt.orderID),
+				).Return(tt.errDB).Once()
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second*4)
+			defer cancel()
+
+			err := srv.RecheckOrder(ctx, "admin", tt.orderID)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func Test_service_AdjustBalance(t *testing.T) {
+	const (
+		adminID = "2cf50925-d72d-488b-94e5-426acce77f3c"
+		userID  = "1cf50925-d72d-488b-94e5-426acce77f3c"
+	)
+
+	tests := []struct {
+		name        string
+		adjustment  models.BalanceAdjustment
+		callMock    bool
+		balance     *storage.Balance
+		errDB       error
+		wantBalance *models.Balance
+		wantErr     error
+	}{
+		{
+			name: "баллы начислены",
+			adjustment: models.BalanceAdjustment{
+				Amount:  money.FromInt(100),
+				Reason:  models.ReasonCompensation,
+				Comment: " задержка доставки ",
+			},
+			callMock:    true,
+			balance:     &storage.Balance{Current: money.FromInt(150), Withdrawn: money.FromInt(10)},
+			wantBalance: &models.Balance{Current: money.FromInt(150), Withdrawn: money.FromInt(10)},
+		},
+		{
+			name: "неизвестная причина",
+			adjustment: models.BalanceAdjustment{
+				Amount:  money.FromInt(100),
+				Reason:  "gift",
+				Comment: "подарок",
+			},
+			wantErr: models.ErrInvalidAdjustment,
+		},
+		{
+			name: "без комментария",
+			adjustment: models.BalanceAdjustment{
+				Amount: money.FromInt(100),
+				Reason: models.ReasonCorrection,
+			},
+			wantErr: models.ErrInvalidAdjustment,
+		},
+		{
+			name: "списание больше баланса",
+			adjustment: models.BalanceAdjustment{
+				Amount:  money.FromInt(-100),
+				Reason:  models.ReasonCorrection,
+				Comment: "задержка доставки",
+			},
+			callMock: true,
+			errDB:    storage.ErrConstraints,
+			wantErr:  models.ErrInsufficientFunds,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			stor := mocks.NewStorage(t)
+			srv := NewService(nil, stor, nil, nil)
+
+			if tt.callMock {
+				stor.On("AdjustBalance",
+					mock.AnythingOfType("*context.timerCtx"),
+					storage.BalanceAdjustment{
+						UserID:  userID,
+						AdminID: adminID,
+						Amount:  tt.adjustment.Amount,
+						Reason:  string(tt.adjustment.Reason),
+						Comment: "задержка доставки",
+					},
+				).Return(tt.balance, tt.errDB).Once()
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second*4)
+			defer cancel()
+
+			balance, err := srv.AdjustBalance(ctx, adminID, userID, tt.adjustment)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantBalance, balance)
+		})
+	}
+}
+
+func Test_service_ReconcileBalances(t *testing.T) {
+	const (
+		adminID = "2cf50925-d72d-488b-94e5-426acce77f3c"
+		userID1 = "1cf50925-d72d-488b-94e5-426acce77f3c"
+		userID2 = "3cf50925-d72d-488b-94e5-426acce77f3c"
+	)
+
+	drifts := []storage.BalanceDrift{
+		{UserID: userID1, Current: money.FromInt(150), Withdrawn: money.FromInt(10), ExpectedCurrent: money.FromInt(100), ExpectedWithdrawn: money.FromInt(10)},
+		{UserID: userID2, Current: money.FromInt(50), Withdrawn: money.FromInt(0), ExpectedCurrent: money.FromInt(50), ExpectedWithdrawn: money.FromInt(20)},
+	}
+
+	type correction struct {
+		userID  string
+		author  string
+		drift   *storage.BalanceDrift
+		errDB   error
+		applied bool
+	}
+
+	tests := []struct {
+		name          string
+		adminID       models.UserID
+		correct       bool
+		drifts        []storage.BalanceDrift
+		errDrifts     error
+		corrections   []correction
+		wantDrifted   int
+		wantCorrected int
+		wantAmount    money.Amount
+		wantErr       error
+	}{
+		{
+			name:      "расхождений нет",
+			adminID:   adminID,
+			errDrifts: storage.ErrNoRecordsFound,
+		},
+		{
+			name:        "отчет без исправления",
+			adminID:     adminID,
+			drifts:      drifts,
+			wantDrifted: 2,
+			wantAmount:  money.FromInt(50),
+		},
+		{
+			name:    "исправление администратором",
+			adminID: adminID,
+			correct: true,
+			drifts:  drifts,
+			corrections: []correction{
+				{userID: userID1, author: adminID, drift: &drifts[0], applied: true},
+			},
+			wantDrifted:   2,
+			wantCorrected: 1,
+			wantAmount:    money.FromInt(50),
+		},
+		{
+			name:    "исправление системой без администратора",
+			correct: true,
+			drifts:  drifts[:1],
+			corrections: []correction{
+				{userID: userID1, author: "", errDB: storage.ErrNoRecordsFound},
+			},
+			wantDrifted: 1,
+			wantAmount:  money.FromInt(50),
+		},
+		{
+			name:    "ошибка исправления не прерывает сверку",
+			adminID: adminID,
+			correct: true,
+			drifts:  drifts,
+			corrections: []correction{
+				{userID: userID1, author: adminID, errDB: storage.ErrConstraints},
+			},
+			wantDrifted: 2,
+			wantAmount:  money.FromInt(50),
+		},
+		{
+			name:    "некорректный id администратора",
+			adminID: "admin",
+			wantErr: models.ErrUserIDMandatory,
+		},
+		{
+			name:      "ошибка хранилища",
+			adminID:   adminID,
+			errDrifts: fmt.Errorf("internal"),
+			wantErr:   models.ErrInternal,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			stor := mocks.NewStorage(t)
+			srv := NewService(nil, stor, nil, nil)
+
+			if tt.wantErr == nil || tt.errDrifts != nil {
+				stor.On("BalanceDrifts",
+					mock.AnythingOfType("*context.timerCtx"),
+				).Return(tt.drifts, tt.errDrifts).Once()
+			}
+			for _, c := range tt.corrections {
+				stor.On("CorrectBalanceDrift",
+					mock.AnythingOfType("*context.timerCtx"),
+					c.userID,
+					c.author,
+				).Return(c.drift, c.errDB).Once()
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second*4)
+			defer cancel()
+
+			report, err := srv.ReconcileBalances(ctx, tt.adminID, tt.correct)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Nil(t, report)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantDrifted, report.Drifted)
+			assert.Equal(t, tt.wantCorrected, report.Corrected)
+			assert.Equal(t, tt.wantAmount, report.DriftAmount)
+			assert.Len(t, report.Users, tt.wantDrifted)
+			for i, c := range tt.corrections {
+				assert.Equal(t, c.applied, report.Users[i].Corrected)
+			}
+		})
+	}
+}
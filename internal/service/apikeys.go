@@ -0,0 +1,192 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/vladislav-kr/gophermart/internal/domain/models"
+	"github.com/vladislav-kr/gophermart/internal/logger"
+	"github.com/vladislav-kr/gophermart/internal/storage"
+)
+
+const (
+	// префикс API ключей, отличает их от access токенов в логах и при утечке
+	apiKeyPrefix = "gmk_"
+	// длина начала ключа, по которому владелец узнает его в списке
+	apiKeyDisplayLength = len(apiKeyPrefix) + 8
+)
+
+// CreateAPIKey выпускает API ключ, действующий от имени пользователя.
+// Хранится только хеш, значение ключа возвращается один раз.
+func (s *service) CreateAPIKey(ctx context.Context, userID models.UserID, req models.APIKeyRequest) (*models.NewAPIKey, error) {
+	if !userID.Validate() {
+		return nil, models.ErrUserIDMandatory
+	}
+
+	if err := req.Validate(time.Now()); err != nil {
+		return nil, err
+	}
+
+	token, _, err := newOpaqueToken()
+	if err != nil {
+		return nil, fmt.Errorf("api key generation %v: %w", err, models.ErrInternal)
+	}
+	key := apiKeyPrefix + token
+
+	scopes := make([]string, 0, len(req.Scopes))
+	for _, scope := range req.Scopes {
+		scopes = append(scopes, string(scope))
+	}
+
+	created, err := s.storage.CreateAPIKey(ctx, storage.CreateAPIKey{
+		UserID:    string(userID),
+		Name:      req.Name,
+		Prefix:    key[:apiKeyDisplayLength],
+		KeyHash:   hashToken(key),
+		Scopes:    scopes,
+		ExpiresAt: req.ExpiresAt,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("create api key %v: %w", err, models.ErrInternal)
+	}
+
+	s.log.Info("api key created",
+		slog.String("user_id", string(userID)),
+		slog.String("key_id", created.KeyID),
+	)
+
+	return &models.NewAPIKey{
+		APIKey: apiKeyFromStorage(created),
+		Key:    key,
+	}, nil
+}
+
+// APIKeys ключи пользователя без их значений
+func (s *service) APIKeys(ctx context.Context, userID models.UserID) ([]models.APIKey, error) {
+	if !userID.Validate() {
+		return nil, models.ErrUserIDMandatory
+	}
+
+	dbKeys, err := s.storage.APIKeys(ctx, string(userID))
+	if err != nil {
+		switch {
+		case errors.Is(err, storage.ErrNoRecordsFound):
+			return nil, models.ErrNoRecordsFound
+		default:
+			return nil, fmt.Errorf("api keys %v: %w", err, models.ErrInternal)
+		}
+	}
+
+	keys := make([]models.APIKey, 0, len(dbKeys))
+	for i := range dbKeys {
+		keys = append(keys, apiKeyFromStorage(&dbKeys[i]))
+	}
+
+	return keys, nil
+}
+
+// RevokeAPIKey отзывает ключ пользователя, чужой ключ считается ненайденным
+func (s *service) RevokeAPIKey(ctx context.Context, userID models.UserID, keyID string) error {
+	if !userID.Validate() {
+		return models.ErrUserIDMandatory
+	}
+
+	if _, err := uuid.Parse(keyID); err != nil {
+		return models.ErrNoRecordsFound
+	}
+
+	if err := s.storage.RevokeAPIKey(ctx, string(userID), keyID); err != nil {
+		switch {
+		case errors.Is(err, storage.ErrNoRecordsFound):
+			return models.ErrNoRecordsFound
+		default:
+			return fmt.Errorf("revoke api key %v: %w", err, models.ErrInternal)
+		}
+	}
+
+	s.log.Info("api key revoked",
+		slog.String("user_id", string(userID)),
+		slog.String("key_id", keyID),
+	)
+
+	return nil
+}
+
+// AuthenticateAPIKey проверяет ключ и его право на операцию scope,
+// учитывает использование. Вернет ключ с владельцем, от имени которого выполняется запрос.
+func (s *service) AuthenticateAPIKey(
+	ctx context.Context,
+	key string,
+	scope models.APIKeyScope,
+	client models.Client,
+) (*models.APIKey, error) {
+	if !strings.HasPrefix(key, apiKeyPrefix) {
+		return nil, models.ErrInvalidAPIKey
+	}
+
+	dbKey, err := s.storage.APIKeyByHash(ctx, hashToken(key))
+	if err != nil {
+		switch {
+		case errors.Is(err, storage.ErrNoRecordsFound):
+			return nil, models.ErrInvalidAPIKey
+		default:
+			return nil, fmt.Errorf("api key by hash %v: %w", err, models.ErrInternal)
+		}
+	}
+
+	apiKey := apiKeyFromStorage(dbKey)
+	if !apiKey.Usable(time.Now()) {
+		return nil, models.ErrInvalidAPIKey
+	}
+
+	if !apiKey.Allows(scope) {
+		return nil, models.ErrAPIKeyScope
+	}
+
+	state, err := s.authState(ctx, dbKey.UserID)
+	if err != nil {
+		switch {
+		case errors.Is(err, storage.ErrNoRecordsFound):
+			return nil, models.ErrInvalidAPIKey
+		default:
+			return nil, fmt.Errorf("user by id %v: %w", err, models.ErrInternal)
+		}
+	}
+
+	if err := state.user.Active(); err != nil {
+		return nil, err
+	}
+
+	// учет использования не должен мешать приему заказа
+	if err := s.storage.RecordAPIKeyUsage(ctx, apiKey.KeyID, client.IP); err != nil {
+		s.log.Error("record api key usage", logger.Error(err))
+	}
+
+	return &apiKey, nil
+}
+
+func apiKeyFromStorage(k *storage.APIKey) models.APIKey {
+	scopes := make([]models.APIKeyScope, 0, len(k.Scopes))
+	for _, scope := range k.Scopes {
+		scopes = append(scopes, models.APIKeyScope(scope))
+	}
+
+	return models.APIKey{
+		KeyID:      k.KeyID,
+		UserID:     models.UserID(k.UserID),
+		Name:       k.Name,
+		Prefix:     k.Prefix,
+		Scopes:     scopes,
+		CreatedAt:  k.CreatedAt,
+		ExpiresAt:  k.ExpiresAt,
+		LastUsedAt: k.LastUsedAt,
+		LastUsedIP: k.LastUsedIP,
+		UseCount:   k.UseCount,
+		RevokedAt:  k.RevokedAt,
+	}
+}
@@ -0,0 +1,163 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/vladislav-kr/gophermart/internal/domain/models"
+	"github.com/vladislav-kr/gophermart/internal/service/mocks"
+	"github.com/vladislav-kr/gophermart/internal/storage"
+)
+
+func Test_service_CreateAPIKey(t *testing.T) {
+	const userID = "1cf50925-d72d-488b-94e5-426acce77f3c"
+
+	stor := mocks.NewStorage(t)
+	srv := NewService(nil, stor, nil, nil)
+
+	var saved storage.CreateAPIKey
+	stor.On("CreateAPIKey", mock.Anything, mock.AnythingOfType("storage.CreateAPIKey")).
+		Run(func(args mock.Arguments) {
+			saved = args.Get(1).(storage.CreateAPIKey)
+		}).
+		Return(&storage.APIKey{
+			KeyID:  "6b1e2c9a-4f55-4c1b-9d8e-2f3a4b5c6d7e",
+			UserID: userID,
+			Name:   "POS",
+			Prefix: "gmk_abcdefgh",
+			Scopes: []string{"orders:upload"},
+		}, nil).Once()
+
+	key, err := srv.CreateAPIKey(context.Background(), userID, models.APIKeyRequest{
+		Name:   "POS",
+		Scopes: []models.APIKeyScope{models.ScopeOrdersUpload},
+	})
+	require.NoError(t, err)
+
+	// хранится только хеш, значение ключа отдается клиенту
+	assert.True(t, strings.HasPrefix(key.Key, apiKeyPrefix))
+	assert.Equal(t, hashToken(key.Key), saved.KeyHash)
+	assert.Equal(t, key.Key[:apiKeyDisplayLength], saved.Prefix)
+	assert.Equal(t, []string{"orders:upload"}, saved.Scopes)
+	assert.Equal(t, []models.APIKeyScope{models.ScopeOrdersUpload}, key.Scopes)
+
+	_, err = srv.CreateAPIKey(context.Background(), userID, models.APIKeyRequest{
+		Name:   "POS",
+		Scopes: []models.APIKeyScope{"orders:read"},
+	})
+	assert.ErrorIs(t, err, models.ErrInvalidAPIKeyRequest)
+}
+
+func Test_service_AuthenticateAPIKey(t *testing.T) {
+	const (
+		userID = "1cf50925-d72d-488b-94e5-426acce77f3c"
+		keyID  = "6b1e2c9a-4f55-4c1b-9d8e-2f3a4b5c6d7e"
+	)
+	past := time.Now().Add(-time.Hour)
+
+	tests := []struct {
+		name        string
+		key         string
+		dbKey       *storage.APIKey
+		errDB       error
+		user        *storage.User
+		recordUsage bool
+		wantErr     error
+	}{
+		{
+			name:        "ключ действителен",
+			key:         "gmk_valid",
+			dbKey:       &storage.APIKey{KeyID: keyID, UserID: userID, Scopes: []string{"orders:upload"}},
+			user:        &storage.User{UserID: userID, Role: "user"},
+			recordUsage: true,
+		},
+		{
+			name:    "неизвестный формат ключа",
+			key:     "access-token",
+			wantErr: models.ErrInvalidAPIKey,
+		},
+		{
+			name:    "ключ не найден",
+			key:     "gmk_unknown",
+			errDB:   storage.ErrNoRecordsFound,
+			wantErr: models.ErrInvalidAPIKey,
+		},
+		{
+			name:    "ключ отозван",
+			key:     "gmk_revoked",
+			dbKey:   &storage.APIKey{KeyID: keyID, UserID: userID, Scopes: []string{"orders:upload"}, RevokedAt: &past},
+			wantErr: models.ErrInvalidAPIKey,
+		},
+		{
+			name:    "срок ключа истек",
+			key:     "gmk_expired",
+			dbKey:   &storage.APIKey{KeyID: keyID, UserID: userID, Scopes: []string{"orders:upload"}, ExpiresAt: &past},
+			wantErr: models.ErrInvalidAPIKey,
+		},
+		{
+			name:    "нет права на операцию",
+			key:     "gmk_scope",
+			dbKey:   &storage.APIKey{KeyID: keyID, UserID: userID, Scopes: []string{}},
+			wantErr: models.ErrAPIKeyScope,
+		},
+		{
+			name:    "владелец заблокирован",
+			key:     "gmk_blocked",
+			dbKey:   &storage.APIKey{KeyID: keyID, UserID: userID, Scopes: []string{"orders:upload"}},
+			user:    &storage.User{UserID: userID, Role: "user", IsBlocked: true},
+			wantErr: models.ErrUserBlocked,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			stor := mocks.NewStorage(t)
+			srv := NewService(nil, stor, nil, nil)
+
+			if tt.dbKey != nil || tt.errDB != nil {
+				stor.On("APIKeyByHash", mock.Anything, hashToken(tt.key)).Return(tt.dbKey, tt.errDB).Once()
+			}
+			if tt.user != nil {
+				stor.On("UserByID", mock.Anything, userID).Return(tt.user, nil).Once()
+			}
+			if tt.recordUsage {
+				stor.On("RecordAPIKeyUsage", mock.Anything, keyID, "10.0.0.1").Return(nil).Once()
+			}
+
+			key, err := srv.AuthenticateAPIKey(context.Background(), tt.key,
+				models.ScopeOrdersUpload, models.Client{IP: "10.0.0.1"})
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, models.UserID(userID), key.UserID)
+		})
+	}
+}
+
+func Test_service_RevokeAPIKey(t *testing.T) {
+	const (
+		userID = "1cf50925-d72d-488b-94e5-426acce77f3c"
+		keyID  = "6b1e2c9a-4f55-4c1b-9d8e-2f3a4b5c6d7e"
+	)
+
+	stor := mocks.NewStorage(t)
+	srv := NewService(nil, stor, nil, nil)
+
+	stor.On("RevokeAPIKey", mock.Anything, userID, keyID).Return(nil).Once()
+	assert.NoError(t, srv.RevokeAPIKey(context.Background(), userID, keyID))
+
+	stor.On("RevokeAPIKey", mock.Anything, userID, keyID).Return(storage.ErrNoRecordsFound).Once()
+	assert.ErrorIs(t, srv.RevokeAPIKey(context.Background(), userID, keyID), models.ErrNoRecordsFound)
+
+	// некорректный идентификатор не доходит до хранилища
+	assert.ErrorIs(t, srv.RevokeAPIKey(context.Background(), userID, "not-uuid"), models.ErrNoRecordsFound)
+}
@@ -0,0 +1,63 @@
+package expirepoints
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/vladislav-kr/gophermart/internal/domain/models"
+	"github.com/vladislav-kr/gophermart/internal/logger"
+)
+
+type Expirer interface {
+	ExpirePoints(ctx context.Context) (*models.PointsExpiry, error)
+}
+
+// expirePoints периодическое сгорание баллов с истекшим сроком
+type expirePoints struct {
+	expirer Expirer
+	// период между запусками
+	interval time.Duration
+	// таймаут одного запуска
+	timeout time.Duration
+
+	log *slog.Logger
+}
+
+func New(e Expirer, interval, timeout time.Duration) *expirePoints {
+	return &expirePoints{
+		expirer:  e,
+		interval: interval,
+		timeout:  timeout,
+		log:      logger.Logger().With(slog.String("component", "expire-points")),
+	}
+}
+
+// Run списывает сгоревшие баллы каждые interval до отмены контекста,
+// нулевой interval отключает сгорание
+func (ep *expirePoints) Run(ctx context.Context) {
+	if ep.interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(ep.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			ep.expire(ctx)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (ep *expirePoints) expire(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, ep.timeout)
+	defer cancel()
+
+	if _, err := ep.expirer.ExpirePoints(ctx); err != nil {
+		ep.log.Error("expire points", logger.Error(err))
+	}
+}
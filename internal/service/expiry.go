@@ -0,0 +1,63 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/vladislav-kr/gophermart/internal/domain/models"
+	"github.com/vladislav-kr/gophermart/internal/logger"
+	"github.com/vladislav-kr/gophermart/internal/metrics"
+	"github.com/vladislav-kr/gophermart/internal/storage"
+)
+
+// пользователей за один запуск сгорания, остальные обрабатываются следующим запуском
+const expirePointsBatch = 500
+
+// ExpirePoints списывает остатки партий баллов с наступившим сроком.
+// Ошибка сгорания у одного пользователя не прерывает обработку остальных.
+func (s *service) ExpirePoints(ctx context.Context) (*models.PointsExpiry, error) {
+	report := &models.PointsExpiry{
+		ExpiredAt: time.Now(),
+	}
+
+	users, err := s.storage.UsersWithExpiredPoints(ctx, report.ExpiredAt, expirePointsBatch)
+	if err != nil {
+		switch {
+		case errors.Is(err, storage.ErrNoRecordsFound):
+			return report, nil
+		default:
+			return nil, fmt.Errorf("users with expired points %v: %w", err, models.ErrInternal)
+		}
+	}
+
+	for _, userID := range users {
+		amount, err := s.storage.ExpirePoints(ctx, userID, report.ExpiredAt)
+		if err != nil {
+			if !errors.Is(err, storage.ErrNoRecordsFound) {
+				s.log.Error("expire points",
+					slog.String("user_id", userID),
+					logger.Error(err),
+				)
+			}
+			continue
+		}
+		if amount.IsZero() {
+			continue
+		}
+
+		report.Users++
+		report.Amount += amount
+	}
+
+	metrics.Mertics().PointsExpiredAdd(report.Amount.Float64())
+
+	s.log.Info("points expiry",
+		slog.Int("users", report.Users),
+		slog.String("amount", report.Amount.String()),
+	)
+
+	return report, nil
+}
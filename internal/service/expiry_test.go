@@ -0,0 +1,185 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/vladislav-kr/gophermart/internal/domain/models"
+	"github.com/vladislav-kr/gophermart/internal/domain/money"
+	"github.com/vladislav-kr/gophermart/internal/service/mocks"
+	"github.com/vladislav-kr/gophermart/internal/storage"
+)
+
+func Test_service_ExpirePoints(t *testing.T) {
+	const (
+		userID1 = "1cf50925-d72d-488b-94e5-426acce77f3c"
+		userID2 = "3cf50925-d72d-488b-94e5-426acce77f3c"
+	)
+
+	type expiry struct {
+		userID string
+		amount money.Amount
+		errDB  error
+	}
+
+	tests := []struct {
+		name       string
+		users      []string
+		errUsers   error
+		expiries   []expiry
+		wantUsers  int
+		wantAmount money.Amount
+		wantErr    error
+	}{
+		{
+			name:     "сгорать нечему",
+			errUsers: storage.ErrNoRecordsFound,
+		},
+		{
+			name:  "баллы сгорели у двух пользователей",
+			users: []string{userID1, userID2},
+			expiries: []expiry{
+				{userID: userID1, amount: money.MustParse("150.5")},
+				{userID: userID2, amount: money.FromInt(20)},
+			},
+			wantUsers:  2,
+			wantAmount: money.MustParse("170.5"),
+		},
+		{
+			name:  "ошибка у одного пользователя не прерывает сгорание",
+			users: []string{userID1, userID2},
+			expiries: []expiry{
+				{userID: userID1, errDB: fmt.Errorf("internal")},
+				{userID: userID2, amount: money.FromInt(20)},
+			},
+			wantUsers:  1,
+			wantAmount: money.FromInt(20),
+		},
+		{
+			name:  "партии закрыты без списания при нулевом остатке",
+			users: []string{userID1},
+			expiries: []expiry{
+				{userID: userID1, amount: 0},
+			},
+		},
+		{
+			name:     "ошибка хранилища",
+			errUsers: fmt.Errorf("internal"),
+			wantErr:  models.ErrInternal,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			stor := mocks.NewStorage(t)
+			srv := NewService(nil, stor, nil, nil)
+
+			stor.On("UsersWithExpiredPoints",
+				mock.AnythingOfType("*context.timerCtx"),
+				mock.AnythingOfType("time.Time"),
+				uint32(expirePointsBatch),
+			).Return(tt.users, tt.errUsers).Once()
+
+			for _, e := range tt.expiries {
+				stor.On("ExpirePoints",
+					mock.AnythingOfType("*context.timerCtx"),
+					e.userID,
+					mock.AnythingOfType("time.Time"),
+				).Return(e.amount, e.errDB).Once()
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second*4)
+			defer cancel()
+
+			report, err := srv.ExpirePoints(ctx)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Nil(t, report)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantUsers, report.Users)
+			assert.Equal(t, tt.wantAmount, report.Amount)
+		})
+	}
+}
+
+func Test_service_UserBalanceExpiringSoon(t *testing.T) {
+	const userID = "5cbb01ca-db9a-4ab7-beef-652a7ec89a9d"
+	expiresAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name         string
+		window       time.Duration
+		expiring     []storage.ExpiringPoints
+		errExpiring  error
+		wantExpiring []models.ExpiringPoints
+		wantErr      error
+	}{
+		{
+			name: "окно не задано",
+		},
+		{
+			name:   "баллы скоро сгорят",
+			window: 30 * 24 * time.Hour,
+			expiring: []storage.ExpiringPoints{
+				{Amount: money.MustParse("120.5"), ExpiresAt: expiresAt},
+			},
+			wantExpiring: []models.ExpiringPoints{
+				{Amount: money.MustParse("120.5"), ExpiresAt: expiresAt},
+			},
+		},
+		{
+			name:        "сгорающих баллов нет",
+			window:      30 * 24 * time.Hour,
+			errExpiring: storage.ErrNoRecordsFound,
+		},
+		{
+			name:        "ошибка хранилища",
+			window:      30 * 24 * time.Hour,
+			errExpiring: fmt.Errorf("internal"),
+			wantErr:     models.ErrInternal,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			stor := mocks.NewStorage(t)
+			srv := NewService(nil, stor, nil, nil, WithExpiringSoon(tt.window))
+
+			stor.On("UserBalance",
+				mock.AnythingOfType("*context.timerCtx"),
+				userID,
+			).Return(&storage.Balance{Current: money.FromInt(500)}, nil).Once()
+
+			if tt.window > 0 {
+				stor.On("ExpiringPoints",
+					mock.AnythingOfType("*context.timerCtx"),
+					userID,
+					mock.AnythingOfType("time.Time"),
+				).Return(tt.expiring, tt.errExpiring).Once()
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second*4)
+			defer cancel()
+
+			balance, err := srv.UserBalance(ctx, userID)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Nil(t, balance)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, money.FromInt(500), balance.Current)
+			assert.Equal(t, tt.wantExpiring, balance.ExpiringSoon)
+		})
+	}
+}
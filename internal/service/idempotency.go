@@ -0,0 +1,117 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/vladislav-kr/gophermart/internal/domain/models"
+	"github.com/vladislav-kr/gophermart/internal/storage"
+)
+
+// ключей за один запрос очистки, чтобы не держать блокировки долго
+const purgeIdempotencyBatch = 1000
+
+// время, после которого незавершенный запрос с ключом считается прерванным,
+// больше таймаутов обработчиков
+const idempotencyLockTimeout = time.Minute
+
+// BeginIdempotent захватывает ключ идемпотентности пользователя.
+// Вернет nil, если запрос нужно выполнить, или сохраненный ответ для повтора.
+func (s *service) BeginIdempotent(
+	ctx context.Context,
+	req models.IdempotentRequest,
+) (*models.IdempotentResponse, error) {
+	if !req.UserID.Validate() {
+		return nil, models.ErrUserIDMandatory
+	}
+
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	saved, err := s.storage.BeginIdempotency(ctx, storage.IdempotencyKey{
+		UserID:      string(req.UserID),
+		Key:         req.Key,
+		RequestHash: req.Hash,
+		ExpiresAt:   time.Now().Add(s.idempotencyTTL),
+	}, idempotencyLockTimeout)
+	if err != nil {
+		switch {
+		// ключ одновременно захватывают и освобождают другие запросы
+		case errors.Is(err, storage.ErrUniqueViolation):
+			return nil, models.ErrIdempotencyInProgress
+		default:
+			return nil, fmt.Errorf("begin idempotency %v: %w", err, models.ErrInternal)
+		}
+	}
+
+	switch {
+	case saved == nil:
+		return nil, nil
+	case !bytes.Equal(saved.RequestHash, req.Hash):
+		return nil, models.ErrIdempotencyKeyReused
+	case saved.StatusCode == nil:
+		return nil, models.ErrIdempotencyInProgress
+	}
+
+	return &models.IdempotentResponse{
+		StatusCode:  *saved.StatusCode,
+		ContentType: saved.ContentType,
+		Body:        saved.Body,
+	}, nil
+}
+
+// CompleteIdempotent сохраняет ответ на запрос по захваченному ключу
+func (s *service) CompleteIdempotent(
+	ctx context.Context,
+	req models.IdempotentRequest,
+	resp models.IdempotentResponse,
+) error {
+	statusCode := resp.StatusCode
+	if err := s.storage.CompleteIdempotency(ctx, storage.IdempotencyKey{
+		UserID:      string(req.UserID),
+		Key:         req.Key,
+		RequestHash: req.Hash,
+		StatusCode:  &statusCode,
+		ContentType: resp.ContentType,
+		Body:        resp.Body,
+	}); err != nil {
+		switch {
+		case errors.Is(err, storage.ErrNoRecordsFound):
+			return models.ErrNoRecordsFound
+		default:
+			return fmt.Errorf("complete idempotency %v: %w", err, models.ErrInternal)
+		}
+	}
+	return nil
+}
+
+// ReleaseIdempotent освобождает ключ запроса, завершившегося ошибкой сервера,
+// повтор с тем же ключом выполнится заново
+func (s *service) ReleaseIdempotent(ctx context.Context, req models.IdempotentRequest) error {
+	if err := s.storage.DeleteIdempotency(ctx, string(req.UserID), req.Key); err != nil {
+		return fmt.Errorf("delete idempotency %v: %w", err, models.ErrInternal)
+	}
+	return nil
+}
+
+// PurgeIdempotency удаляет истекшие ключи идемпотентности пачками,
+// вернет количество удаленных ключей
+func (s *service) PurgeIdempotency(ctx context.Context) (int64, error) {
+	now := time.Now()
+
+	var purged int64
+	for {
+		n, err := s.storage.PurgeIdempotency(ctx, now, purgeIdempotencyBatch)
+		if err != nil {
+			return purged, fmt.Errorf("purge idempotency %v: %w", err, models.ErrInternal)
+		}
+		purged += n
+		if n < purgeIdempotencyBatch || ctx.Err() != nil {
+			return purged, nil
+		}
+	}
+}
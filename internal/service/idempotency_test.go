@@ -0,0 +1,197 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/vladislav-kr/gophermart/internal/domain/models"
+	"github.com/vladislav-kr/gophermart/internal/service/mocks"
+	"github.com/vladislav-kr/gophermart/internal/storage"
+)
+
+func Test_service_BeginIdempotent(t *testing.T) {
+	const userID = "1cf50925-d72d-488b-94e5-426acce77f3c"
+
+	completed := 200
+
+	req := models.IdempotentRequest{
+		UserID: userID,
+		Key:    "retry-1",
+		Hash:   []byte("hash"),
+	}
+
+	tests := []struct {
+		name     string
+		req      models.IdempotentRequest
+		callMock bool
+		saved    *storage.IdempotencyKey
+		err      error
+		want     *models.IdempotentResponse
+		wantErr  error
+	}{
+		{
+			name:     "ключ захвачен",
+			req:      req,
+			callMock: true,
+		},
+		{
+			name:     "повтор получает сохраненный ответ",
+			req:      req,
+			callMock: true,
+			saved: &storage.IdempotencyKey{
+				RequestHash: []byte("hash"),
+				StatusCode:  &completed,
+				ContentType: "application/json",
+				Body:        []byte(`{"status":"OK"}`),
+			},
+			want: &models.IdempotentResponse{
+				StatusCode:  200,
+				ContentType: "application/json",
+				Body:        []byte(`{"status":"OK"}`),
+			},
+		},
+		{
+			name:     "ключ с другим запросом",
+			req:      req,
+			callMock: true,
+			saved:    &storage.IdempotencyKey{RequestHash: []byte("other"), StatusCode: &completed},
+			wantErr:  models.ErrIdempotencyKeyReused,
+		},
+		{
+			name:     "первый запрос еще выполняется",
+			req:      req,
+			callMock: true,
+			saved:    &storage.IdempotencyKey{RequestHash: []byte("hash")},
+			wantErr:  models.ErrIdempotencyInProgress,
+		},
+		{
+			name:     "ключ захватывают и освобождают параллельно",
+			req:      req,
+			callMock: true,
+			err:      storage.ErrUniqueViolation,
+			wantErr:  models.ErrIdempotencyInProgress,
+		},
+		{
+			name:     "ошибка хранилища",
+			req:      req,
+			callMock: true,
+			err:      storage.ErrInternal,
+			wantErr:  models.ErrInternal,
+		},
+		{
+			name: "слишком длинный ключ",
+			req: models.IdempotentRequest{
+				UserID: userID,
+				Key:    strings.Repeat("k", models.MaxIdempotencyKeyLength+1),
+			},
+			wantErr: models.ErrInvalidIdempotencyKey,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			stor := mocks.NewStorage(t)
+			srv := NewService(nil, stor, nil, nil, WithIdempotencyTTL(time.Hour))
+
+			if tt.callMock {
+				stor.On("BeginIdempotency",
+					mock.Anything,
+					mock.MatchedBy(func(key storage.IdempotencyKey) bool {
+						return key.UserID == userID && key.Key == tt.req.Key &&
+							time.Until(key.ExpiresAt) > time.Minute*59
+					}),
+					idempotencyLockTimeout,
+				).Return(tt.saved, tt.err)
+			}
+
+			got, err := srv.BeginIdempotent(context.Background(), tt.req)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_service_CompleteIdempotent(t *testing.T) {
+	const userID = "1cf50925-d72d-488b-94e5-426acce77f3c"
+
+	stor := mocks.NewStorage(t)
+	srv := NewService(nil, stor, nil, nil)
+
+	req := models.IdempotentRequest{UserID: userID, Key: "retry-1", Hash: []byte("hash")}
+
+	stor.On("CompleteIdempotency", mock.Anything, mock.MatchedBy(func(key storage.IdempotencyKey) bool {
+		return key.StatusCode != nil && *key.StatusCode == 402 && string(key.Body) == "body"
+	})).Return(nil).Once()
+
+	assert.NoError(t, srv.CompleteIdempotent(context.Background(), req, models.IdempotentResponse{
+		StatusCode: 402,
+		Body:       []byte("body"),
+	}))
+
+	stor.On("DeleteIdempotency", mock.Anything, userID, "retry-1").Return(nil).Once()
+	assert.NoError(t, srv.ReleaseIdempotent(context.Background(), req))
+}
+
+func Test_service_PurgeIdempotency(t *testing.T) {
+	tests := []struct {
+		name       string
+		batches    []int64
+		errDB      error
+		wantPurged int64
+		wantErr    error
+	}{
+		{
+			name:       "истекших ключей нет",
+			batches:    []int64{0},
+			wantPurged: 0,
+		},
+		{
+			name:       "удаление несколькими пачками",
+			batches:    []int64{purgeIdempotencyBatch, purgeIdempotencyBatch, 15},
+			wantPurged: 2*purgeIdempotencyBatch + 15,
+		},
+		{
+			name:    "ошибка хранилища",
+			errDB:   fmt.Errorf("internal"),
+			wantErr: models.ErrInternal,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			stor := mocks.NewStorage(t)
+			srv := NewService(nil, stor, nil, nil)
+
+			if tt.errDB != nil {
+				stor.On("PurgeIdempotency", mock.Anything, mock.AnythingOfType("time.Time"), uint32(purgeIdempotencyBatch)).
+					Return(int64(0), tt.errDB).Once()
+			}
+			for _, n := range tt.batches {
+				stor.On("PurgeIdempotency", mock.Anything, mock.AnythingOfType("time.Time"), uint32(purgeIdempotencyBatch)).
+					Return(n, nil).Once()
+			}
+
+			purged, err := srv.PurgeIdempotency(context.Background())
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantPurged, purged)
+		})
+	}
+}
@@ -2,7 +2,6 @@ package jwt
 
 import (
 	"context"
-	"crypto/rsa"
 	"time"
 
 	"github.com/go-chi/jwtauth/v5"
@@ -11,33 +10,72 @@ import (
 )
 
 const (
-	UserID string = "userID"
+	UserID     string = "userID"
+	Generation string = "gen"
+	Role       string = "role"
+	APIKeyID   string = "akid"
+	SessionID  string = "sid"
+
+	// роль по умолчанию для токенов, выпущенных без атрибута role
+	defaultRole = "user"
 )
 
+// Claims атрибуты access токена
+type Claims struct {
+	UserID string
+	// jti, идентификатор токена для отзыва
+	TokenID string
+	// поколение токенов пользователя, увеличивается при выходе со всех устройств
+	Generation int64
+	// роль пользователя на момент выпуска токена
+	Role      string
+	ExpiresAt time.Time
+	// ключ, которым аутентифицирован запрос, пусто для access токена
+	APIKeyID string
+	// сессия, в которой выпущен токен
+	SessionID string
+}
+
 func NewToken(
-	userID string,
+	claims Claims,
 	exp time.Duration,
-	key *rsa.PrivateKey,
+	keys *KeySet,
 ) (string, error) {
 
 	token, err := jwt.NewBuilder().
 		Issuer("gophermart").
-		Audience([]string{string(userID)}).
+		Audience([]string{string(claims.UserID)}).
+		JwtID(claims.TokenID).
 		IssuedAt(time.Now()).
 		Expiration(time.Now().Add(exp)).
-		Claim(UserID, userID).
+		Claim(UserID, claims.UserID).
+		Claim(Generation, claims.Generation).
+		Claim(Role, claims.Role).
+		Claim(SessionID, claims.SessionID).
 		Build()
 	if err != nil {
 		return "", err
 	}
 
-	signed, err := jwt.Sign(token, jwt.WithKey(jwa.RS256, key))
+	signed, err := jwt.Sign(token, jwt.WithKey(jwa.RS256, keys.signingKey))
 	if err != nil {
 		return "", err
 	}
 	return string(signed), nil
 }
 
+// NewAPIKeyToken неподписанный токен запроса, аутентифицированного API ключом.
+// Кладется в контекст вместо access токена, чтобы обработчики получали пользователя как обычно.
+func NewAPIKeyToken(userID, keyID string) (jwt.Token, error) {
+	return jwt.NewBuilder().
+		Issuer("gophermart").
+		IssuedAt(time.Now()).
+		Claim(UserID, userID).
+		Claim(Role, defaultRole).
+		Claim(APIKeyID, keyID).
+		Build()
+}
+
 // jwt.Token из контекста
 func TokenFromContext(ctx context.Context) jwt.Token {
 	if token, ok := ctx.Value(jwtauth.TokenCtxKey).(jwt.Token); ok {
@@ -63,3 +101,47 @@ func ClaimJWTFromContext[T any](ctx context.Context, key string) (T, bool) {
 
 	return result, false
 }
+
+// Claims токена из контекста
+func ClaimsFromContext(ctx context.Context) (Claims, bool) {
+	token := TokenFromContext(ctx)
+	if token == nil {
+		return Claims{}, false
+	}
+	return ClaimsFromToken(token), true
+}
+
+func ClaimsFromToken(token jwt.Token) Claims {
+	claims := Claims{
+		TokenID:   token.JwtID(),
+		ExpiresAt: token.Expiration(),
+	}
+
+	private := token.PrivateClaims()
+	if userID, ok := private[UserID].(string); ok {
+		claims.UserID = userID
+	}
+
+	claims.Role = defaultRole
+	if role, ok := private[Role].(string); ok && role != "" {
+		claims.Role = role
+	}
+
+	if keyID, ok := private[APIKeyID].(string); ok {
+		claims.APIKeyID = keyID
+	}
+
+	if sessionID, ok := private[SessionID].(string); ok {
+		claims.SessionID = sessionID
+	}
+
+	// после разбора JSON числа приходят как float64
+	switch gen := private[Generation].(type) {
+	case float64:
+		claims.Generation = int64(gen)
+	case int64:
+		claims.Generation = gen
+	}
+
+	return claims
+}
@@ -0,0 +1,169 @@
+package jwt
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/lestrrat-go/jwx/v2/jwa"
+	"github.com/lestrrat-go/jwx/v2/jwk"
+	"github.com/lestrrat-go/jwx/v2/jwt"
+)
+
+var (
+	ErrNoSigningKey = errors.New("no signing key")
+)
+
+// KeySet ключ подписи токенов и набор открытых ключей для их проверки.
+// Открытых ключей может быть несколько, это позволяет менять ключ подписи
+// без отзыва уже выданных токенов.
+type KeySet struct {
+	signingKey jwk.Key
+	publicKeys jwk.Set
+}
+
+// NewKeySet набор из закрытых и открытых ключей, ключ задается по kid.
+// Подписывает ключ signingKID, если он пуст - последний по kid закрытый ключ.
+func NewKeySet(signingKID string, privateKeys map[string]*rsa.PrivateKey, publicKeys map[string]*rsa.PublicKey) (*KeySet, error) {
+	ks := &KeySet{
+		publicKeys: jwk.NewSet(),
+	}
+
+	kids := make([]string, 0, len(privateKeys))
+	for kid := range privateKeys {
+		kids = append(kids, kid)
+	}
+	sort.Strings(kids)
+
+	if signingKID == "" && len(kids) > 0 {
+		signingKID = kids[len(kids)-1]
+	}
+
+	for _, kid := range kids {
+		if kid == signingKID {
+			key, err := newJWK(kid, privateKeys[kid])
+			if err != nil {
+				return nil, err
+			}
+			ks.signingKey = key
+		}
+		if err := ks.addPublicKey(kid, &privateKeys[kid].PublicKey); err != nil {
+			return nil, err
+		}
+	}
+
+	for kid, publicKey := range publicKeys {
+		if _, ok := ks.publicKeys.LookupKeyID(kid); ok {
+			continue
+		}
+		if err := ks.addPublicKey(kid, publicKey); err != nil {
+			return nil, err
+		}
+	}
+
+	if ks.signingKey == nil {
+		return nil, fmt.Errorf("kid %q: %w", signingKID, ErrNoSigningKey)
+	}
+
+	return ks, nil
+}
+
+// GenerateKeySet набор из одного нового ключа, живет до перезапуска сервиса
+func GenerateKeySet() (*KeySet, error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2<<10)
+	if err != nil {
+		return nil, fmt.Errorf("generate RSA private key: %w", err)
+	}
+	return NewKeySet("", map[string]*rsa.PrivateKey{uuid.NewString(): key}, nil)
+}
+
+// LoadKeySet читает ключи в формате PEM.
+// Из каталога dir читаются все файлы *.pem, kid - имя файла без расширения.
+// Файл privateKeyFile - дополнительный закрытый ключ.
+func LoadKeySet(dir, privateKeyFile, signingKID string) (*KeySet, error) {
+	privateKeys := make(map[string]*rsa.PrivateKey)
+	publicKeys := make(map[string]*rsa.PublicKey)
+
+	files := make([]string, 0)
+	if dir != "" {
+		matches, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+		if err != nil {
+			return nil, fmt.Errorf("read keys dir: %w", err)
+		}
+		files = append(files, matches...)
+	}
+	if privateKeyFile != "" {
+		files = append(files, privateKeyFile)
+	}
+
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("read key file: %w", err)
+		}
+
+		key, err := jwk.ParseKey(data, jwk.WithPEM(true))
+		if err != nil {
+			return nil, fmt.Errorf("parse key %s: %w", file, err)
+		}
+
+		kid := strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+
+		var raw interface{}
+		if err := key.Raw(&raw); err != nil {
+			return nil, fmt.Errorf("raw key %s: %w", file, err)
+		}
+
+		switch k := raw.(type) {
+		case *rsa.PrivateKey:
+			privateKeys[kid] = k
+		case *rsa.PublicKey:
+			publicKeys[kid] = k
+		default:
+			return nil, fmt.Errorf("key %s: unsupported key type %T", file, raw)
+		}
+	}
+
+	return NewKeySet(signingKID, privateKeys, publicKeys)
+}
+
+// PublicKeys открытые ключи в формате JWKS
+func (ks *KeySet) PublicKeys() jwk.Set {
+	return ks.publicKeys
+}
+
+// Verify проверяет подпись токена одним из открытых ключей по kid
+func (ks *KeySet) Verify(token string) (jwt.Token, error) {
+	return jwt.Parse([]byte(token), jwt.WithKeySet(ks.publicKeys), jwt.WithValidate(true))
+}
+
+func (ks *KeySet) addPublicKey(kid string, publicKey *rsa.PublicKey) error {
+	key, err := newJWK(kid, publicKey)
+	if err != nil {
+		return err
+	}
+	if err := key.Set(jwk.KeyUsageKey, jwk.ForSignature); err != nil {
+		return fmt.Errorf("set key usage: %w", err)
+	}
+	return ks.publicKeys.AddKey(key)
+}
+
+func newJWK(kid string, raw interface{}) (jwk.Key, error) {
+	key, err := jwk.FromRaw(raw)
+	if err != nil {
+		return nil, fmt.Errorf("jwk from raw key: %w", err)
+	}
+	if err := key.Set(jwk.KeyIDKey, kid); err != nil {
+		return nil, fmt.Errorf("set kid: %w", err)
+	}
+	if err := key.Set(jwk.AlgorithmKey, jwa.RS256); err != nil {
+		return nil, fmt.Errorf("set alg: %w", err)
+	}
+	return key, nil
+}
@@ -0,0 +1,80 @@
+package jwt
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/lestrrat-go/jwx/v2/jws"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeTestKey(t *testing.T, dir, name string, private bool) *rsa.PrivateKey {
+	key, err := rsa.GenerateKey(rand.Reader, 2<<10)
+	require.NoError(t, err)
+
+	block := &pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}
+	if !private {
+		der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+		require.NoError(t, err)
+		block = &pem.Block{Type: "PUBLIC KEY", Bytes: der}
+	}
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, name), pem.EncodeToMemory(block), 0o600))
+	return key
+}
+
+func TestLoadKeySet(t *testing.T) {
+	dir := t.TempDir()
+	oldKey := writeTestKey(t, dir, "2024-01.pem", true)
+	writeTestKey(t, dir, "2024-02.pem", true)
+	writeTestKey(t, dir, "partner.pem", false)
+
+	// подписывает последний по имени закрытый ключ
+	keys, err := LoadKeySet(dir, "", "")
+	require.NoError(t, err)
+	assert.Equal(t, "2024-02", keys.signingKey.KeyID())
+	assert.Equal(t, 3, keys.PublicKeys().Len())
+
+	token, err := NewToken(Claims{UserID: "a3b1f1b2-54a6-4a59-8c2e-7f9e5f0e2f4d"}, time.Minute, keys)
+	require.NoError(t, err)
+
+	msg, err := jws.Parse([]byte(token))
+	require.NoError(t, err)
+	assert.Equal(t, "2024-02", msg.Signatures()[0].ProtectedHeaders().KeyID())
+
+	parsed, err := keys.Verify(token)
+	require.NoError(t, err)
+	assert.Equal(t, "a3b1f1b2-54a6-4a59-8c2e-7f9e5f0e2f4d", parsed.PrivateClaims()[UserID])
+
+	// токен, подписанный предыдущим ключом, проверяется после ротации
+	oldKeys, err := NewKeySet("2024-01", map[string]*rsa.PrivateKey{"2024-01": oldKey}, nil)
+	require.NoError(t, err)
+	oldToken, err := NewToken(Claims{UserID: "a3b1f1b2-54a6-4a59-8c2e-7f9e5f0e2f4d"}, time.Minute, oldKeys)
+	require.NoError(t, err)
+	_, err = keys.Verify(oldToken)
+	assert.NoError(t, err)
+
+	// токен, подписанный неизвестным ключом, отклоняется
+	foreign, err := GenerateKeySet()
+	require.NoError(t, err)
+	foreignToken, err := NewToken(Claims{UserID: "a3b1f1b2-54a6-4a59-8c2e-7f9e5f0e2f4d"}, time.Minute, foreign)
+	require.NoError(t, err)
+	_, err = keys.Verify(foreignToken)
+	assert.Error(t, err)
+
+	// явно заданный ключ подписи
+	keys, err = LoadKeySet(dir, "", "2024-01")
+	require.NoError(t, err)
+	assert.Equal(t, "2024-01", keys.signingKey.KeyID())
+
+	// ключ подписи должен быть закрытым
+	_, err = LoadKeySet(dir, "", "partner")
+	assert.ErrorIs(t, err, ErrNoSigningKey)
+}
@@ -0,0 +1,159 @@
+package loginlimiter
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/vladislav-kr/gophermart/internal/domain/models"
+	"github.com/vladislav-kr/gophermart/internal/storage"
+)
+
+// Store счётчики неудачных попыток входа.
+// Счётчик сбрасывается, если с последней неудачи прошло больше ttl.
+type Store interface {
+	LoginAttempts(ctx context.Context, key string) (*storage.LoginAttempts, error)
+	IncrementLoginAttempts(ctx context.Context, key string, ttl time.Duration) (*storage.LoginAttempts, error)
+	ResetLoginAttempts(ctx context.Context, key string) error
+}
+
+// Policy пороги для одного вида счётчика
+type Policy struct {
+	// попытки без задержки
+	FreeAttempts int
+	// после MaxFailures неудач ключ блокируется на Lockout
+	MaxFailures int
+}
+
+type Config struct {
+	Login Policy
+	IP    Policy
+	// задержка после первой платной попытки, удваивается с каждой следующей
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	Lockout   time.Duration
+}
+
+// DefaultConfig пороги по умолчанию, на IP их больше из-за NAT
+func DefaultConfig() Config {
+	return Config{
+		Login:     Policy{FreeAttempts: 3, MaxFailures: 10},
+		IP:        Policy{FreeAttempts: 20, MaxFailures: 100},
+		BaseDelay: time.Second,
+		MaxDelay:  time.Minute,
+		Lockout:   time.Minute * 15,
+	}
+}
+
+type limiter struct {
+	store Store
+	cfg   Config
+	now   func() time.Time
+}
+
+func New(store Store, cfg Config) *limiter {
+	return &limiter{
+		store: store,
+		cfg:   cfg,
+		now:   time.Now,
+	}
+}
+
+func loginKey(login string) string {
+	return "login:" + strings.ToLower(login)
+}
+
+func ipKey(ip string) string {
+	return "ip:" + ip
+}
+
+// Allow вернет *models.TooManyAttemptsError, если попытка входа сейчас запрещена
+func (l *limiter) Allow(ctx context.Context, login, ip string) error {
+	var retryAfter time.Duration
+
+	for _, k := range l.keys(login, ip) {
+		attempts, err := l.store.LoginAttempts(ctx, k.key)
+		if err != nil {
+			return fmt.Errorf("login attempts %s: %w", k.key, err)
+		}
+		if wait := l.retryAfter(attempts, k.policy); wait > retryAfter {
+			retryAfter = wait
+		}
+	}
+
+	if retryAfter > 0 {
+		return &models.TooManyAttemptsError{RetryAfter: retryAfter}
+	}
+	return nil
+}
+
+// Failure учитывает неудачную попытку входа
+func (l *limiter) Failure(ctx context.Context, login, ip string) error {
+	for _, k := range l.keys(login, ip) {
+		if _, err := l.store.IncrementLoginAttempts(ctx, k.key, l.ttl()); err != nil {
+			return fmt.Errorf("increment login attempts %s: %w", k.key, err)
+		}
+	}
+	return nil
+}
+
+// Success сбрасывает счётчик логина.
+// Счётчик IP не сбрасывается, чтобы удачный вход в свою учетную запись
+// не открывал перебор чужих.
+func (l *limiter) Success(ctx context.Context, login string) error {
+	if err := l.store.ResetLoginAttempts(ctx, loginKey(login)); err != nil {
+		return fmt.Errorf("reset login attempts: %w", err)
+	}
+	return nil
+}
+
+type limitKey struct {
+	key    string
+	policy Policy
+}
+
+func (l *limiter) keys(login, ip string) []limitKey {
+	keys := make([]limitKey, 0, 2)
+	if login != "" {
+		keys = append(keys, limitKey{key: loginKey(login), policy: l.cfg.Login})
+	}
+	if ip != "" {
+		keys = append(keys, limitKey{key: ipKey(ip), policy: l.cfg.IP})
+	}
+	return keys
+}
+
+// ttl счётчика должен покрывать и блокировку, и максимальную задержку
+func (l *limiter) ttl() time.Duration {
+	if l.cfg.Lockout > l.cfg.MaxDelay {
+		return l.cfg.Lockout
+	}
+	return l.cfg.MaxDelay
+}
+
+func (l *limiter) retryAfter(attempts *storage.LoginAttempts, p Policy) time.Duration {
+	if attempts == nil || attempts.Failures <= p.FreeAttempts {
+		return 0
+	}
+
+	var delay time.Duration
+	switch {
+	case p.MaxFailures > 0 && attempts.Failures >= p.MaxFailures:
+		delay = l.cfg.Lockout
+	default:
+		delay = l.cfg.BaseDelay
+		for i := p.FreeAttempts + 1; i < attempts.Failures && delay < l.cfg.MaxDelay; i++ {
+			delay *= 2
+		}
+		if delay > l.cfg.MaxDelay {
+			delay = l.cfg.MaxDelay
+		}
+	}
+
+	wait := attempts.LastFailure.Add(delay).Sub(l.now())
+	if wait < 0 {
+		return 0
+	}
+	return wait
+}
@@ -0,0 +1,117 @@
+package loginlimiter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vladislav-kr/gophermart/internal/domain/models"
+)
+
+func newTestLimiter(now *time.Time) *limiter {
+	store := NewMemoryStore()
+	store.now = func() time.Time { return *now }
+
+	l := New(store, Config{
+		Login:     Policy{FreeAttempts: 2, MaxFailures: 6},
+		IP:        Policy{FreeAttempts: 10, MaxFailures: 20},
+		BaseDelay: time.Second,
+		MaxDelay:  time.Second * 4,
+		Lockout:   time.Minute * 15,
+	})
+	l.now = func() time.Time { return *now }
+	return l
+}
+
+func retryAfter(t *testing.T, err error) time.Duration {
+	t.Helper()
+	var tooMany *models.TooManyAttemptsError
+	require.ErrorAs(t, err, &tooMany)
+	assert.ErrorIs(t, err, models.ErrTooManyAttempts)
+	return tooMany.RetryAfter
+}
+
+func TestLimiter_progressiveDelay(t *testing.T) {
+	now := time.Date(2024, 3, 14, 12, 0, 0, 0, time.UTC)
+	l := newTestLimiter(&now)
+	ctx := context.Background()
+
+	// бесплатные попытки
+	for i := 0; i < 2; i++ {
+		require.NoError(t, l.Allow(ctx, "User", "10.0.0.1"))
+		require.NoError(t, l.Failure(ctx, "User", "10.0.0.1"))
+	}
+	require.NoError(t, l.Allow(ctx, "user", "10.0.0.1"))
+
+	// задержка удваивается и ограничена MaxDelay
+	for _, want := range []time.Duration{time.Second, time.Second * 2, time.Second * 4} {
+		require.NoError(t, l.Failure(ctx, "user", "10.0.0.1"))
+		assert.Equal(t, want, retryAfter(t, l.Allow(ctx, "user", "10.0.0.1")))
+		now = now.Add(want)
+		require.NoError(t, l.Allow(ctx, "user", "10.0.0.1"))
+	}
+
+	// после MaxFailures логин блокируется
+	require.NoError(t, l.Failure(ctx, "user", "10.0.0.1"))
+	assert.Equal(t, time.Minute*15, retryAfter(t, l.Allow(ctx, "user", "10.0.0.2")))
+
+	// блокировка логина не распространяется на другие логины с того же IP
+	require.NoError(t, l.Allow(ctx, "other", "10.0.0.1"))
+
+	now = now.Add(time.Minute * 15)
+	require.NoError(t, l.Allow(ctx, "user", "10.0.0.1"))
+}
+
+func TestLimiter_success(t *testing.T) {
+	now := time.Date(2024, 3, 14, 12, 0, 0, 0, time.UTC)
+	l := newTestLimiter(&now)
+	ctx := context.Background()
+
+	for i := 0; i < 4; i++ {
+		require.NoError(t, l.Failure(ctx, "user", "10.0.0.1"))
+	}
+	assert.Error(t, l.Allow(ctx, "user", ""))
+
+	require.NoError(t, l.Success(ctx, "user"))
+	assert.NoError(t, l.Allow(ctx, "user", "10.0.0.1"))
+}
+
+func TestLimiter_ip(t *testing.T) {
+	now := time.Date(2024, 3, 14, 12, 0, 0, 0, time.UTC)
+	l := newTestLimiter(&now)
+	ctx := context.Background()
+
+	// перебор разных логинов с одного IP
+	for i := 0; i < 20; i++ {
+		require.NoError(t, l.Failure(ctx, string(rune('a'+i)), "10.0.0.1"))
+	}
+	assert.Equal(t, time.Minute*15, retryAfter(t, l.Allow(ctx, "new-user", "10.0.0.1")))
+	assert.NoError(t, l.Allow(ctx, "new-user", "10.0.0.2"))
+}
+
+func TestMemoryStore_expiration(t *testing.T) {
+	now := time.Date(2024, 3, 14, 12, 0, 0, 0, time.UTC)
+	store := NewMemoryStore()
+	store.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	attempts, err := store.IncrementLoginAttempts(ctx, "login:user", time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, 1, attempts.Failures)
+
+	attempts, err = store.IncrementLoginAttempts(ctx, "login:user", time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, 2, attempts.Failures)
+
+	// счётчик начинается заново после ttl
+	now = now.Add(time.Minute * 2)
+	attempts, err = store.LoginAttempts(ctx, "login:user")
+	require.NoError(t, err)
+	assert.Equal(t, 0, attempts.Failures)
+
+	attempts, err = store.IncrementLoginAttempts(ctx, "login:user", time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, 1, attempts.Failures)
+}
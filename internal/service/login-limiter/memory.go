@@ -0,0 +1,76 @@
+package loginlimiter
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/vladislav-kr/gophermart/internal/storage"
+)
+
+type memoryEntry struct {
+	attempts  storage.LoginAttempts
+	expiresAt time.Time
+}
+
+// memoryStore счётчики в памяти процесса, для одной реплики
+type memoryStore struct {
+	mu        sync.Mutex
+	items     map[string]memoryEntry
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+func NewMemoryStore() *memoryStore {
+	return &memoryStore{
+		items: make(map[string]memoryEntry),
+		now:   time.Now,
+	}
+}
+
+func (m *memoryStore) LoginAttempts(_ context.Context, key string) (*storage.LoginAttempts, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry, ok := m.items[key]
+	if !ok || m.now().After(entry.expiresAt) {
+		return &storage.LoginAttempts{}, nil
+	}
+	attempts := entry.attempts
+	return &attempts, nil
+}
+
+func (m *memoryStore) IncrementLoginAttempts(_ context.Context, key string, ttl time.Duration) (*storage.LoginAttempts, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	entry, ok := m.items[key]
+	if !ok || now.After(entry.expiresAt) {
+		entry = memoryEntry{}
+	}
+	entry.attempts.Failures++
+	entry.attempts.LastFailure = now
+	entry.expiresAt = now.Add(ttl)
+	m.items[key] = entry
+
+	// устаревшие счётчики удаляются не чаще раза за ttl
+	if now.Sub(m.lastSweep) > ttl {
+		for k, e := range m.items {
+			if now.After(e.expiresAt) {
+				delete(m.items, k)
+			}
+		}
+		m.lastSweep = now
+	}
+
+	attempts := entry.attempts
+	return &attempts, nil
+}
+
+func (m *memoryStore) ResetLoginAttempts(_ context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.items, key)
+	return nil
+}
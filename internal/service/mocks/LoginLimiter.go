@@ -0,0 +1,82 @@
+// Code generated by mockery v2.53.7. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// LoginLimiter is an autogenerated mock type for the LoginLimiter type
+type LoginLimiter struct {
+	mock.Mock
+}
+
+// Allow provides a mock function with given fields: ctx, login, ip
+func (_m *LoginLimiter) Allow(ctx context.Context, login string, ip string) error {
+	ret := _m.Called(ctx, login, ip)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Allow")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, login, ip)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Failure provides a mock function with given fields: ctx, login, ip
+func (_m *LoginLimiter) Failure(ctx context.Context, login string, ip string) error {
+	ret := _m.Called(ctx, login, ip)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Failure")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, login, ip)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Success provides a mock function with given fields: ctx, login
+func (_m *LoginLimiter) Success(ctx context.Context, login string) error {
+	ret := _m.Called(ctx, login)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Success")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, login)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewLoginLimiter creates a new instance of LoginLimiter. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewLoginLimiter(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *LoginLimiter {
+	mock := &LoginLimiter{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -1,4 +1,4 @@
-// Code generated by mockery v2.37.0. DO NOT EDIT.
+// Code generated by mockery v2.53.7. DO NOT EDIT.
 
 package mocks
 
@@ -13,6 +13,10 @@ type PasswordGenerator struct {
 func (_m *PasswordGenerator) CompareHashAndPassword(hashedPassword []byte, password []byte) error {
 	ret := _m.Called(hashedPassword, password)
 
+	if len(ret) == 0 {
+		panic("no return value specified for CompareHashAndPassword")
+	}
+
 	var r0 error
 	if rf, ok := ret.Get(0).(func([]byte, []byte) error); ok {
 		r0 = rf(hashedPassword, password)
@@ -27,6 +31,10 @@ func (_m *PasswordGenerator) CompareHashAndPassword(hashedPassword []byte, passw
 func (_m *PasswordGenerator) GenerateFromPassword(password []byte) ([]byte, error) {
 	ret := _m.Called(password)
 
+	if len(ret) == 0 {
+		panic("no return value specified for GenerateFromPassword")
+	}
+
 	var r0 []byte
 	var r1 error
 	if rf, ok := ret.Get(0).(func([]byte) ([]byte, error)); ok {
@@ -49,6 +57,24 @@ func (_m *PasswordGenerator) GenerateFromPassword(password []byte) ([]byte, erro
 	return r0, r1
 }
 
+// NeedsRehash provides a mock function with given fields: hashedPassword
+func (_m *PasswordGenerator) NeedsRehash(hashedPassword []byte) bool {
+	ret := _m.Called(hashedPassword)
+
+	if len(ret) == 0 {
+		panic("no return value specified for NeedsRehash")
+	}
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func([]byte) bool); ok {
+		r0 = rf(hashedPassword)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	return r0
+}
+
 // NewPasswordGenerator creates a new instance of PasswordGenerator. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewPasswordGenerator(t interface {
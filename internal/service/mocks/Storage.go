@@ -1,4 +1,4 @@
-// Code generated by mockery v2.37.0. DO NOT EDIT.
+// Code generated by mockery v2.53.7. DO NOT EDIT.
 
 package mocks
 
@@ -6,22 +6,993 @@ import (
 	context "context"
 
 	mock "github.com/stretchr/testify/mock"
+	money "github.com/vladislav-kr/gophermart/internal/domain/money"
 
 	storage "github.com/vladislav-kr/gophermart/internal/storage"
+
+	time "time"
 )
 
-// Storage is an autogenerated mock type for the Storage type
-type Storage struct {
-	mock.Mock
+// Storage is an autogenerated mock type for the Storage type
+type Storage struct {
+	mock.Mock
+}
+
+// APIKeyByHash provides a mock function with given fields: ctx, keyHash
+func (_m *Storage) APIKeyByHash(ctx context.Context, keyHash []byte) (*storage.APIKey, error) {
+	ret := _m.Called(ctx, keyHash)
+
+	if len(ret) == 0 {
+		panic("no return value specified for APIKeyByHash")
+	}
+
+	var r0 *storage.APIKey
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, []byte) (*storage.APIKey, error)); ok {
+		return rf(ctx, keyHash)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, []byte) *storage.APIKey); ok {
+		r0 = rf(ctx, keyHash)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*storage.APIKey)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, []byte) error); ok {
+		r1 = rf(ctx, keyHash)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// APIKeys provides a mock function with given fields: ctx, userID
+func (_m *Storage) APIKeys(ctx context.Context, userID string) ([]storage.APIKey, error) {
+	ret := _m.Called(ctx, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for APIKeys")
+	}
+
+	var r0 []storage.APIKey
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) ([]storage.APIKey, error)); ok {
+		return rf(ctx, userID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) []storage.APIKey); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]storage.APIKey)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// AdjustBalance provides a mock function with given fields: ctx, adjustment
+func (_m *Storage) AdjustBalance(ctx context.Context, adjustment storage.BalanceAdjustment) (*storage.Balance, error) {
+	ret := _m.Called(ctx, adjustment)
+
+	if len(ret) == 0 {
+		panic("no return value specified for AdjustBalance")
+	}
+
+	var r0 *storage.Balance
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, storage.BalanceAdjustment) (*storage.Balance, error)); ok {
+		return rf(ctx, adjustment)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, storage.BalanceAdjustment) *storage.Balance); ok {
+		r0 = rf(ctx, adjustment)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*storage.Balance)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, storage.BalanceAdjustment) error); ok {
+		r1 = rf(ctx, adjustment)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// BalanceDrifts provides a mock function with given fields: ctx
+func (_m *Storage) BalanceDrifts(ctx context.Context) ([]storage.BalanceDrift, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for BalanceDrifts")
+	}
+
+	var r0 []storage.BalanceDrift
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]storage.BalanceDrift, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []storage.BalanceDrift); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]storage.BalanceDrift)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// BalanceHistory provides a mock function with given fields: ctx, userID
+func (_m *Storage) BalanceHistory(ctx context.Context, userID string) ([]storage.BalanceEntry, error) {
+	ret := _m.Called(ctx, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for BalanceHistory")
+	}
+
+	var r0 []storage.BalanceEntry
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) ([]storage.BalanceEntry, error)); ok {
+		return rf(ctx, userID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) []storage.BalanceEntry); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]storage.BalanceEntry)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// BeginIdempotency provides a mock function with given fields: ctx, key, lockTimeout
+func (_m *Storage) BeginIdempotency(ctx context.Context, key storage.IdempotencyKey, lockTimeout time.Duration) (*storage.IdempotencyKey, error) {
+	ret := _m.Called(ctx, key, lockTimeout)
+
+	if len(ret) == 0 {
+		panic("no return value specified for BeginIdempotency")
+	}
+
+	var r0 *storage.IdempotencyKey
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, storage.IdempotencyKey, time.Duration) (*storage.IdempotencyKey, error)); ok {
+		return rf(ctx, key, lockTimeout)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, storage.IdempotencyKey, time.Duration) *storage.IdempotencyKey); ok {
+		r0 = rf(ctx, key, lockTimeout)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*storage.IdempotencyKey)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, storage.IdempotencyKey, time.Duration) error); ok {
+		r1 = rf(ctx, key, lockTimeout)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ChangePassword provides a mock function with given fields: ctx, userID, passwordHash
+func (_m *Storage) ChangePassword(ctx context.Context, userID string, passwordHash []byte) (int64, error) {
+	ret := _m.Called(ctx, userID, passwordHash)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ChangePassword")
+	}
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, []byte) (int64, error)); ok {
+		return rf(ctx, userID, passwordHash)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, []byte) int64); ok {
+		r0 = rf(ctx, userID, passwordHash)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, []byte) error); ok {
+		r1 = rf(ctx, userID, passwordHash)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CompleteIdempotency provides a mock function with given fields: ctx, key
+func (_m *Storage) CompleteIdempotency(ctx context.Context, key storage.IdempotencyKey) error {
+	ret := _m.Called(ctx, key)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CompleteIdempotency")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, storage.IdempotencyKey) error); ok {
+		r0 = rf(ctx, key)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CompleteMFAChallenge provides a mock function with given fields: ctx, tokenHash
+func (_m *Storage) CompleteMFAChallenge(ctx context.Context, tokenHash []byte) error {
+	ret := _m.Called(ctx, tokenHash)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CompleteMFAChallenge")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, []byte) error); ok {
+		r0 = rf(ctx, tokenHash)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ConfirmTOTP provides a mock function with given fields: ctx, userID, step, recoveryCodes
+func (_m *Storage) ConfirmTOTP(ctx context.Context, userID string, step int64, recoveryCodes [][]byte) error {
+	ret := _m.Called(ctx, userID, step, recoveryCodes)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ConfirmTOTP")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, int64, [][]byte) error); ok {
+		r0 = rf(ctx, userID, step, recoveryCodes)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CorrectBalanceDrift provides a mock function with given fields: ctx, userID, adminID
+func (_m *Storage) CorrectBalanceDrift(ctx context.Context, userID string, adminID string) (*storage.BalanceDrift, error) {
+	ret := _m.Called(ctx, userID, adminID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CorrectBalanceDrift")
+	}
+
+	var r0 *storage.BalanceDrift
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) (*storage.BalanceDrift, error)); ok {
+		return rf(ctx, userID, adminID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *storage.BalanceDrift); ok {
+		r0 = rf(ctx, userID, adminID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*storage.BalanceDrift)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, userID, adminID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateAPIKey provides a mock function with given fields: ctx, key
+func (_m *Storage) CreateAPIKey(ctx context.Context, key storage.CreateAPIKey) (*storage.APIKey, error) {
+	ret := _m.Called(ctx, key)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateAPIKey")
+	}
+
+	var r0 *storage.APIKey
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, storage.CreateAPIKey) (*storage.APIKey, error)); ok {
+		return rf(ctx, key)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, storage.CreateAPIKey) *storage.APIKey); ok {
+		r0 = rf(ctx, key)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*storage.APIKey)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, storage.CreateAPIKey) error); ok {
+		r1 = rf(ctx, key)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateMFAChallenge provides a mock function with given fields: ctx, challenge
+func (_m *Storage) CreateMFAChallenge(ctx context.Context, challenge storage.MFAChallenge) error {
+	ret := _m.Called(ctx, challenge)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateMFAChallenge")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, storage.MFAChallenge) error); ok {
+		r0 = rf(ctx, challenge)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CreateOrder provides a mock function with given fields: ctx, userID, order
+func (_m *Storage) CreateOrder(ctx context.Context, userID string, order storage.CreateOrder) error {
+	ret := _m.Called(ctx, userID, order)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateOrder")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, storage.CreateOrder) error); ok {
+		r0 = rf(ctx, userID, order)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CreateOrders provides a mock function with given fields: ctx, userID, orderIDs
+func (_m *Storage) CreateOrders(ctx context.Context, userID string, orderIDs []string) ([]storage.OrderOwner, error) {
+	ret := _m.Called(ctx, userID, orderIDs)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateOrders")
+	}
+
+	var r0 []storage.OrderOwner
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, []string) ([]storage.OrderOwner, error)); ok {
+		return rf(ctx, userID, orderIDs)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, []string) []storage.OrderOwner); ok {
+		r0 = rf(ctx, userID, orderIDs)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]storage.OrderOwner)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, []string) error); ok {
+		r1 = rf(ctx, userID, orderIDs)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreatePasswordResetToken provides a mock function with given fields: ctx, token
+func (_m *Storage) CreatePasswordResetToken(ctx context.Context, token storage.PasswordResetToken) error {
+	ret := _m.Called(ctx, token)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreatePasswordResetToken")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, storage.PasswordResetToken) error); ok {
+		r0 = rf(ctx, token)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CreateRefreshToken provides a mock function with given fields: ctx, token
+func (_m *Storage) CreateRefreshToken(ctx context.Context, token storage.RefreshToken) error {
+	ret := _m.Called(ctx, token)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateRefreshToken")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, storage.RefreshToken) error); ok {
+		r0 = rf(ctx, token)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CreateUser provides a mock function with given fields: ctx, login, passwordHash
+func (_m *Storage) CreateUser(ctx context.Context, login string, passwordHash []byte) (string, error) {
+	ret := _m.Called(ctx, login, passwordHash)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateUser")
+	}
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, []byte) (string, error)); ok {
+		return rf(ctx, login, passwordHash)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, []byte) string); ok {
+		r0 = rf(ctx, login, passwordHash)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, []byte) error); ok {
+		r1 = rf(ctx, login, passwordHash)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DeleteIdempotency provides a mock function with given fields: ctx, userID, key
+func (_m *Storage) DeleteIdempotency(ctx context.Context, userID string, key string) error {
+	ret := _m.Called(ctx, userID, key)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteIdempotency")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, userID, key)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteUser provides a mock function with given fields: ctx, userID, forfeit
+func (_m *Storage) DeleteUser(ctx context.Context, userID string, forfeit bool) (money.Amount, error) {
+	ret := _m.Called(ctx, userID, forfeit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteUser")
+	}
+
+	var r0 money.Amount
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, bool) (money.Amount, error)); ok {
+		return rf(ctx, userID, forfeit)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, bool) money.Amount); ok {
+		r0 = rf(ctx, userID, forfeit)
+	} else {
+		r0 = ret.Get(0).(money.Amount)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, bool) error); ok {
+		r1 = rf(ctx, userID, forfeit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ExpirePoints provides a mock function with given fields: ctx, userID, now
+func (_m *Storage) ExpirePoints(ctx context.Context, userID string, now time.Time) (money.Amount, error) {
+	ret := _m.Called(ctx, userID, now)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ExpirePoints")
+	}
+
+	var r0 money.Amount
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, time.Time) (money.Amount, error)); ok {
+		return rf(ctx, userID, now)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, time.Time) money.Amount); ok {
+		r0 = rf(ctx, userID, now)
+	} else {
+		r0 = ret.Get(0).(money.Amount)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, time.Time) error); ok {
+		r1 = rf(ctx, userID, now)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ExpiringPoints provides a mock function with given fields: ctx, userID, until
+func (_m *Storage) ExpiringPoints(ctx context.Context, userID string, until time.Time) ([]storage.ExpiringPoints, error) {
+	ret := _m.Called(ctx, userID, until)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ExpiringPoints")
+	}
+
+	var r0 []storage.ExpiringPoints
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, time.Time) ([]storage.ExpiringPoints, error)); ok {
+		return rf(ctx, userID, until)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, time.Time) []storage.ExpiringPoints); ok {
+		r0 = rf(ctx, userID, until)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]storage.ExpiringPoints)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, time.Time) error); ok {
+		r1 = rf(ctx, userID, until)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// FailMFAChallenge provides a mock function with given fields: ctx, tokenHash
+func (_m *Storage) FailMFAChallenge(ctx context.Context, tokenHash []byte) error {
+	ret := _m.Called(ctx, tokenHash)
+
+	if len(ret) == 0 {
+		panic("no return value specified for FailMFAChallenge")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, []byte) error); ok {
+		r0 = rf(ctx, tokenHash)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// LedgerBalance provides a mock function with given fields: ctx, userID, at
+func (_m *Storage) LedgerBalance(ctx context.Context, userID string, at time.Time) (*storage.Balance, error) {
+	ret := _m.Called(ctx, userID, at)
+
+	if len(ret) == 0 {
+		panic("no return value specified for LedgerBalance")
+	}
+
+	var r0 *storage.Balance
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, time.Time) (*storage.Balance, error)); ok {
+		return rf(ctx, userID, at)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, time.Time) *storage.Balance); ok {
+		r0 = rf(ctx, userID, at)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*storage.Balance)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, time.Time) error); ok {
+		r1 = rf(ctx, userID, at)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MFAChallenge provides a mock function with given fields: ctx, tokenHash
+func (_m *Storage) MFAChallenge(ctx context.Context, tokenHash []byte) (*storage.MFAChallenge, error) {
+	ret := _m.Called(ctx, tokenHash)
+
+	if len(ret) == 0 {
+		panic("no return value specified for MFAChallenge")
+	}
+
+	var r0 *storage.MFAChallenge
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, []byte) (*storage.MFAChallenge, error)); ok {
+		return rf(ctx, tokenHash)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, []byte) *storage.MFAChallenge); ok {
+		r0 = rf(ctx, tokenHash)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*storage.MFAChallenge)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, []byte) error); ok {
+		r1 = rf(ctx, tokenHash)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// OrderEvents provides a mock function with given fields: ctx, orderID
+func (_m *Storage) OrderEvents(ctx context.Context, orderID string) ([]storage.OrderEvent, error) {
+	ret := _m.Called(ctx, orderID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for OrderEvents")
+	}
+
+	var r0 []storage.OrderEvent
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) ([]storage.OrderEvent, error)); ok {
+		return rf(ctx, orderID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) []storage.OrderEvent); ok {
+		r0 = rf(ctx, orderID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]storage.OrderEvent)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, orderID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Orders provides a mock function with given fields: ctx, userID
+func (_m *Storage) Orders(ctx context.Context, userID string) ([]storage.Order, error) {
+	ret := _m.Called(ctx, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Orders")
+	}
+
+	var r0 []storage.Order
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) ([]storage.Order, error)); ok {
+		return rf(ctx, userID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) []storage.Order); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]storage.Order)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// OrdersPage provides a mock function with given fields: ctx, filter
+func (_m *Storage) OrdersPage(ctx context.Context, filter storage.OrdersFilter) ([]storage.Order, error) {
+	ret := _m.Called(ctx, filter)
+
+	if len(ret) == 0 {
+		panic("no return value specified for OrdersPage")
+	}
+
+	var r0 []storage.Order
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, storage.OrdersFilter) ([]storage.Order, error)); ok {
+		return rf(ctx, filter)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, storage.OrdersFilter) []storage.Order); ok {
+		r0 = rf(ctx, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]storage.Order)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, storage.OrdersFilter) error); ok {
+		r1 = rf(ctx, filter)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PurgeIdempotency provides a mock function with given fields: ctx, now, limit
+func (_m *Storage) PurgeIdempotency(ctx context.Context, now time.Time, limit uint32) (int64, error) {
+	ret := _m.Called(ctx, now, limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for PurgeIdempotency")
+	}
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time, uint32) (int64, error)); ok {
+		return rf(ctx, now, limit)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time, uint32) int64); ok {
+		r0 = rf(ctx, now, limit)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, time.Time, uint32) error); ok {
+		r1 = rf(ctx, now, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RecheckOrder provides a mock function with given fields: ctx, orderID
+func (_m *Storage) RecheckOrder(ctx context.Context, orderID string) error {
+	ret := _m.Called(ctx, orderID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RecheckOrder")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, orderID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RecordAPIKeyUsage provides a mock function with given fields: ctx, keyID, ip
+func (_m *Storage) RecordAPIKeyUsage(ctx context.Context, keyID string, ip string) error {
+	ret := _m.Called(ctx, keyID, ip)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RecordAPIKeyUsage")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, keyID, ip)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RehashPassword provides a mock function with given fields: ctx, userID, oldHash, newHash
+func (_m *Storage) RehashPassword(ctx context.Context, userID string, oldHash []byte, newHash []byte) error {
+	ret := _m.Called(ctx, userID, oldHash, newHash)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RehashPassword")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, []byte, []byte) error); ok {
+		r0 = rf(ctx, userID, oldHash, newHash)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ResetPassword provides a mock function with given fields: ctx, tokenHash, passwordHash
+func (_m *Storage) ResetPassword(ctx context.Context, tokenHash []byte, passwordHash []byte) (string, error) {
+	ret := _m.Called(ctx, tokenHash, passwordHash)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ResetPassword")
+	}
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, []byte, []byte) (string, error)); ok {
+		return rf(ctx, tokenHash, passwordHash)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, []byte, []byte) string); ok {
+		r0 = rf(ctx, tokenHash, passwordHash)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, []byte, []byte) error); ok {
+		r1 = rf(ctx, tokenHash, passwordHash)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RevokeAPIKey provides a mock function with given fields: ctx, userID, keyID
+func (_m *Storage) RevokeAPIKey(ctx context.Context, userID string, keyID string) error {
+	ret := _m.Called(ctx, userID, keyID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RevokeAPIKey")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, userID, keyID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RevokeRefreshToken provides a mock function with given fields: ctx, userID, tokenHash
+func (_m *Storage) RevokeRefreshToken(ctx context.Context, userID string, tokenHash []byte) error {
+	ret := _m.Called(ctx, userID, tokenHash)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RevokeRefreshToken")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, []byte) error); ok {
+		r0 = rf(ctx, userID, tokenHash)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RevokeSession provides a mock function with given fields: ctx, userID, sessionID
+func (_m *Storage) RevokeSession(ctx context.Context, userID string, sessionID string) error {
+	ret := _m.Called(ctx, userID, sessionID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RevokeSession")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, userID, sessionID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RevokeToken provides a mock function with given fields: ctx, token
+func (_m *Storage) RevokeToken(ctx context.Context, token storage.RevokedToken) error {
+	ret := _m.Called(ctx, token)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RevokeToken")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, storage.RevokedToken) error); ok {
+		r0 = rf(ctx, token)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
 }
 
-// CreateOrder provides a mock function with given fields: ctx, userID, order
-func (_m *Storage) CreateOrder(ctx context.Context, userID string, order storage.CreateOrder) error {
-	ret := _m.Called(ctx, userID, order)
+// RevokeUserTokens provides a mock function with given fields: ctx, userID
+func (_m *Storage) RevokeUserTokens(ctx context.Context, userID string) (int64, error) {
+	ret := _m.Called(ctx, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RevokeUserTokens")
+	}
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (int64, error)); ok {
+		return rf(ctx, userID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) int64); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RotateRefreshToken provides a mock function with given fields: ctx, tokenHash, newToken
+func (_m *Storage) RotateRefreshToken(ctx context.Context, tokenHash []byte, newToken storage.RefreshToken) (*storage.RefreshToken, error) {
+	ret := _m.Called(ctx, tokenHash, newToken)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RotateRefreshToken")
+	}
+
+	var r0 *storage.RefreshToken
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, []byte, storage.RefreshToken) (*storage.RefreshToken, error)); ok {
+		return rf(ctx, tokenHash, newToken)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, []byte, storage.RefreshToken) *storage.RefreshToken); ok {
+		r0 = rf(ctx, tokenHash, newToken)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*storage.RefreshToken)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, []byte, storage.RefreshToken) error); ok {
+		r1 = rf(ctx, tokenHash, newToken)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SaveSession provides a mock function with given fields: ctx, session
+func (_m *Storage) SaveSession(ctx context.Context, session storage.Session) error {
+	ret := _m.Called(ctx, session)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SaveSession")
+	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, string, storage.CreateOrder) error); ok {
-		r0 = rf(ctx, userID, order)
+	if rf, ok := ret.Get(0).(func(context.Context, storage.Session) error); ok {
+		r0 = rf(ctx, session)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -29,23 +1000,47 @@ func (_m *Storage) CreateOrder(ctx context.Context, userID string, order storage
 	return r0
 }
 
-// CreateUser provides a mock function with given fields: ctx, login, passwordHash
-func (_m *Storage) CreateUser(ctx context.Context, login string, passwordHash []byte) (string, error) {
-	ret := _m.Called(ctx, login, passwordHash)
+// SaveTOTPSecret provides a mock function with given fields: ctx, userID, secret
+func (_m *Storage) SaveTOTPSecret(ctx context.Context, userID string, secret string) error {
+	ret := _m.Called(ctx, userID, secret)
 
-	var r0 string
+	if len(ret) == 0 {
+		panic("no return value specified for SaveTOTPSecret")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, userID, secret)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SearchUsers provides a mock function with given fields: ctx, login, limit
+func (_m *Storage) SearchUsers(ctx context.Context, login string, limit uint32) ([]storage.User, error) {
+	ret := _m.Called(ctx, login, limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SearchUsers")
+	}
+
+	var r0 []storage.User
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, string, []byte) (string, error)); ok {
-		return rf(ctx, login, passwordHash)
+	if rf, ok := ret.Get(0).(func(context.Context, string, uint32) ([]storage.User, error)); ok {
+		return rf(ctx, login, limit)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, string, []byte) string); ok {
-		r0 = rf(ctx, login, passwordHash)
+	if rf, ok := ret.Get(0).(func(context.Context, string, uint32) []storage.User); ok {
+		r0 = rf(ctx, login, limit)
 	} else {
-		r0 = ret.Get(0).(string)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]storage.User)
+		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, string, []byte) error); ok {
-		r1 = rf(ctx, login, passwordHash)
+	if rf, ok := ret.Get(1).(func(context.Context, string, uint32) error); ok {
+		r1 = rf(ctx, login, limit)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -53,20 +1048,72 @@ func (_m *Storage) CreateUser(ctx context.Context, login string, passwordHash []
 	return r0, r1
 }
 
-// Orders provides a mock function with given fields: ctx, userID
-func (_m *Storage) Orders(ctx context.Context, userID string) ([]storage.Order, error) {
+// Sessions provides a mock function with given fields: ctx, userID
+func (_m *Storage) Sessions(ctx context.Context, userID string) ([]storage.Session, error) {
 	ret := _m.Called(ctx, userID)
 
-	var r0 []storage.Order
+	if len(ret) == 0 {
+		panic("no return value specified for Sessions")
+	}
+
+	var r0 []storage.Session
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, string) ([]storage.Order, error)); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, string) ([]storage.Session, error)); ok {
 		return rf(ctx, userID)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, string) []storage.Order); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, string) []storage.Session); ok {
 		r0 = rf(ctx, userID)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]storage.Order)
+			r0 = ret.Get(0).([]storage.Session)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SetUserBlocked provides a mock function with given fields: ctx, userID, blocked
+func (_m *Storage) SetUserBlocked(ctx context.Context, userID string, blocked bool) error {
+	ret := _m.Called(ctx, userID, blocked)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetUserBlocked")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, bool) error); ok {
+		r0 = rf(ctx, userID, blocked)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// TOTP provides a mock function with given fields: ctx, userID
+func (_m *Storage) TOTP(ctx context.Context, userID string) (*storage.TOTP, error) {
+	ret := _m.Called(ctx, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for TOTP")
+	}
+
+	var r0 *storage.TOTP
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*storage.TOTP, error)); ok {
+		return rf(ctx, userID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *storage.TOTP); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*storage.TOTP)
 		}
 	}
 
@@ -79,10 +1126,96 @@ func (_m *Storage) Orders(ctx context.Context, userID string) ([]storage.Order,
 	return r0, r1
 }
 
+// TokenRevoked provides a mock function with given fields: ctx, tokenID
+func (_m *Storage) TokenRevoked(ctx context.Context, tokenID string) (bool, error) {
+	ret := _m.Called(ctx, tokenID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for TokenRevoked")
+	}
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (bool, error)); ok {
+		return rf(ctx, tokenID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) bool); ok {
+		r0 = rf(ctx, tokenID)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, tokenID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// TouchSession provides a mock function with given fields: ctx, sessionID
+func (_m *Storage) TouchSession(ctx context.Context, sessionID string) error {
+	ret := _m.Called(ctx, sessionID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for TouchSession")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, sessionID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UseRecoveryCode provides a mock function with given fields: ctx, userID, codeHash
+func (_m *Storage) UseRecoveryCode(ctx context.Context, userID string, codeHash []byte) error {
+	ret := _m.Called(ctx, userID, codeHash)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UseRecoveryCode")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, []byte) error); ok {
+		r0 = rf(ctx, userID, codeHash)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UseTOTPStep provides a mock function with given fields: ctx, userID, step
+func (_m *Storage) UseTOTPStep(ctx context.Context, userID string, step int64) error {
+	ret := _m.Called(ctx, userID, step)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UseTOTPStep")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, int64) error); ok {
+		r0 = rf(ctx, userID, step)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // User provides a mock function with given fields: ctx, login
 func (_m *Storage) User(ctx context.Context, login string) (*storage.User, error) {
 	ret := _m.Called(ctx, login)
 
+	if len(ret) == 0 {
+		panic("no return value specified for User")
+	}
+
 	var r0 *storage.User
 	var r1 error
 	if rf, ok := ret.Get(0).(func(context.Context, string) (*storage.User, error)); ok {
@@ -109,6 +1242,10 @@ func (_m *Storage) User(ctx context.Context, login string) (*storage.User, error
 func (_m *Storage) UserBalance(ctx context.Context, userID string) (*storage.Balance, error) {
 	ret := _m.Called(ctx, userID)
 
+	if len(ret) == 0 {
+		panic("no return value specified for UserBalance")
+	}
+
 	var r0 *storage.Balance
 	var r1 error
 	if rf, ok := ret.Get(0).(func(context.Context, string) (*storage.Balance, error)); ok {
@@ -131,10 +1268,104 @@ func (_m *Storage) UserBalance(ctx context.Context, userID string) (*storage.Bal
 	return r0, r1
 }
 
+// UserByID provides a mock function with given fields: ctx, userID
+func (_m *Storage) UserByID(ctx context.Context, userID string) (*storage.User, error) {
+	ret := _m.Called(ctx, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UserByID")
+	}
+
+	var r0 *storage.User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*storage.User, error)); ok {
+		return rf(ctx, userID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *storage.User); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*storage.User)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UserOrder provides a mock function with given fields: ctx, userID, orderID
+func (_m *Storage) UserOrder(ctx context.Context, userID string, orderID string) (*storage.Order, error) {
+	ret := _m.Called(ctx, userID, orderID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UserOrder")
+	}
+
+	var r0 *storage.Order
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) (*storage.Order, error)); ok {
+		return rf(ctx, userID, orderID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *storage.Order); ok {
+		r0 = rf(ctx, userID, orderID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*storage.Order)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, userID, orderID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UsersWithExpiredPoints provides a mock function with given fields: ctx, now, limit
+func (_m *Storage) UsersWithExpiredPoints(ctx context.Context, now time.Time, limit uint32) ([]string, error) {
+	ret := _m.Called(ctx, now, limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UsersWithExpiredPoints")
+	}
+
+	var r0 []string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time, uint32) ([]string, error)); ok {
+		return rf(ctx, now, limit)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time, uint32) []string); ok {
+		r0 = rf(ctx, now, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, time.Time, uint32) error); ok {
+		r1 = rf(ctx, now, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Withdraw provides a mock function with given fields: ctx, userID, withdraw
 func (_m *Storage) Withdraw(ctx context.Context, userID string, withdraw storage.WithdrawBonuses) error {
 	ret := _m.Called(ctx, userID, withdraw)
 
+	if len(ret) == 0 {
+		panic("no return value specified for Withdraw")
+	}
+
 	var r0 error
 	if rf, ok := ret.Get(0).(func(context.Context, string, storage.WithdrawBonuses) error); ok {
 		r0 = rf(ctx, userID, withdraw)
@@ -145,10 +1376,44 @@ func (_m *Storage) Withdraw(ctx context.Context, userID string, withdraw storage
 	return r0
 }
 
+// Withdrawal provides a mock function with given fields: ctx, userID, orderID
+func (_m *Storage) Withdrawal(ctx context.Context, userID string, orderID string) (*storage.WithdrawalsBonuses, error) {
+	ret := _m.Called(ctx, userID, orderID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Withdrawal")
+	}
+
+	var r0 *storage.WithdrawalsBonuses
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) (*storage.WithdrawalsBonuses, error)); ok {
+		return rf(ctx, userID, orderID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *storage.WithdrawalsBonuses); ok {
+		r0 = rf(ctx, userID, orderID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*storage.WithdrawalsBonuses)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, userID, orderID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Withdrawals provides a mock function with given fields: ctx, userID
 func (_m *Storage) Withdrawals(ctx context.Context, userID string) ([]storage.WithdrawalsBonuses, error) {
 	ret := _m.Called(ctx, userID)
 
+	if len(ret) == 0 {
+		panic("no return value specified for Withdrawals")
+	}
+
 	var r0 []storage.WithdrawalsBonuses
 	var r1 error
 	if rf, ok := ret.Get(0).(func(context.Context, string) ([]storage.WithdrawalsBonuses, error)); ok {
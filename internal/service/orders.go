@@ -0,0 +1,200 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/vladislav-kr/gophermart/internal/domain/models"
+	"github.com/vladislav-kr/gophermart/internal/storage"
+)
+
+// OrdersPageByUserID страница заказов пользователя.
+// Пустая выборка не ошибка, у страницы просто нет заказов.
+func (s *service) OrdersPageByUserID(
+	ctx context.Context,
+	userID models.UserID,
+	query models.OrdersQuery,
+) (*models.OrdersPage, error) {
+	if !userID.Validate() {
+		return nil, models.ErrUserIDMandatory
+	}
+
+	if err := query.Validate(); err != nil {
+		return nil, err
+	}
+
+	limit := query.PageLimit()
+	filter := storage.OrdersFilter{
+		UserID:   string(userID),
+		Statuses: query.Statuses,
+		From:     query.From,
+		To:       query.To,
+		Desc:     query.Desc,
+		// лишний заказ показывает, что есть следующая страница
+		Limit: uint32(limit + 1),
+	}
+
+	if query.Cursor != "" {
+		cursor, err := models.DecodeOrderCursor(query.Cursor)
+		if err != nil || cursor.Desc != query.Desc {
+			return nil, &models.ValidationError{Fields: []models.FieldError{{
+				Field:   "cursor",
+				Code:    models.CodeInvalidValue,
+				Message: "недействительный курсор",
+			}}}
+		}
+		filter.AfterUploadedAt = cursor.UploadedAt
+		filter.AfterOrderID = string(cursor.OrderID)
+	}
+
+	page := &models.OrdersPage{Orders: []models.Order{}}
+
+	dbOrders, err := s.storage.OrdersPage(ctx, filter)
+	if err != nil {
+		switch {
+		case errors.Is(err, storage.ErrNoRecordsFound):
+			return page, nil
+		default:
+			return nil, fmt.Errorf("orders page %v: %w", err, models.ErrInternal)
+		}
+	}
+
+	if len(dbOrders) > limit {
+		dbOrders = dbOrders[:limit]
+		last := dbOrders[limit-1]
+		page.NextCursor = models.OrderCursor{
+			UploadedAt: last.UploadedAt,
+			OrderID:    models.OrderID(last.OrderID),
+			Desc:       query.Desc,
+		}.Encode()
+	}
+
+	for _, order := range dbOrders {
+		page.Orders = append(page.Orders, models.Order{
+			OrderID:    models.OrderID(order.OrderID),
+			Status:     order.Status,
+			UploadedAt: order.UploadedAt,
+			Accrual:    order.Accrual,
+		})
+	}
+
+	return page, nil
+}
+
+// OrderByUserID заказ пользователя с историей статусов.
+// Чужой заказ не раскрывается и считается ненайденным.
+func (s *service) OrderByUserID(
+	ctx context.Context,
+	userID models.UserID,
+	orderID models.OrderID,
+) (*models.OrderDetails, error) {
+	if !userID.Validate() {
+		return nil, models.ErrUserIDMandatory
+	}
+
+	if !orderID.Validate() {
+		return nil, models.ErrIncorrectOrderNumber
+	}
+
+	order, err := s.storage.UserOrder(ctx, string(userID), string(orderID))
+	if err != nil {
+		switch {
+		case errors.Is(err, storage.ErrNoRecordsFound):
+			return nil, models.ErrNoRecordsFound
+		default:
+			return nil, fmt.Errorf("user order %v: %w", err, models.ErrInternal)
+		}
+	}
+
+	details := &models.OrderDetails{
+		Order: models.Order{
+			OrderID:    models.OrderID(order.OrderID),
+			Status:     order.Status,
+			UploadedAt: order.UploadedAt,
+			Accrual:    order.Accrual,
+		},
+		History: []models.OrderEvent{},
+	}
+
+	events, err := s.storage.OrderEvents(ctx, order.OrderID)
+	if err != nil && !errors.Is(err, storage.ErrNoRecordsFound) {
+		return nil, fmt.Errorf("order events %v: %w", err, models.ErrInternal)
+	}
+
+	for _, event := range events {
+		details.History = append(details.History, models.OrderEvent{
+			Status:    event.Status,
+			Accrual:   event.Accrual,
+			ChangedAt: event.CreatedAt,
+		})
+	}
+
+	return details, nil
+}
+
+// UploadOrders пакетная загрузка номеров заказов. Принятые номера добавляются
+// со статусом NEW без обращения к системе расчета, начисления получит
+// фоновое обновление заказов. Повтор номера в запросе считается уже загруженным.
+func (s *service) UploadOrders(
+	ctx context.Context,
+	userID models.UserID,
+	orderIDs []models.OrderID,
+) (*models.BulkUpload, error) {
+	if !userID.Validate() {
+		return nil, models.ErrUserIDMandatory
+	}
+
+	if len(orderIDs) > models.MaxBulkOrders {
+		return nil, models.ErrTooManyOrders
+	}
+
+	upload := &models.BulkUpload{Results: make([]models.OrderUploadResult, 0, len(orderIDs))}
+
+	valid := make([]string, 0, len(orderIDs))
+	seen := make(map[models.OrderID]struct{}, len(orderIDs))
+	for _, orderID := range orderIDs {
+		if _, ok := seen[orderID]; ok || !orderID.Validate() {
+			continue
+		}
+		seen[orderID] = struct{}{}
+		valid = append(valid, string(orderID))
+	}
+
+	owners := make(map[models.OrderID]storage.OrderOwner, len(valid))
+	if len(valid) > 0 {
+		dbOwners, err := s.storage.CreateOrders(ctx, string(userID), valid)
+		if err != nil {
+			return nil, fmt.Errorf("create orders %v: %w", err, models.ErrInternal)
+		}
+		for _, owner := range dbOwners {
+			owners[models.OrderID(owner.OrderID)] = owner
+		}
+	}
+
+	for _, orderID := range orderIDs {
+		result := models.OrderUploadResult{Number: orderID}
+
+		owner, ok := owners[orderID]
+		switch {
+		case !orderID.Validate():
+			result.Result = models.UploadInvalidNumber
+		case !ok:
+			return nil, fmt.Errorf("order %s not stored: %w", orderID, models.ErrInternal)
+		case owner.UserID != string(userID):
+			result.Result = models.UploadAnotherUser
+		case owner.Inserted:
+			result.Result = models.UploadAccepted
+			upload.Accepted++
+			// повтор номера в том же запросе
+			owner.Inserted = false
+			owners[orderID] = owner
+		default:
+			result.Result = models.UploadAlreadyUploaded
+		}
+
+		upload.Results = append(upload.Results, result)
+	}
+
+	return upload, nil
+}
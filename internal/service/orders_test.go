@@ -0,0 +1,186 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/vladislav-kr/gophermart/internal/domain/models"
+	"github.com/vladislav-kr/gophermart/internal/domain/money"
+	"github.com/vladislav-kr/gophermart/internal/service/mocks"
+	"github.com/vladislav-kr/gophermart/internal/storage"
+)
+
+func Test_service_OrdersPageByUserID(t *testing.T) {
+	const userID = "1cf50925-d72d-488b-94e5-426acce77f3c"
+
+	stor := mocks.NewStorage(t)
+	srv := NewService(nil, stor, nil, nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*4)
+	defer cancel()
+
+	uploaded := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	dbOrders := []storage.Order{
+		{OrderID: "2377225624", UserID: userID, Status: models.StatusNew, UploadedAt: uploaded},
+		{OrderID: "12345678903", UserID: userID, Status: models.StatusNew, UploadedAt: uploaded.Add(time.Minute)},
+		{OrderID: "79927398713", UserID: userID, Status: models.StatusNew, UploadedAt: uploaded.Add(time.Hour)},
+	}
+
+	// первая страница, хранилище вернуло заказ сверх лимита
+	stor.On("OrdersPage", mock.Anything, storage.OrdersFilter{
+		UserID:   userID,
+		Statuses: []string{models.StatusNew},
+		Limit:    3,
+	}).Return(dbOrders, nil).Once()
+
+	page, err := srv.OrdersPageByUserID(ctx, userID, models.OrdersQuery{
+		Statuses: []string{models.StatusNew},
+		Limit:    2,
+	})
+	require.NoError(t, err)
+	require.Len(t, page.Orders, 2)
+	assert.Equal(t, models.OrderID("12345678903"), page.Orders[1].OrderID)
+	require.NotEmpty(t, page.NextCursor)
+
+	// следующая страница продолжается после последнего заказа
+	stor.On("OrdersPage", mock.Anything, storage.OrdersFilter{
+		UserID:          userID,
+		Statuses:        []string{models.StatusNew},
+		AfterUploadedAt: dbOrders[1].UploadedAt,
+		AfterOrderID:    dbOrders[1].OrderID,
+		Limit:           3,
+	}).Return(dbOrders[2:], nil).Once()
+
+	page, err = srv.OrdersPageByUserID(ctx, userID, models.OrdersQuery{
+		Statuses: []string{models.StatusNew},
+		Limit:    2,
+		Cursor:   page.NextCursor,
+	})
+	require.NoError(t, err)
+	require.Len(t, page.Orders, 1)
+	assert.Empty(t, page.NextCursor)
+
+	// пустая выборка
+	stor.On("OrdersPage", mock.Anything, mock.Anything).Return(nil, storage.ErrNoRecordsFound).Once()
+	page, err = srv.OrdersPageByUserID(ctx, userID, models.OrdersQuery{Desc: true})
+	require.NoError(t, err)
+	assert.NotNil(t, page.Orders)
+	assert.Empty(t, page.Orders)
+
+	// курсор другого направления сортировки и мусор не принимаются
+	cursor := models.OrderCursor{UploadedAt: uploaded, OrderID: "2377225624"}.Encode()
+	_, err = srv.OrdersPageByUserID(ctx, userID, models.OrdersQuery{Desc: true, Cursor: cursor})
+	assert.ErrorIs(t, err, models.ErrValidation)
+	_, err = srv.OrdersPageByUserID(ctx, userID, models.OrdersQuery{Cursor: "garbage"})
+	assert.ErrorIs(t, err, models.ErrValidation)
+
+	_, err = srv.OrdersPageByUserID(ctx, userID, models.OrdersQuery{Statuses: []string{"DONE"}})
+	assert.ErrorIs(t, err, models.ErrValidation)
+}
+
+func Test_service_OrderByUserID(t *testing.T) {
+	const (
+		userID  = "1cf50925-d72d-488b-94e5-426acce77f3c"
+		orderID = "2377225624"
+	)
+
+	stor := mocks.NewStorage(t)
+	srv := NewService(nil, stor, nil, nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*4)
+	defer cancel()
+
+	uploaded := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	stor.On("UserOrder", mock.Anything, userID, orderID).Return(&storage.Order{
+		OrderID:    orderID,
+		UserID:     userID,
+		Status:     models.StatusProcessed,
+		UploadedAt: uploaded,
+		Accrual:    money.FromInt(500),
+	}, nil).Once()
+	stor.On("OrderEvents", mock.Anything, orderID).Return([]storage.OrderEvent{
+		{OrderID: orderID, Status: models.StatusNew, CreatedAt: uploaded},
+		{OrderID: orderID, Status: models.StatusProcessing, CreatedAt: uploaded.Add(time.Minute)},
+		{OrderID: orderID, Status: models.StatusProcessed, Accrual: money.FromInt(500), CreatedAt: uploaded.Add(time.Hour)},
+	}, nil).Once()
+
+	order, err := srv.OrderByUserID(ctx, userID, orderID)
+	require.NoError(t, err)
+	assert.Equal(t, models.StatusProcessed, order.Status)
+	require.Len(t, order.History, 3)
+	assert.Equal(t, models.StatusNew, order.History[0].Status)
+	assert.Equal(t, money.FromInt(500), order.History[2].Accrual)
+
+	// история еще не записана
+	stor.On("UserOrder", mock.Anything, userID, orderID).Return(&storage.Order{
+		OrderID: orderID,
+		Status:  models.StatusNew,
+	}, nil).Once()
+	stor.On("OrderEvents", mock.Anything, orderID).Return(nil, storage.ErrNoRecordsFound).Once()
+
+	order, err = srv.OrderByUserID(ctx, userID, orderID)
+	require.NoError(t, err)
+	assert.NotNil(t, order.History)
+	assert.Empty(t, order.History)
+
+	stor.On("UserOrder", mock.Anything, userID, orderID).Return(nil, storage.ErrNoRecordsFound).Once()
+	_, err = srv.OrderByUserID(ctx, userID, orderID)
+	assert.ErrorIs(t, err, models.ErrNoRecordsFound)
+
+	// номер не проходит проверку и не доходит до хранилища
+	_, err = srv.OrderByUserID(ctx, userID, "12345")
+	assert.ErrorIs(t, err, models.ErrIncorrectOrderNumber)
+}
+
+func Test_service_UploadOrders(t *testing.T) {
+	const (
+		userID  = "1cf50925-d72d-488b-94e5-426acce77f3c"
+		otherID = "0e1e3f44-5f0c-4e2c-9f0a-64b2ee1a4a3c"
+	)
+
+	stor := mocks.NewStorage(t)
+	srv := NewService(nil, stor, nil, nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*4)
+	defer cancel()
+
+	// неверные номера и повторы не передаются в хранилище
+	stor.On("CreateOrders", mock.Anything, userID, []string{"2377225624", "12345678903", "79927398713"}).
+		Return([]storage.OrderOwner{
+			{OrderID: "2377225624", UserID: userID, Inserted: true},
+			{OrderID: "12345678903", UserID: userID},
+			{OrderID: "79927398713", UserID: otherID},
+		}, nil).Once()
+
+	upload, err := srv.UploadOrders(ctx, userID, []models.OrderID{
+		"2377225624", "12345678903", "79927398713", "12345", "2377225624",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, upload.Accepted)
+	assert.Equal(t, []models.OrderUploadResult{
+		{Number: "2377225624", Result: models.UploadAccepted},
+		{Number: "12345678903", Result: models.UploadAlreadyUploaded},
+		{Number: "79927398713", Result: models.UploadAnotherUser},
+		{Number: "12345", Result: models.UploadInvalidNumber},
+		{Number: "2377225624", Result: models.UploadAlreadyUploaded},
+	}, upload.Results)
+
+	// все номера неверные
+	upload, err = srv.UploadOrders(ctx, userID, []models.OrderID{"12345"})
+	require.NoError(t, err)
+	assert.Equal(t, 0, upload.Accepted)
+	assert.Equal(t, models.UploadInvalidNumber, upload.Results[0].Result)
+
+	_, err = srv.UploadOrders(ctx, userID, make([]models.OrderID, models.MaxBulkOrders+1))
+	assert.ErrorIs(t, err, models.ErrTooManyOrders)
+
+	stor.On("CreateOrders", mock.Anything, userID, []string{"2377225624"}).
+		Return(nil, storage.ErrInternal).Once()
+	_, err = srv.UploadOrders(ctx, userID, []models.OrderID{"2377225624"})
+	assert.ErrorIs(t, err, models.ErrInternal)
+}
@@ -1,30 +1,210 @@
 package passwordgenerator
 
 import (
+	"bytes"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
 	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/vladislav-kr/gophermart/internal/domain/models"
+	"golang.org/x/crypto/argon2"
 	"golang.org/x/crypto/bcrypt"
 )
 
+var (
+	ErrUnknownHashFormat = errors.New("unknown password hash format")
+	ErrInvalidHash       = errors.New("invalid password hash")
+)
+
+const argon2idPrefix = "$argon2id$"
+
+// Argon2Params параметры argon2id, хранятся в самом хеше
+type Argon2Params struct {
+	// память в KiB
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// DefaultArgon2Params рекомендации OWASP для argon2id
+func DefaultArgon2Params() Argon2Params {
+	return Argon2Params{
+		Memory:      64 * 1024,
+		Iterations:  3,
+		Parallelism: 2,
+		SaltLength:  16,
+		KeyLength:   32,
+	}
+}
+
+// generator хеширует пароли argon2id,
+// проверяет хеши argon2id и bcrypt по формату сохраненного хеша
 type generator struct {
-	// bcrypt package
-	// const (
-	// 	MinCost     int = 4  // the minimum allowable cost as passed in to GenerateFromPassword
-	// 	MaxCost     int = 31 // the maximum allowable cost as passed in to GenerateFromPassword
-	// 	DefaultCost int = 10 // the cost that will actually be set if a cost below MinCost is passed into GenerateFromPassword
-	// )
-	cost int
+	params Argon2Params
 }
 
-func New(cost int) *generator {
-	return &generator{
-		cost: cost,
+type Option func(*generator)
+
+// WithArgon2Params параметры новых хешей, незаданные берутся по умолчанию
+func WithArgon2Params(p Argon2Params) Option {
+	return func(g *generator) {
+		if p.Memory > 0 {
+			g.params.Memory = p.Memory
+		}
+		if p.Iterations > 0 {
+			g.params.Iterations = p.Iterations
+		}
+		if p.Parallelism > 0 {
+			g.params.Parallelism = p.Parallelism
+		}
+		if p.SaltLength > 0 {
+			g.params.SaltLength = p.SaltLength
+		}
+		if p.KeyLength > 0 {
+			g.params.KeyLength = p.KeyLength
+		}
 	}
 }
 
+func New(opts ...Option) *generator {
+	g := &generator{
+		params: DefaultArgon2Params(),
+	}
+	for _, fn := range opts {
+		fn(g)
+	}
+	return g
+}
+
 func (g *generator) CompareHashAndPassword(hashedPassword, password []byte) error {
+	switch {
+	case bytes.HasPrefix(hashedPassword, []byte(argon2idPrefix)):
+		return compareArgon2id(hashedPassword, password)
+	case isBcrypt(hashedPassword):
+		return compareBcrypt(hashedPassword, password)
+	default:
+		return ErrUnknownHashFormat
+	}
+}
+
+// GenerateFromPassword хеш argon2id в формате PHC:
+// $argon2id$v=19$m=65536,t=3,p=2$<salt>$<hash>
+func (g *generator) GenerateFromPassword(password []byte) ([]byte, error) {
+	salt := make([]byte, g.params.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("generate salt: %w", err)
+	}
+
+	key := argon2.IDKey(password, salt,
+		g.params.Iterations,
+		g.params.Memory,
+		g.params.Parallelism,
+		g.params.KeyLength,
+	)
+
+	return []byte(fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2idPrefix,
+		argon2.Version,
+		g.params.Memory,
+		g.params.Iterations,
+		g.params.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	)), nil
+}
+
+// NeedsRehash хеш создан другим алгоритмом или с другими параметрами
+func (g *generator) NeedsRehash(hashedPassword []byte) bool {
+	if !bytes.HasPrefix(hashedPassword, []byte(argon2idPrefix)) {
+		return true
+	}
+
+	params, salt, key, err := decodeArgon2id(hashedPassword)
+	if err != nil {
+		return true
+	}
+
+	return params.Memory != g.params.Memory ||
+		params.Iterations != g.params.Iterations ||
+		params.Parallelism != g.params.Parallelism ||
+		uint32(len(salt)) != g.params.SaltLength ||
+		uint32(len(key)) != g.params.KeyLength
+}
+
+func compareArgon2id(hashedPassword, password []byte) error {
+	params, salt, key, err := decodeArgon2id(hashedPassword)
+	if err != nil {
+		return err
+	}
+
+	other := argon2.IDKey(password, salt,
+		params.Iterations,
+		params.Memory,
+		params.Parallelism,
+		uint32(len(key)),
+	)
+
+	if subtle.ConstantTimeCompare(key, other) != 1 {
+		return models.ErrMismatchedHashAndPassword
+	}
+	return nil
+}
+
+func decodeArgon2id(hashedPassword []byte) (Argon2Params, []byte, []byte, error) {
+	// "", "argon2id", "v=19", "m=65536,t=3,p=2", salt, hash
+	parts := strings.Split(string(hashedPassword), "$")
+	if len(parts) != 6 {
+		return Argon2Params{}, nil, nil, ErrInvalidHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil {
+		return Argon2Params{}, nil, nil, fmt.Errorf("version %v: %w", err, ErrInvalidHash)
+	}
+	if version != argon2.Version {
+		return Argon2Params{}, nil, nil, fmt.Errorf("unsupported version %d: %w", version, ErrInvalidHash)
+	}
+
+	params := Argon2Params{}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d",
+		&params.Memory,
+		&params.Iterations,
+		&params.Parallelism,
+	); err != nil {
+		return Argon2Params{}, nil, nil, fmt.Errorf("params %v: %w", err, ErrInvalidHash)
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return Argon2Params{}, nil, nil, fmt.Errorf("salt %v: %w", err, ErrInvalidHash)
+	}
+
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return Argon2Params{}, nil, nil, fmt.Errorf("hash %v: %w", err, ErrInvalidHash)
+	}
+
+	params.SaltLength = uint32(len(salt))
+	params.KeyLength = uint32(len(key))
+
+	return params, salt, key, nil
+}
+
+func isBcrypt(hashedPassword []byte) bool {
+	for _, prefix := range []string{"$2a$", "$2b$", "$2y$"} {
+		if bytes.HasPrefix(hashedPassword, []byte(prefix)) {
+			return true
+		}
+	}
+	return false
+}
+
+func compareBcrypt(hashedPassword, password []byte) error {
 	if err := bcrypt.CompareHashAndPassword(
 		hashedPassword,
 		password,
@@ -38,6 +218,3 @@ func (g *generator) CompareHashAndPassword(hashedPassword, password []byte) erro
 	}
 	return nil
 }
-func (g *generator) GenerateFromPassword(password []byte) ([]byte, error) {
-	return bcrypt.GenerateFromPassword(password, g.cost)
-}
@@ -3,22 +3,59 @@ package passwordgenerator
 import (
 	"testing"
 
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/vladislav-kr/gophermart/internal/domain/models"
 	"golang.org/x/crypto/bcrypt"
 )
 
 func Test_generator(t *testing.T) {
 	passwordTest := "QwErFDJD3236$FJ2324fjf1231"
 
-	passGen := New(bcrypt.DefaultCost)
+	passGen := New()
 
 	hash, err := passGen.GenerateFromPassword([]byte(passwordTest))
 	require.NoError(t, err)
+	assert.Regexp(t, `^\$argon2id\$v=19\$m=65536,t=3,p=2\$`, string(hash))
 
 	err = passGen.CompareHashAndPassword(hash, []byte(passwordTest))
 	require.NoError(t, err)
 
 	err = passGen.CompareHashAndPassword(hash, []byte("QwErFDJD3236$FJ2324fjf1232"))
-	require.Error(t, err)
+	require.ErrorIs(t, err, models.ErrMismatchedHashAndPassword)
 
+	assert.False(t, passGen.NeedsRehash(hash))
+}
+
+func Test_generator_bcrypt(t *testing.T) {
+	passwordTest := "QwErFDJD3236$FJ2324fjf1231"
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(passwordTest), bcrypt.MinCost)
+	require.NoError(t, err)
+
+	passGen := New()
+
+	// старые хеши bcrypt проверяются и требуют перехеширования
+	require.NoError(t, passGen.CompareHashAndPassword(hash, []byte(passwordTest)))
+	require.ErrorIs(t,
+		passGen.CompareHashAndPassword(hash, []byte("QwErFDJD3236$FJ2324fjf1232")),
+		models.ErrMismatchedHashAndPassword,
+	)
+	assert.True(t, passGen.NeedsRehash(hash))
+}
+
+func Test_generator_NeedsRehash(t *testing.T) {
+	old := New(WithArgon2Params(Argon2Params{Memory: 16 * 1024, Iterations: 2}))
+	hash, err := old.GenerateFromPassword([]byte("password"))
+	require.NoError(t, err)
+
+	assert.False(t, old.NeedsRehash(hash))
+
+	// параметры изменились, хеш по-прежнему проверяется
+	passGen := New()
+	assert.True(t, passGen.NeedsRehash(hash))
+	assert.NoError(t, passGen.CompareHashAndPassword(hash, []byte("password")))
+
+	assert.True(t, passGen.NeedsRehash([]byte("$argon2id$v=19$broken")))
+	assert.ErrorIs(t, passGen.CompareHashAndPassword([]byte("plain"), []byte("plain")), ErrUnknownHashFormat)
 }
@@ -0,0 +1,149 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/vladislav-kr/gophermart/internal/clients"
+	"github.com/vladislav-kr/gophermart/internal/domain/models"
+	"github.com/vladislav-kr/gophermart/internal/logger"
+	"github.com/vladislav-kr/gophermart/internal/storage"
+)
+
+// ChangePassword меняет пароль после проверки текущего.
+// Все выданные токены и сессии отзываются, взамен открывается новая сессия.
+func (s *service) ChangePassword(
+	ctx context.Context,
+	userID models.UserID,
+	change models.PasswordChange,
+	client models.Client,
+) (*models.Tokens, error) {
+	if !userID.Validate() {
+		return nil, models.ErrUserIDMandatory
+	}
+
+	if err := change.Validate(s.policy); err != nil {
+		return nil, err
+	}
+
+	user, err := s.storage.UserByID(ctx, string(userID))
+	if err != nil {
+		return nil, fmt.Errorf("user by id %v: %w", err, models.ErrInternal)
+	}
+
+	if err := userFromStorage(user).Active(); err != nil {
+		return nil, err
+	}
+
+	// подбор текущего пароля по украденному токену ограничивается как вход
+	if err := s.limiter.Allow(ctx, user.Login, ""); err != nil {
+		if errors.Is(err, models.ErrTooManyAttempts) {
+			return nil, err
+		}
+		s.log.Error("login limiter allow", logger.Error(err))
+	}
+
+	if err := s.generator.CompareHashAndPassword(
+		user.Password,
+		[]byte(change.OldPassword),
+	); err != nil {
+		switch {
+		case errors.Is(err, models.ErrMismatchedHashAndPassword):
+			s.loginFailed(ctx, user.Login, "")
+			return nil, models.ErrIncorrectPassword
+		default:
+			return nil, fmt.Errorf("compare hash and password %v: %w", err, models.ErrInternal)
+		}
+	}
+
+	passHash, err := s.generator.GenerateFromPassword([]byte(change.NewPassword))
+	if err != nil {
+		return nil, fmt.Errorf("generate hash password %v: %w", err, models.ErrInternal)
+	}
+
+	generation, err := s.storage.ChangePassword(ctx, user.UserID, passHash)
+	if err != nil {
+		return nil, fmt.Errorf("change password %v: %w", err, models.ErrInternal)
+	}
+	s.authStates.Delete(user.UserID)
+
+	return s.issueTokens(ctx, userFromStorage(user), generation, client)
+}
+
+// RequestPasswordReset отправляет токен сброса пароля.
+// Для несуществующего или заблокированного пользователя ничего не делает,
+// чтобы ответ не раскрывал наличие учетной записи.
+func (s *service) RequestPasswordReset(ctx context.Context, req models.PasswordResetRequest) error {
+	if s.notifier == nil {
+		return fmt.Errorf("notifier is not configured: %w", models.ErrInternal)
+	}
+
+	user, err := s.storage.User(ctx, req.Login)
+	if err != nil {
+		switch {
+		case errors.Is(err, storage.ErrNoRecordsFound):
+			return nil
+		default:
+			return fmt.Errorf("storage user %v: %w", err, models.ErrInternal)
+		}
+	}
+
+	if err := userFromStorage(user).Active(); err != nil {
+		return nil
+	}
+
+	token, tokenHash, err := newOpaqueToken()
+	if err != nil {
+		return fmt.Errorf("reset token generation %v: %w", err, models.ErrInternal)
+	}
+
+	expiresAt := time.Now().Add(s.passwordResetTTL)
+	if err := s.storage.CreatePasswordResetToken(ctx, storage.PasswordResetToken{
+		UserID:    user.UserID,
+		TokenHash: tokenHash,
+		ExpiresAt: expiresAt,
+	}); err != nil {
+		return fmt.Errorf("create password reset token %v: %w", err, models.ErrInternal)
+	}
+
+	if err := s.notifier.PasswordReset(ctx, clients.PasswordResetMessage{
+		Login:     user.Login,
+		Token:     token,
+		ExpiresAt: expiresAt,
+	}); err != nil {
+		return fmt.Errorf("notify password reset %v: %w", err, models.ErrInternal)
+	}
+
+	return nil
+}
+
+// ResetPassword устанавливает новый пароль по одноразовому токену
+// и отзывает все выданные пользователю токены
+func (s *service) ResetPassword(ctx context.Context, reset models.PasswordReset) error {
+	if err := reset.Validate(s.policy); err != nil {
+		return err
+	}
+
+	passHash, err := s.generator.GenerateFromPassword([]byte(reset.NewPassword))
+	if err != nil {
+		return fmt.Errorf("generate hash password %v: %w", err, models.ErrInternal)
+	}
+
+	userID, err := s.storage.ResetPassword(ctx, hashToken(reset.Token), passHash)
+	if err != nil {
+		switch {
+		case errors.Is(err, storage.ErrNoRecordsFound):
+			return models.ErrInvalidResetToken
+		default:
+			return fmt.Errorf("reset password %v: %w", err, models.ErrInternal)
+		}
+	}
+	s.authStates.Delete(userID)
+
+	s.log.Info("password reset", slog.String("user_id", userID))
+
+	return nil
+}
@@ -0,0 +1,184 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/vladislav-kr/gophermart/internal/clients"
+	"github.com/vladislav-kr/gophermart/internal/domain/models"
+	"github.com/vladislav-kr/gophermart/internal/service/mocks"
+	"github.com/vladislav-kr/gophermart/internal/storage"
+)
+
+func Test_service_ChangePassword(t *testing.T) {
+	const userID = "1cf50925-d72d-488b-94e5-426acce77f3c"
+
+	user := &storage.User{
+		UserID:   userID,
+		Login:    "mylogin",
+		Password: []byte("old-hash"),
+		Role:     "user",
+	}
+
+	tests := []struct {
+		name    string
+		change  models.PasswordChange
+		errGen  error
+		wantErr error
+	}{
+		{
+			name: "пароль изменен",
+			change: models.PasswordChange{
+				OldPassword: "oldPassword1",
+				NewPassword: "newPassword1",
+			},
+		},
+		{
+			name: "неверный текущий пароль",
+			change: models.PasswordChange{
+				OldPassword: "wrongPassword1",
+				NewPassword: "newPassword1",
+			},
+			errGen:  models.ErrMismatchedHashAndPassword,
+			wantErr: models.ErrIncorrectPassword,
+		},
+		{
+			name: "новый пароль не соответствует требованиям",
+			change: models.PasswordChange{
+				OldPassword: "oldPassword1",
+				NewPassword: "123",
+			},
+			wantErr: models.ErrValidation,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			stor := mocks.NewStorage(t)
+			gen := mocks.NewPasswordGenerator(t)
+			limiter := mocks.NewLoginLimiter(t)
+			srv := NewService(gen, stor, nil, testKeySet(t), WithLoginLimiter(limiter))
+
+			if tt.wantErr != models.ErrValidation {
+				stor.On("UserByID", mock.Anything, userID).Return(user, nil).Once()
+				limiter.On("Allow", mock.Anything, user.Login, "").Return(nil).Once()
+				gen.On("CompareHashAndPassword", user.Password, []byte(tt.change.OldPassword)).
+					Return(tt.errGen).Once()
+			}
+			if tt.errGen != nil {
+				limiter.On("Failure", mock.Anything, user.Login, "").Return(nil).Once()
+			}
+			if tt.wantErr == nil {
+				gen.On("GenerateFromPassword", []byte(tt.change.NewPassword)).
+					Return([]byte("new-hash"), nil).Once()
+				stor.On("ChangePassword", mock.Anything, userID, []byte("new-hash")).
+					Return(int64(2), nil).Once()
+				stor.On("SaveSession", mock.Anything, mock.AnythingOfType("storage.Session")).
+					Return(nil).Once()
+				stor.On("CreateRefreshToken", mock.Anything, mock.AnythingOfType("storage.RefreshToken")).
+					Return(nil).Once()
+			}
+
+			tokens, err := srv.ChangePassword(context.Background(), userID, tt.change, models.Client{})
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.NotEmpty(t, tokens.AccessToken)
+			assert.NotEmpty(t, tokens.RefreshToken)
+		})
+	}
+}
+
+func Test_service_RequestPasswordReset(t *testing.T) {
+	t.Run("токен отправлен", func(t *testing.T) {
+		t.Parallel()
+		stor := mocks.NewStorage(t)
+		notifier := mocks.NewNotifier(t)
+		srv := NewService(nil, stor, nil, nil,
+			WithNotifier(notifier),
+			WithPasswordResetTTL(time.Minute*10),
+		)
+
+		user := &storage.User{UserID: "1cf50925-d72d-488b-94e5-426acce77f3c", Login: "mylogin"}
+		stor.On("User", mock.Anything, "mylogin").Return(user, nil).Once()
+
+		var tokenHash []byte
+		stor.On("CreatePasswordResetToken", mock.Anything,
+			mock.MatchedBy(func(token storage.PasswordResetToken) bool {
+				tokenHash = token.TokenHash
+				return token.UserID == user.UserID &&
+					time.Until(token.ExpiresAt) <= time.Minute*10
+			}),
+		).Return(nil).Once()
+
+		// в уведомлении открытый токен, в хранилище только его хеш
+		notifier.On("PasswordReset", mock.Anything,
+			mock.MatchedBy(func(msg clients.PasswordResetMessage) bool {
+				return msg.Login == "mylogin" && string(tokenHash) == string(hashToken(msg.Token))
+			}),
+		).Return(nil).Once()
+
+		require.NoError(t, srv.RequestPasswordReset(context.Background(),
+			models.PasswordResetRequest{Login: "mylogin"}))
+	})
+
+	t.Run("пользователь не найден", func(t *testing.T) {
+		t.Parallel()
+		stor := mocks.NewStorage(t)
+		srv := NewService(nil, stor, nil, nil, WithNotifier(mocks.NewNotifier(t)))
+
+		stor.On("User", mock.Anything, "unknown").Return(nil, storage.ErrNoRecordsFound).Once()
+
+		assert.NoError(t, srv.RequestPasswordReset(context.Background(),
+			models.PasswordResetRequest{Login: "unknown"}))
+	})
+}
+
+func Test_service_ResetPassword(t *testing.T) {
+	tests := []struct {
+		name    string
+		reset   models.PasswordReset
+		errDB   error
+		wantErr error
+	}{
+		{
+			name:  "пароль установлен",
+			reset: models.PasswordReset{Token: "reset-token", NewPassword: "newPassword1"},
+		},
+		{
+			name:    "токен недействителен",
+			reset:   models.PasswordReset{Token: "used-token", NewPassword: "newPassword1"},
+			errDB:   storage.ErrNoRecordsFound,
+			wantErr: models.ErrInvalidResetToken,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			stor := mocks.NewStorage(t)
+			gen := mocks.NewPasswordGenerator(t)
+			srv := NewService(gen, stor, nil, nil)
+
+			gen.On("GenerateFromPassword", []byte(tt.reset.NewPassword)).Return([]byte("new-hash"), nil).Once()
+			stor.On("ResetPassword", mock.Anything, hashToken(tt.reset.Token), []byte("new-hash")).
+				Return("1cf50925-d72d-488b-94e5-426acce77f3c", tt.errDB).Once()
+
+			err := srv.ResetPassword(context.Background(), tt.reset)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package purgeidempotency
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/vladislav-kr/gophermart/internal/logger"
+)
+
+type Purger interface {
+	PurgeIdempotency(ctx context.Context) (int64, error)
+}
+
+// purgeIdempotency периодическое удаление истекших ключей идемпотентности
+type purgeIdempotency struct {
+	purger Purger
+	// период между запусками
+	interval time.Duration
+	// таймаут одного запуска
+	timeout time.Duration
+
+	log *slog.Logger
+}
+
+func New(p Purger, interval, timeout time.Duration) *purgeIdempotency {
+	return &purgeIdempotency{
+		purger:   p,
+		interval: interval,
+		timeout:  timeout,
+		log:      logger.Logger().With(slog.String("component", "purge-idempotency")),
+	}
+}
+
+// Run удаляет истекшие ключи каждые interval до отмены контекста,
+// нулевой interval отключает очистку
+func (pi *purgeIdempotency) Run(ctx context.Context) {
+	if pi.interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(pi.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			pi.purge(ctx)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (pi *purgeIdempotency) purge(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, pi.timeout)
+	defer cancel()
+
+	purged, err := pi.purger.PurgeIdempotency(ctx)
+	if err != nil {
+		pi.log.Error("purge idempotency keys", logger.Error(err))
+		return
+	}
+
+	pi.log.Debug("purge idempotency keys", slog.Int64("purged", purged))
+}
@@ -0,0 +1,79 @@
+package reconcilebalances
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/vladislav-kr/gophermart/internal/domain/models"
+	"github.com/vladislav-kr/gophermart/internal/logger"
+)
+
+type Reconciler interface {
+	ReconcileBalances(ctx context.Context, adminID models.UserID, correct bool) (*models.ReconciliationReport, error)
+}
+
+// reconcileBalances периодическая сверка балансов
+type reconcileBalances struct {
+	reconciler Reconciler
+	// период между сверками
+	interval time.Duration
+	// таймаут одной сверки
+	timeout time.Duration
+	// исправлять расхождения корректировкой
+	correct bool
+
+	log *slog.Logger
+}
+
+func New(r Reconciler, interval, timeout time.Duration, correct bool) *reconcileBalances {
+	return &reconcileBalances{
+		reconciler: r,
+		interval:   interval,
+		timeout:    timeout,
+		correct:    correct,
+		log:        logger.Logger().With(slog.String("component", "reconcile-balances")),
+	}
+}
+
+// Run сверяет балансы каждые interval до отмены контекста,
+// нулевой interval отключает сверку
+func (rb *reconcileBalances) Run(ctx context.Context) {
+	if rb.interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(rb.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			rb.reconcile(ctx)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (rb *reconcileBalances) reconcile(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, rb.timeout)
+	defer cancel()
+
+	report, err := rb.reconciler.ReconcileBalances(ctx, "", rb.correct)
+	if err != nil {
+		rb.log.Error("reconcile balances", logger.Error(err))
+		return
+	}
+
+	for _, drift := range report.Users {
+		rb.log.Warn("balance drift",
+			slog.String("user_id", string(drift.UserID)),
+			slog.String("current", drift.Current.String()),
+			slog.String("expected_current", drift.ExpectedCurrent.String()),
+			slog.String("withdrawn", drift.Withdrawn.String()),
+			slog.String("expected_withdrawn", drift.ExpectedWithdrawn.String()),
+			slog.Bool("corrected", drift.Corrected),
+		)
+	}
+}
@@ -0,0 +1,92 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/vladislav-kr/gophermart/internal/domain/models"
+	"github.com/vladislav-kr/gophermart/internal/logger"
+	"github.com/vladislav-kr/gophermart/internal/metrics"
+	"github.com/vladislav-kr/gophermart/internal/storage"
+)
+
+// ReconcileBalances сверяет балансы с заказами, списаниями и корректировками.
+// При correct расхождение текущего остатка исправляется корректировкой от имени
+// администратора, без администратора корректировка записывается как системная.
+// Ошибка исправления одного баланса не прерывает сверку остальных.
+func (s *service) ReconcileBalances(
+	ctx context.Context,
+	adminID models.UserID,
+	correct bool,
+) (*models.ReconciliationReport, error) {
+	if adminID != "" && !adminID.Validate() {
+		return nil, models.ErrUserIDMandatory
+	}
+
+	report := &models.ReconciliationReport{
+		CheckedAt: time.Now(),
+		Users:     []models.BalanceDrift{},
+	}
+
+	drifts, err := s.storage.BalanceDrifts(ctx)
+	if err != nil && !errors.Is(err, storage.ErrNoRecordsFound) {
+		return nil, fmt.Errorf("balance drifts %v: %w", err, models.ErrInternal)
+	}
+
+	for _, d := range drifts {
+		drift := models.BalanceDrift{
+			UserID:            models.UserID(d.UserID),
+			Current:           d.Current,
+			Withdrawn:         d.Withdrawn,
+			ExpectedCurrent:   d.ExpectedCurrent,
+			ExpectedWithdrawn: d.ExpectedWithdrawn,
+		}
+
+		if correct && d.Current != d.ExpectedCurrent {
+			drift.Corrected = s.correctBalanceDrift(ctx, adminID, &drift)
+		}
+		if drift.Corrected {
+			report.Corrected++
+		}
+
+		report.Drifted++
+		report.DriftAmount += (drift.ExpectedCurrent - drift.Current).Abs()
+		report.Users = append(report.Users, drift)
+	}
+
+	metrics.Mertics().BalanceDrift(report.Drifted, report.DriftAmount.Float64())
+	metrics.Mertics().BalanceCorrectionsAdd(report.Corrected)
+
+	s.log.Info("balance reconciliation",
+		slog.String("admin_id", string(adminID)),
+		slog.Int("drifted", report.Drifted),
+		slog.Int("corrected", report.Corrected),
+		slog.String("drift_amount", report.DriftAmount.String()),
+	)
+
+	return report, nil
+}
+
+// correctBalanceDrift исправляет остаток, расхождение обновляется
+// значениями, пересчитанными под блокировкой
+func (s *service) correctBalanceDrift(ctx context.Context, adminID models.UserID, drift *models.BalanceDrift) bool {
+	corrected, err := s.storage.CorrectBalanceDrift(ctx, string(drift.UserID), string(adminID))
+	if err != nil {
+		if !errors.Is(err, storage.ErrNoRecordsFound) {
+			s.log.Error("correct balance drift",
+				slog.String("user_id", string(drift.UserID)),
+				logger.Error(err),
+			)
+		}
+		return false
+	}
+
+	drift.Current = corrected.Current
+	drift.Withdrawn = corrected.Withdrawn
+	drift.ExpectedCurrent = corrected.ExpectedCurrent
+	drift.ExpectedWithdrawn = corrected.ExpectedWithdrawn
+	return true
+}
@@ -2,16 +2,18 @@ package service
 
 import (
 	"context"
-	"crypto/rsa"
 	"errors"
 	"fmt"
 	"log/slog"
 	"time"
 
+	"github.com/vladislav-kr/gophermart/internal/cache"
 	"github.com/vladislav-kr/gophermart/internal/clients"
 	"github.com/vladislav-kr/gophermart/internal/domain/models"
+	"github.com/vladislav-kr/gophermart/internal/domain/money"
 	"github.com/vladislav-kr/gophermart/internal/logger"
 	"github.com/vladislav-kr/gophermart/internal/service/jwt"
+	"github.com/vladislav-kr/gophermart/internal/service/totp"
 	"github.com/vladislav-kr/gophermart/internal/storage"
 )
 
@@ -19,11 +21,61 @@ import (
 type Storage interface {
 	CreateUser(ctx context.Context, login string, passwordHash []byte) (string, error)
 	User(ctx context.Context, login string) (*storage.User, error)
+	UserByID(ctx context.Context, userID string) (*storage.User, error)
+	RehashPassword(ctx context.Context, userID string, oldHash, newHash []byte) error
+	ChangePassword(ctx context.Context, userID string, passwordHash []byte) (int64, error)
+	CreatePasswordResetToken(ctx context.Context, token storage.PasswordResetToken) error
+	ResetPassword(ctx context.Context, tokenHash, passwordHash []byte) (string, error)
 	CreateOrder(ctx context.Context, userID string, order storage.CreateOrder) error
+	CreateOrders(ctx context.Context, userID string, orderIDs []string) ([]storage.OrderOwner, error)
 	Orders(ctx context.Context, userID string) ([]storage.Order, error)
+	OrdersPage(ctx context.Context, filter storage.OrdersFilter) ([]storage.Order, error)
+	UserOrder(ctx context.Context, userID, orderID string) (*storage.Order, error)
+	OrderEvents(ctx context.Context, orderID string) ([]storage.OrderEvent, error)
 	UserBalance(ctx context.Context, userID string) (*storage.Balance, error)
+	LedgerBalance(ctx context.Context, userID string, at time.Time) (*storage.Balance, error)
 	Withdrawals(ctx context.Context, userID string) ([]storage.WithdrawalsBonuses, error)
+	Withdrawal(ctx context.Context, userID, orderID string) (*storage.WithdrawalsBonuses, error)
 	Withdraw(ctx context.Context, userID string, withdraw storage.WithdrawBonuses) error
+	CreateRefreshToken(ctx context.Context, token storage.RefreshToken) error
+	RotateRefreshToken(ctx context.Context, tokenHash []byte, newToken storage.RefreshToken) (*storage.RefreshToken, error)
+	RevokeRefreshToken(ctx context.Context, userID string, tokenHash []byte) error
+	RevokeToken(ctx context.Context, token storage.RevokedToken) error
+	TokenRevoked(ctx context.Context, tokenID string) (bool, error)
+	RevokeUserTokens(ctx context.Context, userID string) (int64, error)
+	SaveSession(ctx context.Context, session storage.Session) error
+	Sessions(ctx context.Context, userID string) ([]storage.Session, error)
+	TouchSession(ctx context.Context, sessionID string) error
+	RevokeSession(ctx context.Context, userID, sessionID string) error
+	BeginIdempotency(ctx context.Context, key storage.IdempotencyKey, lockTimeout time.Duration) (*storage.IdempotencyKey, error)
+	CompleteIdempotency(ctx context.Context, key storage.IdempotencyKey) error
+	DeleteIdempotency(ctx context.Context, userID, key string) error
+	PurgeIdempotency(ctx context.Context, now time.Time, limit uint32) (int64, error)
+	CreateAPIKey(ctx context.Context, key storage.CreateAPIKey) (*storage.APIKey, error)
+	APIKeys(ctx context.Context, userID string) ([]storage.APIKey, error)
+	APIKeyByHash(ctx context.Context, keyHash []byte) (*storage.APIKey, error)
+	RevokeAPIKey(ctx context.Context, userID, keyID string) error
+	RecordAPIKeyUsage(ctx context.Context, keyID, ip string) error
+	SaveTOTPSecret(ctx context.Context, userID, secret string) error
+	TOTP(ctx context.Context, userID string) (*storage.TOTP, error)
+	ConfirmTOTP(ctx context.Context, userID string, step int64, recoveryCodes [][]byte) error
+	UseTOTPStep(ctx context.Context, userID string, step int64) error
+	UseRecoveryCode(ctx context.Context, userID string, codeHash []byte) error
+	CreateMFAChallenge(ctx context.Context, challenge storage.MFAChallenge) error
+	MFAChallenge(ctx context.Context, tokenHash []byte) (*storage.MFAChallenge, error)
+	FailMFAChallenge(ctx context.Context, tokenHash []byte) error
+	CompleteMFAChallenge(ctx context.Context, tokenHash []byte) error
+	SearchUsers(ctx context.Context, login string, limit uint32) ([]storage.User, error)
+	SetUserBlocked(ctx context.Context, userID string, blocked bool) error
+	DeleteUser(ctx context.Context, userID string, forfeit bool) (money.Amount, error)
+	RecheckOrder(ctx context.Context, orderID string) error
+	AdjustBalance(ctx context.Context, adjustment storage.BalanceAdjustment) (*storage.Balance, error)
+	BalanceHistory(ctx context.Context, userID string) ([]storage.BalanceEntry, error)
+	BalanceDrifts(ctx context.Context) ([]storage.BalanceDrift, error)
+	CorrectBalanceDrift(ctx context.Context, userID, adminID string) (*storage.BalanceDrift, error)
+	UsersWithExpiredPoints(ctx context.Context, now time.Time, limit uint32) ([]string, error)
+	ExpirePoints(ctx context.Context, userID string, now time.Time) (money.Amount, error)
+	ExpiringPoints(ctx context.Context, userID string, until time.Time) ([]storage.ExpiringPoints, error)
 }
 
 //go:generate mockery --name Accrual
@@ -31,42 +83,219 @@ type Accrual interface {
 	Order(ctx context.Context, orderID string) (*clients.OrderAccrual, time.Duration, error)
 }
 
+//go:generate mockery --name Notifier
+type Notifier interface {
+	PasswordReset(ctx context.Context, msg clients.PasswordResetMessage) error
+}
+
+//go:generate mockery --name LoginLimiter
+type LoginLimiter interface {
+	Allow(ctx context.Context, login, ip string) error
+	Failure(ctx context.Context, login, ip string) error
+	Success(ctx context.Context, login string) error
+}
+
+//go:generate mockery --name TOTPGenerator
+type TOTPGenerator interface {
+	GenerateSecret() (string, error)
+	URI(account, secret string) string
+	Validate(secret, code string) (int64, error)
+}
+
 //go:generate mockery --name PasswordGenerator
 type PasswordGenerator interface {
 	CompareHashAndPassword(hashedPassword, password []byte) error
 	GenerateFromPassword(password []byte) ([]byte, error)
+	NeedsRehash(hashedPassword []byte) bool
 }
 
+const (
+	defaultAccessTokenTTL     = time.Minute * 15
+	defaultRefreshTokenTTL    = time.Hour * 24 * 30
+	defaultRevocationCacheTTL = time.Second * 30
+	defaultPasswordResetTTL   = time.Hour
+	defaultMFAChallengeTTL    = time.Minute * 5
+	defaultIdempotencyTTL     = time.Hour * 24
+)
+
 type service struct {
-	generator  PasswordGenerator
-	storage    Storage
-	accrual    Accrual
-	privateKey *rsa.PrivateKey
-	log        *slog.Logger
+	generator PasswordGenerator
+	storage   Storage
+	accrual   Accrual
+	keys      *jwt.KeySet
+	limiter   LoginLimiter
+	notifier  Notifier
+	totp      TOTPGenerator
+	policy    models.CredentialPolicy
+	log       *slog.Logger
+
+	// остаток баланса удаленной учетной записи
+	deletedBalance models.DeletedBalancePolicy
+
+	accessTokenTTL   time.Duration
+	refreshTokenTTL  time.Duration
+	passwordResetTTL time.Duration
+	mfaChallengeTTL  time.Duration
+	idempotencyTTL   time.Duration
+
+	// окно баллов, которые скоро сгорят, в ответе баланса, 0 - не показывать
+	expiringSoon time.Duration
+
+	// кеш проверки отзыва токенов, чтобы не обращаться к хранилищу на каждый запрос
+	revocationCacheTTL time.Duration
+	revokedTokens      *cache.Cache[string, bool]
+	revokedSessions    *cache.Cache[string, bool]
+	authStates         *cache.Cache[string, authState]
+}
+
+type Option func(*service)
+
+// WithTokenTTL время жизни access и refresh токенов
+func WithTokenTTL(access, refresh time.Duration) Option {
+	return func(s *service) {
+		if access > 0 {
+			s.accessTokenTTL = access
+		}
+		if refresh > 0 {
+			s.refreshTokenTTL = refresh
+		}
+	}
 }
 
-func NewService(g PasswordGenerator, s Storage, a Accrual, privateKey *rsa.PrivateKey) *service {
-	return &service{
-		generator:  g,
-		storage:    s,
-		accrual:    a,
-		privateKey: privateKey,
-		log:        logger.Logger().With(slog.String("component", "service")),
+// WithRevocationCacheTTL время жизни кеша отозванных токенов
+func WithRevocationCacheTTL(ttl time.Duration) Option {
+	return func(s *service) {
+		if ttl > 0 {
+			s.revocationCacheTTL = ttl
+		}
 	}
 }
 
-func (s *service) Login(ctx context.Context, cred models.Credentials) (string, error) {
+// WithLoginLimiter ограничение неудачных попыток входа
+func WithLoginLimiter(l LoginLimiter) Option {
+	return func(s *service) {
+		if l != nil {
+			s.limiter = l
+		}
+	}
+}
+
+// WithNotifier доставка токенов сброса пароля
+func WithNotifier(n Notifier) Option {
+	return func(s *service) {
+		s.notifier = n
+	}
+}
+
+// WithPasswordResetTTL время жизни токена сброса пароля
+func WithPasswordResetTTL(ttl time.Duration) Option {
+	return func(s *service) {
+		if ttl > 0 {
+			s.passwordResetTTL = ttl
+		}
+	}
+}
+
+// WithTOTP генератор одноразовых кодов второго фактора
+func WithTOTP(g TOTPGenerator) Option {
+	return func(s *service) {
+		if g != nil {
+			s.totp = g
+		}
+	}
+}
+
+// WithMFAChallengeTTL время на ввод второго фактора после проверки пароля
+func WithMFAChallengeTTL(ttl time.Duration) Option {
+	return func(s *service) {
+		if ttl > 0 {
+			s.mfaChallengeTTL = ttl
+		}
+	}
+}
+
+// WithCredentialPolicy требования к логину и паролю при регистрации и смене пароля
+func WithCredentialPolicy(p models.CredentialPolicy) Option {
+	return func(s *service) {
+		s.policy = p
+	}
+}
+
+// WithDeletedBalancePolicy остаток баланса при удалении учетной записи
+func WithDeletedBalancePolicy(p models.DeletedBalancePolicy) Option {
+	return func(s *service) {
+		if p.Validate() {
+			s.deletedBalance = p
+		}
+	}
+}
+
+// WithIdempotencyTTL время хранения ответов на запросы с Idempotency-Key
+func WithIdempotencyTTL(ttl time.Duration) Option {
+	return func(s *service) {
+		if ttl > 0 {
+			s.idempotencyTTL = ttl
+		}
+	}
+}
+
+// WithExpiringSoon показывать в балансе баллы, сгорающие в течение window
+func WithExpiringSoon(window time.Duration) Option {
+	return func(s *service) {
+		if window > 0 {
+			s.expiringSoon = window
+		}
+	}
+}
+
+func NewService(g PasswordGenerator, s Storage, a Accrual, keys *jwt.KeySet, opts ...Option) *service {
+	srv := &service{
+		generator:          g,
+		storage:            s,
+		accrual:            a,
+		keys:               keys,
+		limiter:            noLimit{},
+		totp:               totp.New(),
+		policy:             models.DefaultCredentialPolicy(),
+		deletedBalance:     models.DeletedBalanceFreeze,
+		log:                logger.Logger().With(slog.String("component", "service")),
+		accessTokenTTL:     defaultAccessTokenTTL,
+		refreshTokenTTL:    defaultRefreshTokenTTL,
+		passwordResetTTL:   defaultPasswordResetTTL,
+		mfaChallengeTTL:    defaultMFAChallengeTTL,
+		idempotencyTTL:     defaultIdempotencyTTL,
+		revocationCacheTTL: defaultRevocationCacheTTL,
+	}
+	for _, fn := range opts {
+		fn(srv)
+	}
+	srv.revokedTokens = cache.New[string, bool](srv.revocationCacheTTL)
+	srv.revokedSessions = cache.New[string, bool](srv.revocationCacheTTL)
+	srv.authStates = cache.New[string, authState](srv.revocationCacheTTL)
+	return srv
+}
+
+func (s *service) Login(ctx context.Context, cred models.Credentials, client models.Client) (*models.Tokens, error) {
 	if err := cred.Validate(); err != nil {
-		return "", models.ErrIncorrectCredentials
+		return nil, models.ErrIncorrectCredentials
+	}
+
+	// проверка до сравнения пароля, чтобы перебор не нагружал CPU
+	if err := s.limiter.Allow(ctx, cred.Login, client.IP); err != nil {
+		if errors.Is(err, models.ErrTooManyAttempts) {
+			return nil, err
+		}
+		s.log.Error("login limiter allow", logger.Error(err))
 	}
 
 	user, err := s.storage.User(ctx, cred.Login)
 	if err != nil {
 		switch {
 		case errors.Is(err, storage.ErrNoRecordsFound):
-			return "", models.ErrIncorrectCredentials
+			s.loginFailed(ctx, cred.Login, client.IP)
+			return nil, models.ErrIncorrectCredentials
 		default:
-			return "", fmt.Errorf("storage user %v: %w", err, models.ErrInternal)
+			return nil, fmt.Errorf("storage user %v: %w", err, models.ErrInternal)
 		}
 	}
 
@@ -76,47 +305,96 @@ func (s *service) Login(ctx context.Context, cred models.Credentials) (string, e
 	); err != nil {
 		switch {
 		case errors.Is(err, models.ErrMismatchedHashAndPassword):
-			return "", models.ErrIncorrectCredentials
+			s.loginFailed(ctx, cred.Login, client.IP)
+			return nil, models.ErrIncorrectCredentials
 		default:
-			return "", fmt.Errorf("compare hash and password %v: %w", err, models.ErrInternal)
+			return nil, fmt.Errorf("compare hash and password %v: %w", err, models.ErrInternal)
 		}
 	}
 
-	token, err := jwt.NewToken(user.UserID, time.Minute*15, s.privateKey)
+	// статус проверяется после пароля, чтобы не раскрывать его без знания пароля
+	if err := userFromStorage(user).Active(); err != nil {
+		return nil, err
+	}
+
+	if s.generator.NeedsRehash(user.Password) {
+		s.rehashPassword(ctx, user.UserID, user.Password, cred.Password)
+	}
+
+	// с включенной 2FA токены выдаются после второго шага, счётчик неудачных
+	// попыток сбрасывается только после него, иначе повторный ввод пароля
+	// открывал бы новые попытки подбора кода
+	if err := s.requireSecondFactor(ctx, user.UserID); err != nil {
+		return nil, err
+	}
+
+	s.loginSucceeded(ctx, cred.Login)
+
+	return s.issueTokens(ctx, userFromStorage(user), user.Generation, client)
+}
+
+// rehashPassword переводит хеш на текущий алгоритм и параметры,
+// пока пароль известен в открытом виде. Хеш заменяется, только если
+// пароль не сменили после чтения пользователя. Ошибка не мешает входу.
+func (s *service) rehashPassword(ctx context.Context, userID string, oldHash []byte, password string) {
+	passHash, err := s.generator.GenerateFromPassword([]byte(password))
 	if err != nil {
-		return "", fmt.Errorf("token generation %v: %w", err, models.ErrInternal)
+		s.log.Error("rehash password", logger.Error(err))
+		return
 	}
 
-	return token, nil
+	if err := s.storage.RehashPassword(ctx, userID, oldHash, passHash); err != nil {
+		switch {
+		case errors.Is(err, storage.ErrNoRecordsFound):
+			s.log.Debug("password changed concurrently, rehash skipped",
+				slog.String("user_id", userID),
+			)
+		default:
+			s.log.Error("rehash password hash", logger.Error(err))
+		}
+	}
 }
 
-func (s *service) Register(ctx context.Context, cred models.Credentials) (string, error) {
+// loginSucceeded сбрасывает счётчик неудачных попыток после полной аутентификации
+func (s *service) loginSucceeded(ctx context.Context, login string) {
+	if err := s.limiter.Success(ctx, login); err != nil {
+		s.log.Error("login limiter success", logger.Error(err))
+	}
+}
 
-	if err := cred.Validate(); err != nil {
-		return "", models.ErrIncorrectCredentials
+// loginFailed учитывает неудачную попытку, ошибка счётчика не мешает ответу клиенту
+func (s *service) loginFailed(ctx context.Context, login, ip string) {
+	if err := s.limiter.Failure(ctx, login, ip); err != nil {
+		s.log.Error("login limiter failure", logger.Error(err))
+	}
+}
+
+func (s *service) Register(ctx context.Context, cred models.Credentials, client models.Client) (*models.Tokens, error) {
+
+	if err := s.policy.Validate(cred); err != nil {
+		return nil, err
 	}
 
 	passHash, err := s.generator.GenerateFromPassword([]byte(cred.Password))
 	if err != nil {
-		return "", fmt.Errorf("generate hash password %v: %w", err, models.ErrInternal)
+		return nil, fmt.Errorf("generate hash password %v: %w", err, models.ErrInternal)
 	}
 
 	userUUID, err := s.storage.CreateUser(ctx, cred.Login, passHash)
 	if err != nil {
 		switch {
 		case errors.Is(err, storage.ErrUniqueViolation):
-			return "", models.ErrLoginAlreadyExists
+			return nil, models.ErrLoginAlreadyExists
 		default:
-			return "", fmt.Errorf("create user %v: %w", err, models.ErrInternal)
+			return nil, fmt.Errorf("create user %v: %w", err, models.ErrInternal)
 		}
 	}
 
-	token, err := jwt.NewToken(userUUID, time.Minute*15, s.privateKey)
-	if err != nil {
-		return "", fmt.Errorf("token generation %v: %w", err, models.ErrInternal)
-	}
-
-	return token, nil
+	return s.issueTokens(ctx, models.User{
+		UserID: models.UserID(userUUID),
+		Login:  cred.Login,
+		Role:   models.RoleUser,
+	}, 0, client)
 
 }
 
@@ -197,7 +475,49 @@ func (s *service) UserBalance(ctx context.Context, userID models.UserID) (*model
 
 	balance, err := s.storage.UserBalance(ctx, string(userID))
 	if err != nil {
-		return nil, fmt.Errorf("user balance %v: %w", err, models.ErrInternal)
+		switch {
+		case errors.Is(err, storage.ErrNoRecordsFound):
+			return nil, models.ErrNoRecordsFound
+		default:
+			return nil, fmt.Errorf("user balance %v: %w", err, models.ErrInternal)
+		}
+	}
+
+	result := &models.Balance{
+		Current:   balance.Current,
+		Withdrawn: balance.Withdrawn,
+	}
+
+	if s.expiringSoon > 0 {
+		expiring, err := s.storage.ExpiringPoints(ctx, string(userID), time.Now().Add(s.expiringSoon))
+		if err != nil && !errors.Is(err, storage.ErrNoRecordsFound) {
+			return nil, fmt.Errorf("expiring points %v: %w", err, models.ErrInternal)
+		}
+		for _, p := range expiring {
+			result.ExpiringSoon = append(result.ExpiringSoon, models.ExpiringPoints{
+				Amount:    p.Amount,
+				ExpiresAt: p.ExpiresAt,
+			})
+		}
+	}
+
+	return result, nil
+}
+
+// BalanceAt остатки пользователя, пересчитанные по журналу баллов на момент at
+func (s *service) BalanceAt(ctx context.Context, userID models.UserID, at time.Time) (*models.Balance, error) {
+	if !userID.Validate() {
+		return nil, models.ErrUserIDMandatory
+	}
+
+	balance, err := s.storage.LedgerBalance(ctx, string(userID), at)
+	if err != nil {
+		switch {
+		case errors.Is(err, storage.ErrNoRecordsFound):
+			return nil, models.ErrNoRecordsFound
+		default:
+			return nil, fmt.Errorf("ledger balance %v: %w", err, models.ErrInternal)
+		}
 	}
 
 	return &models.Balance{
@@ -233,6 +553,36 @@ func (s *service) WithdrawalsByUserID(ctx context.Context, userID models.UserID)
 	return withdrawals, nil
 }
 
+// BalanceHistory история начислений, списаний и корректировок баланса
+func (s *service) BalanceHistory(ctx context.Context, userID models.UserID) ([]models.BalanceEntry, error) {
+	if !userID.Validate() {
+		return nil, models.ErrUserIDMandatory
+	}
+
+	dbEntries, err := s.storage.BalanceHistory(ctx, string(userID))
+	if err != nil {
+		switch {
+		case errors.Is(err, storage.ErrNoRecordsFound):
+			return nil, models.ErrNoRecordsFound
+		default:
+			return nil, fmt.Errorf("balance history %v: %w", err, models.ErrInternal)
+		}
+	}
+
+	entries := make([]models.BalanceEntry, 0, len(dbEntries))
+	for _, e := range dbEntries {
+		entries = append(entries, models.BalanceEntry{
+			Type:        e.Type,
+			Order:       models.OrderID(e.Order),
+			Reason:      models.AdjustmentReason(e.Reason),
+			Sum:         e.Sum,
+			ProcessedAt: e.ProcessedAt,
+		})
+	}
+
+	return entries, nil
+}
+
 func (s *service) Withdraw(ctx context.Context, userID models.UserID, withdraw models.WithdrawBonuses) error {
 	if !userID.Validate() {
 		return models.ErrUserIDMandatory
@@ -242,11 +592,17 @@ func (s *service) Withdraw(ctx context.Context, userID models.UserID, withdraw m
 		return models.ErrIncorrectOrderNumber
 	}
 
+	if err := withdraw.Validate(); err != nil {
+		return err
+	}
+
 	if err := s.storage.Withdraw(ctx, string(userID), storage.WithdrawBonuses{
 		Order: string(withdraw.Order),
 		Sum:   withdraw.Sum,
 	}); err != nil {
 		switch {
+		case errors.Is(err, storage.ErrWithdrawalExists):
+			return s.withdrawalExists(ctx, userID, withdraw.Order)
 		case errors.Is(err, storage.ErrConstraints):
 			return models.ErrInsufficientFunds
 		default:
@@ -257,3 +613,36 @@ func (s *service) Withdraw(ctx context.Context, userID models.UserID, withdraw m
 	return nil
 
 }
+
+// withdrawalExists ошибка повторного списания с уже выполненным списанием
+func (s *service) withdrawalExists(ctx context.Context, userID models.UserID, orderID models.OrderID) error {
+	withdrawal, err := s.storage.Withdrawal(ctx, string(userID), string(orderID))
+	if err != nil {
+		return fmt.Errorf("withdrawal %v: %w", err, models.ErrInternal)
+	}
+
+	return &models.WithdrawalExistsError{
+		Withdrawal: models.WithdrawalsBonuses{
+			Order:       models.OrderID(withdrawal.Order),
+			Sum:         withdrawal.Sum,
+			ProcessedAt: withdrawal.ProcessedAt,
+		},
+	}
+}
+
+func userFromStorage(u *storage.User) models.User {
+	return models.User{
+		UserID:    models.UserID(u.UserID),
+		Login:     u.Login,
+		IsBlocked: u.IsBlocked,
+		IsDeleted: u.IsDeleted,
+		Role:      models.Role(u.Role),
+	}
+}
+
+// noLimit попытки входа не ограничиваются
+type noLimit struct{}
+
+func (noLimit) Allow(context.Context, string, string) error   { return nil }
+func (noLimit) Failure(context.Context, string, string) error { return nil }
+func (noLimit) Success(context.Context, string) error         { return nil }
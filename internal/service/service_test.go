@@ -2,8 +2,6 @@ package service
 
 import (
 	"context"
-	"crypto/rand"
-	"crypto/rsa"
 	"fmt"
 	"testing"
 	"time"
@@ -13,12 +11,14 @@ import (
 	"github.com/stretchr/testify/require"
 	"github.com/vladislav-kr/gophermart/internal/clients"
 	"github.com/vladislav-kr/gophermart/internal/domain/models"
+	"github.com/vladislav-kr/gophermart/internal/domain/money"
+	"github.com/vladislav-kr/gophermart/internal/service/jwt"
 	"github.com/vladislav-kr/gophermart/internal/service/mocks"
 	"github.com/vladislav-kr/gophermart/internal/storage"
 )
 
-func testRSAPrivateKey(t *testing.T) *rsa.PrivateKey {
-	k, err := rsa.GenerateKey(rand.Reader, 2<<10)
+func testKeySet(t *testing.T) *jwt.KeySet {
+	k, err := jwt.GenerateKeySet()
 	require.NoError(t, err)
 	return k
 }
@@ -27,7 +27,7 @@ func Test_service_Login(t *testing.T) {
 	stor := mocks.NewStorage(t)
 	gen := mocks.NewPasswordGenerator(t)
 
-	srv := NewService(gen, stor, nil, testRSAPrivateKey(t))
+	srv := NewService(gen, stor, nil, testKeySet(t))
 
 	type mockArgs struct {
 		callStorage   bool
@@ -35,6 +35,8 @@ func Test_service_Login(t *testing.T) {
 		user          *storage.User
 		err           error
 		errGen        error
+		rehash        bool
+		errRehash     error
 	}
 	type args struct {
 		cred models.Credentials
@@ -53,7 +55,7 @@ func Test_service_Login(t *testing.T) {
 			args: args{
 				cred: models.Credentials{
 					Login:    "mylogin",
-					Password: "1234",
+					Password: "",
 				},
 			},
 			wantErr: models.ErrIncorrectCredentials,
@@ -135,6 +137,48 @@ func Test_service_Login(t *testing.T) {
 			},
 			wantErr: models.ErrInternal,
 		},
+		{
+			name:    "пользователь заблокирован",
+			service: srv,
+			args: args{
+				cred: models.Credentials{
+					Login:    "mylogin7",
+					Password: "123457777789",
+				},
+				mock: mockArgs{
+					callStorage:   true,
+					callGenerator: true,
+					user: &storage.User{
+						UserID:    "3b1a3c52-6ab5-4f1e-9d4c-ef2d7a1f0b11",
+						Login:     "mylogin7",
+						Password:  []byte("123457777789"),
+						IsBlocked: true,
+					},
+				},
+			},
+			wantErr: models.ErrUserBlocked,
+		},
+		{
+			name:    "пользователь удален",
+			service: srv,
+			args: args{
+				cred: models.Credentials{
+					Login:    "mylogin8",
+					Password: "123458888889",
+				},
+				mock: mockArgs{
+					callStorage:   true,
+					callGenerator: true,
+					user: &storage.User{
+						UserID:    "8c0f4e3b-1d6a-4c8e-b7a2-5e9f3d1c2b44",
+						Login:     "mylogin8",
+						Password:  []byte("123458888889"),
+						IsDeleted: true,
+					},
+				},
+			},
+			wantErr: models.ErrUserDeleted,
+		},
 		{
 			name:    "успешная аутентификация",
 			service: srv,
@@ -156,6 +200,49 @@ func Test_service_Login(t *testing.T) {
 			},
 			wantErr: nil,
 		},
+		{
+			name:    "хеш пароля устарел",
+			service: srv,
+			args: args{
+				cred: models.Credentials{
+					Login:    "mylogin10",
+					Password: "1234510101010",
+				},
+				mock: mockArgs{
+					callStorage:   true,
+					callGenerator: true,
+					user: &storage.User{
+						UserID:   "9c0f4e3b-1d6a-4c8e-b7a2-5e9f3d1c2b44",
+						Login:    "mylogin10",
+						Password: []byte("$2a$10$old-bcrypt-hash"),
+					},
+					rehash: true,
+				},
+			},
+			wantErr: nil,
+		},
+		{
+			name:    "пароль сменили до пересчета хеша",
+			service: srv,
+			args: args{
+				cred: models.Credentials{
+					Login:    "mylogin11",
+					Password: "1234511111111",
+				},
+				mock: mockArgs{
+					callStorage:   true,
+					callGenerator: true,
+					user: &storage.User{
+						UserID:   "8c0f4e3b-1d6a-4c8e-b7a2-5e9f3d1c2b45",
+						Login:    "mylogin11",
+						Password: []byte("$2a$10$stale-bcrypt-hash"),
+					},
+					rehash:    true,
+					errRehash: storage.ErrNoRecordsFound,
+				},
+			},
+			wantErr: nil,
+		},
 	}
 
 	for _, tt := range tests {
@@ -174,29 +261,127 @@ func Test_service_Login(t *testing.T) {
 					[]byte(tt.args.cred.Password),
 				).Return(tt.args.mock.errGen)
 			}
+			if tt.wantErr == nil {
+				gen.On("NeedsRehash", tt.args.mock.user.Password).Return(tt.args.mock.rehash)
+			}
+			if tt.args.mock.rehash {
+				newHash := []byte("$argon2id$v=19$new-hash")
+				gen.On("GenerateFromPassword", []byte(tt.args.cred.Password)).Return(newHash, nil).Once()
+				stor.On("RehashPassword",
+					mock.AnythingOfType("*context.timerCtx"),
+					tt.args.mock.user.UserID,
+					tt.args.mock.user.Password,
+					newHash,
+				).Return(tt.args.mock.errRehash).Once()
+			}
+			if tt.wantErr == nil {
+				stor.On("TOTP",
+					mock.AnythingOfType("*context.timerCtx"),
+					tt.args.mock.user.UserID,
+				).Return(nil, storage.ErrNoRecordsFound)
+				stor.On("SaveSession",
+					mock.AnythingOfType("*context.timerCtx"),
+					mock.MatchedBy(func(session storage.Session) bool {
+						return session.UserID == tt.args.mock.user.UserID &&
+							session.IP == "127.0.0.1"
+					}),
+				).Return(nil)
+				stor.On("CreateRefreshToken",
+					mock.AnythingOfType("*context.timerCtx"),
+					mock.MatchedBy(func(token storage.RefreshToken) bool {
+						return token.UserID == tt.args.mock.user.UserID
+					}),
+				).Return(nil)
+			}
 
 			ctx, cancel := context.WithTimeout(context.Background(), time.Second*4)
 			defer cancel()
 
-			token, err := tt.service.Login(ctx, tt.args.cred)
+			tokens, err := tt.service.Login(ctx, tt.args.cred, models.Client{IP: "127.0.0.1"})
 
 			if tt.wantErr != nil {
 				assert.ErrorAs(t, err, &tt.wantErr)
-				assert.Empty(t, token)
+				assert.Nil(t, tokens)
 				return
 			}
 
 			assert.NoError(t, err)
-			assert.NotEmpty(t, token)
+			assert.NotEmpty(t, tokens.AccessToken)
+			assert.NotEmpty(t, tokens.RefreshToken)
 
 		})
 	}
 }
 
+func Test_service_Login_limiter(t *testing.T) {
+	const (
+		login = "mylogin9"
+		ip    = "10.0.0.1"
+	)
+	cred := models.Credentials{
+		Login:    login,
+		Password: "123459999999",
+	}
+	client := models.Client{IP: ip}
+
+	t.Run("попытка входа запрещена", func(t *testing.T) {
+		t.Parallel()
+		limiter := mocks.NewLoginLimiter(t)
+		srv := NewService(nil, mocks.NewStorage(t), nil, nil, WithLoginLimiter(limiter))
+
+		limiter.On("Allow", mock.Anything, login, ip).
+			Return(&models.TooManyAttemptsError{RetryAfter: time.Second}).Once()
+
+		_, err := srv.Login(context.Background(), cred, client)
+		assert.ErrorIs(t, err, models.ErrTooManyAttempts)
+	})
+
+	t.Run("неудачная попытка учитывается", func(t *testing.T) {
+		t.Parallel()
+		limiter := mocks.NewLoginLimiter(t)
+		stor := mocks.NewStorage(t)
+		srv := NewService(nil, stor, nil, nil, WithLoginLimiter(limiter))
+
+		limiter.On("Allow", mock.Anything, login, ip).Return(nil).Once()
+		stor.On("User", mock.Anything, login).Return(nil, storage.ErrNoRecordsFound).Once()
+		limiter.On("Failure", mock.Anything, login, ip).Return(nil).Once()
+
+		_, err := srv.Login(context.Background(), cred, client)
+		assert.ErrorIs(t, err, models.ErrIncorrectCredentials)
+	})
+
+	t.Run("успешный вход сбрасывает счётчик", func(t *testing.T) {
+		t.Parallel()
+		limiter := mocks.NewLoginLimiter(t)
+		stor := mocks.NewStorage(t)
+		gen := mocks.NewPasswordGenerator(t)
+		srv := NewService(gen, stor, nil, testKeySet(t), WithLoginLimiter(limiter))
+
+		user := &storage.User{
+			UserID:   "1cf50925-d72d-488b-94e5-426acce77f3c",
+			Login:    login,
+			Password: []byte(cred.Password),
+		}
+		limiter.On("Allow", mock.Anything, login, ip).Return(fmt.Errorf("db error")).Once()
+		stor.On("User", mock.Anything, login).Return(user, nil).Once()
+		gen.On("CompareHashAndPassword", user.Password, []byte(cred.Password)).Return(nil).Once()
+		gen.On("NeedsRehash", user.Password).Return(false).Once()
+		limiter.On("Success", mock.Anything, login).Return(nil).Once()
+		stor.On("TOTP", mock.Anything, user.UserID).Return(nil, storage.ErrNoRecordsFound).Once()
+		stor.On("SaveSession", mock.Anything, mock.AnythingOfType("storage.Session")).Return(nil).Once()
+		stor.On("CreateRefreshToken", mock.Anything, mock.AnythingOfType("storage.RefreshToken")).Return(nil).Once()
+
+		// ошибка счётчика не блокирует вход
+		tokens, err := srv.Login(context.Background(), cred, client)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, tokens.AccessToken)
+	})
+}
+
 func Test_service_Register(t *testing.T) {
 	stor := mocks.NewStorage(t)
 	gen := mocks.NewPasswordGenerator(t)
-	srv := NewService(gen, stor, nil, testRSAPrivateKey(t))
+	srv := NewService(gen, stor, nil, testKeySet(t))
 
 	type mockArgs struct {
 		callStorage   bool
@@ -225,7 +410,7 @@ func Test_service_Register(t *testing.T) {
 					Password: "12345",
 				},
 			},
-			wantErr: models.ErrIncorrectCredentials,
+			wantErr: models.ErrValidation,
 		},
 		{
 			name:    "хеш пароля не сгенерировался",
@@ -233,7 +418,7 @@ func Test_service_Register(t *testing.T) {
 			args: args{
 				cred: models.Credentials{
 					Login:    "mylogin2",
-					Password: "1234566",
+					Password: "12345667",
 				},
 				mock: mockArgs{
 					callGenerator: true,
@@ -312,25 +497,176 @@ func Test_service_Register(t *testing.T) {
 					[]byte(tt.args.cred.Password),
 				).Return(tt.args.mock.passHash, tt.args.mock.errGen)
 			}
+			if tt.wantErr == nil {
+				stor.On("SaveSession",
+					mock.AnythingOfType("*context.timerCtx"),
+					mock.MatchedBy(func(session storage.Session) bool {
+						return session.UserID == tt.args.mock.userUUID
+					}),
+				).Return(nil)
+				stor.On("CreateRefreshToken",
+					mock.AnythingOfType("*context.timerCtx"),
+					mock.MatchedBy(func(token storage.RefreshToken) bool {
+						return token.UserID == tt.args.mock.userUUID
+					}),
+				).Return(nil)
+			}
 
 			ctx, cancel := context.WithTimeout(context.Background(), time.Second*4)
 			defer cancel()
 
-			token, err := tt.service.Register(ctx, tt.args.cred)
+			tokens, err := tt.service.Register(ctx, tt.args.cred, models.Client{})
 
 			if tt.wantErr != nil {
 				assert.ErrorAs(t, err, &tt.wantErr)
-				assert.Empty(t, token)
+				assert.Nil(t, tokens)
 				return
 			}
 
 			assert.NoError(t, err)
-			assert.NotEmpty(t, token)
+			assert.NotEmpty(t, tokens.AccessToken)
+			assert.NotEmpty(t, tokens.RefreshToken)
 
 		})
 	}
 }
 
+func Test_service_Refresh(t *testing.T) {
+	stor := mocks.NewStorage(t)
+	srv := NewService(nil, stor, nil, testKeySet(t))
+
+	type mockArgs struct {
+		callStorage bool
+		rotated     *storage.RefreshToken
+		err         error
+	}
+	type args struct {
+		refreshToken string
+		mock         mockArgs
+	}
+	tests := []struct {
+		name    string
+		service *service
+		args    args
+		wantErr error
+	}{
+		{
+			name:    "пустой refresh токен",
+			service: srv,
+			args: args{
+				refreshToken: "",
+			},
+			wantErr: models.ErrInvalidRefreshToken,
+		},
+		{
+			name:    "refresh токен не найден",
+			service: srv,
+			args: args{
+				refreshToken: "refresh-token-1",
+				mock: mockArgs{
+					callStorage: true,
+					err:         storage.ErrNoRecordsFound,
+				},
+			},
+			wantErr: models.ErrInvalidRefreshToken,
+		},
+		{
+			name:    "срок действия refresh токена истек",
+			service: srv,
+			args: args{
+				refreshToken: "refresh-token-2",
+				mock: mockArgs{
+					callStorage: true,
+					err:         storage.ErrTokenExpired,
+				},
+			},
+			wantErr: models.ErrInvalidRefreshToken,
+		},
+		{
+			name:    "повторное использование refresh токена",
+			service: srv,
+			args: args{
+				refreshToken: "refresh-token-3",
+				mock: mockArgs{
+					callStorage: true,
+					err:         storage.ErrTokenReused,
+				},
+			},
+			wantErr: models.ErrRefreshTokenReused,
+		},
+		{
+			name:    "ошибка хранилища",
+			service: srv,
+			args: args{
+				refreshToken: "refresh-token-4",
+				mock: mockArgs{
+					callStorage: true,
+					err:         fmt.Errorf("db error"),
+				},
+			},
+			wantErr: models.ErrInternal,
+		},
+		{
+			name:    "токены успешно обновлены",
+			service: srv,
+			args: args{
+				refreshToken: "refresh-token-5",
+				mock: mockArgs{
+					callStorage: true,
+					rotated: &storage.RefreshToken{
+						TokenID:  "0e1e3f44-5f0c-4e2c-9f0a-64b2ee1a4a3c",
+						FamilyID: "7d4c1b0a-2a39-4a0e-8f3c-1f8a4b2f1e55",
+						UserID:   "1cf50925-d72d-488b-94e5-426acce77f3c",
+					},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if tt.args.mock.callStorage {
+				stor.On("RotateRefreshToken",
+					mock.AnythingOfType("*context.timerCtx"),
+					hashToken(tt.args.refreshToken),
+					mock.AnythingOfType("storage.RefreshToken"),
+				).Return(tt.args.mock.rotated, tt.args.mock.err)
+			}
+			if tt.args.mock.rotated != nil {
+				stor.On("UserByID",
+					mock.AnythingOfType("*context.timerCtx"),
+					tt.args.mock.rotated.UserID,
+				).Return(&storage.User{UserID: tt.args.mock.rotated.UserID}, nil)
+				stor.On("SaveSession",
+					mock.AnythingOfType("*context.timerCtx"),
+					mock.MatchedBy(func(session storage.Session) bool {
+						return session.SessionID == tt.args.mock.rotated.FamilyID &&
+							session.UserAgent == "test-agent"
+					}),
+				).Return(nil)
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second*4)
+			defer cancel()
+
+			tokens, err := tt.service.Refresh(ctx, tt.args.refreshToken, models.Client{UserAgent: "test-agent"})
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Nil(t, tokens)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.NotEmpty(t, tokens.AccessToken)
+			assert.NotEmpty(t, tokens.RefreshToken)
+			assert.NotEqual(t, tt.args.refreshToken, tokens.RefreshToken)
+		})
+	}
+}
+
 func Test_service_Order(t *testing.T) {
 
 	stor := mocks.NewStorage(t)
@@ -442,7 +778,7 @@ func Test_service_Order(t *testing.T) {
 						accrualOrder: &clients.OrderAccrual{
 							Order:   "23772256246",
 							Status:  "REGISTERED",
-							Accural: 0,
+							Accural: money.FromInt(0),
 						},
 					},
 					creOrd: mockCreOrd{
@@ -450,7 +786,7 @@ func Test_service_Order(t *testing.T) {
 						order: storage.CreateOrder{
 							OrderID: "23772256246",
 							Status:  "NEW",
-							Accrual: 0,
+							Accrual: money.FromInt(0),
 						},
 						err: storage.ErrInternal,
 					},
@@ -470,7 +806,7 @@ func Test_service_Order(t *testing.T) {
 						accrualOrder: &clients.OrderAccrual{
 							Order:   "23772256659",
 							Status:  "REGISTERED",
-							Accural: 0,
+							Accural: money.FromInt(0),
 						},
 					},
 					creOrd: mockCreOrd{
@@ -478,7 +814,7 @@ func Test_service_Order(t *testing.T) {
 						order: storage.CreateOrder{
 							OrderID: "23772256659",
 							Status:  "NEW",
-							Accrual: 0,
+							Accrual: money.FromInt(0),
 						},
 					},
 				},
@@ -578,7 +914,7 @@ func Test_service_OrdersByUserID(t *testing.T) {
 							OrderID:    "2377225624",
 							Status:     "PROCESSED",
 							UploadedAt: time.Time{},
-							Accrual:    500,
+							Accrual:    money.FromInt(500),
 						},
 					},
 					err: nil,
@@ -589,7 +925,7 @@ func Test_service_OrdersByUserID(t *testing.T) {
 					OrderID:    "2377225624",
 					Status:     "PROCESSED",
 					UploadedAt: time.Time{},
-					Accrual:    500,
+					Accrual:    money.FromInt(500),
 				},
 			},
 			wantErr: nil,
@@ -670,15 +1006,15 @@ func Test_service_UserBalance(t *testing.T) {
 				mock: mockArgs{
 					call: true,
 					balance: &storage.Balance{
-						Current:   500,
-						Withdrawn: 200,
+						Current:   money.FromInt(500),
+						Withdrawn: money.FromInt(200),
 					},
 					err: nil,
 				},
 			},
 			wantBalance: &models.Balance{
-				Current:   500,
-				Withdrawn: 200,
+				Current:   money.FromInt(500),
+				Withdrawn: money.FromInt(200),
 			},
 			wantErr: nil,
 		},
@@ -711,6 +1047,90 @@ func Test_service_UserBalance(t *testing.T) {
 	}
 }
 
+func Test_service_BalanceAt(t *testing.T) {
+	stor := mocks.NewStorage(t)
+	srv := NewService(nil, stor, nil, nil)
+	at := time.Date(2024, 4, 1, 12, 0, 0, 0, time.UTC)
+	type mockArgs struct {
+		call    bool
+		balance *storage.Balance
+		err     error
+	}
+	tests := []struct {
+		name        string
+		userID      models.UserID
+		mock        mockArgs
+		wantErr     error
+		wantBalance *models.Balance
+	}{
+		{
+			name:    "некорректный id пользователя",
+			userID:  "user_id_1",
+			wantErr: models.ErrUserIDMandatory,
+		},
+		{
+			name:   "пользователь не найден",
+			userID: "0f1b8ef8-2b51-4a6b-9d3c-3c3f0c2d7a10",
+			mock: mockArgs{
+				call: true,
+				err:  storage.ErrNoRecordsFound,
+			},
+			wantErr: models.ErrNoRecordsFound,
+		},
+		{
+			name:   "ошибка журнала",
+			userID: "6d8f4b0e-6f5c-4f4e-8f58-1e0f64f0d1c2",
+			mock: mockArgs{
+				call: true,
+				err:  fmt.Errorf("internal"),
+			},
+			wantErr: models.ErrInternal,
+		},
+		{
+			name:   "остатки пересчитаны по журналу",
+			userID: "a4c2e6d1-9b3f-4d8e-b7a2-5f1e3c9d0b47",
+			mock: mockArgs{
+				call: true,
+				balance: &storage.Balance{
+					Current:   money.FromInt(300),
+					Withdrawn: money.FromInt(100),
+				},
+			},
+			wantBalance: &models.Balance{
+				Current:   money.FromInt(300),
+				Withdrawn: money.FromInt(100),
+			},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if tt.mock.call {
+				stor.On("LedgerBalance",
+					mock.AnythingOfType("*context.timerCtx"),
+					string(tt.userID),
+					at,
+				).Return(tt.mock.balance, tt.mock.err)
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second*4)
+			defer cancel()
+
+			balance, err := srv.BalanceAt(ctx, tt.userID, at)
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Nil(t, balance)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantBalance, balance)
+		})
+	}
+}
+
 func Test_service_WithdrawalsByUserID(t *testing.T) {
 	stor := mocks.NewStorage(t)
 	srv := NewService(nil, stor, nil, nil)
@@ -760,7 +1180,7 @@ func Test_service_WithdrawalsByUserID(t *testing.T) {
 					withdrawals: []storage.WithdrawalsBonuses{
 						{
 							Order:       "12345678903",
-							Sum:         500,
+							Sum:         money.FromInt(500),
 							ProcessedAt: time.Time{},
 						},
 					},
@@ -769,7 +1189,7 @@ func Test_service_WithdrawalsByUserID(t *testing.T) {
 			wantWithdrawals: []models.WithdrawalsBonuses{
 				{
 					Order:       "12345678903",
-					Sum:         500,
+					Sum:         money.FromInt(500),
 					ProcessedAt: time.Time{},
 				},
 			},
@@ -841,6 +1261,29 @@ func Test_service_Withdraw(t *testing.T) {
 			},
 			wantErr: models.ErrIncorrectOrderNumber,
 		},
+		{
+			name:    "нулевая сумма списания",
+			service: srv,
+			args: args{
+				userID: "4de614bf-4f57-495f-aa03-71410472e707",
+				withdraw: models.WithdrawBonuses{
+					Order: "12345678903",
+				},
+			},
+			wantErr: models.ErrInvalidSum,
+		},
+		{
+			name:    "отрицательная сумма списания",
+			service: srv,
+			args: args{
+				userID: "4de614bf-4f57-495f-aa03-71410472e707",
+				withdraw: models.WithdrawBonuses{
+					Order: "12345678903",
+					Sum:   money.MustParse("-0.5"),
+				},
+			},
+			wantErr: models.ErrInvalidSum,
+		},
 		{
 			name:    "для списания недостаточно средств",
 			service: srv,
@@ -850,13 +1293,13 @@ func Test_service_Withdraw(t *testing.T) {
 					call: true,
 					withdraw: storage.WithdrawBonuses{
 						Order: "12345678903",
-						Sum:   500,
+						Sum:   money.FromInt(500),
 					},
 					err: storage.ErrConstraints,
 				},
 				withdraw: models.WithdrawBonuses{
 					Order: "12345678903",
-					Sum:   500,
+					Sum:   money.FromInt(500),
 				},
 			},
 			wantErr: models.ErrInsufficientFunds,
@@ -870,13 +1313,13 @@ func Test_service_Withdraw(t *testing.T) {
 					call: true,
 					withdraw: storage.WithdrawBonuses{
 						Order: "2377225624",
-						Sum:   300,
+						Sum:   money.FromInt(300),
 					},
 					err: nil,
 				},
 				withdraw: models.WithdrawBonuses{
 					Order: "2377225624",
-					Sum:   300,
+					Sum:   money.FromInt(300),
 				},
 			},
 			wantErr: nil,
@@ -910,3 +1353,34 @@ func Test_service_Withdraw(t *testing.T) {
 		})
 	}
 }
+
+func Test_service_WithdrawExists(t *testing.T) {
+	const userID = "4de614bf-4f57-495f-aa03-71410472e707"
+
+	stor := mocks.NewStorage(t)
+	srv := NewService(nil, stor, nil, nil)
+
+	processedAt := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	withdraw := storage.WithdrawBonuses{Order: "2377225624", Sum: money.FromInt(300)}
+
+	stor.On("Withdraw", mock.Anything, userID, withdraw).Return(
+		fmt.Errorf("withdrawals insert: %w", storage.ErrWithdrawalExists),
+	).Once()
+	stor.On("Withdrawal", mock.Anything, userID, "2377225624").Return(&storage.WithdrawalsBonuses{
+		Order:       "2377225624",
+		Sum:         money.FromInt(250),
+		ProcessedAt: processedAt,
+	}, nil).Once()
+
+	err := srv.Withdraw(context.Background(), userID, models.WithdrawBonuses{Order: "2377225624", Sum: money.FromInt(300)})
+
+	var exists *models.WithdrawalExistsError
+	require.ErrorAs(t, err, &exists)
+	assert.ErrorIs(t, err, models.ErrWithdrawalExists)
+	assert.NotErrorIs(t, err, models.ErrInsufficientFunds)
+	assert.Equal(t, models.WithdrawalsBonuses{
+		Order:       "2377225624",
+		Sum:         money.FromInt(250),
+		ProcessedAt: processedAt,
+	}, exists.Withdrawal)
+}
@@ -0,0 +1,74 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/google/uuid"
+	"github.com/vladislav-kr/gophermart/internal/domain/models"
+	"github.com/vladislav-kr/gophermart/internal/service/jwt"
+	"github.com/vladislav-kr/gophermart/internal/storage"
+)
+
+// Sessions действующие сессии владельца токена, текущая отмечена
+func (s *service) Sessions(ctx context.Context, claims jwt.Claims) ([]models.Session, error) {
+	if !models.UserID(claims.UserID).Validate() {
+		return nil, models.ErrUserIDMandatory
+	}
+
+	dbSessions, err := s.storage.Sessions(ctx, claims.UserID)
+	if err != nil {
+		switch {
+		case errors.Is(err, storage.ErrNoRecordsFound):
+			return nil, models.ErrNoRecordsFound
+		default:
+			return nil, fmt.Errorf("sessions %v: %w", err, models.ErrInternal)
+		}
+	}
+
+	sessions := make([]models.Session, 0, len(dbSessions))
+	for _, session := range dbSessions {
+		sessions = append(sessions, models.Session{
+			SessionID:  session.SessionID,
+			IP:         session.IP,
+			UserAgent:  session.UserAgent,
+			CreatedAt:  session.CreatedAt,
+			LastSeenAt: session.LastSeenAt,
+			Current:    session.SessionID == claims.SessionID,
+		})
+	}
+
+	return sessions, nil
+}
+
+// RevokeSession завершает сессию пользователя, ее токены перестают
+// приниматься сразу на этом экземпляре и по истечении кеша на остальных.
+// Чужая сессия считается ненайденной.
+func (s *service) RevokeSession(ctx context.Context, userID models.UserID, sessionID string) error {
+	if !userID.Validate() {
+		return models.ErrUserIDMandatory
+	}
+
+	if _, err := uuid.Parse(sessionID); err != nil {
+		return models.ErrNoRecordsFound
+	}
+
+	if err := s.storage.RevokeSession(ctx, string(userID), sessionID); err != nil {
+		switch {
+		case errors.Is(err, storage.ErrNoRecordsFound):
+			return models.ErrNoRecordsFound
+		default:
+			return fmt.Errorf("revoke session %v: %w", err, models.ErrInternal)
+		}
+	}
+	s.revokedSessions.Set(sessionID, true)
+
+	s.log.Info("session revoked",
+		slog.String("user_id", string(userID)),
+		slog.String("session_id", sessionID),
+	)
+
+	return nil
+}
@@ -0,0 +1,70 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/vladislav-kr/gophermart/internal/domain/models"
+	"github.com/vladislav-kr/gophermart/internal/service/jwt"
+	"github.com/vladislav-kr/gophermart/internal/service/mocks"
+	"github.com/vladislav-kr/gophermart/internal/storage"
+)
+
+func Test_service_Sessions(t *testing.T) {
+	const (
+		userID    = "1cf50925-d72d-488b-94e5-426acce77f3c"
+		currentID = "7d4c1b0a-2a39-4a0e-8f3c-1f8a4b2f1e55"
+		otherID   = "0e1e3f44-5f0c-4e2c-9f0a-64b2ee1a4a3c"
+	)
+
+	stor := mocks.NewStorage(t)
+	srv := NewService(nil, stor, nil, nil)
+
+	claims := jwt.Claims{UserID: userID, SessionID: currentID}
+
+	stor.On("Sessions", mock.Anything, userID).Return([]storage.Session{
+		{SessionID: otherID, UserID: userID, IP: "10.0.0.2", UserAgent: "curl/8.0"},
+		{SessionID: currentID, UserID: userID, IP: "10.0.0.1", UserAgent: "Mozilla/5.0"},
+	}, nil).Once()
+
+	sessions, err := srv.Sessions(context.Background(), claims)
+	require.NoError(t, err)
+	require.Len(t, sessions, 2)
+	assert.False(t, sessions[0].Current)
+	assert.True(t, sessions[1].Current)
+	assert.Equal(t, "Mozilla/5.0", sessions[1].UserAgent)
+
+	stor.On("Sessions", mock.Anything, userID).Return(nil, storage.ErrNoRecordsFound).Once()
+	_, err = srv.Sessions(context.Background(), claims)
+	assert.ErrorIs(t, err, models.ErrNoRecordsFound)
+}
+
+func Test_service_RevokeSession(t *testing.T) {
+	const (
+		userID    = "1cf50925-d72d-488b-94e5-426acce77f3c"
+		sessionID = "7d4c1b0a-2a39-4a0e-8f3c-1f8a4b2f1e55"
+	)
+
+	stor := mocks.NewStorage(t)
+	srv := NewService(nil, stor, nil, nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*4)
+	defer cancel()
+
+	stor.On("RevokeSession", mock.Anything, userID, sessionID).Return(nil).Once()
+	assert.NoError(t, srv.RevokeSession(ctx, userID, sessionID))
+
+	// токены завершенной сессии отклоняются без обращения к хранилищу
+	err := srv.ValidateToken(ctx, jwt.Claims{UserID: userID, TokenID: "token-1", SessionID: sessionID})
+	assert.ErrorIs(t, err, models.ErrTokenRevoked)
+
+	stor.On("RevokeSession", mock.Anything, userID, sessionID).Return(storage.ErrNoRecordsFound).Once()
+	assert.ErrorIs(t, srv.RevokeSession(ctx, userID, sessionID), models.ErrNoRecordsFound)
+
+	// некорректный идентификатор не доходит до хранилища
+	assert.ErrorIs(t, srv.RevokeSession(ctx, userID, "not-uuid"), models.ErrNoRecordsFound)
+}
@@ -0,0 +1,310 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lestrrat-go/jwx/v2/jwk"
+	"github.com/vladislav-kr/gophermart/internal/domain/models"
+	"github.com/vladislav-kr/gophermart/internal/service/jwt"
+	"github.com/vladislav-kr/gophermart/internal/storage"
+)
+
+// newOpaqueToken случайный токен для клиента и его хеш для хранилища
+func newOpaqueToken() (string, []byte, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", nil, err
+	}
+	token := base64.RawURLEncoding.EncodeToString(buf)
+	return token, hashToken(token), nil
+}
+
+func hashToken(token string) []byte {
+	sum := sha256.Sum256([]byte(token))
+	return sum[:]
+}
+
+func (s *service) newAccessToken(user models.User, generation int64, sessionID string) (string, error) {
+	return jwt.NewToken(jwt.Claims{
+		UserID:     string(user.UserID),
+		TokenID:    uuid.NewString(),
+		Generation: generation,
+		Role:       string(user.Role),
+		SessionID:  sessionID,
+	}, s.accessTokenTTL, s.keys)
+}
+
+// issueTokens открывает сессию и выпускает access токен и refresh токен нового семейства.
+// Семейство refresh токенов и есть сессия, их идентификаторы совпадают.
+func (s *service) issueTokens(
+	ctx context.Context,
+	user models.User,
+	generation int64,
+	client models.Client,
+) (*models.Tokens, error) {
+	sessionID := uuid.NewString()
+
+	accessToken, err := s.newAccessToken(user, generation, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("token generation %v: %w", err, models.ErrInternal)
+	}
+
+	refreshToken, refreshHash, err := newOpaqueToken()
+	if err != nil {
+		return nil, fmt.Errorf("refresh token generation %v: %w", err, models.ErrInternal)
+	}
+
+	expiresAt := time.Now().Add(s.refreshTokenTTL)
+
+	if err := s.storage.SaveSession(ctx, storage.Session{
+		SessionID: sessionID,
+		UserID:    string(user.UserID),
+		IP:        client.IP,
+		UserAgent: client.UserAgent,
+		ExpiresAt: expiresAt,
+	}); err != nil {
+		return nil, fmt.Errorf("save session %v: %w", err, models.ErrInternal)
+	}
+
+	if err := s.storage.CreateRefreshToken(ctx, storage.RefreshToken{
+		TokenID:   uuid.NewString(),
+		FamilyID:  sessionID,
+		UserID:    string(user.UserID),
+		TokenHash: refreshHash,
+		ExpiresAt: expiresAt,
+	}); err != nil {
+		return nil, fmt.Errorf("create refresh token %v: %w", err, models.ErrInternal)
+	}
+
+	return &models.Tokens{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int64(s.accessTokenTTL.Seconds()),
+	}, nil
+}
+
+// PublicKeys открытые ключи проверки токенов
+func (s *service) PublicKeys() jwk.Set {
+	return s.keys.PublicKeys()
+}
+
+// Refresh обменивает refresh токен на новую пару токенов.
+// Использованный токен больше не действителен, его повторное
+// предъявление отзывает все токены семейства.
+// Адрес и клиент сессии обновляются по последнему обмену.
+func (s *service) Refresh(ctx context.Context, refreshToken string, client models.Client) (*models.Tokens, error) {
+	if refreshToken == "" {
+		return nil, models.ErrInvalidRefreshToken
+	}
+
+	newRefreshToken, newRefreshHash, err := newOpaqueToken()
+	if err != nil {
+		return nil, fmt.Errorf("refresh token generation %v: %w", err, models.ErrInternal)
+	}
+
+	rotated, err := s.storage.RotateRefreshToken(ctx, hashToken(refreshToken), storage.RefreshToken{
+		TokenID:   uuid.NewString(),
+		TokenHash: newRefreshHash,
+		ExpiresAt: time.Now().Add(s.refreshTokenTTL),
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, storage.ErrNoRecordsFound),
+			errors.Is(err, storage.ErrTokenExpired):
+			return nil, models.ErrInvalidRefreshToken
+		case errors.Is(err, storage.ErrTokenReused):
+			s.log.Warn("refresh token reuse detected, token family revoked")
+			return nil, models.ErrRefreshTokenReused
+		default:
+			return nil, fmt.Errorf("rotate refresh token %v: %w", err, models.ErrInternal)
+		}
+	}
+
+	user, err := s.storage.UserByID(ctx, rotated.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("user by id %v: %w", err, models.ErrInternal)
+	}
+
+	u := userFromStorage(user)
+	if err := u.Active(); err != nil {
+		return nil, err
+	}
+
+	// семейства, выпущенные до появления сессий, получают сессию при первом обмене
+	if err := s.storage.SaveSession(ctx, storage.Session{
+		SessionID: rotated.FamilyID,
+		UserID:    rotated.UserID,
+		IP:        client.IP,
+		UserAgent: client.UserAgent,
+		ExpiresAt: rotated.ExpiresAt,
+	}); err != nil {
+		return nil, fmt.Errorf("save session %v: %w", err, models.ErrInternal)
+	}
+	s.revokedSessions.Delete(rotated.FamilyID)
+
+	accessToken, err := s.newAccessToken(u, user.Generation, rotated.FamilyID)
+	if err != nil {
+		return nil, fmt.Errorf("token generation %v: %w", err, models.ErrInternal)
+	}
+
+	return &models.Tokens{
+		AccessToken:  accessToken,
+		RefreshToken: newRefreshToken,
+		ExpiresIn:    int64(s.accessTokenTTL.Seconds()),
+	}, nil
+}
+
+// authState состояние пользователя, с которым сверяется каждый токен
+type authState struct {
+	generation int64
+	user       models.User
+}
+
+func (s *service) authState(ctx context.Context, userID string) (authState, error) {
+	if state, ok := s.authStates.Get(userID); ok {
+		return state, nil
+	}
+
+	user, err := s.storage.UserByID(ctx, userID)
+	if err != nil {
+		return authState{}, err
+	}
+
+	state := authState{
+		generation: user.Generation,
+		user:       userFromStorage(user),
+	}
+	s.authStates.Set(userID, state)
+
+	return state, nil
+}
+
+// ValidateToken проверяет, что access токен и его сессия не отозваны,
+// а пользователь не заблокирован после его выдачи
+func (s *service) ValidateToken(ctx context.Context, claims jwt.Claims) error {
+	if claims.SessionID != "" {
+		revoked, err := s.sessionRevoked(ctx, claims.SessionID)
+		if err != nil {
+			return err
+		}
+		if revoked {
+			return models.ErrTokenRevoked
+		}
+	}
+
+	if claims.TokenID != "" {
+		revoked, ok := s.revokedTokens.Get(claims.TokenID)
+		if !ok {
+			var err error
+			if revoked, err = s.storage.TokenRevoked(ctx, claims.TokenID); err != nil {
+				return fmt.Errorf("token revoked %v: %w", err, models.ErrInternal)
+			}
+			s.revokedTokens.Set(claims.TokenID, revoked)
+		}
+		if revoked {
+			return models.ErrTokenRevoked
+		}
+	}
+
+	state, err := s.authState(ctx, claims.UserID)
+	if err != nil {
+		switch {
+		case errors.Is(err, storage.ErrNoRecordsFound):
+			return models.ErrTokenRevoked
+		default:
+			return fmt.Errorf("user by id %v: %w", err, models.ErrInternal)
+		}
+	}
+
+	if err := state.user.Active(); err != nil {
+		return err
+	}
+
+	if claims.Generation < state.generation {
+		return models.ErrTokenRevoked
+	}
+
+	// после смены роли токен перевыпускается, чтобы права не расходились с хранилищем
+	if models.Role(claims.Role) != state.user.Role {
+		return models.ErrTokenRevoked
+	}
+
+	return nil
+}
+
+// sessionRevoked проверка сессии токена. Обращение к хранилищу
+// заодно отмечает активность, поэтому время последнего запроса
+// известно с точностью до времени жизни кеша.
+func (s *service) sessionRevoked(ctx context.Context, sessionID string) (bool, error) {
+	if revoked, ok := s.revokedSessions.Get(sessionID); ok {
+		return revoked, nil
+	}
+
+	revoked := false
+	if err := s.storage.TouchSession(ctx, sessionID); err != nil {
+		switch {
+		case errors.Is(err, storage.ErrNoRecordsFound):
+			revoked = true
+		default:
+			return false, fmt.Errorf("touch session %v: %w", err, models.ErrInternal)
+		}
+	}
+	s.revokedSessions.Set(sessionID, revoked)
+
+	return revoked, nil
+}
+
+// Logout завершает сессию токена, отзывает access токен и, если передан, refresh токен
+func (s *service) Logout(ctx context.Context, claims jwt.Claims, refreshToken string) error {
+	if !models.UserID(claims.UserID).Validate() {
+		return models.ErrUserIDMandatory
+	}
+
+	if claims.SessionID != "" {
+		if err := s.storage.RevokeSession(ctx, claims.UserID, claims.SessionID); err != nil &&
+			!errors.Is(err, storage.ErrNoRecordsFound) {
+			return fmt.Errorf("revoke session %v: %w", err, models.ErrInternal)
+		}
+		s.revokedSessions.Set(claims.SessionID, true)
+	}
+
+	if claims.TokenID != "" {
+		if err := s.storage.RevokeToken(ctx, storage.RevokedToken{
+			TokenID:   claims.TokenID,
+			UserID:    claims.UserID,
+			ExpiresAt: claims.ExpiresAt,
+		}); err != nil {
+			return fmt.Errorf("revoke token %v: %w", err, models.ErrInternal)
+		}
+		s.revokedTokens.SetWithTTL(claims.TokenID, true, time.Until(claims.ExpiresAt))
+	}
+
+	if refreshToken != "" {
+		if err := s.storage.RevokeRefreshToken(ctx, claims.UserID, hashToken(refreshToken)); err != nil {
+			return fmt.Errorf("revoke refresh token %v: %w", err, models.ErrInternal)
+		}
+	}
+
+	return nil
+}
+
+// LogoutAll отзывает все выданные пользователю токены
+func (s *service) LogoutAll(ctx context.Context, userID models.UserID) error {
+	if !userID.Validate() {
+		return models.ErrUserIDMandatory
+	}
+
+	if _, err := s.storage.RevokeUserTokens(ctx, string(userID)); err != nil {
+		return fmt.Errorf("revoke user tokens %v: %w", err, models.ErrInternal)
+	}
+	s.authStates.Delete(string(userID))
+
+	return nil
+}
@@ -0,0 +1,286 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/vladislav-kr/gophermart/internal/domain/models"
+	"github.com/vladislav-kr/gophermart/internal/service/jwt"
+	"github.com/vladislav-kr/gophermart/internal/service/mocks"
+	"github.com/vladislav-kr/gophermart/internal/storage"
+)
+
+func Test_service_ValidateToken(t *testing.T) {
+	type mockArgs struct {
+		callSession bool
+		errSession  error
+		callRevoked bool
+		revoked     bool
+		errRevoked  error
+		callUser    bool
+		user        *storage.User
+		errUser     error
+	}
+	tests := []struct {
+		name    string
+		claims  jwt.Claims
+		mock    mockArgs
+		wantErr error
+	}{
+		{
+			name: "токен действителен",
+			claims: jwt.Claims{
+				UserID:     "1cf50925-d72d-488b-94e5-426acce77f3c",
+				TokenID:    "token-1",
+				Generation: 1,
+				Role:       string(models.RoleSupport),
+			},
+			mock: mockArgs{
+				callRevoked: true,
+				callUser:    true,
+				user:        &storage.User{Generation: 1, Role: string(models.RoleSupport)},
+			},
+		},
+		{
+			name: "токен отозван",
+			claims: jwt.Claims{
+				UserID:  "1cf50925-d72d-488b-94e5-426acce77f3c",
+				TokenID: "token-2",
+			},
+			mock: mockArgs{
+				callRevoked: true,
+				revoked:     true,
+			},
+			wantErr: models.ErrTokenRevoked,
+		},
+		{
+			name: "выход со всех устройств после выдачи токена",
+			claims: jwt.Claims{
+				UserID:     "1cf50925-d72d-488b-94e5-426acce77f3c",
+				TokenID:    "token-3",
+				Generation: 0,
+			},
+			mock: mockArgs{
+				callRevoked: true,
+				callUser:    true,
+				user:        &storage.User{Generation: 1},
+			},
+			wantErr: models.ErrTokenRevoked,
+		},
+		{
+			name: "пользователь не существует",
+			claims: jwt.Claims{
+				UserID:  "1cf50925-d72d-488b-94e5-426acce77f3c",
+				TokenID: "token-4",
+			},
+			mock: mockArgs{
+				callRevoked: true,
+				callUser:    true,
+				errUser:     storage.ErrNoRecordsFound,
+			},
+			wantErr: models.ErrTokenRevoked,
+		},
+		{
+			name: "роль изменена после выдачи токена",
+			claims: jwt.Claims{
+				UserID:  "1cf50925-d72d-488b-94e5-426acce77f3c",
+				TokenID: "token-7",
+				Role:    string(models.RoleUser),
+			},
+			mock: mockArgs{
+				callRevoked: true,
+				callUser:    true,
+				user:        &storage.User{Role: string(models.RoleAdmin)},
+			},
+			wantErr: models.ErrTokenRevoked,
+		},
+		{
+			name: "пользователь заблокирован после выдачи токена",
+			claims: jwt.Claims{
+				UserID:  "1cf50925-d72d-488b-94e5-426acce77f3c",
+				TokenID: "token-6",
+			},
+			mock: mockArgs{
+				callRevoked: true,
+				callUser:    true,
+				user:        &storage.User{IsBlocked: true},
+			},
+			wantErr: models.ErrUserBlocked,
+		},
+		{
+			name: "сессия действительна",
+			claims: jwt.Claims{
+				UserID:    "1cf50925-d72d-488b-94e5-426acce77f3c",
+				TokenID:   "token-8",
+				SessionID: "7d4c1b0a-2a39-4a0e-8f3c-1f8a4b2f1e55",
+			},
+			mock: mockArgs{
+				callSession: true,
+				callRevoked: true,
+				callUser:    true,
+				user:        &storage.User{},
+			},
+		},
+		{
+			name: "сессия завершена",
+			claims: jwt.Claims{
+				UserID:    "1cf50925-d72d-488b-94e5-426acce77f3c",
+				TokenID:   "token-9",
+				SessionID: "0e1e3f44-5f0c-4e2c-9f0a-64b2ee1a4a3c",
+			},
+			mock: mockArgs{
+				callSession: true,
+				errSession:  storage.ErrNoRecordsFound,
+			},
+			wantErr: models.ErrTokenRevoked,
+		},
+		{
+			name: "ошибка хранилища при проверке сессии",
+			claims: jwt.Claims{
+				UserID:    "1cf50925-d72d-488b-94e5-426acce77f3c",
+				TokenID:   "token-10",
+				SessionID: "9a8b7c6d-5e4f-4a3b-8c2d-1e0f9a8b7c6d",
+			},
+			mock: mockArgs{
+				callSession: true,
+				errSession:  fmt.Errorf("db error"),
+			},
+			wantErr: models.ErrInternal,
+		},
+		{
+			name: "ошибка хранилища",
+			claims: jwt.Claims{
+				UserID:  "1cf50925-d72d-488b-94e5-426acce77f3c",
+				TokenID: "token-5",
+			},
+			mock: mockArgs{
+				callRevoked: true,
+				errRevoked:  fmt.Errorf("db error"),
+			},
+			wantErr: models.ErrInternal,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			stor := mocks.NewStorage(t)
+			srv := NewService(nil, stor, nil, nil)
+
+			if tt.mock.callSession {
+				stor.On("TouchSession",
+					mock.AnythingOfType("*context.timerCtx"),
+					tt.claims.SessionID,
+				).Return(tt.mock.errSession).Once()
+			}
+			if tt.mock.callRevoked {
+				stor.On("TokenRevoked",
+					mock.AnythingOfType("*context.timerCtx"),
+					tt.claims.TokenID,
+				).Return(tt.mock.revoked, tt.mock.errRevoked).Once()
+			}
+			if tt.mock.callUser {
+				stor.On("UserByID",
+					mock.AnythingOfType("*context.timerCtx"),
+					tt.claims.UserID,
+				).Return(tt.mock.user, tt.mock.errUser).Once()
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second*4)
+			defer cancel()
+
+			err := srv.ValidateToken(ctx, tt.claims)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+
+			// повторная проверка обслуживается из кеша
+			assert.NoError(t, srv.ValidateToken(ctx, tt.claims))
+		})
+	}
+}
+
+func Test_service_Logout(t *testing.T) {
+	stor := mocks.NewStorage(t)
+	srv := NewService(nil, stor, nil, nil)
+
+	claims := jwt.Claims{
+		UserID:    "1cf50925-d72d-488b-94e5-426acce77f3c",
+		TokenID:   "token-logout",
+		SessionID: "7d4c1b0a-2a39-4a0e-8f3c-1f8a4b2f1e55",
+		ExpiresAt: time.Now().Add(time.Minute),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*4)
+	defer cancel()
+
+	stor.On("RevokeSession",
+		mock.AnythingOfType("*context.timerCtx"),
+		claims.UserID,
+		claims.SessionID,
+	).Return(nil).Once()
+	stor.On("RevokeToken",
+		mock.AnythingOfType("*context.timerCtx"),
+		storage.RevokedToken{
+			TokenID:   claims.TokenID,
+			UserID:    claims.UserID,
+			ExpiresAt: claims.ExpiresAt,
+		},
+	).Return(nil).Once()
+	stor.On("RevokeRefreshToken",
+		mock.AnythingOfType("*context.timerCtx"),
+		claims.UserID,
+		hashToken("refresh-token"),
+	).Return(nil).Once()
+
+	assert.NoError(t, srv.Logout(ctx, claims, "refresh-token"))
+
+	// отозванный в этом процессе токен отклоняется без обращения к хранилищу
+	assert.ErrorIs(t, srv.ValidateToken(ctx, claims), models.ErrTokenRevoked)
+
+	assert.ErrorIs(t, srv.Logout(ctx, jwt.Claims{}, ""), models.ErrUserIDMandatory)
+}
+
+func Test_service_LogoutAll(t *testing.T) {
+	stor := mocks.NewStorage(t)
+	srv := NewService(nil, stor, nil, nil)
+
+	userID := models.UserID("1cf50925-d72d-488b-94e5-426acce77f3c")
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*4)
+	defer cancel()
+
+	stor.On("RevokeUserTokens",
+		mock.AnythingOfType("*context.timerCtx"),
+		string(userID),
+	).Return(int64(3), nil).Once()
+	stor.On("TokenRevoked",
+		mock.AnythingOfType("*context.timerCtx"),
+		mock.AnythingOfType("string"),
+	).Return(false, nil)
+	stor.On("UserByID",
+		mock.AnythingOfType("*context.timerCtx"),
+		string(userID),
+	).Return(&storage.User{UserID: string(userID), Generation: 3}, nil).Once()
+
+	assert.NoError(t, srv.LogoutAll(ctx, userID))
+
+	// токены предыдущих поколений отклоняются
+	err := srv.ValidateToken(ctx, jwt.Claims{UserID: string(userID), TokenID: "old", Generation: 2})
+	assert.ErrorIs(t, err, models.ErrTokenRevoked)
+	err = srv.ValidateToken(ctx, jwt.Claims{UserID: string(userID), TokenID: "new", Generation: 3})
+	assert.NoError(t, err)
+
+	stor.On("RevokeUserTokens",
+		mock.AnythingOfType("*context.timerCtx"),
+		string(userID),
+	).Return(int64(0), fmt.Errorf("db error")).Once()
+	assert.ErrorIs(t, srv.LogoutAll(ctx, userID), models.ErrInternal)
+}
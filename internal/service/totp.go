@@ -0,0 +1,275 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/vladislav-kr/gophermart/internal/domain/models"
+	"github.com/vladislav-kr/gophermart/internal/logger"
+	"github.com/vladislav-kr/gophermart/internal/storage"
+)
+
+const (
+	recoveryCodesCount = 10
+	// неверные коды на один токен второго шага, дальше нужно снова ввести пароль
+	maxMFAAttempts = 5
+)
+
+// EnrollTOTP начинает подключение 2FA: секрет действует после подтверждения кодом.
+// Повторный вызов до подтверждения выдает новый секрет.
+func (s *service) EnrollTOTP(ctx context.Context, userID models.UserID) (*models.TOTPEnrollment, error) {
+	if !userID.Validate() {
+		return nil, models.ErrUserIDMandatory
+	}
+
+	user, err := s.storage.UserByID(ctx, string(userID))
+	if err != nil {
+		return nil, fmt.Errorf("user by id %v: %w", err, models.ErrInternal)
+	}
+
+	secret, err := s.totp.GenerateSecret()
+	if err != nil {
+		return nil, fmt.Errorf("generate totp secret %v: %w", err, models.ErrInternal)
+	}
+
+	if err := s.storage.SaveTOTPSecret(ctx, user.UserID, secret); err != nil {
+		switch {
+		case errors.Is(err, storage.ErrUniqueViolation):
+			return nil, models.ErrTOTPAlreadyEnabled
+		default:
+			return nil, fmt.Errorf("save totp secret %v: %w", err, models.ErrInternal)
+		}
+	}
+
+	return &models.TOTPEnrollment{
+		Secret: secret,
+		URI:    s.totp.URI(user.Login, secret),
+	}, nil
+}
+
+// ConfirmTOTP включает 2FA после проверки первого кода,
+// вернет коды восстановления
+func (s *service) ConfirmTOTP(ctx context.Context, userID models.UserID, code models.TOTPCode) (*models.RecoveryCodes, error) {
+	if !userID.Validate() {
+		return nil, models.ErrUserIDMandatory
+	}
+
+	t, err := s.storage.TOTP(ctx, string(userID))
+	if err != nil {
+		switch {
+		case errors.Is(err, storage.ErrNoRecordsFound):
+			return nil, models.ErrTOTPNotEnrolled
+		default:
+			return nil, fmt.Errorf("user totp %v: %w", err, models.ErrInternal)
+		}
+	}
+
+	if t.ConfirmedAt != nil {
+		return nil, models.ErrTOTPAlreadyEnabled
+	}
+
+	step, err := s.totp.Validate(t.Secret, code.Code)
+	if err != nil {
+		return nil, models.ErrInvalidTOTPCode
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, fmt.Errorf("recovery codes generation %v: %w", err, models.ErrInternal)
+	}
+
+	if err := s.storage.ConfirmTOTP(ctx, string(userID), step, hashes); err != nil {
+		switch {
+		case errors.Is(err, storage.ErrNoRecordsFound):
+			return nil, models.ErrTOTPAlreadyEnabled
+		default:
+			return nil, fmt.Errorf("confirm totp %v: %w", err, models.ErrInternal)
+		}
+	}
+
+	s.log.Info("totp enabled", slog.String("user_id", string(userID)))
+
+	return &models.RecoveryCodes{Codes: codes}, nil
+}
+
+// LoginMFA второй шаг входа: обменивает токен после проверки пароля
+// и код TOTP или код восстановления на пару токенов
+func (s *service) LoginMFA(ctx context.Context, req models.MFALogin, client models.Client) (*models.Tokens, error) {
+	if req.ChallengeToken == "" {
+		return nil, models.ErrInvalidMFAChallenge
+	}
+	tokenHash := hashToken(req.ChallengeToken)
+
+	challenge, err := s.storage.MFAChallenge(ctx, tokenHash)
+	if err != nil {
+		switch {
+		case errors.Is(err, storage.ErrNoRecordsFound):
+			return nil, models.ErrInvalidMFAChallenge
+		default:
+			return nil, fmt.Errorf("mfa challenge %v: %w", err, models.ErrInternal)
+		}
+	}
+
+	if challenge.Attempts >= maxMFAAttempts {
+		return nil, models.ErrInvalidMFAChallenge
+	}
+
+	user, err := s.storage.UserByID(ctx, challenge.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("user by id %v: %w", err, models.ErrInternal)
+	}
+
+	// подбор кода ограничивается вместе с подбором пароля
+	if err := s.limiter.Allow(ctx, user.Login, client.IP); err != nil {
+		if errors.Is(err, models.ErrTooManyAttempts) {
+			return nil, err
+		}
+		s.log.Error("login limiter allow", logger.Error(err))
+	}
+
+	if err := s.verifySecondFactor(ctx, user.UserID, req.Code); err != nil {
+		if errors.Is(err, models.ErrInvalidTOTPCode) {
+			if err := s.storage.FailMFAChallenge(ctx, tokenHash); err != nil {
+				s.log.Error("fail mfa challenge", logger.Error(err))
+			}
+			s.loginFailed(ctx, user.Login, client.IP)
+		}
+		return nil, err
+	}
+
+	if err := s.storage.CompleteMFAChallenge(ctx, tokenHash); err != nil {
+		switch {
+		case errors.Is(err, storage.ErrNoRecordsFound):
+			return nil, models.ErrInvalidMFAChallenge
+		default:
+			return nil, fmt.Errorf("complete mfa challenge %v: %w", err, models.ErrInternal)
+		}
+	}
+
+	if err := userFromStorage(user).Active(); err != nil {
+		return nil, err
+	}
+
+	s.loginSucceeded(ctx, user.Login)
+
+	return s.issueTokens(ctx, userFromStorage(user), user.Generation, client)
+}
+
+// requireSecondFactor вернет *models.MFARequiredError с токеном второго шага,
+// если у пользователя подтверждена 2FA
+func (s *service) requireSecondFactor(ctx context.Context, userID string) error {
+	t, err := s.storage.TOTP(ctx, userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, storage.ErrNoRecordsFound):
+			return nil
+		default:
+			return fmt.Errorf("user totp %v: %w", err, models.ErrInternal)
+		}
+	}
+
+	if t.ConfirmedAt == nil {
+		return nil
+	}
+
+	token, tokenHash, err := newOpaqueToken()
+	if err != nil {
+		return fmt.Errorf("mfa challenge generation %v: %w", err, models.ErrInternal)
+	}
+
+	if err := s.storage.CreateMFAChallenge(ctx, storage.MFAChallenge{
+		UserID:    userID,
+		TokenHash: tokenHash,
+		ExpiresAt: time.Now().Add(s.mfaChallengeTTL),
+	}); err != nil {
+		return fmt.Errorf("create mfa challenge %v: %w", err, models.ErrInternal)
+	}
+
+	return models.NewMFARequiredError(token, s.mfaChallengeTTL)
+}
+
+// verifySecondFactor проверяет код TOTP, каждый шаг принимается один раз,
+// либо погашает код восстановления
+func (s *service) verifySecondFactor(ctx context.Context, userID, code string) error {
+	if !isTOTPCode(code) {
+		if err := s.storage.UseRecoveryCode(ctx, userID, hashToken(normalizeRecoveryCode(code))); err != nil {
+			switch {
+			case errors.Is(err, storage.ErrNoRecordsFound):
+				return models.ErrInvalidTOTPCode
+			default:
+				return fmt.Errorf("use recovery code %v: %w", err, models.ErrInternal)
+			}
+		}
+		s.log.Info("recovery code used", slog.String("user_id", userID))
+		return nil
+	}
+
+	t, err := s.storage.TOTP(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("user totp %v: %w", err, models.ErrInternal)
+	}
+
+	step, err := s.totp.Validate(t.Secret, code)
+	if err != nil {
+		return models.ErrInvalidTOTPCode
+	}
+
+	if err := s.storage.UseTOTPStep(ctx, userID, step); err != nil {
+		switch {
+		case errors.Is(err, storage.ErrNoRecordsFound):
+			return models.ErrInvalidTOTPCode
+		default:
+			return fmt.Errorf("use totp step %v: %w", err, models.ErrInternal)
+		}
+	}
+
+	return nil
+}
+
+func isTOTPCode(code string) bool {
+	if len(code) != 6 {
+		return false
+	}
+	for _, r := range code {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+var recoveryEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// newRecoveryCodes коды вида abcd-efgh и их хеши
+func newRecoveryCodes() ([]string, [][]byte, error) {
+	codes := make([]string, 0, recoveryCodesCount)
+	hashes := make([][]byte, 0, recoveryCodesCount)
+
+	buf := make([]byte, 5)
+	for i := 0; i < recoveryCodesCount; i++ {
+		if _, err := rand.Read(buf); err != nil {
+			return nil, nil, err
+		}
+		raw := strings.ToLower(recoveryEncoding.EncodeToString(buf))
+		codes = append(codes, raw[:4]+"-"+raw[4:])
+		hashes = append(hashes, hashToken(raw))
+	}
+
+	return codes, hashes, nil
+}
+
+// normalizeRecoveryCode код без регистра, дефисов и пробелов
+func normalizeRecoveryCode(code string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' {
+			return -1
+		}
+		return r
+	}, strings.ToLower(code))
+}
@@ -0,0 +1,156 @@
+package totp
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidCode   = errors.New("invalid totp code")
+	ErrInvalidSecret = errors.New("invalid totp secret")
+)
+
+const (
+	defaultIssuer = "Gophermart"
+	// 160 бит, рекомендация RFC 4226 для HMAC-SHA1
+	secretLength = 20
+	digits       = 6
+	period       = 30
+)
+
+var encoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// generator одноразовые коды RFC 6238: HMAC-SHA1, 6 цифр, шаг 30 секунд,
+// параметры совпадают с умолчаниями приложений-аутентификаторов
+type generator struct {
+	issuer string
+	// допустимое расхождение часов в шагах в каждую сторону
+	skew int64
+	now  func() time.Time
+}
+
+type Option func(*generator)
+
+// WithIssuer название сервиса в приложении-аутентификаторе
+func WithIssuer(issuer string) Option {
+	return func(g *generator) {
+		if issuer != "" {
+			g.issuer = issuer
+		}
+	}
+}
+
+// WithSkew допустимое расхождение часов клиента в шагах
+func WithSkew(steps int64) Option {
+	return func(g *generator) {
+		if steps >= 0 {
+			g.skew = steps
+		}
+	}
+}
+
+// WithClock источник времени, для тестов
+func WithClock(now func() time.Time) Option {
+	return func(g *generator) {
+		if now != nil {
+			g.now = now
+		}
+	}
+}
+
+func New(opts ...Option) *generator {
+	g := &generator{
+		issuer: defaultIssuer,
+		skew:   1,
+		now:    time.Now,
+	}
+	for _, fn := range opts {
+		fn(g)
+	}
+	return g
+}
+
+// GenerateSecret случайный секрет в base32 без выравнивания
+func (g *generator) GenerateSecret() (string, error) {
+	buf := make([]byte, secretLength)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("generate secret: %w", err)
+	}
+	return encoding.EncodeToString(buf), nil
+}
+
+// URI otpauth для QR-кода приложения-аутентификатора
+func (g *generator) URI(account, secret string) string {
+	label := url.PathEscape(g.issuer) + ":" + url.PathEscape(account)
+
+	query := url.Values{}
+	query.Set("secret", secret)
+	query.Set("issuer", g.issuer)
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", fmt.Sprint(digits))
+	query.Set("period", fmt.Sprint(period))
+
+	return "otpauth://totp/" + label + "?" + query.Encode()
+}
+
+// Validate проверяет код с учетом расхождения часов,
+// вернет номер шага, которому соответствует код, для защиты от повторного использования
+func (g *generator) Validate(secret, code string) (int64, error) {
+	key, err := decodeSecret(secret)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(code) != digits {
+		return 0, ErrInvalidCode
+	}
+
+	current := g.now().Unix() / period
+	for step := current - g.skew; step <= current+g.skew; step++ {
+		if hmac.Equal([]byte(hotp(key, step)), []byte(code)) {
+			return step, nil
+		}
+	}
+
+	return 0, ErrInvalidCode
+}
+
+// Code код для момента t
+func (g *generator) Code(secret string, t time.Time) (string, error) {
+	key, err := decodeSecret(secret)
+	if err != nil {
+		return "", err
+	}
+	return hotp(key, t.Unix()/period), nil
+}
+
+func decodeSecret(secret string) ([]byte, error) {
+	key, err := encoding.DecodeString(strings.ToUpper(strings.TrimRight(secret, "=")))
+	if err != nil || len(key) == 0 {
+		return nil, ErrInvalidSecret
+	}
+	return key, nil
+}
+
+// hotp RFC 4226 с динамическим усечением
+func hotp(key []byte, counter int64) string {
+	msg := make([]byte, 8)
+	binary.BigEndian.PutUint64(msg, uint64(counter))
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg)
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	return fmt.Sprintf("%0*d", digits, value%1_000_000)
+}
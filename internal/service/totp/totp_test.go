@@ -0,0 +1,107 @@
+package totp
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// секрет и значения из приложения B RFC 6238 для SHA1, усеченные до 6 цифр
+var rfcSecret = base32.StdEncoding.WithPadding(base32.NoPadding).
+	EncodeToString([]byte("12345678901234567890"))
+
+func TestGenerator_Code(t *testing.T) {
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{unix: 59, want: "287082"},
+		{unix: 1111111109, want: "081804"},
+		{unix: 1111111111, want: "050471"},
+		{unix: 1234567890, want: "005924"},
+		{unix: 2000000000, want: "279037"},
+	}
+	g := New()
+	for _, tt := range tests {
+		code, err := g.Code(rfcSecret, time.Unix(tt.unix, 0))
+		require.NoError(t, err)
+		assert.Equal(t, tt.want, code, "time %d", tt.unix)
+	}
+}
+
+func TestGenerator_Validate(t *testing.T) {
+	now := time.Unix(1111111109, 0)
+	g := New(WithClock(func() time.Time { return now }))
+
+	tests := []struct {
+		name     string
+		code     string
+		wantStep int64
+		wantErr  error
+	}{
+		{
+			name:     "код текущего шага",
+			code:     "081804",
+			wantStep: 1111111109 / 30,
+		},
+		{
+			name:     "код предыдущего шага в пределах расхождения",
+			code:     mustCode(t, g, now.Add(-30*time.Second)),
+			wantStep: 1111111109/30 - 1,
+		},
+		{
+			name:    "код вне окна",
+			code:    mustCode(t, g, now.Add(-90*time.Second)),
+			wantErr: ErrInvalidCode,
+		},
+		{
+			name:    "неверная длина",
+			code:    "12345",
+			wantErr: ErrInvalidCode,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			step, err := g.Validate(rfcSecret, tt.code)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantStep, step)
+		})
+	}
+
+	_, err := g.Validate("not base32!", "081804")
+	assert.ErrorIs(t, err, ErrInvalidSecret)
+}
+
+func TestGenerator_SecretAndURI(t *testing.T) {
+	g := New(WithIssuer("Gopher Mart"))
+
+	secret, err := g.GenerateSecret()
+	require.NoError(t, err)
+	assert.Len(t, secret, 32)
+
+	code, err := g.Code(secret, time.Now())
+	require.NoError(t, err)
+	_, err = g.Validate(secret, code)
+	assert.NoError(t, err)
+
+	uri := g.URI("user@example", secret)
+	assert.True(t, strings.HasPrefix(uri, "otpauth://totp/Gopher%20Mart:user@example?"))
+	assert.Contains(t, uri, "secret="+secret)
+	assert.Contains(t, uri, "issuer=Gopher+Mart")
+}
+
+func mustCode(t *testing.T, g *generator, at time.Time) string {
+	code, err := g.Code(rfcSecret, at)
+	require.NoError(t, err)
+	return code
+}
@@ -0,0 +1,338 @@
+package service
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/vladislav-kr/gophermart/internal/domain/models"
+	"github.com/vladislav-kr/gophermart/internal/service/mocks"
+	"github.com/vladislav-kr/gophermart/internal/service/totp"
+	"github.com/vladislav-kr/gophermart/internal/storage"
+)
+
+const testTOTPSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+// генератор с фиксированным временем, текущий код и его шаг
+func testTOTP(t *testing.T) (TOTPGenerator, string, int64) {
+	t.Helper()
+	now := time.Unix(1111111109, 0)
+	g := totp.New(totp.WithClock(func() time.Time { return now }))
+	code, err := g.Code(testTOTPSecret, now)
+	require.NoError(t, err)
+	return g, code, now.Unix() / 30
+}
+
+func Test_service_EnrollTOTP(t *testing.T) {
+	const userID = "1cf50925-d72d-488b-94e5-426acce77f3c"
+
+	stor := mocks.NewStorage(t)
+	gen := mocks.NewTOTPGenerator(t)
+	srv := NewService(nil, stor, nil, nil, WithTOTP(gen))
+
+	stor.On("UserByID", mock.Anything, userID).Return(&storage.User{UserID: userID, Login: "mylogin"}, nil)
+	gen.On("GenerateSecret").Return(testTOTPSecret, nil)
+	gen.On("URI", "mylogin", testTOTPSecret).Return("otpauth://totp/Gophermart:mylogin")
+
+	stor.On("SaveTOTPSecret", mock.Anything, userID, testTOTPSecret).Return(nil).Once()
+	enrollment, err := srv.EnrollTOTP(context.Background(), userID)
+	require.NoError(t, err)
+	assert.Equal(t, testTOTPSecret, enrollment.Secret)
+	assert.Equal(t, "otpauth://totp/Gophermart:mylogin", enrollment.URI)
+
+	stor.On("SaveTOTPSecret", mock.Anything, userID, testTOTPSecret).Return(storage.ErrUniqueViolation).Once()
+	_, err = srv.EnrollTOTP(context.Background(), userID)
+	assert.ErrorIs(t, err, models.ErrTOTPAlreadyEnabled)
+}
+
+func Test_service_ConfirmTOTP(t *testing.T) {
+	const userID = "1cf50925-d72d-488b-94e5-426acce77f3c"
+	gen, code, step := testTOTP(t)
+	confirmed := time.Now()
+
+	tests := []struct {
+		name    string
+		totp    *storage.TOTP
+		errDB   error
+		code    string
+		wantErr error
+	}{
+		{
+			name: "2FA включена",
+			totp: &storage.TOTP{UserID: userID, Secret: testTOTPSecret},
+			code: code,
+		},
+		{
+			name:    "неверный код",
+			totp:    &storage.TOTP{UserID: userID, Secret: testTOTPSecret},
+			code:    "000000",
+			wantErr: models.ErrInvalidTOTPCode,
+		},
+		{
+			name:    "подключение не начато",
+			errDB:   storage.ErrNoRecordsFound,
+			code:    code,
+			wantErr: models.ErrTOTPNotEnrolled,
+		},
+		{
+			name:    "уже включена",
+			totp:    &storage.TOTP{UserID: userID, Secret: testTOTPSecret, ConfirmedAt: &confirmed},
+			code:    code,
+			wantErr: models.ErrTOTPAlreadyEnabled,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			stor := mocks.NewStorage(t)
+			srv := NewService(nil, stor, nil, nil, WithTOTP(gen))
+
+			stor.On("TOTP", mock.Anything, userID).Return(tt.totp, tt.errDB).Once()
+
+			var hashes [][]byte
+			if tt.wantErr == nil {
+				stor.On("ConfirmTOTP", mock.Anything, userID, step, mock.AnythingOfType("[][]uint8")).
+					Run(func(args mock.Arguments) {
+						hashes = args.Get(3).([][]byte)
+					}).
+					Return(nil).Once()
+			}
+
+			codes, err := srv.ConfirmTOTP(context.Background(), userID, models.TOTPCode{Code: tt.code})
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, codes.Codes, recoveryCodesCount)
+			require.Len(t, hashes, recoveryCodesCount)
+
+			// хранятся хеши кодов без дефиса
+			assert.Regexp(t, regexp.MustCompile(`^[a-z2-7]{4}-[a-z2-7]{4}$`), codes.Codes[0])
+			assert.Equal(t, hashToken(normalizeRecoveryCode(codes.Codes[0])), hashes[0])
+		})
+	}
+}
+
+func Test_service_Login_secondFactor(t *testing.T) {
+	confirmed := time.Now()
+	user := &storage.User{
+		UserID:   "1cf50925-d72d-488b-94e5-426acce77f3c",
+		Login:    "mylogin",
+		Password: []byte("hash"),
+	}
+	cred := models.Credentials{Login: user.Login, Password: "password1"}
+
+	stor := mocks.NewStorage(t)
+	gen := mocks.NewPasswordGenerator(t)
+	srv := NewService(gen, stor, nil, testKeySet(t), WithMFAChallengeTTL(time.Minute))
+
+	stor.On("User", mock.Anything, user.Login).Return(user, nil).Once()
+	gen.On("CompareHashAndPassword", user.Password, []byte(cred.Password)).Return(nil).Once()
+	gen.On("NeedsRehash", user.Password).Return(false).Once()
+	stor.On("TOTP", mock.Anything, user.UserID).
+		Return(&storage.TOTP{UserID: user.UserID, Secret: testTOTPSecret, ConfirmedAt: &confirmed}, nil).Once()
+
+	var challenge storage.MFAChallenge
+	stor.On("CreateMFAChallenge", mock.Anything, mock.AnythingOfType("storage.MFAChallenge")).
+		Run(func(args mock.Arguments) {
+			challenge = args.Get(1).(storage.MFAChallenge)
+		}).
+		Return(nil).Once()
+
+	// токены не выдаются до второго шага
+	tokens, err := srv.Login(context.Background(), cred, models.Client{IP: "127.0.0.1"})
+	assert.Nil(t, tokens)
+
+	var mfa *models.MFARequiredError
+	require.ErrorAs(t, err, &mfa)
+	assert.ErrorIs(t, err, models.ErrMFARequired)
+	assert.Equal(t, int64(60), mfa.Challenge.ExpiresIn)
+	assert.Equal(t, hashToken(mfa.Challenge.ChallengeToken), challenge.TokenHash)
+	assert.Equal(t, user.UserID, challenge.UserID)
+}
+
+func Test_service_LoginMFA(t *testing.T) {
+	const challengeToken = "challenge-token"
+	user := &storage.User{
+		UserID: "1cf50925-d72d-488b-94e5-426acce77f3c",
+		Login:  "mylogin",
+	}
+	gen, code, step := testTOTP(t)
+	confirmed := time.Now()
+	userTOTP := &storage.TOTP{UserID: user.UserID, Secret: testTOTPSecret, ConfirmedAt: &confirmed}
+
+	tests := []struct {
+		name      string
+		code      string
+		challenge *storage.MFAChallenge
+		errStep   error
+		recovery  bool
+		errCode   error
+		wantErr   error
+	}{
+		{
+			name:      "верный код TOTP",
+			code:      code,
+			challenge: &storage.MFAChallenge{UserID: user.UserID},
+		},
+		{
+			name:      "код восстановления",
+			code:      "ABCD-EFGH",
+			challenge: &storage.MFAChallenge{UserID: user.UserID},
+			recovery:  true,
+		},
+		{
+			name:      "повтор использованного кода",
+			code:      code,
+			challenge: &storage.MFAChallenge{UserID: user.UserID},
+			errStep:   storage.ErrNoRecordsFound,
+			wantErr:   models.ErrInvalidTOTPCode,
+		},
+		{
+			name:      "неверный код TOTP",
+			code:      "000000",
+			challenge: &storage.MFAChallenge{UserID: user.UserID},
+			wantErr:   models.ErrInvalidTOTPCode,
+		},
+		{
+			name:      "использованный код восстановления",
+			code:      "abcd-efgh",
+			challenge: &storage.MFAChallenge{UserID: user.UserID},
+			recovery:  true,
+			errCode:   storage.ErrNoRecordsFound,
+			wantErr:   models.ErrInvalidTOTPCode,
+		},
+		{
+			name:      "попытки исчерпаны",
+			code:      code,
+			challenge: &storage.MFAChallenge{UserID: user.UserID, Attempts: maxMFAAttempts},
+			wantErr:   models.ErrInvalidMFAChallenge,
+		},
+		{
+			name:    "токен второго шага недействителен",
+			code:    code,
+			wantErr: models.ErrInvalidMFAChallenge,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			stor := mocks.NewStorage(t)
+			srv := NewService(nil, stor, nil, testKeySet(t), WithTOTP(gen))
+			tokenHash := hashToken(challengeToken)
+
+			if tt.challenge == nil {
+				stor.On("MFAChallenge", mock.Anything, tokenHash).Return(nil, storage.ErrNoRecordsFound).Once()
+			} else {
+				stor.On("MFAChallenge", mock.Anything, tokenHash).Return(tt.challenge, nil).Once()
+			}
+
+			verify := tt.challenge != nil && tt.challenge.Attempts < maxMFAAttempts
+			if verify {
+				stor.On("UserByID", mock.Anything, user.UserID).Return(user, nil).Once()
+				if tt.recovery {
+					stor.On("UseRecoveryCode", mock.Anything, user.UserID, hashToken("abcdefgh")).
+						Return(tt.errCode).Once()
+				} else {
+					stor.On("TOTP", mock.Anything, user.UserID).Return(userTOTP, nil).Once()
+					if tt.code == code {
+						stor.On("UseTOTPStep", mock.Anything, user.UserID, step).Return(tt.errStep).Once()
+					}
+				}
+			}
+			if tt.wantErr == models.ErrInvalidTOTPCode {
+				stor.On("FailMFAChallenge", mock.Anything, tokenHash).Return(nil).Once()
+			}
+			if verify && tt.wantErr == nil {
+				stor.On("CompleteMFAChallenge", mock.Anything, tokenHash).Return(nil).Once()
+				stor.On("SaveSession", mock.Anything, mock.AnythingOfType("storage.Session")).
+					Return(nil).Once()
+				stor.On("CreateRefreshToken", mock.Anything, mock.AnythingOfType("storage.RefreshToken")).
+					Return(nil).Once()
+			}
+
+			tokens, err := srv.LoginMFA(context.Background(), models.MFALogin{
+				ChallengeToken: challengeToken,
+				Code:           tt.code,
+			}, models.Client{IP: "127.0.0.1"})
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.NotEmpty(t, tokens.AccessToken)
+		})
+	}
+}
+
+func Test_service_Login_secondFactorLimiter(t *testing.T) {
+	const (
+		ip             = "127.0.0.1"
+		challengeToken = "challenge-token"
+	)
+	confirmed := time.Now()
+	user := &storage.User{
+		UserID:   "1cf50925-d72d-488b-94e5-426acce77f3c",
+		Login:    "mylogin",
+		Password: []byte("hash"),
+	}
+	cred := models.Credentials{Login: user.Login, Password: "password1"}
+	userTOTP := &storage.TOTP{UserID: user.UserID, Secret: testTOTPSecret, ConfirmedAt: &confirmed}
+	totpGen, code, step := testTOTP(t)
+	tokenHash := hashToken(challengeToken)
+
+	stor := mocks.NewStorage(t)
+	gen := mocks.NewPasswordGenerator(t)
+	limiter := mocks.NewLoginLimiter(t)
+	srv := NewService(gen, stor, nil, testKeySet(t), WithTOTP(totpGen), WithLoginLimiter(limiter))
+
+	// верный пароль без второго фактора не сбрасывает неудачные попытки
+	limiter.On("Allow", mock.Anything, user.Login, ip).Return(nil).Once()
+	stor.On("User", mock.Anything, user.Login).Return(user, nil).Once()
+	gen.On("CompareHashAndPassword", user.Password, []byte(cred.Password)).Return(nil).Once()
+	gen.On("NeedsRehash", user.Password).Return(false).Once()
+	stor.On("TOTP", mock.Anything, user.UserID).Return(userTOTP, nil).Once()
+	stor.On("CreateMFAChallenge", mock.Anything, mock.AnythingOfType("storage.MFAChallenge")).Return(nil).Once()
+
+	_, err := srv.Login(context.Background(), cred, models.Client{IP: ip})
+	require.ErrorIs(t, err, models.ErrMFARequired)
+	limiter.AssertNotCalled(t, "Success", mock.Anything, user.Login)
+
+	// неверный код учитывается как неудачная попытка
+	stor.On("MFAChallenge", mock.Anything, tokenHash).Return(&storage.MFAChallenge{UserID: user.UserID}, nil).Twice()
+	stor.On("UserByID", mock.Anything, user.UserID).Return(user, nil).Twice()
+	limiter.On("Allow", mock.Anything, user.Login, ip).Return(nil).Twice()
+	stor.On("TOTP", mock.Anything, user.UserID).Return(userTOTP, nil).Twice()
+	stor.On("FailMFAChallenge", mock.Anything, tokenHash).Return(nil).Once()
+	limiter.On("Failure", mock.Anything, user.Login, ip).Return(nil).Once()
+
+	_, err = srv.LoginMFA(context.Background(), models.MFALogin{
+		ChallengeToken: challengeToken,
+		Code:           "000000",
+	}, models.Client{IP: ip})
+	require.ErrorIs(t, err, models.ErrInvalidTOTPCode)
+	limiter.AssertNotCalled(t, "Success", mock.Anything, user.Login)
+
+	// счётчик сбрасывается только после второго фактора
+	stor.On("UseTOTPStep", mock.Anything, user.UserID, step).Return(nil).Once()
+	stor.On("CompleteMFAChallenge", mock.Anything, tokenHash).Return(nil).Once()
+	limiter.On("Success", mock.Anything, user.Login).Return(nil).Once()
+	stor.On("SaveSession", mock.Anything, mock.AnythingOfType("storage.Session")).Return(nil).Once()
+	stor.On("CreateRefreshToken", mock.Anything, mock.AnythingOfType("storage.RefreshToken")).Return(nil).Once()
+
+	tokens, err := srv.LoginMFA(context.Background(), models.MFALogin{
+		ChallengeToken: challengeToken,
+		Code:           code,
+	}, models.Client{IP: ip})
+	require.NoError(t, err)
+	assert.NotEmpty(t, tokens.AccessToken)
+}
@@ -1,17 +1,21 @@
 package storage
 
-import "time"
+import (
+	"time"
+
+	"github.com/vladislav-kr/gophermart/internal/domain/money"
+)
 
 type Balance struct {
-	Current   float64 `json:"current" db:"current"`
-	Withdrawn float64 `json:"withdrawn" db:"withdrawn"`
+	Current   money.Amount `json:"current" db:"current"`
+	Withdrawn money.Amount `json:"withdrawn" db:"withdrawn"`
 }
 
 type CreateOrder struct {
 	OrderID string
 	// UserID  string
 	Status  string
-	Accrual float64
+	Accrual money.Amount
 }
 type UpdateOrderID struct {
 	UserID  string `db:"user_id"`
@@ -19,34 +23,185 @@ type UpdateOrderID struct {
 }
 
 type UpdateOrder struct {
-	UserID  string  `db:"user_id"`
-	OrderID string  `db:"order_id"`
-	Status  string  `db:"status"`
-	Accrual float64 `db:"accrual"`
+	UserID  string       `db:"user_id"`
+	OrderID string       `db:"order_id"`
+	Status  string       `db:"status"`
+	Accrual money.Amount `db:"accrual"`
 }
 
 type Order struct {
-	OrderID    string    `db:"order_id"`
-	UserID     string    `db:"user_id"`
-	Status     string    `db:"status"`
-	UploadedAt time.Time `db:"uploaded_at"`
-	ChangedAt  time.Time `db:"changed_at"`
-	Accrual    float64   `db:"accrual"`
+	OrderID    string       `db:"order_id"`
+	UserID     string       `db:"user_id"`
+	Status     string       `db:"status"`
+	UploadedAt time.Time    `db:"uploaded_at"`
+	ChangedAt  time.Time    `db:"changed_at"`
+	Accrual    money.Amount `db:"accrual"`
+}
+
+// OrderOwner владелец заказа после пакетной загрузки
+type OrderOwner struct {
+	OrderID string `db:"order_id"`
+	UserID  string `db:"user_id"`
+	// заказ добавлен этой загрузкой
+	Inserted bool `db:"-"`
+}
+
+// OrderEvent смена статуса заказа и начисление на момент смены
+type OrderEvent struct {
+	OrderID   string       `db:"order_id"`
+	Status    string       `db:"status"`
+	Accrual   money.Amount `db:"accrual"`
+	CreatedAt time.Time    `db:"created_at"`
+}
+
+// OrdersFilter выборка заказов пользователя по ключу (uploaded_at, order_id).
+// Нулевые значения полей не ограничивают выборку.
+type OrdersFilter struct {
+	UserID   string
+	Statuses []string
+	From     time.Time
+	To       time.Time
+	Desc     bool
+	// заказы строго после указанного в порядке сортировки
+	AfterUploadedAt time.Time
+	AfterOrderID    string
+	Limit           uint32
 }
 
 type User struct {
-	UserID   string `db:"user_id"`
-	Login    string `db:"login"`
-	Password []byte `db:"pass_hash"`
+	UserID     string `db:"user_id"`
+	Login      string `db:"login"`
+	Password   []byte `db:"pass_hash"`
+	Generation int64  `db:"token_generation"`
+	IsBlocked  bool   `db:"is_blocked"`
+	IsDeleted  bool   `db:"is_delete"`
+	Role       string `db:"role"`
 }
 
 type WithdrawalsBonuses struct {
-	Order       string    `db:"order_id"`
-	Sum         float64   `db:"sum"`
-	ProcessedAt time.Time `db:"processed_at"`
+	Order       string       `db:"order_id"`
+	Sum         money.Amount `db:"sum"`
+	ProcessedAt time.Time    `db:"processed_at"`
 }
 
 type WithdrawBonuses struct {
-	Order string  `db:"order_id"`
-	Sum   float64 `db:"sum"`
+	Order string       `db:"order_id"`
+	Sum   money.Amount `db:"sum"`
+}
+
+type RefreshToken struct {
+	TokenID   string    `db:"token_id"`
+	FamilyID  string    `db:"family_id"`
+	UserID    string    `db:"user_id"`
+	TokenHash []byte    `db:"token_hash"`
+	ExpiresAt time.Time `db:"expires_at"`
+}
+
+type RevokedToken struct {
+	TokenID   string    `db:"token_id"`
+	UserID    string    `db:"user_id"`
+	ExpiresAt time.Time `db:"expires_at"`
+}
+
+type BalanceAdjustment struct {
+	UserID  string       `db:"user_id"`
+	AdminID string       `db:"admin_id"`
+	Amount  money.Amount `db:"amount"`
+	Reason  string       `db:"reason"`
+	Comment string       `db:"comment"`
+}
+
+type BalanceEntry struct {
+	Type        string       `db:"entry_type"`
+	Order       string       `db:"order_id"`
+	Reason      string       `db:"reason"`
+	Sum         money.Amount `db:"sum"`
+	ProcessedAt time.Time    `db:"processed_at"`
+}
+
+// BalanceDrift расхождение user_balance с заказами, списаниями и корректировками
+type BalanceDrift struct {
+	UserID            string       `db:"user_id"`
+	Current           money.Amount `db:"current"`
+	Withdrawn         money.Amount `db:"withdrawn"`
+	ExpectedCurrent   money.Amount `db:"expected_current"`
+	ExpectedWithdrawn money.Amount `db:"expected_withdrawn"`
+}
+
+// ExpiringPoints остаток партий баллов с общим сроком сгорания
+type ExpiringPoints struct {
+	Amount    money.Amount `db:"amount"`
+	ExpiresAt time.Time    `db:"expires_at"`
+}
+
+type LoginAttempts struct {
+	Failures    int       `db:"failures"`
+	LastFailure time.Time `db:"last_failure"`
+}
+
+type PasswordResetToken struct {
+	UserID    string    `db:"user_id"`
+	TokenHash []byte    `db:"token_hash"`
+	ExpiresAt time.Time `db:"expires_at"`
+}
+
+type CreateAPIKey struct {
+	UserID    string
+	Name      string
+	Prefix    string
+	KeyHash   []byte
+	Scopes    []string
+	ExpiresAt *time.Time
+}
+
+type APIKey struct {
+	KeyID      string     `db:"key_id"`
+	UserID     string     `db:"user_id"`
+	Name       string     `db:"name"`
+	Prefix     string     `db:"prefix"`
+	Scopes     []string   `db:"scopes"`
+	CreatedAt  time.Time  `db:"created_at"`
+	ExpiresAt  *time.Time `db:"expires_at"`
+	LastUsedAt *time.Time `db:"last_used_at"`
+	LastUsedIP string     `db:"last_used_ip"`
+	UseCount   int64      `db:"use_count"`
+	RevokedAt  *time.Time `db:"revoked_at"`
+}
+
+type TOTP struct {
+	UserID       string     `db:"user_id"`
+	Secret       string     `db:"secret"`
+	ConfirmedAt  *time.Time `db:"confirmed_at"`
+	LastUsedStep int64      `db:"last_used_step"`
+}
+
+type MFAChallenge struct {
+	UserID    string    `db:"user_id"`
+	TokenHash []byte    `db:"token_hash"`
+	ExpiresAt time.Time `db:"expires_at"`
+	Attempts  int       `db:"attempts"`
+}
+
+// Session вход пользователя, идентификатор совпадает с семейством refresh токенов
+type Session struct {
+	SessionID  string    `db:"session_id"`
+	UserID     string    `db:"user_id"`
+	IP         string    `db:"ip"`
+	UserAgent  string    `db:"user_agent"`
+	CreatedAt  time.Time `db:"created_at"`
+	LastSeenAt time.Time `db:"last_seen_at"`
+	ExpiresAt  time.Time `db:"expires_at"`
+}
+
+// IdempotencyKey ответ на запрос с заголовком Idempotency-Key
+type IdempotencyKey struct {
+	UserID      string `db:"user_id"`
+	Key         string `db:"idempotency_key"`
+	RequestHash []byte `db:"request_hash"`
+	// nil - запрос еще выполняется
+	StatusCode  *int      `db:"status_code"`
+	ContentType string    `db:"content_type"`
+	Body        []byte    `db:"body"`
+	CreatedAt   time.Time `db:"created_at"`
+	ExpiresAt   time.Time `db:"expires_at"`
 }
@@ -0,0 +1,159 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/vladislav-kr/gophermart/internal/domain/money"
+	"github.com/vladislav-kr/gophermart/internal/logger"
+	"github.com/vladislav-kr/gophermart/internal/storage"
+)
+
+// DeleteUser помечает пользователя удаленным, обезличивает логин и следы входов,
+// отзывает токены, сессии и API ключи, удаляет второй фактор.
+// Заказы, списания и журнал корректировок сохраняются для учета без изменений.
+// Заказы удаленного пользователя больше не опрашиваются и не пополняют баланс,
+// уже начатое начисление удаление дожидается на блокировке строки пользователя.
+// При forfeit остаток баланса списывается системной записью в журнале корректировок.
+// Вернет списанную сумму или ErrNoRecordsFound, если пользователь не найден или уже удален.
+func (s *dbStorage) DeleteUser(ctx context.Context, userID string, forfeit bool) (money.Amount, error) {
+	tx, err := s.pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return 0, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer func() {
+		if err := tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			s.log.Error("transaction delete user rollback", logger.Error(err))
+		}
+	}()
+
+	args := pgx.NamedArgs{"userID": userID}
+
+	queryUser := `
+		UPDATE users
+		SET
+			is_delete = TRUE,
+			login = 'deleted-' || user_id::TEXT,
+			pass_hash = ''::BYTEA,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE
+			user_id = @userID
+			AND NOT COALESCE(is_delete, FALSE)`
+
+	tag, err := tx.Exec(ctx, queryUser, args)
+	if err != nil {
+		return 0, fmt.Errorf("users update is_delete %v: %w", err, storage.ErrInternal)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return 0, storage.ErrNoRecordsFound
+	}
+
+	if _, err := revokeUserTokens(ctx, tx, userID); err != nil {
+		return 0, err
+	}
+
+	queries := []struct {
+		name  string
+		query string
+	}{
+		{"anonymize sessions", `
+			UPDATE sessions
+			SET
+				ip = '',
+				user_agent = ''
+			WHERE
+				user_id = @userID`},
+		{"revoke api keys", `
+			UPDATE api_keys
+			SET
+				revoked_at = COALESCE(revoked_at, CURRENT_TIMESTAMP),
+				last_used_ip = NULL
+			WHERE
+				user_id = @userID`},
+		{"delete totp", `DELETE FROM user_totp WHERE user_id = @userID`},
+		{"delete recovery codes", `DELETE FROM totp_recovery_codes WHERE user_id = @userID`},
+		{"delete mfa challenges", `DELETE FROM mfa_challenges WHERE user_id = @userID`},
+		{"delete password reset tokens", `DELETE FROM password_reset_tokens WHERE user_id = @userID`},
+		{"delete idempotency keys", `DELETE FROM idempotency_keys WHERE user_id = @userID`},
+	}
+
+	for _, q := range queries {
+		if _, err := tx.Exec(ctx, q.query, args); err != nil {
+			return 0, fmt.Errorf("%s %v: %w", q.name, err, storage.ErrInternal)
+		}
+	}
+
+	var forfeited money.Amount
+	if forfeit {
+		if forfeited, err = forfeitBalance(ctx, tx, userID); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("transaction delete user commit: %w", err)
+	}
+
+	return forfeited, nil
+}
+
+// forfeitBalance обнуляет текущий баланс проводкой в транзакции вызывающего,
+// списание записывается в журнал корректировок без администратора
+func forfeitBalance(ctx context.Context, tx pgx.Tx, userID string) (money.Amount, error) {
+	queryBalance := `
+		SELECT
+			current
+		FROM
+			user_balance
+		WHERE
+			user_id = @userID
+		FOR UPDATE`
+
+	var current money.Amount
+	if err := tx.QueryRow(ctx, queryBalance, pgx.NamedArgs{"userID": userID}).Scan(&current); err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			return 0, nil
+		default:
+			return 0, fmt.Errorf("user_balance forfeit %v: %w", err, storage.ErrInternal)
+		}
+	}
+
+	if current <= 0 {
+		return 0, nil
+	}
+
+	adjustmentID := uuid.NewString()
+
+	if _, err := postEntry(ctx, tx, ledgerEntry{
+		kind:      entryAdjustment,
+		userID:    userID,
+		reference: adjustmentID,
+		account:   accountAdjustment,
+		amount:    -current,
+	}); err != nil {
+		return 0, err
+	}
+
+	queryAdjustment := `
+		INSERT INTO
+			balance_adjustments (adjustment_id, user_id, admin_id, amount, reason, comment)
+		VALUES
+			(@adjustmentID, @userID, NULL, @amount, 'account_deletion', 'остаток аннулирован при удалении учетной записи')`
+
+	argsAdjustment := pgx.NamedArgs{
+		"adjustmentID": adjustmentID,
+		"userID":       userID,
+		"amount":       -current,
+	}
+
+	if _, err := tx.Exec(ctx, queryAdjustment, argsAdjustment); err != nil {
+		return 0, fmt.Errorf("balance_adjustments insert %v: %w", err, storage.ErrInternal)
+	}
+
+	return current, nil
+}
@@ -0,0 +1,200 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/vladislav-kr/gophermart/internal/logger"
+	"github.com/vladislav-kr/gophermart/internal/storage"
+)
+
+// SearchUsers пользователи, логин которых содержит подстроку login
+func (s *dbStorage) SearchUsers(ctx context.Context, login string, limit uint32) ([]storage.User, error) {
+	if limit == 0 {
+		limit = 50
+	}
+
+	query := `
+		SELECT
+			user_id,
+			login,
+			pass_hash,
+			token_generation,
+			COALESCE(is_blocked, FALSE) AS is_blocked,
+			COALESCE(is_delete, FALSE) AS is_delete,
+			role
+		FROM
+			users
+		WHERE
+			login ILIKE '%' || @login || '%' ESCAPE '\'
+		ORDER BY
+			login
+		LIMIT
+			@limit`
+
+	escaper := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+	args := pgx.NamedArgs{
+		"login": escaper.Replace(login),
+		"limit": limit,
+	}
+
+	rows, err := s.pool.Query(ctx, query, args)
+	if err != nil {
+		return nil, fmt.Errorf("query search users %v: %w", err, storage.ErrInternal)
+	}
+
+	users, err := pgx.CollectRows(rows, pgx.RowToStructByName[storage.User])
+	if err != nil {
+		return nil, fmt.Errorf("collect rows users %v: %w", err, storage.ErrInternal)
+	}
+
+	if len(users) == 0 {
+		return nil, storage.ErrNoRecordsFound
+	}
+
+	return users, nil
+}
+
+func (s *dbStorage) SetUserBlocked(ctx context.Context, userID string, blocked bool) error {
+	query := `
+		UPDATE users
+		SET
+			is_blocked = @blocked,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE
+			user_id = @userID`
+
+	args := pgx.NamedArgs{
+		"userID":  userID,
+		"blocked": blocked,
+	}
+
+	tag, err := s.pool.Exec(ctx, query, args)
+	if err != nil {
+		return fmt.Errorf("users update is_blocked %v: %w", err, storage.ErrInternal)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return storage.ErrNoRecordsFound
+	}
+
+	return nil
+}
+
+// RecheckOrder возвращает заказ в статус NEW для повторного опроса системы расчёта.
+// Начисление за обработанный заказ сторнируется в той же транзакции, новое
+// начисление проводится после повторного расчёта. Вернет ErrConstraints, если
+// начисленные баллы уже потрачены.
+func (s *dbStorage) RecheckOrder(ctx context.Context, orderID string) error {
+	tx, err := s.pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer func() {
+		if err := tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			s.log.Error("transaction recheck order rollback", logger.Error(err))
+		}
+	}()
+
+	queryOrder := `
+		SELECT
+			status
+		FROM
+			orders
+		WHERE
+			order_id = @orderID
+		FOR UPDATE`
+
+	args := pgx.NamedArgs{"orderID": orderID}
+
+	var status string
+	if err := tx.QueryRow(ctx, queryOrder, args).Scan(&status); err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			return storage.ErrNoRecordsFound
+		default:
+			return fmt.Errorf("recheck order %v: %w", err, storage.ErrInternal)
+		}
+	}
+
+	if status == "PROCESSED" {
+		queryAccruals := `
+			SELECT
+				e.entry_id
+			FROM
+				ledger_entries e
+			WHERE
+				e.kind = 'accrual'
+				AND e.reference = @orderID
+				AND NOT EXISTS (
+					SELECT
+						1
+					FROM
+						ledger_entries r
+					WHERE
+						r.reverses_entry_id = e.entry_id
+				)`
+
+		rows, err := tx.Query(ctx, queryAccruals, args)
+		if err != nil {
+			return fmt.Errorf("query order accruals %v: %w", err, storage.ErrInternal)
+		}
+
+		entryIDs, err := pgx.CollectRows(rows, pgx.RowTo[string])
+		if err != nil {
+			return fmt.Errorf("collect rows order accruals %v: %w", err, storage.ErrInternal)
+		}
+
+		for _, entryID := range entryIDs {
+			if _, err := reverseEntry(ctx, tx, entryID); err != nil {
+				return err
+			}
+		}
+	}
+
+	// сторнированное начисление обнуляется, смена статуса попадает в историю заказа
+	queryRecheck := `
+		WITH
+			updated AS (
+				UPDATE orders
+				SET
+					status = 'NEW',
+					accrual = CASE
+						WHEN status = 'PROCESSED' THEN 0
+						ELSE accrual
+					END,
+					changed_at = CURRENT_TIMESTAMP
+				WHERE
+					order_id = @orderID
+				RETURNING
+					order_id,
+					status,
+					accrual
+			)
+		INSERT INTO
+			order_events (order_id, status, accrual)
+		SELECT
+			order_id,
+			status,
+			COALESCE(accrual, 0)
+		FROM
+			updated
+		WHERE
+			@previous::TEXT <> 'NEW'`
+
+	args["previous"] = status
+
+	if _, err := tx.Exec(ctx, queryRecheck, args); err != nil {
+		return fmt.Errorf("recheck order %v: %w", err, storage.ErrInternal)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("transaction recheck order commit: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,163 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/vladislav-kr/gophermart/internal/storage"
+)
+
+const apiKeyColumns = `
+			key_id,
+			user_id,
+			name,
+			prefix,
+			scopes,
+			created_at,
+			expires_at,
+			last_used_at,
+			COALESCE(last_used_ip, '') AS last_used_ip,
+			use_count,
+			revoked_at`
+
+// CreateAPIKey сохраняет хеш нового API ключа
+func (s *dbStorage) CreateAPIKey(ctx context.Context, key storage.CreateAPIKey) (*storage.APIKey, error) {
+	query := `
+		INSERT INTO
+			api_keys (key_id, user_id, name, prefix, key_hash, scopes, expires_at)
+		VALUES
+			(@keyID, @userID, @name, @prefix, @keyHash, @scopes, @expiresAt)
+		RETURNING` + apiKeyColumns
+
+	args := pgx.NamedArgs{
+		"keyID":     uuid.NewString(),
+		"userID":    key.UserID,
+		"name":      key.Name,
+		"prefix":    key.Prefix,
+		"keyHash":   key.KeyHash,
+		"scopes":    key.Scopes,
+		"expiresAt": key.ExpiresAt,
+	}
+
+	rows, err := s.pool.Query(ctx, query, args)
+	if err != nil {
+		return nil, fmt.Errorf("api_keys insert %v: %w", err, storage.ErrInternal)
+	}
+
+	created, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[storage.APIKey])
+	if err != nil {
+		return nil, fmt.Errorf("collect row api key %v: %w", err, storage.ErrInternal)
+	}
+
+	return &created, nil
+}
+
+// APIKeys ключи пользователя, включая отозванные, новые первыми
+func (s *dbStorage) APIKeys(ctx context.Context, userID string) ([]storage.APIKey, error) {
+	query := `
+		SELECT` + apiKeyColumns + `
+		FROM
+			api_keys
+		WHERE
+			user_id = @userID
+		ORDER BY
+			created_at DESC`
+
+	rows, err := s.pool.Query(ctx, query, pgx.NamedArgs{"userID": userID})
+	if err != nil {
+		return nil, fmt.Errorf("query api keys %v: %w", err, storage.ErrInternal)
+	}
+
+	keys, err := pgx.CollectRows(rows, pgx.RowToStructByName[storage.APIKey])
+	if err != nil {
+		return nil, fmt.Errorf("collect rows api keys %v: %w", err, storage.ErrInternal)
+	}
+
+	if len(keys) == 0 {
+		return nil, storage.ErrNoRecordsFound
+	}
+
+	return keys, nil
+}
+
+// APIKeyByHash ключ по хешу, проверка срока и отзыва остается вызывающему
+func (s *dbStorage) APIKeyByHash(ctx context.Context, keyHash []byte) (*storage.APIKey, error) {
+	query := `
+		SELECT` + apiKeyColumns + `
+		FROM
+			api_keys
+		WHERE
+			key_hash = @keyHash`
+
+	rows, err := s.pool.Query(ctx, query, pgx.NamedArgs{"keyHash": keyHash})
+	if err != nil {
+		return nil, fmt.Errorf("query api key %v: %w", err, storage.ErrInternal)
+	}
+
+	key, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[storage.APIKey])
+	if err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			return nil, storage.ErrNoRecordsFound
+		default:
+			return nil, fmt.Errorf("collect row api key %v: %w", err, storage.ErrInternal)
+		}
+	}
+
+	return &key, nil
+}
+
+// RevokeAPIKey отзывает ключ пользователя,
+// вернет ErrNoRecordsFound, если ключ не найден или уже отозван
+func (s *dbStorage) RevokeAPIKey(ctx context.Context, userID, keyID string) error {
+	query := `
+		UPDATE api_keys
+		SET
+			revoked_at = CURRENT_TIMESTAMP
+		WHERE
+			key_id = @keyID
+			AND user_id = @userID
+			AND revoked_at IS NULL`
+
+	args := pgx.NamedArgs{
+		"keyID":  keyID,
+		"userID": userID,
+	}
+
+	tag, err := s.pool.Exec(ctx, query, args)
+	if err != nil {
+		return fmt.Errorf("api_keys update revoked_at %v: %w", err, storage.ErrInternal)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return storage.ErrNoRecordsFound
+	}
+
+	return nil
+}
+
+// RecordAPIKeyUsage учитывает использование ключа
+func (s *dbStorage) RecordAPIKeyUsage(ctx context.Context, keyID, ip string) error {
+	query := `
+		UPDATE api_keys
+		SET
+			last_used_at = CURRENT_TIMESTAMP,
+			last_used_ip = @ip,
+			use_count = use_count + 1
+		WHERE
+			key_id = @keyID`
+
+	args := pgx.NamedArgs{
+		"keyID": keyID,
+		"ip":    ip,
+	}
+
+	if _, err := s.pool.Exec(ctx, query, args); err != nil {
+		return fmt.Errorf("api_keys update usage %v: %w", err, storage.ErrInternal)
+	}
+
+	return nil
+}
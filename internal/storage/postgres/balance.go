@@ -0,0 +1,109 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/vladislav-kr/gophermart/internal/logger"
+	"github.com/vladislav-kr/gophermart/internal/storage"
+)
+
+// AdjustBalance ручная корректировка баланса с записью в журнал корректировок.
+// Проводка записывается в журнал баллов, списание больше текущего баланса
+// отклоняется ограничением fk_current. Начисленные корректировкой баллы не сгорают.
+func (s *dbStorage) AdjustBalance(
+	ctx context.Context,
+	adjustment storage.BalanceAdjustment,
+) (*storage.Balance, error) {
+	tx, err := s.pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer func() {
+		if err := tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			s.log.Error("transaction adjust balance rollback", logger.Error(err))
+		}
+	}()
+
+	adjustmentID := uuid.NewString()
+
+	balance, err := postEntry(ctx, tx, ledgerEntry{
+		kind:      entryAdjustment,
+		userID:    adjustment.UserID,
+		reference: adjustmentID,
+		account:   accountAdjustment,
+		amount:    adjustment.Amount,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	queryAdjustment := `
+		INSERT INTO
+			balance_adjustments (adjustment_id, user_id, admin_id, amount, reason, comment)
+		VALUES
+			(@adjustmentID, @userID, @adminID, @amount, @reason, @comment)`
+
+	argsAdjustment := pgx.NamedArgs{
+		"adjustmentID": adjustmentID,
+		"userID":       adjustment.UserID,
+		"adminID":      adjustment.AdminID,
+		"amount":       adjustment.Amount,
+		"reason":       adjustment.Reason,
+		"comment":      adjustment.Comment,
+	}
+
+	if _, err := tx.Exec(ctx, queryAdjustment, argsAdjustment); err != nil {
+		return nil, fmt.Errorf("balance_adjustments insert %v: %w", err, storage.ErrInternal)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("transaction adjust balance commit: %w", err)
+	}
+
+	return balance, nil
+}
+
+// BalanceHistory проводки журнала по счету пользователя, новые первыми
+func (s *dbStorage) BalanceHistory(ctx context.Context, userID string) ([]storage.BalanceEntry, error) {
+	query := `
+		SELECT
+			e.kind AS entry_type,
+			CASE
+				WHEN e.kind IN ('accrual', 'withdrawal', 'reversal') THEN e.reference
+				ELSE ''
+			END AS order_id,
+			COALESCE(a.reason, '') AS reason,
+			p.amount AS sum,
+			e.created_at AS processed_at
+		FROM
+			ledger_entries e
+			JOIN ledger_postings p ON p.entry_id = e.entry_id
+			AND p.account = 'user'
+			LEFT JOIN balance_adjustments a ON e.kind = 'adjustment'
+			AND a.adjustment_id::TEXT = e.reference
+		WHERE
+			e.user_id = @userID
+		ORDER BY
+			e.created_at DESC,
+			p.posting_id DESC`
+
+	rows, err := s.pool.Query(ctx, query, pgx.NamedArgs{"userID": userID})
+	if err != nil {
+		return nil, fmt.Errorf("query balance history by userID %s: %w", userID, err)
+	}
+
+	entries, err := pgx.CollectRows(rows, pgx.RowToStructByName[storage.BalanceEntry])
+	if err != nil {
+		return nil, fmt.Errorf("collect rows balance history: %w", err)
+	}
+
+	if len(entries) == 0 {
+		return nil, storage.ErrNoRecordsFound
+	}
+
+	return entries, nil
+}
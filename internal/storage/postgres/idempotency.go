@@ -0,0 +1,208 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/vladislav-kr/gophermart/internal/logger"
+	"github.com/vladislav-kr/gophermart/internal/storage"
+)
+
+// beginIdempotencyAttempts попытки захвата ключа, который удаляется параллельно
+const beginIdempotencyAttempts = 3
+
+// BeginIdempotency захватывает ключ для выполнения запроса.
+// Вернет nil, если ключ захвачен, иначе сохраненную запись ключа.
+// Истекший ключ и ключ, запрос по которому не завершился за lockTimeout,
+// захватываются заново. Вернет ErrUniqueViolation, если ключ не удалось
+// ни захватить, ни прочитать за beginIdempotencyAttempts попыток.
+func (s *dbStorage) BeginIdempotency(
+	ctx context.Context,
+	key storage.IdempotencyKey,
+	lockTimeout time.Duration,
+) (*storage.IdempotencyKey, error) {
+	tx, err := s.pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("begin transaction %v: %w", err, storage.ErrInternal)
+	}
+	defer func() {
+		if err := tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			s.log.Error("transaction begin idempotency rollback", logger.Error(err))
+		}
+	}()
+
+	args := pgx.NamedArgs{
+		"userID":      key.UserID,
+		"key":         key.Key,
+		"requestHash": key.RequestHash,
+		"expiresAt":   key.ExpiresAt,
+		"lockedAfter": time.Now().Add(-lockTimeout),
+	}
+
+	queryStale := `
+		DELETE FROM idempotency_keys
+		WHERE
+			user_id = @userID
+			AND idempotency_key = @key
+			AND (
+				expires_at < CURRENT_TIMESTAMP
+				OR (status_code IS NULL AND created_at < @lockedAfter)
+			)`
+
+	if _, err := tx.Exec(ctx, queryStale, args); err != nil {
+		return nil, fmt.Errorf("delete stale idempotency key %v: %w", err, storage.ErrInternal)
+	}
+
+	queryInsert := `
+		INSERT INTO
+			idempotency_keys (user_id, idempotency_key, request_hash, expires_at)
+		VALUES
+			(@userID, @key, @requestHash, @expiresAt)
+		ON CONFLICT DO NOTHING`
+
+	querySelect := `
+		SELECT
+			user_id,
+			idempotency_key,
+			request_hash,
+			status_code,
+			content_type,
+			body,
+			created_at,
+			expires_at
+		FROM
+			idempotency_keys
+		WHERE
+			user_id = @userID
+			AND idempotency_key = @key`
+
+	// запись, из-за которой вставка не удалась, могла быть удалена параллельным
+	// запросом до чтения, тогда ключ захватывается заново
+	for attempt := 0; attempt < beginIdempotencyAttempts; attempt++ {
+		tag, err := tx.Exec(ctx, queryInsert, args)
+		if err != nil {
+			return nil, fmt.Errorf("insert idempotency key %v: %w", err, storage.ErrInternal)
+		}
+
+		if tag.RowsAffected() > 0 {
+			if err := tx.Commit(ctx); err != nil {
+				return nil, fmt.Errorf("commit begin idempotency %v: %w", err, storage.ErrInternal)
+			}
+			return nil, nil
+		}
+
+		rows, err := tx.Query(ctx, querySelect, args)
+		if err != nil {
+			return nil, fmt.Errorf("query idempotency key %v: %w", err, storage.ErrInternal)
+		}
+
+		saved, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[storage.IdempotencyKey])
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				continue
+			}
+			return nil, fmt.Errorf("collect one row idempotency key %v: %w", err, storage.ErrInternal)
+		}
+
+		if err := tx.Commit(ctx); err != nil {
+			return nil, fmt.Errorf("commit begin idempotency %v: %w", err, storage.ErrInternal)
+		}
+
+		return &saved, nil
+	}
+
+	return nil, fmt.Errorf("idempotency key %s: %w", key.Key, storage.ErrUniqueViolation)
+}
+
+// CompleteIdempotency сохраняет ответ на запрос по захваченному ключу
+func (s *dbStorage) CompleteIdempotency(ctx context.Context, key storage.IdempotencyKey) error {
+	query := `
+		UPDATE idempotency_keys
+		SET
+			status_code = @statusCode,
+			content_type = @contentType,
+			body = @body
+		WHERE
+			user_id = @userID
+			AND idempotency_key = @key
+			AND request_hash = @requestHash
+			AND status_code IS NULL`
+
+	args := pgx.NamedArgs{
+		"userID":      key.UserID,
+		"key":         key.Key,
+		"requestHash": key.RequestHash,
+		"statusCode":  key.StatusCode,
+		"contentType": key.ContentType,
+		"body":        key.Body,
+	}
+
+	tag, err := s.pool.Exec(ctx, query, args)
+	if err != nil {
+		return fmt.Errorf("complete idempotency key %v: %w", err, storage.ErrInternal)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return storage.ErrNoRecordsFound
+	}
+
+	return nil
+}
+
+// DeleteIdempotency освобождает незавершенный ключ, чтобы запрос можно было повторить
+func (s *dbStorage) DeleteIdempotency(ctx context.Context, userID, key string) error {
+	query := `
+		DELETE FROM idempotency_keys
+		WHERE
+			user_id = @userID
+			AND idempotency_key = @key
+			AND status_code IS NULL`
+
+	args := pgx.NamedArgs{
+		"userID": userID,
+		"key":    key,
+	}
+
+	if _, err := s.pool.Exec(ctx, query, args); err != nil {
+		return fmt.Errorf("delete idempotency key %v: %w", err, storage.ErrInternal)
+	}
+
+	return nil
+}
+
+// PurgeIdempotency удаляет до limit ключей, истекших к now.
+// Вернет количество удаленных ключей.
+func (s *dbStorage) PurgeIdempotency(ctx context.Context, now time.Time, limit uint32) (int64, error) {
+	if limit == 0 {
+		limit = 1000
+	}
+
+	query := `
+		DELETE FROM idempotency_keys
+		WHERE
+			ctid IN (
+				SELECT
+					ctid
+				FROM
+					idempotency_keys
+				WHERE
+					expires_at < @now
+				LIMIT
+					@limit
+			)`
+
+	args := pgx.NamedArgs{
+		"now":   now,
+		"limit": limit,
+	}
+
+	tag, err := s.pool.Exec(ctx, query, args)
+	if err != nil {
+		return 0, fmt.Errorf("purge idempotency keys %v: %w", err, storage.ErrInternal)
+	}
+
+	return tag.RowsAffected(), nil
+}
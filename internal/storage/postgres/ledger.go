@@ -0,0 +1,269 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/vladislav-kr/gophermart/internal/domain/money"
+	"github.com/vladislav-kr/gophermart/internal/storage"
+)
+
+// виды проводок журнала баллов
+const (
+	entryAccrual    = "accrual"
+	entryWithdrawal = "withdrawal"
+	entryAdjustment = "adjustment"
+	entryReversal   = "reversal"
+	entryExpiry     = "expiry"
+)
+
+// системные счета, корреспондирующие со счетом пользователя
+const (
+	accountAccrual    = "accrual"    // начисления системы лояльности
+	accountRedemption = "redemption" // списания в счет оплаты заказов
+	accountAdjustment = "adjustment" // корректировки баланса
+	accountExpiry     = "expiry"     // сгоревшие баллы
+)
+
+// ledgerEntry проводка между счетом пользователя и системным счетом,
+// amount - изменение баллов пользователя
+type ledgerEntry struct {
+	// пустой - новый идентификатор
+	id        string
+	kind      string
+	userID    string
+	reference string
+	account   string
+	amount    money.Amount
+	// срок сгорания партии начисленных баллов, 0 - не сгорают
+	expiryMonths int
+	// сторнируемая проводка и ее вид, только для reversal
+	reverses     string
+	reversesKind string
+}
+
+// queryPostEntry записывает проводку с двумя движениями и обновляет
+// кеш остатков user_balance в том же запросе. Начисление открывает
+// новую партию баллов.
+const queryPostEntry = `
+	WITH
+		entry AS (
+			INSERT INTO
+				ledger_entries (entry_id, kind, user_id, reference, reverses_entry_id)
+			VALUES
+				(@entryID, @kind, @userID, @reference, @reversesEntryID)
+			RETURNING
+				entry_id
+		),
+		postings AS (
+			INSERT INTO
+				ledger_postings (entry_id, account, user_id, amount)
+			SELECT
+				entry.entry_id,
+				posting.account,
+				posting.user_id,
+				posting.amount
+			FROM
+				entry,
+				(
+					VALUES
+						('user', @userID::UUID, @amount::NUMERIC),
+						(@account, NULL::UUID, - @amount::NUMERIC)
+				) AS posting (account, user_id, amount)
+		),
+		lot AS (
+			INSERT INTO
+				point_lots (user_id, entry_id, amount, remaining, expires_at)
+			SELECT
+				@userID,
+				entry.entry_id,
+				@amount,
+				@amount,
+				CASE
+					WHEN @expiryMonths::INT > 0 THEN CURRENT_TIMESTAMP + make_interval(months => @expiryMonths::INT)
+				END
+			FROM
+				entry
+			WHERE
+				@amount::NUMERIC > 0
+		)
+	UPDATE user_balance
+	SET
+		current = current + @amount,
+		withdrawn = withdrawn + @withdrawn
+	WHERE
+		user_id = @userID
+	RETURNING
+		current,
+		withdrawn`
+
+func (e ledgerEntry) args() pgx.NamedArgs {
+	// сторно списания уменьшает и сумму списаний
+	var withdrawn money.Amount
+	if e.kind == entryWithdrawal || e.reversesKind == entryWithdrawal {
+		withdrawn = -e.amount
+	}
+	entryID := e.id
+	if entryID == "" {
+		entryID = uuid.NewString()
+	}
+	var reverses *string
+	if e.reverses != "" {
+		reverses = &e.reverses
+	}
+	return pgx.NamedArgs{
+		"entryID":         entryID,
+		"kind":            e.kind,
+		"userID":          e.userID,
+		"reference":       e.reference,
+		"account":         e.account,
+		"amount":          e.amount,
+		"withdrawn":       withdrawn,
+		"expiryMonths":    e.expiryMonths,
+		"reversesEntryID": reverses,
+	}
+}
+
+// postEntry записывает проводку в транзакции вызывающего и возвращает остатки.
+// Списание расходует партии баллов от старых к новым, сгорание закрывает
+// партии само. Уход остатка в минус отклоняется ограничением fk_current.
+func postEntry(ctx context.Context, tx pgx.Tx, entry ledgerEntry) (*storage.Balance, error) {
+	if entry.amount < 0 && entry.kind != entryExpiry {
+		if err := consumeLots(ctx, tx, entry.userID, -entry.amount); err != nil {
+			return nil, err
+		}
+	}
+
+	rows, err := tx.Query(ctx, queryPostEntry, entry.args())
+	if err != nil {
+		return nil, fmt.Errorf("post ledger entry %v: %w", err, storage.ErrInternal)
+	}
+
+	balance, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[storage.Balance])
+	if err != nil {
+		return nil, ledgerError(err)
+	}
+
+	return &balance, nil
+}
+
+// reverseEntry сторнирует начисление или списание entryID в транзакции
+// вызывающего: записывает проводку reversal с обратными движениями по тем же
+// счетам. Сторно начисления расходует партии баллов как списание, поэтому
+// потраченное начисление отклоняется ограничением fk_current. Повторное
+// сторно вернет ErrUniqueViolation.
+func reverseEntry(ctx context.Context, tx pgx.Tx, entryID string) (*storage.Balance, error) {
+	type original struct {
+		Kind      string       `db:"kind"`
+		UserID    string       `db:"user_id"`
+		Reference string       `db:"reference"`
+		Account   string       `db:"account"`
+		Amount    money.Amount `db:"amount"`
+	}
+
+	// amount - движение по системному счету, обратное изменению баллов пользователя
+	query := `
+		SELECT
+			e.kind,
+			e.user_id,
+			e.reference,
+			p.account,
+			p.amount
+		FROM
+			ledger_entries e
+			JOIN ledger_postings p ON p.entry_id = e.entry_id
+			AND p.account <> 'user'
+		WHERE
+			e.entry_id = @entryID`
+
+	rows, err := tx.Query(ctx, query, pgx.NamedArgs{"entryID": entryID})
+	if err != nil {
+		return nil, fmt.Errorf("query ledger entry %v: %w", err, storage.ErrInternal)
+	}
+
+	entry, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[original])
+	if err != nil {
+		return nil, ledgerError(err)
+	}
+
+	if entry.Kind != entryAccrual && entry.Kind != entryWithdrawal {
+		return nil, fmt.Errorf("reverse %s entry: %w", entry.Kind, storage.ErrConstraints)
+	}
+
+	return postEntry(ctx, tx, ledgerEntry{
+		kind:         entryReversal,
+		userID:       entry.UserID,
+		reference:    entry.Reference,
+		account:      entry.Account,
+		amount:       entry.Amount,
+		reverses:     entryID,
+		reversesKind: entry.Kind,
+	})
+}
+
+func ledgerError(err error) error {
+	var pgErr *pgconn.PgError
+	switch {
+	case errors.Is(err, pgx.ErrNoRows),
+		errors.As(err, &pgErr) && pgErr.Code == pgerrcode.ForeignKeyViolation:
+		return storage.ErrNoRecordsFound
+	case errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation:
+		return fmt.Errorf("post ledger entry %v: %w", err, storage.ErrUniqueViolation)
+	case errors.As(err, &pgErr) && pgErr.Code == pgerrcode.CheckViolation:
+		return fmt.Errorf("post ledger entry %v: %w", err, storage.ErrConstraints)
+	default:
+		return fmt.Errorf("post ledger entry %v: %w", err, storage.ErrInternal)
+	}
+}
+
+// LedgerBalance остатки пользователя, пересчитанные по журналу на момент at.
+// Вернет ErrNoRecordsFound, если у пользователя нет баланса.
+func (s *dbStorage) LedgerBalance(ctx context.Context, userID string, at time.Time) (*storage.Balance, error) {
+	query := `
+		SELECT
+			COALESCE(SUM(p.amount), 0) AS current,
+			COALESCE(- SUM(p.amount) FILTER (
+				WHERE
+					e.kind = 'withdrawal'
+					OR r.kind = 'withdrawal'
+			), 0) AS withdrawn
+		FROM
+			user_balance b
+			LEFT JOIN ledger_entries e ON e.user_id = b.user_id
+			AND e.created_at <= @at
+			LEFT JOIN ledger_entries r ON r.entry_id = e.reverses_entry_id
+			LEFT JOIN ledger_postings p ON p.entry_id = e.entry_id
+			AND p.account = 'user'
+		WHERE
+			b.user_id = @userID
+		GROUP BY
+			b.user_id`
+
+	args := pgx.NamedArgs{
+		"userID": userID,
+		"at":     at,
+	}
+
+	rows, err := s.pool.Query(ctx, query, args)
+	if err != nil {
+		return nil, fmt.Errorf("query ledger balance by userID %s: %w", userID, err)
+	}
+
+	balance, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[storage.Balance])
+	if err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			return nil, storage.ErrNoRecordsFound
+		default:
+			return nil, fmt.Errorf("collect one row ledger balance %v: %w", err, storage.ErrInternal)
+		}
+	}
+
+	return &balance, nil
+}
@@ -0,0 +1,103 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/vladislav-kr/gophermart/internal/storage"
+)
+
+// LoginAttempts счётчик неудачных попыток входа, общий для всех реплик
+func (s *dbStorage) LoginAttempts(ctx context.Context, key string) (*storage.LoginAttempts, error) {
+	query := `
+		SELECT
+			failures,
+			last_failure
+		FROM
+			login_attempts
+		WHERE
+			key = @key
+			AND expires_at > CURRENT_TIMESTAMP`
+
+	rows, err := s.pool.Query(ctx, query, pgx.NamedArgs{"key": key})
+	if err != nil {
+		return nil, fmt.Errorf("query login_attempts %v: %w", err, storage.ErrInternal)
+	}
+
+	attempts, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[storage.LoginAttempts])
+	if err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			return &storage.LoginAttempts{}, nil
+		default:
+			return nil, fmt.Errorf("collect row login_attempts %v: %w", err, storage.ErrInternal)
+		}
+	}
+
+	return &attempts, nil
+}
+
+// IncrementLoginAttempts атомарно увеличивает счётчик, устаревший счётчик начинается заново.
+// Заодно удаляются устаревшие счётчики других ключей.
+func (s *dbStorage) IncrementLoginAttempts(
+	ctx context.Context,
+	key string,
+	ttl time.Duration,
+) (*storage.LoginAttempts, error) {
+	query := `
+		WITH
+			expired AS (
+				DELETE FROM login_attempts
+				WHERE
+					expires_at < CURRENT_TIMESTAMP
+					AND key <> @key
+			)
+		INSERT INTO
+			login_attempts (key, failures, last_failure, expires_at)
+		VALUES
+			(@key, 1, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP + make_interval(secs => @ttl))
+		ON CONFLICT (key) DO UPDATE
+		SET
+			failures = CASE
+				WHEN login_attempts.expires_at < CURRENT_TIMESTAMP THEN 1
+				ELSE login_attempts.failures + 1
+			END,
+			last_failure = EXCLUDED.last_failure,
+			expires_at = EXCLUDED.expires_at
+		RETURNING
+			failures,
+			last_failure`
+
+	args := pgx.NamedArgs{
+		"key": key,
+		"ttl": ttl.Seconds(),
+	}
+
+	rows, err := s.pool.Query(ctx, query, args)
+	if err != nil {
+		return nil, fmt.Errorf("upsert login_attempts %v: %w", err, storage.ErrInternal)
+	}
+
+	attempts, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[storage.LoginAttempts])
+	if err != nil {
+		return nil, fmt.Errorf("collect row login_attempts %v: %w", err, storage.ErrInternal)
+	}
+
+	return &attempts, nil
+}
+
+func (s *dbStorage) ResetLoginAttempts(ctx context.Context, key string) error {
+	query := `
+		DELETE FROM login_attempts
+		WHERE
+			key = @key`
+
+	if _, err := s.pool.Exec(ctx, query, pgx.NamedArgs{"key": key}); err != nil {
+		return fmt.Errorf("delete login_attempts %v: %w", err, storage.ErrInternal)
+	}
+
+	return nil
+}
@@ -0,0 +1,254 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/vladislav-kr/gophermart/internal/domain/money"
+	"github.com/vladislav-kr/gophermart/internal/logger"
+	"github.com/vladislav-kr/gophermart/internal/storage"
+)
+
+// consumeLots уменьшает остатки партий пользователя на amount, начиная
+// с самых старых. Партии меняются под блокировкой баланса, чтобы параллельные
+// списания не расходовали один и тот же остаток.
+func consumeLots(ctx context.Context, tx pgx.Tx, userID string, amount money.Amount) error {
+	queryLock := `
+		SELECT
+			user_id
+		FROM
+			user_balance
+		WHERE
+			user_id = @userID
+		FOR UPDATE`
+
+	args := pgx.NamedArgs{
+		"userID": userID,
+		"amount": amount,
+	}
+
+	if _, err := tx.Exec(ctx, queryLock, args); err != nil {
+		return fmt.Errorf("user_balance lock %v: %w", err, storage.ErrInternal)
+	}
+
+	// earlier - сумма остатков партий, расходуемых раньше текущей
+	query := `
+		WITH
+			ordered AS (
+				SELECT
+					lot_id,
+					remaining,
+					SUM(remaining) OVER (
+						ORDER BY
+							earned_at,
+							lot_id
+					) - remaining AS earlier
+				FROM
+					point_lots
+				WHERE
+					user_id = @userID
+					AND remaining > 0
+			)
+		UPDATE point_lots l
+		SET
+			remaining = l.remaining - LEAST(o.remaining, @amount::NUMERIC - o.earlier)
+		FROM
+			ordered o
+		WHERE
+			l.lot_id = o.lot_id
+			AND o.earlier < @amount`
+
+	if _, err := tx.Exec(ctx, query, args); err != nil {
+		return fmt.Errorf("point_lots consume %v: %w", err, storage.ErrInternal)
+	}
+
+	return nil
+}
+
+// UsersWithExpiredPoints пользователи с несгоревшим остатком партий, срок которых наступил к now.
+// Баланс удаленных пользователей заморожен и не сгорает.
+func (s *dbStorage) UsersWithExpiredPoints(ctx context.Context, now time.Time, limit uint32) ([]string, error) {
+	if limit == 0 {
+		limit = 100
+	}
+
+	query := `
+		SELECT DISTINCT
+			l.user_id::TEXT
+		FROM
+			point_lots l
+			JOIN users u ON u.user_id = l.user_id
+		WHERE
+			l.remaining > 0
+			AND l.expires_at <= @now
+			AND NOT COALESCE(u.is_delete, FALSE)
+		LIMIT
+			@limit`
+
+	args := pgx.NamedArgs{
+		"now":   now,
+		"limit": limit,
+	}
+
+	rows, err := s.pool.Query(ctx, query, args)
+	if err != nil {
+		return nil, fmt.Errorf("query users with expired points %v: %w", err, storage.ErrInternal)
+	}
+
+	users, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, fmt.Errorf("collect rows users with expired points %v: %w", err, storage.ErrInternal)
+	}
+
+	if len(users) == 0 {
+		return nil, storage.ErrNoRecordsFound
+	}
+
+	return users, nil
+}
+
+// ExpirePoints закрывает партии пользователя со сроком до now и списывает
+// их остаток проводкой сгорания. Вернет сгоревшую сумму или ErrNoRecordsFound,
+// если сгорать нечему или пользователь удален. Сумма не превышает текущего
+// остатка, расхождение партий с балансом остается сверке.
+func (s *dbStorage) ExpirePoints(ctx context.Context, userID string, now time.Time) (money.Amount, error) {
+	tx, err := s.pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return 0, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer func() {
+		if err := tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			s.log.Error("transaction expire points rollback", logger.Error(err))
+		}
+	}()
+
+	queryBalance := `
+		SELECT
+			b.current
+		FROM
+			user_balance b
+			JOIN users u ON u.user_id = b.user_id
+		WHERE
+			b.user_id = @userID
+			AND NOT COALESCE(u.is_delete, FALSE)
+		FOR UPDATE OF b`
+
+	args := pgx.NamedArgs{
+		"userID": userID,
+		"now":    now,
+	}
+
+	var current money.Amount
+	if err := tx.QueryRow(ctx, queryBalance, args).Scan(&current); err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			return 0, storage.ErrNoRecordsFound
+		default:
+			return 0, fmt.Errorf("user_balance lock %v: %w", err, storage.ErrInternal)
+		}
+	}
+
+	queryExpired := `
+		SELECT
+			COALESCE(SUM(remaining), 0)
+		FROM
+			point_lots
+		WHERE
+			user_id = @userID
+			AND remaining > 0
+			AND expires_at <= @now`
+
+	var expired money.Amount
+	if err := tx.QueryRow(ctx, queryExpired, args).Scan(&expired); err != nil {
+		return 0, fmt.Errorf("point_lots expired sum %v: %w", err, storage.ErrInternal)
+	}
+
+	if expired <= 0 {
+		return 0, storage.ErrNoRecordsFound
+	}
+
+	amount := min(expired, current)
+
+	var entryID *string
+	if amount > 0 {
+		id := uuid.NewString()
+		entryID = &id
+
+		if _, err := postEntry(ctx, tx, ledgerEntry{
+			id:        id,
+			kind:      entryExpiry,
+			userID:    userID,
+			reference: now.UTC().Format(time.RFC3339),
+			account:   accountExpiry,
+			amount:    -amount,
+		}); err != nil {
+			return 0, err
+		}
+	}
+
+	queryClose := `
+		UPDATE point_lots
+		SET
+			remaining = 0,
+			expiry_entry_id = @entryID
+		WHERE
+			user_id = @userID
+			AND remaining > 0
+			AND expires_at <= @now`
+
+	args["entryID"] = entryID
+
+	if _, err := tx.Exec(ctx, queryClose, args); err != nil {
+		return 0, fmt.Errorf("point_lots expire %v: %w", err, storage.ErrInternal)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("transaction expire points commit: %w", err)
+	}
+
+	return amount, nil
+}
+
+// ExpiringPoints несгоревший остаток партий пользователя со сроком до until,
+// сгруппированный по сроку, ближайшие первыми
+func (s *dbStorage) ExpiringPoints(ctx context.Context, userID string, until time.Time) ([]storage.ExpiringPoints, error) {
+	query := `
+		SELECT
+			SUM(remaining) AS amount,
+			expires_at
+		FROM
+			point_lots
+		WHERE
+			user_id = @userID
+			AND remaining > 0
+			AND expires_at <= @until
+		GROUP BY
+			expires_at
+		ORDER BY
+			expires_at`
+
+	args := pgx.NamedArgs{
+		"userID": userID,
+		"until":  until,
+	}
+
+	rows, err := s.pool.Query(ctx, query, args)
+	if err != nil {
+		return nil, fmt.Errorf("query expiring points by userID %s: %w", userID, err)
+	}
+
+	points, err := pgx.CollectRows(rows, pgx.RowToStructByName[storage.ExpiringPoints])
+	if err != nil {
+		return nil, fmt.Errorf("collect rows expiring points %v: %w", err, storage.ErrInternal)
+	}
+
+	if len(points) == 0 {
+		return nil, storage.ErrNoRecordsFound
+	}
+
+	return points, nil
+}
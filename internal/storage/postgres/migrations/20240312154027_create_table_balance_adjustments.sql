@@ -0,0 +1,22 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE balance_adjustments (
+    adjustment_id UUID PRIMARY KEY,
+    user_id UUID NOT NULL,
+    admin_id UUID NOT NULL,
+    amount NUMERIC(15, 3) NOT NULL,
+    reason VARCHAR(30) NOT NULL,
+    comment TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_users FOREIGN KEY (user_id) REFERENCES users (user_id),
+    CONSTRAINT fk_admins FOREIGN KEY (admin_id) REFERENCES users (user_id),
+    CONSTRAINT fk_amount CHECK (amount <> 0)
+);
+
+CREATE INDEX balance_adjustments_user_id_idx ON balance_adjustments (user_id, created_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS balance_adjustments;
+-- +goose StatementEnd
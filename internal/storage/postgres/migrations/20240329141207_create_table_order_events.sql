@@ -0,0 +1,30 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE order_events (
+    event_id BIGSERIAL PRIMARY KEY,
+    order_id TEXT NOT NULL,
+    status VARCHAR(15) NOT NULL,
+    accrual NUMERIC(15, 3) NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_orders FOREIGN KEY (order_id) REFERENCES orders (order_id)
+);
+
+CREATE INDEX IF NOT EXISTS order_events_order_id_idx ON order_events (order_id, event_id);
+
+-- история уже загруженных заказов начинается с их текущего статуса
+INSERT INTO
+    order_events (order_id, status, accrual, created_at)
+SELECT
+    order_id,
+    status,
+    COALESCE(accrual, 0),
+    COALESCE(changed_at, uploaded_at, CURRENT_TIMESTAMP)
+FROM
+    orders;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS order_events_order_id_idx;
+DROP TABLE IF EXISTS order_events;
+-- +goose StatementEnd
@@ -0,0 +1,138 @@
+-- +goose Up
+-- +goose StatementBegin
+-- проводка журнала баллов: accrual - начисление за заказ, withdrawal - списание
+-- в счет оплаты заказа, adjustment - корректировка, reversal - сторно проводки
+CREATE TABLE ledger_entries (
+    entry_id UUID PRIMARY KEY,
+    kind VARCHAR(15) NOT NULL,
+    user_id UUID NOT NULL,
+    -- номер заказа, идентификатор корректировки или сторнируемой проводки
+    reference TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_users FOREIGN KEY (user_id) REFERENCES users (user_id),
+    CONSTRAINT fk_kind CHECK (kind IN ('accrual', 'withdrawal', 'adjustment', 'reversal'))
+);
+CREATE INDEX IF NOT EXISTS ledger_entries_user_id_idx ON ledger_entries (user_id, created_at);
+
+-- движение по счету: user - баллы пользователя, остальные счета системные.
+-- Положительная сумма увеличивает остаток счета, сумма движений проводки равна нулю.
+CREATE TABLE ledger_postings (
+    posting_id BIGSERIAL PRIMARY KEY,
+    entry_id UUID NOT NULL,
+    account VARCHAR(15) NOT NULL,
+    user_id UUID,
+    amount NUMERIC(15, 3) NOT NULL,
+    CONSTRAINT fk_ledger_entries FOREIGN KEY (entry_id) REFERENCES ledger_entries (entry_id),
+    CONSTRAINT fk_account CHECK (account IN ('user', 'accrual', 'redemption', 'adjustment')),
+    CONSTRAINT fk_user_account CHECK ((account = 'user') = (user_id IS NOT NULL)),
+    CONSTRAINT fk_amount CHECK (amount <> 0)
+);
+CREATE INDEX IF NOT EXISTS ledger_postings_entry_id_idx ON ledger_postings (entry_id);
+CREATE INDEX IF NOT EXISTS ledger_postings_user_id_idx ON ledger_postings (user_id) WHERE user_id IS NOT NULL;
+
+CREATE FUNCTION ledger_entry_balanced() RETURNS TRIGGER AS $$
+BEGIN
+    IF (SELECT SUM(amount) FROM ledger_postings WHERE entry_id = NEW.entry_id) <> 0 THEN
+        RAISE EXCEPTION 'ledger entry % is not balanced', NEW.entry_id;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+-- баланс проводки проверяется при фиксации транзакции, когда записаны все движения
+CREATE CONSTRAINT TRIGGER ledger_postings_balanced
+    AFTER INSERT ON ledger_postings
+    DEFERRABLE INITIALLY DEFERRED
+    FOR EACH ROW EXECUTE FUNCTION ledger_entry_balanced();
+
+CREATE FUNCTION ledger_append_only() RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'ledger is append-only, use reversal entries';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER ledger_entries_append_only
+    BEFORE UPDATE OR DELETE ON ledger_entries
+    FOR EACH ROW EXECUTE FUNCTION ledger_append_only();
+
+CREATE TRIGGER ledger_postings_append_only
+    BEFORE UPDATE OR DELETE ON ledger_postings
+    FOR EACH ROW EXECUTE FUNCTION ledger_append_only();
+
+-- журнал восстанавливается по заказам, списаниям и корректировкам,
+-- user_balance остается как есть до сверки
+CREATE TEMPORARY TABLE ledger_backfill ON COMMIT DROP AS
+SELECT
+    uuid_generate_v4() AS entry_id,
+    'accrual' AS kind,
+    user_id,
+    order_id AS reference,
+    'accrual' AS account,
+    accrual AS amount,
+    COALESCE(changed_at, uploaded_at, CURRENT_TIMESTAMP) AS created_at
+FROM
+    orders
+WHERE
+    accrual > 0
+UNION ALL
+SELECT
+    uuid_generate_v4(),
+    'withdrawal',
+    user_id,
+    order_id,
+    'redemption',
+    - sum,
+    COALESCE(processed_at, CURRENT_TIMESTAMP)
+FROM
+    withdrawals
+WHERE
+    sum > 0
+UNION ALL
+SELECT
+    uuid_generate_v4(),
+    'adjustment',
+    user_id,
+    adjustment_id::TEXT,
+    'adjustment',
+    amount,
+    COALESCE(created_at, CURRENT_TIMESTAMP)
+FROM
+    balance_adjustments;
+
+INSERT INTO
+    ledger_entries (entry_id, kind, user_id, reference, created_at)
+SELECT
+    entry_id,
+    kind,
+    user_id,
+    reference,
+    created_at
+FROM
+    ledger_backfill;
+
+INSERT INTO
+    ledger_postings (entry_id, account, user_id, amount)
+SELECT
+    entry_id,
+    'user',
+    user_id,
+    amount
+FROM
+    ledger_backfill
+UNION ALL
+SELECT
+    entry_id,
+    account,
+    NULL,
+    - amount
+FROM
+    ledger_backfill;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS ledger_postings;
+DROP TABLE IF EXISTS ledger_entries;
+DROP FUNCTION IF EXISTS ledger_append_only();
+DROP FUNCTION IF EXISTS ledger_entry_balanced();
+-- +goose StatementEnd
@@ -0,0 +1,10 @@
+-- +goose Up
+-- +goose StatementBegin
+-- за заказ начисляется не больше одного раза
+CREATE UNIQUE INDEX IF NOT EXISTS ledger_entries_accrual_uidx ON ledger_entries (reference) WHERE kind = 'accrual';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS ledger_entries_accrual_uidx;
+-- +goose StatementEnd
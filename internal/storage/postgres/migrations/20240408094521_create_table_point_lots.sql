@@ -0,0 +1,52 @@
+-- +goose Up
+-- +goose StatementBegin
+-- expiry - сгорание остатка партий с истекшим сроком
+ALTER TABLE ledger_entries DROP CONSTRAINT fk_kind;
+ALTER TABLE ledger_entries ADD CONSTRAINT fk_kind CHECK (kind IN ('accrual', 'withdrawal', 'adjustment', 'reversal', 'expiry'));
+ALTER TABLE ledger_postings DROP CONSTRAINT fk_account;
+ALTER TABLE ledger_postings ADD CONSTRAINT fk_account CHECK (account IN ('user', 'accrual', 'redemption', 'adjustment', 'expiry'));
+
+-- партия начисленных баллов, списания расходуют остатки партий от старых к новым
+CREATE TABLE point_lots (
+    lot_id BIGSERIAL PRIMARY KEY,
+    user_id UUID NOT NULL,
+    -- проводка начисления, NULL у остатков до введения партий
+    entry_id UUID,
+    amount NUMERIC(15, 3) NOT NULL,
+    remaining NUMERIC(15, 3) NOT NULL,
+    earned_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    -- NULL - баллы не сгорают
+    expires_at TIMESTAMP WITH TIME ZONE,
+    -- проводка сгорания остатка
+    expiry_entry_id UUID,
+    CONSTRAINT fk_users FOREIGN KEY (user_id) REFERENCES users (user_id),
+    CONSTRAINT fk_ledger_entries FOREIGN KEY (entry_id) REFERENCES ledger_entries (entry_id),
+    CONSTRAINT fk_expiry_ledger_entries FOREIGN KEY (expiry_entry_id) REFERENCES ledger_entries (entry_id),
+    CONSTRAINT fk_amount CHECK (amount > 0),
+    CONSTRAINT fk_remaining CHECK (remaining >= 0 AND remaining <= amount)
+);
+CREATE INDEX IF NOT EXISTS point_lots_user_id_idx ON point_lots (user_id, earned_at, lot_id) WHERE remaining > 0;
+CREATE INDEX IF NOT EXISTS point_lots_expires_at_idx ON point_lots (expires_at) WHERE remaining > 0;
+
+-- остаток, начисленный до введения партий, переносится одной партией без срока
+INSERT INTO
+    point_lots (user_id, amount, remaining)
+SELECT
+    user_id,
+    current,
+    current
+FROM
+    user_balance
+WHERE
+    current > 0;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS point_lots;
+-- проводки сгорания из журнала не удаляются, старые ограничения не проверяются на них
+ALTER TABLE ledger_postings DROP CONSTRAINT fk_account;
+ALTER TABLE ledger_postings ADD CONSTRAINT fk_account CHECK (account IN ('user', 'accrual', 'redemption', 'adjustment')) NOT VALID;
+ALTER TABLE ledger_entries DROP CONSTRAINT fk_kind;
+ALTER TABLE ledger_entries ADD CONSTRAINT fk_kind CHECK (kind IN ('accrual', 'withdrawal', 'adjustment', 'reversal')) NOT VALID;
+-- +goose StatementEnd
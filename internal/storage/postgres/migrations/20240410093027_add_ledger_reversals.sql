@@ -0,0 +1,58 @@
+-- +goose Up
+-- +goose StatementBegin
+-- сторно ссылается на отменяемую проводку, проводка сторнируется не больше одного раза
+ALTER TABLE ledger_entries ADD COLUMN IF NOT EXISTS reverses_entry_id UUID;
+ALTER TABLE ledger_entries ADD CONSTRAINT fk_reverses_ledger_entries FOREIGN KEY (reverses_entry_id) REFERENCES ledger_entries (entry_id);
+ALTER TABLE ledger_entries ADD CONSTRAINT fk_reversal CHECK ((kind = 'reversal') = (reverses_entry_id IS NOT NULL));
+CREATE UNIQUE INDEX IF NOT EXISTS ledger_entries_reverses_uidx ON ledger_entries (reverses_entry_id) WHERE reverses_entry_id IS NOT NULL;
+
+-- после сторно начисления заказ рассчитывается заново, поэтому за заказ
+-- допускается одно несторнированное начисление вместо одного начисления вообще
+DROP INDEX IF EXISTS ledger_entries_accrual_uidx;
+CREATE INDEX IF NOT EXISTS ledger_entries_accrual_idx ON ledger_entries (reference) WHERE kind = 'accrual';
+
+CREATE FUNCTION ledger_accrual_once() RETURNS TRIGGER AS $$
+BEGIN
+    IF EXISTS (
+        SELECT
+            1
+        FROM
+            ledger_entries e
+        WHERE
+            e.kind = 'accrual'
+            AND e.reference = NEW.reference
+            AND NOT EXISTS (
+                SELECT
+                    1
+                FROM
+                    ledger_entries r
+                WHERE
+                    r.reverses_entry_id = e.entry_id
+            )
+    ) THEN
+        RAISE EXCEPTION 'order % is already accrued', NEW.reference
+            USING ERRCODE = 'unique_violation';
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+-- начисления за один заказ проводятся под блокировкой строки заказа
+CREATE TRIGGER ledger_entries_accrual_once
+    BEFORE INSERT ON ledger_entries
+    FOR EACH ROW
+    WHEN (NEW.kind = 'accrual')
+    EXECUTE FUNCTION ledger_accrual_once();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TRIGGER IF EXISTS ledger_entries_accrual_once ON ledger_entries;
+DROP FUNCTION IF EXISTS ledger_accrual_once();
+DROP INDEX IF EXISTS ledger_entries_accrual_idx;
+CREATE UNIQUE INDEX IF NOT EXISTS ledger_entries_accrual_uidx ON ledger_entries (reference) WHERE kind = 'accrual';
+DROP INDEX IF EXISTS ledger_entries_reverses_uidx;
+ALTER TABLE ledger_entries DROP CONSTRAINT IF EXISTS fk_reversal;
+ALTER TABLE ledger_entries DROP CONSTRAINT IF EXISTS fk_reverses_ledger_entries;
+ALTER TABLE ledger_entries DROP COLUMN IF EXISTS reverses_entry_id;
+-- +goose StatementEnd
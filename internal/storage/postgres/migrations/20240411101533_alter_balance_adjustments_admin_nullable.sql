@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+-- NULL - корректировку провела система: периодическая сверка или удаление учетной записи
+ALTER TABLE balance_adjustments ALTER COLUMN admin_id DROP NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DELETE FROM balance_adjustments WHERE admin_id IS NULL;
+ALTER TABLE balance_adjustments ALTER COLUMN admin_id SET NOT NULL;
+-- +goose StatementEnd
@@ -0,0 +1,269 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/vladislav-kr/gophermart/internal/logger"
+	"github.com/vladislav-kr/gophermart/internal/storage"
+)
+
+// OrdersPage заказы пользователя по фильтру, продолжение выборки
+// после заказа из курсора использует индекс (user_id, uploaded_at, order_id)
+func (s *dbStorage) OrdersPage(ctx context.Context, filter storage.OrdersFilter) ([]storage.Order, error) {
+	if filter.Limit == 0 {
+		filter.Limit = 100
+	}
+
+	conditions := []string{"user_id = @userID"}
+	args := pgx.NamedArgs{
+		"userID": filter.UserID,
+		"limit":  filter.Limit,
+	}
+
+	if len(filter.Statuses) > 0 {
+		conditions = append(conditions, "status = ANY(@statuses)")
+		args["statuses"] = filter.Statuses
+	}
+	if !filter.From.IsZero() {
+		conditions = append(conditions, "uploaded_at >= @from")
+		args["from"] = filter.From
+	}
+	if !filter.To.IsZero() {
+		conditions = append(conditions, "uploaded_at < @to")
+		args["to"] = filter.To
+	}
+
+	direction, after := "ASC", ">"
+	if filter.Desc {
+		direction, after = "DESC", "<"
+	}
+	if filter.AfterOrderID != "" {
+		conditions = append(conditions,
+			fmt.Sprintf("(uploaded_at, order_id) %s (@afterUploadedAt, @afterOrderID)", after))
+		args["afterUploadedAt"] = filter.AfterUploadedAt
+		args["afterOrderID"] = filter.AfterOrderID
+	}
+
+	query := fmt.Sprintf(`
+		SELECT
+			order_id,
+			user_id,
+			status,
+			uploaded_at,
+			changed_at,
+			accrual
+		FROM
+			orders
+		WHERE
+			%s
+		ORDER BY
+			uploaded_at %s,
+			order_id %s
+		LIMIT
+			@limit`, strings.Join(conditions, " AND "), direction, direction)
+
+	rows, err := s.pool.Query(ctx, query, args)
+	if err != nil {
+		return nil, fmt.Errorf("query orders page by userID %s: %w", filter.UserID, err)
+	}
+
+	orders, err := pgx.CollectRows(rows, pgx.RowToStructByName[storage.Order])
+	if err != nil {
+		return nil, fmt.Errorf("collect rows orders: %w", err)
+	}
+
+	if len(orders) == 0 {
+		return nil, storage.ErrNoRecordsFound
+	}
+
+	return orders, nil
+}
+
+// CreateOrders добавляет новые заказы пакетом со статусом NEW и возвращает
+// владельцев всех переданных номеров. Владелец читается отдельным запросом,
+// чтобы увидеть заказы, добавленные параллельной загрузкой.
+func (s *dbStorage) CreateOrders(ctx context.Context, userID string, orderIDs []string) ([]storage.OrderOwner, error) {
+	tx, err := s.pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer func() {
+		if err := tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			s.log.Error("transaction create orders rollback", logger.Error(err))
+		}
+	}()
+
+	queryInsert := `
+		WITH
+			inserted AS (
+				INSERT INTO
+					orders (order_id, user_id, status, accrual)
+				SELECT
+					order_id,
+					@userID,
+					'NEW',
+					0
+				FROM
+					unnest(@orderIDs::TEXT[]) AS order_id
+				ON CONFLICT DO NOTHING
+				RETURNING
+					order_id
+			),
+			events AS (
+				INSERT INTO
+					order_events (order_id, status, accrual)
+				SELECT
+					order_id,
+					'NEW',
+					0
+				FROM
+					inserted
+			)
+		SELECT
+			order_id
+		FROM
+			inserted`
+
+	args := pgx.NamedArgs{
+		"userID":   userID,
+		"orderIDs": orderIDs,
+	}
+
+	rows, err := tx.Query(ctx, queryInsert, args)
+	if err != nil {
+		return nil, fmt.Errorf("insert into orders %v: %w", err, storage.ErrInternal)
+	}
+
+	inserted, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, fmt.Errorf("collect rows inserted orders %v: %w", err, storage.ErrInternal)
+	}
+
+	queryOwners := `
+		SELECT
+			order_id,
+			user_id
+		FROM
+			orders
+		WHERE
+			order_id = ANY(@orderIDs)`
+
+	rows, err = tx.Query(ctx, queryOwners, args)
+	if err != nil {
+		return nil, fmt.Errorf("query orders owners %v: %w", err, storage.ErrInternal)
+	}
+
+	owners, err := pgx.CollectRows(rows, pgx.RowToStructByName[storage.OrderOwner])
+	if err != nil {
+		return nil, fmt.Errorf("collect rows orders owners %v: %w", err, storage.ErrInternal)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("transaction create orders commit %v: %w", err, storage.ErrInternal)
+	}
+
+	added := make(map[string]struct{}, len(inserted))
+	for _, orderID := range inserted {
+		added[orderID] = struct{}{}
+	}
+	for i := range owners {
+		_, owners[i].Inserted = added[owners[i].OrderID]
+	}
+
+	return owners, nil
+}
+
+// UserOrder заказ пользователя, чужой заказ не найден
+func (s *dbStorage) UserOrder(ctx context.Context, userID, orderID string) (*storage.Order, error) {
+	query := `
+		SELECT
+			order_id,
+			user_id,
+			status,
+			uploaded_at,
+			changed_at,
+			accrual
+		FROM
+			orders
+		WHERE
+			order_id = @orderID
+			AND user_id = @userID`
+
+	args := pgx.NamedArgs{
+		"orderID": orderID,
+		"userID":  userID,
+	}
+
+	rows, err := s.pool.Query(ctx, query, args)
+	if err != nil {
+		return nil, fmt.Errorf("query order %s: %w", orderID, err)
+	}
+
+	order, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[storage.Order])
+	if err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			return nil, storage.ErrNoRecordsFound
+		default:
+			return nil, fmt.Errorf("collect one row order %v: %w", err, storage.ErrInternal)
+		}
+	}
+
+	return &order, nil
+}
+
+// OrderEvents история статусов заказа в порядке записи
+func (s *dbStorage) OrderEvents(ctx context.Context, orderID string) ([]storage.OrderEvent, error) {
+	query := `
+		SELECT
+			order_id,
+			status,
+			accrual,
+			created_at
+		FROM
+			order_events
+		WHERE
+			order_id = @orderID
+		ORDER BY
+			event_id`
+
+	rows, err := s.pool.Query(ctx, query, pgx.NamedArgs{"orderID": orderID})
+	if err != nil {
+		return nil, fmt.Errorf("query order events %s: %w", orderID, err)
+	}
+
+	events, err := pgx.CollectRows(rows, pgx.RowToStructByName[storage.OrderEvent])
+	if err != nil {
+		return nil, fmt.Errorf("collect rows order events: %w", err)
+	}
+
+	if len(events) == 0 {
+		return nil, storage.ErrNoRecordsFound
+	}
+
+	return events, nil
+}
+
+// insertOrderEvent записывает текущий статус заказа в историю
+func insertOrderEvent(ctx context.Context, tx pgx.Tx, orderID string) error {
+	query := `
+		INSERT INTO
+			order_events (order_id, status, accrual)
+		SELECT
+			order_id,
+			status,
+			COALESCE(accrual, 0)
+		FROM
+			orders
+		WHERE
+			order_id = @orderID`
+
+	if _, err := tx.Exec(ctx, query, pgx.NamedArgs{"orderID": orderID}); err != nil {
+		return fmt.Errorf("insert order event %v: %w", err, storage.ErrInternal)
+	}
+	return nil
+}
@@ -0,0 +1,182 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/vladislav-kr/gophermart/internal/logger"
+	"github.com/vladislav-kr/gophermart/internal/storage"
+)
+
+// ChangePassword меняет хеш пароля и отзывает все токены пользователя,
+// вернет новое поколение токенов
+func (s *dbStorage) ChangePassword(ctx context.Context, userID string, passwordHash []byte) (int64, error) {
+	tx, err := s.pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return 0, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer func() {
+		if err := tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			s.log.Error("transaction change password rollback", logger.Error(err))
+		}
+	}()
+
+	if err := updatePasswordHash(ctx, tx, userID, passwordHash); err != nil {
+		return 0, err
+	}
+
+	generation, err := revokeUserTokens(ctx, tx, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("transaction change password commit: %w", err)
+	}
+
+	return generation, nil
+}
+
+// CreatePasswordResetToken сохраняет токен сброса пароля,
+// ранее выданные неиспользованные токены пользователя удаляются
+func (s *dbStorage) CreatePasswordResetToken(ctx context.Context, token storage.PasswordResetToken) error {
+	query := `
+		WITH
+			previous AS (
+				DELETE FROM password_reset_tokens
+				WHERE
+					user_id = @userID
+					AND used_at IS NULL
+			)
+		INSERT INTO
+			password_reset_tokens (token_hash, user_id, expires_at)
+		VALUES
+			(@tokenHash, @userID, @expiresAt)`
+
+	args := pgx.NamedArgs{
+		"tokenHash": token.TokenHash,
+		"userID":    token.UserID,
+		"expiresAt": token.ExpiresAt,
+	}
+
+	if _, err := s.pool.Exec(ctx, query, args); err != nil {
+		return fmt.Errorf("password_reset_tokens insert %v: %w", err, storage.ErrInternal)
+	}
+
+	return nil
+}
+
+// ResetPassword погашает токен сброса, меняет хеш пароля и отзывает токены пользователя.
+// Вернет ErrNoRecordsFound, если токен не найден, использован или истек.
+func (s *dbStorage) ResetPassword(ctx context.Context, tokenHash, passwordHash []byte) (string, error) {
+	tx, err := s.pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return "", fmt.Errorf("begin transaction: %w", err)
+	}
+	defer func() {
+		if err := tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			s.log.Error("transaction reset password rollback", logger.Error(err))
+		}
+	}()
+
+	queryToken := `
+		UPDATE password_reset_tokens
+		SET
+			used_at = CURRENT_TIMESTAMP
+		WHERE
+			token_hash = @tokenHash
+			AND used_at IS NULL
+			AND expires_at > CURRENT_TIMESTAMP
+		RETURNING
+			user_id`
+
+	var userID string
+	if err := tx.QueryRow(ctx, queryToken, pgx.NamedArgs{"tokenHash": tokenHash}).Scan(&userID); err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			return "", storage.ErrNoRecordsFound
+		default:
+			return "", fmt.Errorf("password_reset_tokens update %v: %w", err, storage.ErrInternal)
+		}
+	}
+
+	if err := updatePasswordHash(ctx, tx, userID, passwordHash); err != nil {
+		return "", err
+	}
+
+	if _, err := revokeUserTokens(ctx, tx, userID); err != nil {
+		return "", err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return "", fmt.Errorf("transaction reset password commit: %w", err)
+	}
+
+	return userID, nil
+}
+
+// RehashPassword заменяет хеш пароля на пересчитанный, только если в базе
+// все еще oldHash. Смена или сброс пароля, зафиксированные после чтения
+// пользователя при входе, не перезаписываются хешем старого пароля.
+// Вернет ErrNoRecordsFound, если пользователя нет или хеш уже изменился.
+func (s *dbStorage) RehashPassword(ctx context.Context, userID string, oldHash, newHash []byte) error {
+	query := `
+		UPDATE users
+		SET
+			pass_hash = @newHash,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE
+			user_id = @userID
+			AND pass_hash = @oldHash`
+
+	args := pgx.NamedArgs{
+		"userID":  userID,
+		"oldHash": oldHash,
+		"newHash": newHash,
+	}
+
+	tag, err := s.pool.Exec(ctx, query, args)
+	if err != nil {
+		return fmt.Errorf("users rehash pass_hash %v: %w", err, storage.ErrInternal)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return storage.ErrNoRecordsFound
+	}
+
+	return nil
+}
+
+// execer пул соединений или транзакция
+type execer interface {
+	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+}
+
+func updatePasswordHash(ctx context.Context, db execer, userID string, passwordHash []byte) error {
+	query := `
+		UPDATE users
+		SET
+			pass_hash = @passwordHash,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE
+			user_id = @userID`
+
+	args := pgx.NamedArgs{
+		"userID":       userID,
+		"passwordHash": passwordHash,
+	}
+
+	tag, err := db.Exec(ctx, query, args)
+	if err != nil {
+		return fmt.Errorf("users update pass_hash %v: %w", err, storage.ErrInternal)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return storage.ErrNoRecordsFound
+	}
+
+	return nil
+}
@@ -0,0 +1,214 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/vladislav-kr/gophermart/internal/logger"
+	"github.com/vladislav-kr/gophermart/internal/storage"
+)
+
+// queryBalanceDrift ожидаемые остатки по обработанным заказам, списаниям,
+// корректировкам, сторно списаний и сгоревшим баллам. Сторно начисления
+// возвращает заказ в расчёт, поэтому уже не учитывается в обработанных заказах. Корректировки сверки не учитываются, иначе исправленный
+// баланс снова расходился бы с ожидаемым.
+const queryBalanceDrift = `
+	WITH
+		expected AS (
+			SELECT
+				b.user_id,
+				b.current,
+				b.withdrawn,
+				COALESCE(o.accrued, 0) - COALESCE(w.withdrawn, 0) + COALESCE(a.adjusted, 0) + COALESCE(x.expired, 0) + COALESCE(r.reversed, 0) AS expected_current,
+				COALESCE(w.withdrawn, 0) - COALESCE(r.reversed, 0) AS expected_withdrawn
+			FROM
+				user_balance b
+				LEFT JOIN (
+					SELECT
+						user_id,
+						SUM(accrual) AS accrued
+					FROM
+						orders
+					WHERE
+						status = 'PROCESSED'
+						AND accrual > 0
+					GROUP BY
+						user_id
+				) o ON o.user_id = b.user_id
+				LEFT JOIN (
+					SELECT
+						user_id,
+						SUM(sum) AS withdrawn
+					FROM
+						withdrawals
+					GROUP BY
+						user_id
+				) w ON w.user_id = b.user_id
+				LEFT JOIN (
+					SELECT
+						user_id,
+						SUM(amount) AS adjusted
+					FROM
+						balance_adjustments
+					WHERE
+						reason <> 'reconciliation'
+					GROUP BY
+						user_id
+				) a ON a.user_id = b.user_id
+				LEFT JOIN (
+					SELECT
+						p.user_id,
+						SUM(p.amount) AS expired
+					FROM
+						ledger_entries e
+						JOIN ledger_postings p ON p.entry_id = e.entry_id
+						AND p.account = 'user'
+					WHERE
+						e.kind = 'expiry'
+					GROUP BY
+						p.user_id
+				) x ON x.user_id = b.user_id
+				LEFT JOIN (
+					SELECT
+						p.user_id,
+						SUM(p.amount) AS reversed
+					FROM
+						ledger_entries e
+						JOIN ledger_entries orig ON orig.entry_id = e.reverses_entry_id
+						AND orig.kind = 'withdrawal'
+						JOIN ledger_postings p ON p.entry_id = e.entry_id
+						AND p.account = 'user'
+					GROUP BY
+						p.user_id
+				) r ON r.user_id = b.user_id
+			WHERE
+				@userID::UUID IS NULL
+				OR b.user_id = @userID
+		)
+	SELECT
+		user_id,
+		current,
+		withdrawn,
+		expected_current,
+		expected_withdrawn
+	FROM
+		expected
+	WHERE
+		current <> expected_current
+		OR withdrawn <> expected_withdrawn
+	ORDER BY
+		user_id`
+
+// BalanceDrifts пользователи, у которых user_balance расходится с заказами и списаниями
+func (s *dbStorage) BalanceDrifts(ctx context.Context) ([]storage.BalanceDrift, error) {
+	rows, err := s.pool.Query(ctx, queryBalanceDrift, pgx.NamedArgs{"userID": nil})
+	if err != nil {
+		return nil, fmt.Errorf("query balance drifts %v: %w", err, storage.ErrInternal)
+	}
+
+	drifts, err := pgx.CollectRows(rows, pgx.RowToStructByName[storage.BalanceDrift])
+	if err != nil {
+		return nil, fmt.Errorf("collect rows balance drifts %v: %w", err, storage.ErrInternal)
+	}
+
+	if len(drifts) == 0 {
+		return nil, storage.ErrNoRecordsFound
+	}
+
+	return drifts, nil
+}
+
+// CorrectBalanceDrift пересчитывает расхождение под блокировкой баланса
+// и исправляет текущий остаток корректировкой с причиной reconciliation.
+// Вернет исправленное расхождение или ErrNoRecordsFound, если остаток уже сходится.
+// Расхождение суммы списаний только сообщается, корректировкой оно не исправляется.
+// Доначисленные сверкой баллы не сгорают. Пустой adminID - исправление системой.
+func (s *dbStorage) CorrectBalanceDrift(ctx context.Context, userID, adminID string) (*storage.BalanceDrift, error) {
+	tx, err := s.pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer func() {
+		if err := tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			s.log.Error("transaction correct balance drift rollback", logger.Error(err))
+		}
+	}()
+
+	queryLock := `
+		SELECT
+			user_id
+		FROM
+			user_balance
+		WHERE
+			user_id = @userID
+		FOR UPDATE`
+
+	args := pgx.NamedArgs{"userID": userID}
+
+	if _, err := tx.Exec(ctx, queryLock, args); err != nil {
+		return nil, fmt.Errorf("user_balance lock %v: %w", err, storage.ErrInternal)
+	}
+
+	rows, err := tx.Query(ctx, queryBalanceDrift, args)
+	if err != nil {
+		return nil, fmt.Errorf("query balance drift %v: %w", err, storage.ErrInternal)
+	}
+
+	drift, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[storage.BalanceDrift])
+	if err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			return nil, storage.ErrNoRecordsFound
+		default:
+			return nil, fmt.Errorf("collect one row balance drift %v: %w", err, storage.ErrInternal)
+		}
+	}
+
+	if drift.Current == drift.ExpectedCurrent {
+		return nil, storage.ErrNoRecordsFound
+	}
+
+	adjustmentID := uuid.NewString()
+	amount := drift.ExpectedCurrent - drift.Current
+
+	if _, err := postEntry(ctx, tx, ledgerEntry{
+		kind:      entryAdjustment,
+		userID:    userID,
+		reference: adjustmentID,
+		account:   accountAdjustment,
+		amount:    amount,
+	}); err != nil {
+		return nil, err
+	}
+
+	queryAdjustment := `
+		INSERT INTO
+			balance_adjustments (adjustment_id, user_id, admin_id, amount, reason, comment)
+		VALUES
+			(@adjustmentID, @userID, NULLIF(@adminID, '')::UUID, @amount, 'reconciliation', @comment)`
+
+	argsAdjustment := pgx.NamedArgs{
+		"adjustmentID": adjustmentID,
+		"userID":       userID,
+		"adminID":      adminID,
+		"amount":       amount,
+		"comment": fmt.Sprintf(
+			"сверка баланса: остаток %s, ожидалось %s",
+			drift.Current,
+			drift.ExpectedCurrent,
+		),
+	}
+
+	if _, err := tx.Exec(ctx, queryAdjustment, argsAdjustment); err != nil {
+		return nil, fmt.Errorf("balance_adjustments insert %v: %w", err, storage.ErrInternal)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("transaction correct balance drift commit: %w", err)
+	}
+
+	return &drift, nil
+}
@@ -0,0 +1,159 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/vladislav-kr/gophermart/internal/logger"
+	"github.com/vladislav-kr/gophermart/internal/storage"
+)
+
+// SaveSession создает сессию или обновляет адрес, клиента и срок действия
+// при обновлении токенов. Отозванная сессия не меняется.
+func (s *dbStorage) SaveSession(ctx context.Context, session storage.Session) error {
+	query := `
+		INSERT INTO
+			sessions (session_id, user_id, ip, user_agent, expires_at)
+		VALUES
+			(@sessionID, @userID, @ip, @userAgent, @expiresAt)
+		ON CONFLICT (session_id) DO UPDATE
+		SET
+			ip = EXCLUDED.ip,
+			user_agent = EXCLUDED.user_agent,
+			last_seen_at = CURRENT_TIMESTAMP,
+			expires_at = EXCLUDED.expires_at
+		WHERE
+			sessions.revoked_at IS NULL`
+
+	args := pgx.NamedArgs{
+		"sessionID": session.SessionID,
+		"userID":    session.UserID,
+		"ip":        session.IP,
+		"userAgent": session.UserAgent,
+		"expiresAt": session.ExpiresAt,
+	}
+
+	if _, err := s.pool.Exec(ctx, query, args); err != nil {
+		return fmt.Errorf("sessions upsert %v: %w", err, storage.ErrInternal)
+	}
+
+	return nil
+}
+
+// Sessions действующие сессии пользователя, последние активные первыми
+func (s *dbStorage) Sessions(ctx context.Context, userID string) ([]storage.Session, error) {
+	query := `
+		SELECT
+			session_id,
+			user_id,
+			ip,
+			user_agent,
+			created_at,
+			last_seen_at,
+			expires_at
+		FROM
+			sessions
+		WHERE
+			user_id = @userID
+			AND revoked_at IS NULL
+			AND expires_at > CURRENT_TIMESTAMP
+		ORDER BY
+			last_seen_at DESC`
+
+	rows, err := s.pool.Query(ctx, query, pgx.NamedArgs{"userID": userID})
+	if err != nil {
+		return nil, fmt.Errorf("query sessions %v: %w", err, storage.ErrInternal)
+	}
+
+	sessions, err := pgx.CollectRows(rows, pgx.RowToStructByName[storage.Session])
+	if err != nil {
+		return nil, fmt.Errorf("collect rows sessions %v: %w", err, storage.ErrInternal)
+	}
+
+	if len(sessions) == 0 {
+		return nil, storage.ErrNoRecordsFound
+	}
+
+	return sessions, nil
+}
+
+// TouchSession отмечает активность сессии,
+// вернет ErrNoRecordsFound, если сессия не найдена, отозвана или истекла
+func (s *dbStorage) TouchSession(ctx context.Context, sessionID string) error {
+	query := `
+		UPDATE sessions
+		SET
+			last_seen_at = CURRENT_TIMESTAMP
+		WHERE
+			session_id = @sessionID
+			AND revoked_at IS NULL
+			AND expires_at > CURRENT_TIMESTAMP`
+
+	tag, err := s.pool.Exec(ctx, query, pgx.NamedArgs{"sessionID": sessionID})
+	if err != nil {
+		return fmt.Errorf("sessions update last_seen_at %v: %w", err, storage.ErrInternal)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return storage.ErrNoRecordsFound
+	}
+
+	return nil
+}
+
+// RevokeSession отзывает сессию пользователя вместе с ее refresh токенами,
+// вернет ErrNoRecordsFound, если сессия не найдена или уже отозвана
+func (s *dbStorage) RevokeSession(ctx context.Context, userID, sessionID string) error {
+	tx, err := s.pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer func() {
+		if err := tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			s.log.Error("transaction revoke session rollback", logger.Error(err))
+		}
+	}()
+
+	querySession := `
+		UPDATE sessions
+		SET
+			revoked_at = CURRENT_TIMESTAMP
+		WHERE
+			session_id = @sessionID
+			AND user_id = @userID
+			AND revoked_at IS NULL`
+
+	args := pgx.NamedArgs{
+		"sessionID": sessionID,
+		"userID":    userID,
+	}
+
+	tag, err := tx.Exec(ctx, querySession, args)
+	if err != nil {
+		return fmt.Errorf("sessions update revoked_at %v: %w", err, storage.ErrInternal)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return storage.ErrNoRecordsFound
+	}
+
+	queryRefresh := `
+		UPDATE refresh_tokens
+		SET
+			revoked_at = CURRENT_TIMESTAMP
+		WHERE
+			family_id = @sessionID
+			AND revoked_at IS NULL`
+
+	if _, err := tx.Exec(ctx, queryRefresh, args); err != nil {
+		return fmt.Errorf("revoke refresh tokens %v: %w", err, storage.ErrInternal)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("transaction revoke session commit: %w", err)
+	}
+
+	return nil
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/vladislav-kr/gophermart/internal/domain/money"
 	"github.com/vladislav-kr/gophermart/internal/logger"
 	"github.com/vladislav-kr/gophermart/internal/storage"
 )
@@ -21,11 +22,15 @@ var _ storage.Storage = (*dbStorage)(nil)
 
 type Config struct {
 	URI string
+	// срок сгорания начисленных баллов в месяцах, 0 - не сгорают
+	PointsExpiryMonths int
 }
 
 type dbStorage struct {
 	pool *pgxpool.Pool
 	log  *slog.Logger
+	// срок сгорания новых партий баллов в месяцах
+	expiryMonths int
 }
 
 func (s *dbStorage) Close() error {
@@ -71,6 +76,7 @@ func New(ctx context.Context, cfg Config) (*dbStorage, error) {
 		log: logger.Logger().With(
 			slog.String("component", "storage"),
 		),
+		expiryMonths: cfg.PointsExpiryMonths,
 	}, nil
 }
 
@@ -82,7 +88,11 @@ func (s *dbStorage) User(ctx context.Context, login string) (*storage.User, erro
 		SELECT
 			user_id,
 			login,
-			pass_hash
+			pass_hash,
+			token_generation,
+			COALESCE(is_blocked, FALSE) AS is_blocked,
+			COALESCE(is_delete, FALSE) AS is_delete,
+			role
 		FROM
 			users
 		WHERE
@@ -108,6 +118,41 @@ func (s *dbStorage) User(ctx context.Context, login string) (*storage.User, erro
 	return &user, nil
 }
 
+func (s *dbStorage) UserByID(ctx context.Context, userID string) (*storage.User, error) {
+	query := `
+		SELECT
+			user_id,
+			login,
+			pass_hash,
+			token_generation,
+			COALESCE(is_blocked, FALSE) AS is_blocked,
+			COALESCE(is_delete, FALSE) AS is_delete,
+			role
+		FROM
+			users
+		WHERE
+			user_id = @userID`
+
+	args := pgx.NamedArgs{"userID": userID}
+
+	rows, err := s.pool.Query(ctx, query, args)
+	if err != nil {
+		return nil, fmt.Errorf("query user by id %v: %w", err, storage.ErrInternal)
+	}
+
+	user, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[storage.User])
+	if err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			return nil, storage.ErrNoRecordsFound
+		default:
+			return nil, fmt.Errorf("collect one row %v: %w", err, storage.ErrInternal)
+		}
+	}
+
+	return &user, nil
+}
+
 func (s *dbStorage) CreateUser(ctx context.Context,
 	login string,
 	passwordHash []byte,
@@ -233,21 +278,20 @@ func (s *dbStorage) CreateOrder(ctx context.Context, userID string, order storag
 		return storage.ErrAlreadyUploadedAnotherUser
 	}
 
-	if order.Accrual > 0 {
-		queryBalance := `
-			UPDATE user_balance
-			SET
-				current = current + @accrual
-			WHERE
-				user_id = @userID`
-
-		argsBalance := pgx.NamedArgs{
-			"userID":  userID,
-			"accrual": order.Accrual,
-		}
+	if err := insertOrderEvent(ctx, tx, order.OrderID); err != nil {
+		return err
+	}
 
-		if _, err := tx.Exec(ctx, queryBalance, argsBalance); err != nil {
-			return fmt.Errorf("user_balance update, %v: %w", err, storage.ErrConstraints)
+	if order.Accrual > 0 {
+		if _, err := postEntry(ctx, tx, ledgerEntry{
+			kind:         entryAccrual,
+			userID:       userID,
+			reference:    order.OrderID,
+			account:      accountAccrual,
+			amount:       order.Accrual,
+			expiryMonths: s.expiryMonths,
+		}); err != nil {
+			return err
 		}
 	}
 	if err := tx.Commit(ctx); err != nil {
@@ -257,15 +301,87 @@ func (s *dbStorage) CreateOrder(ctx context.Context, userID string, order storag
 	return nil
 }
 
+// BatchUpdateOrder сохраняет ответы системы начислений одной транзакцией.
+// Заказ в финальном статусе не изменяется, начисление проводится только
+// при переходе в PROCESSED из нефинального статуса, поэтому повторная
+// доставка того же ответа ничего не меняет. Заказ удаленного пользователя
+// не изменяется: строка пользователя блокируется на чтение, поэтому удаление
+// ждет уже начатое начисление, а начисление после удаления пропускается.
 func (s *dbStorage) BatchUpdateOrder(ctx context.Context, orders []storage.UpdateOrder) error {
+	type credit struct {
+		UserID  string       `db:"user_id"`
+		OrderID string       `db:"order_id"`
+		Accrual money.Amount `db:"accrual"`
+	}
+
+	tx, err := s.pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer func() {
+		if err := tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			s.log.Error("transaction batch update order rollback", logger.Error(err))
+		}
+	}()
+
+	// смена статуса попадает в историю заказа,
+	// запрос вернет заказ, если за него положено начисление
 	query := `
-		UPDATE orders
-		SET
-			status = @status,
-			accrual = @accrual,
-			changed_at = CURRENT_TIMESTAMP
+		WITH
+			previous AS (
+				SELECT
+					o.status,
+					COALESCE(u.is_delete, FALSE) AS is_delete
+				FROM
+					orders o
+					JOIN users u ON u.user_id = o.user_id
+				WHERE
+					o.order_id = @orderID
+				FOR UPDATE OF o
+				FOR SHARE OF u
+			),
+			updated AS (
+				UPDATE orders
+				SET
+					status = @status,
+					accrual = @accrual,
+					changed_at = CURRENT_TIMESTAMP
+				FROM
+					previous
+				WHERE
+					orders.order_id = @orderID
+					AND orders.status NOT IN ('PROCESSED', 'INVALID')
+					AND NOT previous.is_delete
+				RETURNING
+					orders.order_id,
+					orders.user_id,
+					orders.status,
+					orders.accrual
+			),
+			events AS (
+				INSERT INTO
+					order_events (order_id, status, accrual)
+				SELECT
+					updated.order_id,
+					updated.status,
+					updated.accrual
+				FROM
+					updated,
+					previous
+				WHERE
+					updated.status <> previous.status
+			)
+		SELECT
+			updated.user_id,
+			updated.order_id,
+			updated.accrual
+		FROM
+			updated,
+			previous
 		WHERE
-			order_id = @orderID;`
+			updated.status = 'PROCESSED'
+			AND previous.status NOT IN ('PROCESSED', 'INVALID')
+			AND updated.accrual > 0`
 
 	batch := &pgx.Batch{}
 
@@ -277,59 +393,55 @@ func (s *dbStorage) BatchUpdateOrder(ctx context.Context, orders []storage.Updat
 		})
 	}
 
-	results := s.pool.SendBatch(ctx, batch)
-	defer results.Close()
+	results := tx.SendBatch(ctx, batch)
 
-	errs := make([]error, 0)
+	credits := make([]credit, 0)
 	for i := 0; i < len(orders); i++ {
-		if _, err := results.Exec(); err != nil {
-			errs = append(errs, fmt.Errorf("update order: %w", err))
+		rows, err := results.Query()
+		if err != nil {
+			results.Close()
+			return fmt.Errorf("update order %v: %w", err, storage.ErrInternal)
 		}
-	}
-	if len(errs) > 0 {
-		return errors.Join(errs...)
+		orderCredits, err := pgx.CollectRows(rows, pgx.RowToStructByName[credit])
+		if err != nil {
+			results.Close()
+			return fmt.Errorf("update order collect rows %v: %w", err, storage.ErrInternal)
+		}
+		credits = append(credits, orderCredits...)
 	}
 
 	if err := results.Close(); err != nil {
 		return fmt.Errorf("batch results close: %w", err)
 	}
 
-	batchBalance := &pgx.Batch{}
+	if len(credits) > 0 {
+		batchBalance := &pgx.Batch{}
 
-	queryBalance := `
-		UPDATE user_balance
-		SET
-			current = current + @accrual
-		WHERE
-			user_id = @userID`
-
-	for _, order := range orders {
-		batchBalance.Queue(queryBalance, pgx.NamedArgs{
-			"userID":  order.UserID,
-			"accrual": order.Accrual,
-		})
-	}
-
-	resultsBalance := s.pool.SendBatch(ctx, batchBalance)
-	defer resultsBalance.Close()
+		for _, c := range credits {
+			batchBalance.Queue(queryPostEntry, ledgerEntry{
+				kind:         entryAccrual,
+				userID:       c.UserID,
+				reference:    c.OrderID,
+				account:      accountAccrual,
+				amount:       c.Accrual,
+				expiryMonths: s.expiryMonths,
+			}.args())
+		}
 
-	errsBalance := make([]error, 0)
-	for i := 0; i < len(orders); i++ {
-		if _, err := resultsBalance.Exec(); err != nil {
-			errsBalance = append(errsBalance, fmt.Errorf("update user balance: %w", err))
+		if err := tx.SendBatch(ctx, batchBalance).Close(); err != nil {
+			return fmt.Errorf("update user balance %v: %w", err, storage.ErrInternal)
 		}
 	}
-	if len(errsBalance) > 0 {
-		return errors.Join(errsBalance...)
-	}
 
-	if err := resultsBalance.Close(); err != nil {
-		return fmt.Errorf("batch user balance results close: %w", err)
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("transaction batch update order commit: %w", err)
 	}
 
 	return nil
 }
 
+// OrdersForUpdate необработанные заказы для опроса системы начислений,
+// заказы удаленных пользователей не опрашиваются
 func (s *dbStorage) OrdersForUpdate(
 	ctx context.Context,
 	limit uint32,
@@ -341,14 +453,16 @@ func (s *dbStorage) OrdersForUpdate(
 
 	query := `
 		SELECT
-			user_id,
-			order_id
+			o.user_id,
+			o.order_id
 		FROM
-			orders
+			orders o
+			JOIN users u ON u.user_id = o.user_id
 		WHERE
-			status IN ('PROCESSING', 'NEW')
+			o.status IN ('PROCESSING', 'NEW')
+			AND NOT COALESCE(u.is_delete, FALSE)
 		ORDER BY
-			uploaded_at
+			o.uploaded_at
 		LIMIT
 			@limit`
 
@@ -469,6 +583,42 @@ func (s *dbStorage) Withdrawals(ctx context.Context, userID string) ([]storage.W
 	return withdrawals, nil
 }
 
+// Withdrawal списание пользователя по номеру заказа
+func (s *dbStorage) Withdrawal(ctx context.Context, userID, orderID string) (*storage.WithdrawalsBonuses, error) {
+	query := `
+		SELECT
+			order_id,
+			sum,
+			processed_at
+		FROM
+			withdrawals
+		WHERE
+			user_id = @userID
+			AND order_id = @orderID`
+
+	args := pgx.NamedArgs{
+		"userID":  userID,
+		"orderID": orderID,
+	}
+
+	rows, err := s.pool.Query(ctx, query, args)
+	if err != nil {
+		return nil, fmt.Errorf("query withdrawal %s: %w", orderID, err)
+	}
+
+	withdrawal, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[storage.WithdrawalsBonuses])
+	if err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			return nil, storage.ErrNoRecordsFound
+		default:
+			return nil, fmt.Errorf("collect one row withdrawal %v: %w", err, storage.ErrInternal)
+		}
+	}
+
+	return &withdrawal, nil
+}
+
 func (s *dbStorage) Withdraw(
 	ctx context.Context,
 	userID string,
@@ -484,23 +634,8 @@ func (s *dbStorage) Withdraw(
 		}
 	}()
 
-	queryBalance := `
-		UPDATE user_balance
-		SET
-			current = current - @sum,
-			withdrawn = withdrawn + @sum
-		WHERE
-			user_id = @userID`
-
-	argsBalance := pgx.NamedArgs{
-		"userID": userID,
-		"sum":    withdraw.Sum,
-	}
-
-	if _, err := tx.Exec(ctx, queryBalance, argsBalance); err != nil {
-		return fmt.Errorf("user_balance update, %v: %w", err, storage.ErrConstraints)
-	}
-
+	// списание по номеру заказа проверяется раньше баланса,
+	// чтобы повтор не выглядел как нехватка средств
 	queryWithdraw := `
 		INSERT INTO
 			withdrawals (user_id, order_id, sum)
@@ -514,7 +649,30 @@ func (s *dbStorage) Withdraw(
 	}
 
 	if _, err := tx.Exec(ctx, queryWithdraw, argsWithdraw); err != nil {
-		return fmt.Errorf("withdrawals insert: %w", err)
+		var pgErr *pgconn.PgError
+		switch {
+		case errors.As(err, &pgErr) &&
+			pgErr.Code == pgerrcode.UniqueViolation:
+			return fmt.Errorf("withdrawals insert, order %s: %w", withdraw.Order, storage.ErrWithdrawalExists)
+		case errors.As(err, &pgErr) &&
+			pgErr.Code == pgerrcode.CheckViolation:
+			return fmt.Errorf("withdrawals insert, %v: %w", err, storage.ErrConstraints)
+		default:
+			return fmt.Errorf("withdrawals insert, %v: %w", err, storage.ErrInternal)
+		}
+	}
+
+	// нулевое списание не меняет остатков и не попадает в журнал
+	if withdraw.Sum > 0 {
+		if _, err := postEntry(ctx, tx, ledgerEntry{
+			kind:      entryWithdrawal,
+			userID:    userID,
+			reference: withdraw.Order,
+			account:   accountRedemption,
+			amount:    -withdraw.Sum,
+		}); err != nil {
+			return err
+		}
 	}
 
 	if err := tx.Commit(ctx); err != nil {
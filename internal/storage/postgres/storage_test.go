@@ -7,11 +7,13 @@ import (
 	"testing"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 	"github.com/testcontainers/testcontainers-go"
 	tcpostgres "github.com/testcontainers/testcontainers-go/modules/postgres"
 	"github.com/testcontainers/testcontainers-go/wait"
+	"github.com/vladislav-kr/gophermart/internal/domain/money"
 	"github.com/vladislav-kr/gophermart/internal/storage"
 )
 
@@ -133,6 +135,17 @@ func (ts *PostgresTestSuite) TestUser() {
 	ts.NoError(err)
 	ts.Equal(user.UserID, userID)
 	ts.Equal(user.Password, pass1)
+	ts.False(user.IsBlocked)
+	ts.False(user.IsDeleted)
+	ts.Equal("user", user.Role)
+
+	//получение пользователя по идентификатору
+	userByID, err := ts.UserByID(ctx, userID)
+	ts.NoError(err)
+	ts.Equal(user, userByID)
+
+	_, err = ts.UserByID(ctx, uuid.NewString())
+	ts.ErrorIs(err, storage.ErrNoRecordsFound)
 
 	//проверка уникальности пользователей
 	_, err = ts.CreateUser(ctx, login1, pass1)
@@ -154,7 +167,7 @@ func (ts *PostgresTestSuite) TestCreatingOrderCheckBalance() {
 	ord := storage.CreateOrder{
 		OrderID: "create-order-1",
 		Status:  "PROCESSED",
-		Accrual: 50.55,
+		Accrual: money.MustParse("50.55"),
 	}
 
 	err = ts.CreateOrder(ctx, userID, ord)
@@ -185,7 +198,7 @@ func (ts *PostgresTestSuite) TestWithdraw() {
 	ord := storage.CreateOrder{
 		OrderID: "withdraw-order-1",
 		Status:  "PROCESSED",
-		Accrual: 500.5,
+		Accrual: money.MustParse("500.5"),
 	}
 
 	err = ts.CreateOrder(ctx, userID, ord)
@@ -193,7 +206,7 @@ func (ts *PostgresTestSuite) TestWithdraw() {
 
 	withdraw := storage.WithdrawBonuses{
 		Order: "withdraw-order-2",
-		Sum:   500,
+		Sum:   money.FromInt(500),
 	}
 
 	err = ts.Withdraw(ctx, userID, withdraw)
@@ -207,11 +220,25 @@ func (ts *PostgresTestSuite) TestWithdraw() {
 
 	withdraw = storage.WithdrawBonuses{
 		Order: "withdraw-order-3",
-		Sum:   500,
+		Sum:   money.FromInt(500),
 	}
 	// недостаточно средств
 	err = ts.Withdraw(ctx, userID, withdraw)
 	ts.ErrorIs(err, storage.ErrConstraints)
+
+	// повторное списание по тому же заказу
+	err = ts.Withdraw(ctx, userID, storage.WithdrawBonuses{
+		Order: "withdraw-order-2",
+		Sum:   money.MustParse("0.5"),
+	})
+	ts.ErrorIs(err, storage.ErrWithdrawalExists)
+
+	existing, err := ts.Withdrawal(ctx, userID, "withdraw-order-2")
+	ts.Require().NoError(err)
+	ts.Equal(money.FromInt(500), existing.Sum)
+
+	_, err = ts.Withdrawal(ctx, userID, "withdraw-order-3")
+	ts.ErrorIs(err, storage.ErrNoRecordsFound)
 }
 
 // получение заказов пользователя
@@ -225,13 +252,13 @@ func (ts *PostgresTestSuite) TestReadOrders() {
 	err = ts.CreateOrder(ctx, userID, storage.CreateOrder{
 		OrderID: "read-orders-1",
 		Status:  "PROCESSED",
-		Accrual: 100,
+		Accrual: money.FromInt(100),
 	})
 	ts.Require().NoError(err)
 	err = ts.CreateOrder(ctx, userID, storage.CreateOrder{
 		OrderID: "read-orders-2",
 		Status:  "PROCESSED",
-		Accrual: 200,
+		Accrual: money.FromInt(200),
 	})
 	ts.Require().NoError(err)
 
@@ -272,7 +299,7 @@ func (ts *PostgresTestSuite) TestReadOrdersAndBatchUpdate() {
 			UserID:  userID,
 			OrderID: ord.OrderID,
 			Status:  "PROCESSED",
-			Accrual: 100,
+			Accrual: money.FromInt(100),
 		})
 	}
 
@@ -316,3 +343,1227 @@ func (ts *PostgresTestSuite) TestReadOrdersAndBatchUpdate() {
 	ts.True(equal, "обработанные заказы для сохранение, не равны фактически сохраненным заказам")
 
 }
+
+// повторная доставка ответа системы начислений не начисляет баллы повторно
+func (ts *PostgresTestSuite) TestBatchUpdateOrderRedelivery() {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	userID, err := ts.CreateUser(ctx, "user-batch-redelivery", []byte("secret"))
+	ts.Require().NoError(err)
+
+	for _, ord := range []storage.CreateOrder{
+		{OrderID: "redelivery1", Status: "NEW"},
+		{OrderID: "redelivery2", Status: "NEW"},
+		{OrderID: "redelivery3", Status: "NEW"},
+	} {
+		ts.Require().NoError(ts.CreateOrder(ctx, userID, ord))
+	}
+
+	processing := []storage.UpdateOrder{
+		{UserID: userID, OrderID: "redelivery1", Status: "PROCESSING"},
+	}
+	processed := []storage.UpdateOrder{
+		{UserID: userID, OrderID: "redelivery1", Status: "PROCESSED", Accrual: money.FromInt(100)},
+		{UserID: userID, OrderID: "redelivery2", Status: "PROCESSED", Accrual: money.MustParse("50.5")},
+		{UserID: userID, OrderID: "redelivery3", Status: "INVALID"},
+	}
+
+	ts.Require().NoError(ts.BatchUpdateOrder(ctx, processing))
+	ts.Require().NoError(ts.BatchUpdateOrder(ctx, processed))
+	// повтор того же ответа, например после падения воркера
+	ts.Require().NoError(ts.BatchUpdateOrder(ctx, processed))
+	// запоздавший ответ не возвращает заказ из финального статуса
+	ts.Require().NoError(ts.BatchUpdateOrder(ctx, processing))
+
+	balance, err := ts.UserBalance(ctx, userID)
+	ts.Require().NoError(err)
+	ts.Equal(money.MustParse("150.5"), balance.Current)
+
+	recomputed, err := ts.LedgerBalance(ctx, userID, time.Now().Add(time.Minute))
+	ts.Require().NoError(err)
+	ts.Equal(balance, recomputed)
+
+	order, err := ts.UserOrder(ctx, userID, "redelivery1")
+	ts.Require().NoError(err)
+	ts.Equal("PROCESSED", order.Status)
+
+	events, err := ts.OrderEvents(ctx, "redelivery1")
+	ts.Require().NoError(err)
+	ts.Len(events, 3)
+
+	entries, err := ts.BalanceHistory(ctx, userID)
+	ts.Require().NoError(err)
+	ts.Len(entries, 2)
+}
+
+// ротация refresh токена и отзыв семейства при повторном использовании
+func (ts *PostgresTestSuite) TestRefreshTokenRotation() {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	userID, err := ts.CreateUser(ctx, "user-refresh-token", []byte("secret"))
+	ts.Require().NoError(err)
+
+	first := storage.RefreshToken{
+		TokenID:   uuid.NewString(),
+		FamilyID:  uuid.NewString(),
+		UserID:    userID,
+		TokenHash: []byte("refresh-token-hash-1"),
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+	ts.Require().NoError(ts.CreateRefreshToken(ctx, first))
+
+	second, err := ts.RotateRefreshToken(ctx, first.TokenHash, storage.RefreshToken{
+		TokenID:   uuid.NewString(),
+		TokenHash: []byte("refresh-token-hash-2"),
+		ExpiresAt: time.Now().Add(time.Hour),
+	})
+	ts.Require().NoError(err)
+	ts.Equal(first.FamilyID, second.FamilyID)
+	ts.Equal(userID, second.UserID)
+
+	// повторное использование первого токена отзывает семейство
+	_, err = ts.RotateRefreshToken(ctx, first.TokenHash, storage.RefreshToken{
+		TokenID:   uuid.NewString(),
+		TokenHash: []byte("refresh-token-hash-3"),
+		ExpiresAt: time.Now().Add(time.Hour),
+	})
+	ts.ErrorIs(err, storage.ErrTokenReused)
+
+	_, err = ts.RotateRefreshToken(ctx, second.TokenHash, storage.RefreshToken{
+		TokenID:   uuid.NewString(),
+		TokenHash: []byte("refresh-token-hash-4"),
+		ExpiresAt: time.Now().Add(time.Hour),
+	})
+	ts.ErrorIs(err, storage.ErrTokenReused)
+
+	// неизвестный токен
+	_, err = ts.RotateRefreshToken(ctx, []byte("unknown"), storage.RefreshToken{
+		TokenID:   uuid.NewString(),
+		TokenHash: []byte("refresh-token-hash-5"),
+		ExpiresAt: time.Now().Add(time.Hour),
+	})
+	ts.ErrorIs(err, storage.ErrNoRecordsFound)
+}
+
+// отзыв access токенов и выход со всех устройств
+func (ts *PostgresTestSuite) TestRevokeTokens() {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	userID, err := ts.CreateUser(ctx, "user-revoke-tokens", []byte("secret"))
+	ts.Require().NoError(err)
+
+	tokenID := uuid.NewString()
+
+	revoked, err := ts.TokenRevoked(ctx, tokenID)
+	ts.Require().NoError(err)
+	ts.False(revoked)
+
+	ts.Require().NoError(ts.RevokeToken(ctx, storage.RevokedToken{
+		TokenID:   tokenID,
+		UserID:    userID,
+		ExpiresAt: time.Now().Add(time.Minute),
+	}))
+
+	revoked, err = ts.TokenRevoked(ctx, tokenID)
+	ts.Require().NoError(err)
+	ts.True(revoked)
+
+	refresh := storage.RefreshToken{
+		TokenID:   uuid.NewString(),
+		FamilyID:  uuid.NewString(),
+		UserID:    userID,
+		TokenHash: []byte("revoke-tokens-hash-1"),
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+	ts.Require().NoError(ts.CreateRefreshToken(ctx, refresh))
+
+	user, err := ts.UserByID(ctx, userID)
+	ts.Require().NoError(err)
+	ts.Equal(int64(0), user.Generation)
+
+	generation, err := ts.RevokeUserTokens(ctx, userID)
+	ts.Require().NoError(err)
+	ts.Equal(int64(1), generation)
+
+	user, err = ts.User(ctx, "user-revoke-tokens")
+	ts.Require().NoError(err)
+	ts.Equal(int64(1), user.Generation)
+
+	// refresh токены пользователя отозваны
+	_, err = ts.RotateRefreshToken(ctx, refresh.TokenHash, storage.RefreshToken{
+		TokenID:   uuid.NewString(),
+		TokenHash: []byte("revoke-tokens-hash-2"),
+		ExpiresAt: time.Now().Add(time.Hour),
+	})
+	ts.ErrorIs(err, storage.ErrTokenReused)
+}
+
+// административные операции над пользователями и заказами
+func (ts *PostgresTestSuite) TestAdmin() {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	userID, err := ts.CreateUser(ctx, "admin_search%user", []byte("secret"))
+	ts.Require().NoError(err)
+	_, err = ts.CreateUser(ctx, "admin-search-other", []byte("secret"))
+	ts.Require().NoError(err)
+
+	// символы шаблона в строке поиска экранируются
+	users, err := ts.SearchUsers(ctx, "search%", 10)
+	ts.Require().NoError(err)
+	ts.Require().Len(users, 1)
+	ts.Equal(userID, users[0].UserID)
+	ts.Equal("user", users[0].Role)
+
+	_, err = ts.SearchUsers(ctx, "unknown-login", 10)
+	ts.ErrorIs(err, storage.ErrNoRecordsFound)
+
+	ts.Require().NoError(ts.SetUserBlocked(ctx, userID, true))
+	user, err := ts.UserByID(ctx, userID)
+	ts.Require().NoError(err)
+	ts.True(user.IsBlocked)
+
+	ts.ErrorIs(ts.SetUserBlocked(ctx, uuid.NewString(), true), storage.ErrNoRecordsFound)
+
+	ts.Require().NoError(ts.CreateOrder(ctx, userID, storage.CreateOrder{
+		OrderID: "admin-recheck-1",
+		Status:  "INVALID",
+	}))
+	ts.Require().NoError(ts.RecheckOrder(ctx, "admin-recheck-1"))
+
+	ts.Require().NoError(ts.CreateOrder(ctx, userID, storage.CreateOrder{
+		OrderID: "admin-recheck-2",
+		Status:  "PROCESSED",
+		Accrual: money.FromInt(10),
+	}))
+	ts.Require().NoError(ts.RecheckOrder(ctx, "admin-recheck-2"))
+	order, err := ts.UserOrder(ctx, userID, "admin-recheck-2")
+	ts.Require().NoError(err)
+	ts.Equal("NEW", order.Status)
+
+	ts.ErrorIs(ts.RecheckOrder(ctx, "admin-recheck-3"), storage.ErrNoRecordsFound)
+}
+
+// ручная корректировка баланса и история
+func (ts *PostgresTestSuite) TestAdjustBalance() {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	userID, err := ts.CreateUser(ctx, "user-adjust-balance", []byte("secret"))
+	ts.Require().NoError(err)
+	adminID, err := ts.CreateUser(ctx, "admin-adjust-balance", []byte("secret"))
+	ts.Require().NoError(err)
+
+	balance, err := ts.AdjustBalance(ctx, storage.BalanceAdjustment{
+		UserID:  userID,
+		AdminID: adminID,
+		Amount:  money.FromInt(100),
+		Reason:  "compensation",
+		Comment: "задержка доставки",
+	})
+	ts.Require().NoError(err)
+	ts.Equal(money.FromInt(100), balance.Current)
+
+	// баланс не может стать отрицательным
+	_, err = ts.AdjustBalance(ctx, storage.BalanceAdjustment{
+		UserID:  userID,
+		AdminID: adminID,
+		Amount:  money.FromInt(-150),
+		Reason:  "correction",
+		Comment: "ошибка начисления",
+	})
+	ts.ErrorIs(err, storage.ErrConstraints)
+
+	ts.Require().NoError(ts.Withdraw(ctx, userID, storage.WithdrawBonuses{
+		Order: "adjust-balance-1",
+		Sum:   money.FromInt(30),
+	}))
+
+	entries, err := ts.BalanceHistory(ctx, userID)
+	ts.Require().NoError(err)
+	ts.Require().Len(entries, 2)
+	ts.Equal("withdrawal", entries[0].Type)
+	ts.Equal(money.FromInt(-30), entries[0].Sum)
+	ts.Equal("adjustment", entries[1].Type)
+	ts.Equal("compensation", entries[1].Reason)
+	ts.Equal(money.FromInt(100), entries[1].Sum)
+
+	_, err = ts.BalanceHistory(ctx, adminID)
+	ts.ErrorIs(err, storage.ErrNoRecordsFound)
+}
+
+// счётчики неудачных попыток входа
+func (ts *PostgresTestSuite) TestLoginAttempts() {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	attempts, err := ts.LoginAttempts(ctx, "login:attempts")
+	ts.Require().NoError(err)
+	ts.Equal(0, attempts.Failures)
+
+	for i := 1; i <= 3; i++ {
+		attempts, err = ts.IncrementLoginAttempts(ctx, "login:attempts", time.Minute)
+		ts.Require().NoError(err)
+		ts.Equal(i, attempts.Failures)
+	}
+
+	attempts, err = ts.LoginAttempts(ctx, "login:attempts")
+	ts.Require().NoError(err)
+	ts.Equal(3, attempts.Failures)
+	ts.False(attempts.LastFailure.IsZero())
+
+	ts.Require().NoError(ts.ResetLoginAttempts(ctx, "login:attempts"))
+	attempts, err = ts.LoginAttempts(ctx, "login:attempts")
+	ts.Require().NoError(err)
+	ts.Equal(0, attempts.Failures)
+}
+
+// обновление хеша пароля при смене алгоритма
+func (ts *PostgresTestSuite) TestRehashPassword() {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	oldHash := []byte("$2a$10$old-hash")
+	userID, err := ts.CreateUser(ctx, "user-rehash", oldHash)
+	ts.Require().NoError(err)
+
+	ts.Require().NoError(ts.RehashPassword(ctx, userID, oldHash, []byte("$argon2id$v=19$new-hash")))
+
+	user, err := ts.User(ctx, "user-rehash")
+	ts.Require().NoError(err)
+	ts.Equal([]byte("$argon2id$v=19$new-hash"), user.Password)
+
+	// пароль сменили после чтения пользователя при входе
+	_, err = ts.ChangePassword(ctx, userID, []byte("$argon2id$v=19$changed"))
+	ts.Require().NoError(err)
+	ts.ErrorIs(ts.RehashPassword(ctx, userID, oldHash, []byte("$argon2id$v=19$stale")), storage.ErrNoRecordsFound)
+
+	user, err = ts.User(ctx, "user-rehash")
+	ts.Require().NoError(err)
+	ts.Equal([]byte("$argon2id$v=19$changed"), user.Password)
+
+	ts.ErrorIs(ts.RehashPassword(ctx, uuid.NewString(), oldHash, []byte("hash")), storage.ErrNoRecordsFound)
+}
+
+// смена и сброс пароля отзывают токены пользователя
+func (ts *PostgresTestSuite) TestPassword() {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	userID, err := ts.CreateUser(ctx, "user-password", []byte("hash-1"))
+	ts.Require().NoError(err)
+
+	generation, err := ts.ChangePassword(ctx, userID, []byte("hash-2"))
+	ts.Require().NoError(err)
+	ts.Equal(int64(1), generation)
+
+	user, err := ts.UserByID(ctx, userID)
+	ts.Require().NoError(err)
+	ts.Equal([]byte("hash-2"), user.Password)
+
+	// новый токен сброса заменяет предыдущий
+	ts.Require().NoError(ts.CreatePasswordResetToken(ctx, storage.PasswordResetToken{
+		UserID:    userID,
+		TokenHash: []byte("reset-hash-1"),
+		ExpiresAt: time.Now().Add(time.Hour),
+	}))
+	ts.Require().NoError(ts.CreatePasswordResetToken(ctx, storage.PasswordResetToken{
+		UserID:    userID,
+		TokenHash: []byte("reset-hash-2"),
+		ExpiresAt: time.Now().Add(time.Hour),
+	}))
+
+	_, err = ts.ResetPassword(ctx, []byte("reset-hash-1"), []byte("hash-3"))
+	ts.ErrorIs(err, storage.ErrNoRecordsFound)
+
+	resetUserID, err := ts.ResetPassword(ctx, []byte("reset-hash-2"), []byte("hash-3"))
+	ts.Require().NoError(err)
+	ts.Equal(userID, resetUserID)
+
+	// токен одноразовый
+	_, err = ts.ResetPassword(ctx, []byte("reset-hash-2"), []byte("hash-4"))
+	ts.ErrorIs(err, storage.ErrNoRecordsFound)
+
+	user, err = ts.UserByID(ctx, userID)
+	ts.Require().NoError(err)
+	ts.Equal([]byte("hash-3"), user.Password)
+	ts.Equal(int64(2), user.Generation)
+
+	// истекший токен
+	ts.Require().NoError(ts.CreatePasswordResetToken(ctx, storage.PasswordResetToken{
+		UserID:    userID,
+		TokenHash: []byte("reset-hash-3"),
+		ExpiresAt: time.Now().Add(-time.Minute),
+	}))
+	_, err = ts.ResetPassword(ctx, []byte("reset-hash-3"), []byte("hash-5"))
+	ts.ErrorIs(err, storage.ErrNoRecordsFound)
+}
+
+func (ts *PostgresTestSuite) TestAPIKeys() {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	userID, err := ts.CreateUser(ctx, "user-api-keys", []byte("hash"))
+	ts.Require().NoError(err)
+
+	_, err = ts.APIKeys(ctx, userID)
+	ts.ErrorIs(err, storage.ErrNoRecordsFound)
+
+	created, err := ts.CreateAPIKey(ctx, storage.CreateAPIKey{
+		UserID:  userID,
+		Name:    "POS",
+		Prefix:  "gmk_abcdefgh",
+		KeyHash: []byte("key-hash"),
+		Scopes:  []string{"orders:upload"},
+	})
+	ts.Require().NoError(err)
+	ts.Equal([]string{"orders:upload"}, created.Scopes)
+	ts.Nil(created.LastUsedAt)
+
+	ts.Require().NoError(ts.RecordAPIKeyUsage(ctx, created.KeyID, "10.0.0.1"))
+
+	key, err := ts.APIKeyByHash(ctx, []byte("key-hash"))
+	ts.Require().NoError(err)
+	ts.Equal(created.KeyID, key.KeyID)
+	ts.Equal(int64(1), key.UseCount)
+	ts.Equal("10.0.0.1", key.LastUsedIP)
+	ts.NotNil(key.LastUsedAt)
+
+	// чужой ключ не отзывается
+	otherID, err := ts.CreateUser(ctx, "user-api-keys-other", []byte("hash"))
+	ts.Require().NoError(err)
+	ts.ErrorIs(ts.RevokeAPIKey(ctx, otherID, created.KeyID), storage.ErrNoRecordsFound)
+
+	ts.Require().NoError(ts.RevokeAPIKey(ctx, userID, created.KeyID))
+	ts.ErrorIs(ts.RevokeAPIKey(ctx, userID, created.KeyID), storage.ErrNoRecordsFound)
+
+	keys, err := ts.APIKeys(ctx, userID)
+	ts.Require().NoError(err)
+	ts.Require().Len(keys, 1)
+	ts.NotNil(keys[0].RevokedAt)
+
+	_, err = ts.APIKeyByHash(ctx, []byte("unknown"))
+	ts.ErrorIs(err, storage.ErrNoRecordsFound)
+}
+
+func (ts *PostgresTestSuite) TestTOTP() {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	userID, err := ts.CreateUser(ctx, "user-totp", []byte("hash"))
+	ts.Require().NoError(err)
+
+	_, err = ts.TOTP(ctx, userID)
+	ts.ErrorIs(err, storage.ErrNoRecordsFound)
+
+	// до подтверждения секрет можно заменить
+	ts.Require().NoError(ts.SaveTOTPSecret(ctx, userID, "SECRET1"))
+	ts.Require().NoError(ts.SaveTOTPSecret(ctx, userID, "SECRET2"))
+
+	totp, err := ts.TOTP(ctx, userID)
+	ts.Require().NoError(err)
+	ts.Equal("SECRET2", totp.Secret)
+	ts.Nil(totp.ConfirmedAt)
+
+	ts.Require().NoError(ts.ConfirmTOTP(ctx, userID, 100, [][]byte{[]byte("code-1"), []byte("code-2")}))
+	ts.ErrorIs(ts.ConfirmTOTP(ctx, userID, 101, nil), storage.ErrNoRecordsFound)
+	ts.ErrorIs(ts.SaveTOTPSecret(ctx, userID, "SECRET3"), storage.ErrUniqueViolation)
+
+	// шаг подтверждения и более ранние не принимаются
+	ts.ErrorIs(ts.UseTOTPStep(ctx, userID, 100), storage.ErrNoRecordsFound)
+	ts.Require().NoError(ts.UseTOTPStep(ctx, userID, 101))
+	ts.ErrorIs(ts.UseTOTPStep(ctx, userID, 101), storage.ErrNoRecordsFound)
+
+	ts.Require().NoError(ts.UseRecoveryCode(ctx, userID, []byte("code-1")))
+	ts.ErrorIs(ts.UseRecoveryCode(ctx, userID, []byte("code-1")), storage.ErrNoRecordsFound)
+
+	ts.Require().NoError(ts.CreateMFAChallenge(ctx, storage.MFAChallenge{
+		UserID:    userID,
+		TokenHash: []byte("challenge"),
+		ExpiresAt: time.Now().Add(time.Minute),
+	}))
+	ts.Require().NoError(ts.FailMFAChallenge(ctx, []byte("challenge")))
+
+	challenge, err := ts.MFAChallenge(ctx, []byte("challenge"))
+	ts.Require().NoError(err)
+	ts.Equal(userID, challenge.UserID)
+	ts.Equal(1, challenge.Attempts)
+
+	ts.Require().NoError(ts.CompleteMFAChallenge(ctx, []byte("challenge")))
+	ts.ErrorIs(ts.CompleteMFAChallenge(ctx, []byte("challenge")), storage.ErrNoRecordsFound)
+
+	_, err = ts.MFAChallenge(ctx, []byte("challenge"))
+	ts.ErrorIs(err, storage.ErrNoRecordsFound)
+}
+
+func (ts *PostgresTestSuite) TestSessions() {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	userID, err := ts.CreateUser(ctx, "user-sessions", []byte("hash"))
+	ts.Require().NoError(err)
+
+	_, err = ts.Sessions(ctx, userID)
+	ts.ErrorIs(err, storage.ErrNoRecordsFound)
+
+	sessionID := uuid.NewString()
+	expiresAt := time.Now().Add(time.Hour)
+
+	ts.Require().NoError(ts.SaveSession(ctx, storage.Session{
+		SessionID: sessionID,
+		UserID:    userID,
+		IP:        "10.0.0.1",
+		UserAgent: "Mozilla/5.0",
+		ExpiresAt: expiresAt,
+	}))
+	ts.Require().NoError(ts.CreateRefreshToken(ctx, storage.RefreshToken{
+		TokenID:   uuid.NewString(),
+		FamilyID:  sessionID,
+		UserID:    userID,
+		TokenHash: []byte("session-refresh"),
+		ExpiresAt: expiresAt,
+	}))
+
+	// обновление токенов меняет адрес и клиента, время создания остается
+	ts.Require().NoError(ts.SaveSession(ctx, storage.Session{
+		SessionID: sessionID,
+		UserID:    userID,
+		IP:        "10.0.0.2",
+		UserAgent: "Mozilla/5.0",
+		ExpiresAt: expiresAt,
+	}))
+	ts.Require().NoError(ts.TouchSession(ctx, sessionID))
+
+	sessions, err := ts.Sessions(ctx, userID)
+	ts.Require().NoError(err)
+	ts.Require().Len(sessions, 1)
+	ts.Equal("10.0.0.2", sessions[0].IP)
+	ts.False(sessions[0].LastSeenAt.Before(sessions[0].CreatedAt))
+
+	// чужая сессия не завершается
+	otherID, err := ts.CreateUser(ctx, "user-sessions-other", []byte("hash"))
+	ts.Require().NoError(err)
+	ts.ErrorIs(ts.RevokeSession(ctx, otherID, sessionID), storage.ErrNoRecordsFound)
+
+	ts.Require().NoError(ts.RevokeSession(ctx, userID, sessionID))
+	ts.ErrorIs(ts.RevokeSession(ctx, userID, sessionID), storage.ErrNoRecordsFound)
+	ts.ErrorIs(ts.TouchSession(ctx, sessionID), storage.ErrNoRecordsFound)
+
+	// refresh токены завершенной сессии не обмениваются
+	_, err = ts.RotateRefreshToken(ctx, []byte("session-refresh"), storage.RefreshToken{
+		TokenID:   uuid.NewString(),
+		TokenHash: []byte("session-refresh-2"),
+		ExpiresAt: expiresAt,
+	})
+	ts.ErrorIs(err, storage.ErrTokenReused)
+
+	_, err = ts.Sessions(ctx, userID)
+	ts.ErrorIs(err, storage.ErrNoRecordsFound)
+}
+
+// удаление учетной записи с сохранением финансовых записей
+func (ts *PostgresTestSuite) TestDeleteUser() {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	userID, err := ts.CreateUser(ctx, "user-delete", []byte("secret"))
+	ts.Require().NoError(err)
+	adminID, err := ts.CreateUser(ctx, "admin-delete", []byte("secret"))
+	ts.Require().NoError(err)
+
+	_, err = ts.AdjustBalance(ctx, storage.BalanceAdjustment{
+		UserID:  userID,
+		AdminID: adminID,
+		Amount:  money.FromInt(100),
+		Reason:  "compensation",
+		Comment: "задержка доставки",
+	})
+	ts.Require().NoError(err)
+	ts.Require().NoError(ts.Withdraw(ctx, userID, storage.WithdrawBonuses{
+		Order: "delete-user-1",
+		Sum:   money.FromInt(30),
+	}))
+
+	sessionID := uuid.NewString()
+	ts.Require().NoError(ts.SaveSession(ctx, storage.Session{
+		SessionID: sessionID,
+		UserID:    userID,
+		IP:        "10.0.0.1",
+		UserAgent: "Mozilla/5.0",
+		ExpiresAt: time.Now().Add(time.Hour),
+	}))
+
+	forfeited, err := ts.DeleteUser(ctx, userID, true)
+	ts.Require().NoError(err)
+	ts.Equal(money.FromInt(70), forfeited)
+
+	_, err = ts.DeleteUser(ctx, userID, true)
+	ts.ErrorIs(err, storage.ErrNoRecordsFound)
+
+	// логин обезличен и освобожден
+	user, err := ts.UserByID(ctx, userID)
+	ts.Require().NoError(err)
+	ts.True(user.IsDeleted)
+	ts.NotEqual("user-delete", user.Login)
+	_, err = ts.User(ctx, "user-delete")
+	ts.ErrorIs(err, storage.ErrNoRecordsFound)
+
+	ts.ErrorIs(ts.TouchSession(ctx, sessionID), storage.ErrNoRecordsFound)
+
+	balance, err := ts.UserBalance(ctx, userID)
+	ts.Require().NoError(err)
+	ts.Equal(money.FromInt(0), balance.Current)
+
+	// списания и корректировки остаются в истории
+	entries, err := ts.BalanceHistory(ctx, userID)
+	ts.Require().NoError(err)
+	ts.Require().Len(entries, 3)
+	ts.Equal("account_deletion", entries[0].Reason)
+	ts.Equal(money.FromInt(-70), entries[0].Sum)
+
+	// остаток аннулирован системой, а не самим пользователем
+	var systemAdjustments int
+	ts.Require().NoError(ts.testStorager.(*dbStorage).pool.QueryRow(ctx, `
+		SELECT COUNT(*) FROM balance_adjustments
+		WHERE user_id = $1 AND admin_id IS NULL AND reason = 'account_deletion'`, userID,
+	).Scan(&systemAdjustments))
+	ts.Equal(1, systemAdjustments)
+}
+
+// удаленный баланс не пополняется заказами, которые еще обрабатывались
+func (ts *PostgresTestSuite) TestDeleteUserPendingOrders() {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	userID, err := ts.CreateUser(ctx, "user-delete-pending", []byte("secret"))
+	ts.Require().NoError(err)
+
+	ts.Require().NoError(ts.CreateOrder(ctx, userID, storage.CreateOrder{
+		OrderID: "delete-pending-1",
+		Status:  "NEW",
+	}))
+
+	_, err = ts.DeleteUser(ctx, userID, true)
+	ts.Require().NoError(err)
+
+	pending := func() bool {
+		orders, err := ts.OrdersForUpdate(ctx, 1000)
+		if err != nil {
+			ts.Require().ErrorIs(err, storage.ErrNoRecordsFound)
+		}
+		for _, o := range orders {
+			if o.UserID == userID {
+				return true
+			}
+		}
+		return false
+	}
+	ts.False(pending())
+
+	// запоздавший ответ системы начислений не меняет заказ и не начисляет баллы
+	ts.Require().NoError(ts.BatchUpdateOrder(ctx, []storage.UpdateOrder{
+		{OrderID: "delete-pending-1", Status: "PROCESSED", Accrual: money.FromInt(50)},
+	}))
+
+	order, err := ts.UserOrder(ctx, userID, "delete-pending-1")
+	ts.Require().NoError(err)
+	ts.Equal("NEW", order.Status)
+
+	events, err := ts.OrderEvents(ctx, "delete-pending-1")
+	if err != nil {
+		ts.Require().ErrorIs(err, storage.ErrNoRecordsFound)
+	}
+	for _, e := range events {
+		ts.NotEqual("INVALID", e.Status)
+	}
+
+	// перепроверка заказа не возвращает его в опрос
+	ts.Require().NoError(ts.RecheckOrder(ctx, "delete-pending-1"))
+	ts.False(pending())
+
+	balance, err := ts.UserBalance(ctx, userID)
+	ts.Require().NoError(err)
+	ts.Equal(money.FromInt(0), balance.Current)
+}
+
+// постраничная выборка заказов с фильтрами
+func (ts *PostgresTestSuite) TestOrdersPage() {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	userID, err := ts.CreateUser(ctx, "user-orders-page", []byte("secret"))
+	ts.Require().NoError(err)
+
+	for _, order := range []storage.CreateOrder{
+		{OrderID: "page-1", Status: "NEW"},
+		{OrderID: "page-2", Status: "PROCESSED", Accrual: money.FromInt(10)},
+		{OrderID: "page-3", Status: "NEW"},
+	} {
+		ts.Require().NoError(ts.CreateOrder(ctx, userID, order))
+	}
+
+	first, err := ts.OrdersPage(ctx, storage.OrdersFilter{UserID: userID, Limit: 2})
+	ts.Require().NoError(err)
+	ts.Require().Len(first, 2)
+	ts.Equal("page-1", first[0].OrderID)
+
+	next, err := ts.OrdersPage(ctx, storage.OrdersFilter{
+		UserID:          userID,
+		AfterUploadedAt: first[1].UploadedAt,
+		AfterOrderID:    first[1].OrderID,
+		Limit:           2,
+	})
+	ts.Require().NoError(err)
+	ts.Require().Len(next, 1)
+	ts.Equal("page-3", next[0].OrderID)
+
+	desc, err := ts.OrdersPage(ctx, storage.OrdersFilter{
+		UserID:   userID,
+		Statuses: []string{"NEW"},
+		Desc:     true,
+	})
+	ts.Require().NoError(err)
+	ts.Require().Len(desc, 2)
+	ts.Equal("page-3", desc[0].OrderID)
+
+	_, err = ts.OrdersPage(ctx, storage.OrdersFilter{
+		UserID: userID,
+		From:   time.Now().Add(time.Hour),
+	})
+	ts.ErrorIs(err, storage.ErrNoRecordsFound)
+}
+
+// история статусов заказа
+func (ts *PostgresTestSuite) TestOrderEvents() {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	userID, err := ts.CreateUser(ctx, "user-order-events", []byte("secret"))
+	ts.Require().NoError(err)
+	otherID, err := ts.CreateUser(ctx, "other-order-events", []byte("secret"))
+	ts.Require().NoError(err)
+
+	ts.Require().NoError(ts.CreateOrder(ctx, userID, storage.CreateOrder{OrderID: "events-1", Status: "NEW"}))
+
+	update := []storage.UpdateOrder{{UserID: userID, OrderID: "events-1", Status: "PROCESSING"}}
+	ts.Require().NoError(ts.BatchUpdateOrder(ctx, update))
+	// повтор того же статуса не пишется в историю
+	ts.Require().NoError(ts.BatchUpdateOrder(ctx, update))
+	ts.Require().NoError(ts.BatchUpdateOrder(ctx, []storage.UpdateOrder{
+		{UserID: userID, OrderID: "events-1", Status: "PROCESSED", Accrual: money.FromInt(42)},
+	}))
+
+	events, err := ts.OrderEvents(ctx, "events-1")
+	ts.Require().NoError(err)
+	ts.Require().Len(events, 3)
+	ts.Equal("NEW", events[0].Status)
+	ts.Equal("PROCESSING", events[1].Status)
+	ts.Equal("PROCESSED", events[2].Status)
+	ts.Equal(money.FromInt(42), events[2].Accrual)
+
+	order, err := ts.UserOrder(ctx, userID, "events-1")
+	ts.Require().NoError(err)
+	ts.Equal("PROCESSED", order.Status)
+
+	_, err = ts.UserOrder(ctx, otherID, "events-1")
+	ts.ErrorIs(err, storage.ErrNoRecordsFound)
+}
+
+// пакетная загрузка заказов
+func (ts *PostgresTestSuite) TestCreateOrders() {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	userID, err := ts.CreateUser(ctx, "user-create-orders", []byte("secret"))
+	ts.Require().NoError(err)
+	otherID, err := ts.CreateUser(ctx, "other-create-orders", []byte("secret"))
+	ts.Require().NoError(err)
+
+	ts.Require().NoError(ts.CreateOrder(ctx, userID, storage.CreateOrder{OrderID: "bulk-mine", Status: "NEW"}))
+	ts.Require().NoError(ts.CreateOrder(ctx, otherID, storage.CreateOrder{OrderID: "bulk-other", Status: "NEW"}))
+
+	owners, err := ts.CreateOrders(ctx, userID, []string{"bulk-new", "bulk-mine", "bulk-other"})
+	ts.Require().NoError(err)
+	ts.Require().Len(owners, 3)
+
+	byID := make(map[string]storage.OrderOwner, len(owners))
+	for _, owner := range owners {
+		byID[owner.OrderID] = owner
+	}
+	ts.True(byID["bulk-new"].Inserted)
+	ts.Equal(userID, byID["bulk-new"].UserID)
+	ts.False(byID["bulk-mine"].Inserted)
+	ts.Equal(userID, byID["bulk-mine"].UserID)
+	ts.False(byID["bulk-other"].Inserted)
+	ts.Equal(otherID, byID["bulk-other"].UserID)
+
+	events, err := ts.OrderEvents(ctx, "bulk-new")
+	ts.Require().NoError(err)
+	ts.Require().Len(events, 1)
+	ts.Equal("NEW", events[0].Status)
+}
+
+// ключи идемпотентности
+func (ts *PostgresTestSuite) TestIdempotency() {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	userID, err := ts.CreateUser(ctx, "user-idempotency", []byte("secret"))
+	ts.Require().NoError(err)
+
+	key := storage.IdempotencyKey{
+		UserID:      userID,
+		Key:         "retry-1",
+		RequestHash: []byte("hash"),
+		ExpiresAt:   time.Now().Add(time.Hour),
+	}
+
+	saved, err := ts.BeginIdempotency(ctx, key, time.Minute)
+	ts.Require().NoError(err)
+	ts.Nil(saved)
+
+	// ключ занят незавершенным запросом
+	saved, err = ts.BeginIdempotency(ctx, key, time.Minute)
+	ts.Require().NoError(err)
+	ts.Require().NotNil(saved)
+	ts.Nil(saved.StatusCode)
+
+	status := 200
+	key.StatusCode = &status
+	key.ContentType = "application/json"
+	key.Body = []byte(`{"status":"OK"}`)
+	ts.Require().NoError(ts.CompleteIdempotency(ctx, key))
+	ts.ErrorIs(ts.CompleteIdempotency(ctx, key), storage.ErrNoRecordsFound)
+
+	saved, err = ts.BeginIdempotency(ctx, key, time.Minute)
+	ts.Require().NoError(err)
+	ts.Require().NotNil(saved)
+	ts.Equal(200, *saved.StatusCode)
+	ts.Equal(key.Body, saved.Body)
+
+	// незавершенный запрос освобождает ключ
+	other := storage.IdempotencyKey{
+		UserID:      userID,
+		Key:         "retry-2",
+		RequestHash: []byte("hash"),
+		ExpiresAt:   time.Now().Add(time.Hour),
+	}
+	_, err = ts.BeginIdempotency(ctx, other, time.Minute)
+	ts.Require().NoError(err)
+	ts.Require().NoError(ts.DeleteIdempotency(ctx, userID, other.Key))
+	saved, err = ts.BeginIdempotency(ctx, other, time.Minute)
+	ts.Require().NoError(err)
+	ts.Nil(saved)
+
+	// очистка удаляет только истекшие ключи
+	purged, err := ts.PurgeIdempotency(ctx, time.Now().Add(2*time.Hour), 1)
+	ts.Require().NoError(err)
+	ts.Equal(int64(1), purged)
+	purged, err = ts.PurgeIdempotency(ctx, time.Now(), 100)
+	ts.Require().NoError(err)
+	ts.Equal(int64(0), purged)
+}
+
+// журнал баллов и пересчет остатков на момент времени
+func (ts *PostgresTestSuite) TestLedger() {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	before := time.Now().Add(-time.Minute)
+
+	userID, err := ts.CreateUser(ctx, "user-ledger", []byte("secret"))
+	ts.Require().NoError(err)
+
+	ts.Require().NoError(ts.CreateOrder(ctx, userID, storage.CreateOrder{
+		OrderID: "ledger-order-1",
+		Status:  "PROCESSED",
+		Accrual: money.FromInt(200),
+	}))
+	ts.Require().NoError(ts.Withdraw(ctx, userID, storage.WithdrawBonuses{
+		Order: "ledger-order-2",
+		Sum:   money.FromInt(50),
+	}))
+	_, err = ts.AdjustBalance(ctx, storage.BalanceAdjustment{
+		UserID:  userID,
+		AdminID: userID,
+		Amount:  money.FromInt(10),
+		Reason:  "promotion",
+		Comment: "акция",
+	})
+	ts.Require().NoError(err)
+
+	cached, err := ts.UserBalance(ctx, userID)
+	ts.Require().NoError(err)
+	ts.Equal(money.FromInt(160), cached.Current)
+	ts.Equal(money.FromInt(50), cached.Withdrawn)
+
+	recomputed, err := ts.LedgerBalance(ctx, userID, time.Now().Add(time.Minute))
+	ts.Require().NoError(err)
+	ts.Equal(cached, recomputed)
+
+	empty, err := ts.LedgerBalance(ctx, userID, before)
+	ts.Require().NoError(err)
+	ts.Equal(&storage.Balance{}, empty)
+
+	_, err = ts.LedgerBalance(ctx, uuid.NewString(), time.Now())
+	ts.ErrorIs(err, storage.ErrNoRecordsFound)
+
+	entries, err := ts.BalanceHistory(ctx, userID)
+	ts.Require().NoError(err)
+	ts.Require().Len(entries, 3)
+	ts.Equal("adjustment", entries[0].Type)
+	ts.Equal("promotion", entries[0].Reason)
+	ts.Equal("withdrawal", entries[1].Type)
+	ts.Equal("ledger-order-2", entries[1].Order)
+	ts.Equal("accrual", entries[2].Type)
+	ts.Equal(money.FromInt(200), entries[2].Sum)
+
+	// проводки не изменяются и не удаляются
+	pool := ts.testStorager.(*dbStorage).pool
+	_, err = pool.Exec(ctx, `UPDATE ledger_postings SET amount = 1`)
+	ts.Error(err)
+	_, err = pool.Exec(ctx, `DELETE FROM ledger_entries`)
+	ts.Error(err)
+}
+
+// сторно начисления при повторном расчёте и сторно списания
+func (ts *PostgresTestSuite) TestLedgerReversal() {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	userID, err := ts.CreateUser(ctx, "user-ledger-reversal", []byte("secret"))
+	ts.Require().NoError(err)
+
+	ts.Require().NoError(ts.CreateOrder(ctx, userID, storage.CreateOrder{
+		OrderID: "reversal-order-1",
+		Status:  "PROCESSED",
+		Accrual: money.FromInt(100),
+	}))
+
+	// начисление сторнируется, заказ уходит на повторный расчёт
+	ts.Require().NoError(ts.RecheckOrder(ctx, "reversal-order-1"))
+
+	balance, err := ts.UserBalance(ctx, userID)
+	ts.Require().NoError(err)
+	ts.Equal(money.Amount(0), balance.Current)
+
+	order, err := ts.UserOrder(ctx, userID, "reversal-order-1")
+	ts.Require().NoError(err)
+	ts.Equal("NEW", order.Status)
+	ts.Equal(money.Amount(0), order.Accrual)
+
+	entries, err := ts.BalanceHistory(ctx, userID)
+	ts.Require().NoError(err)
+	ts.Require().Len(entries, 2)
+	ts.Equal("reversal", entries[0].Type)
+	ts.Equal("reversal-order-1", entries[0].Order)
+	ts.Equal(money.FromInt(-100), entries[0].Sum)
+
+	// после повторного расчёта заказ начисляется заново, но один раз
+	processed := []storage.UpdateOrder{{OrderID: "reversal-order-1", Status: "PROCESSED", Accrual: money.FromInt(80)}}
+	ts.Require().NoError(ts.BatchUpdateOrder(ctx, processed))
+	ts.Require().NoError(ts.BatchUpdateOrder(ctx, processed))
+
+	balance, err = ts.UserBalance(ctx, userID)
+	ts.Require().NoError(err)
+	ts.Equal(money.FromInt(80), balance.Current)
+
+	pool := ts.testStorager.(*dbStorage).pool
+	_, err = pool.Exec(ctx, `
+		INSERT INTO ledger_entries (entry_id, kind, user_id, reference)
+		VALUES ($1, 'accrual', $2, 'reversal-order-1')`, uuid.NewString(), userID)
+	ts.Error(err)
+
+	// потраченное начисление не сторнируется
+	ts.Require().NoError(ts.Withdraw(ctx, userID, storage.WithdrawBonuses{
+		Order: "reversal-order-2",
+		Sum:   money.FromInt(50),
+	}))
+	ts.ErrorIs(ts.RecheckOrder(ctx, "reversal-order-1"), storage.ErrConstraints)
+
+	order, err = ts.UserOrder(ctx, userID, "reversal-order-1")
+	ts.Require().NoError(err)
+	ts.Equal("PROCESSED", order.Status)
+
+	// сторно списания возвращает баллы и уменьшает сумму списаний
+	var withdrawalID string
+	ts.Require().NoError(pool.QueryRow(ctx, `
+		SELECT entry_id FROM ledger_entries WHERE kind = 'withdrawal' AND reference = $1`,
+		"reversal-order-2",
+	).Scan(&withdrawalID))
+
+	reverse := func() (*storage.Balance, error) {
+		tx, err := pool.Begin(ctx)
+		ts.Require().NoError(err)
+		defer func() { _ = tx.Rollback(ctx) }()
+
+		balance, err := reverseEntry(ctx, tx, withdrawalID)
+		if err != nil {
+			return nil, err
+		}
+		return balance, tx.Commit(ctx)
+	}
+
+	balance, err = reverse()
+	ts.Require().NoError(err)
+	ts.Equal(money.FromInt(80), balance.Current)
+	ts.Equal(money.Amount(0), balance.Withdrawn)
+
+	_, err = reverse()
+	ts.ErrorIs(err, storage.ErrUniqueViolation)
+
+	recomputed, err := ts.LedgerBalance(ctx, userID, time.Now().Add(time.Minute))
+	ts.Require().NoError(err)
+	ts.Equal(balance, recomputed)
+
+	drifts, err := ts.BalanceDrifts(ctx)
+	if err != nil {
+		ts.Require().ErrorIs(err, storage.ErrNoRecordsFound)
+	}
+	for _, d := range drifts {
+		ts.NotEqual(userID, d.UserID)
+	}
+
+	// сторно не изменяется и само не сторнируется
+	var reversalID string
+	ts.Require().NoError(pool.QueryRow(ctx, `
+		SELECT entry_id FROM ledger_entries WHERE reverses_entry_id = $1`, withdrawalID,
+	).Scan(&reversalID))
+	withdrawalID = reversalID
+	_, err = reverse()
+	ts.ErrorIs(err, storage.ErrConstraints)
+	_, err = pool.Exec(ctx, `DELETE FROM ledger_entries WHERE entry_id = $1`, reversalID)
+	ts.ErrorContains(err, "use reversal entries")
+}
+
+// сверка баланса с заказами и списаниями
+func (ts *PostgresTestSuite) TestReconcileBalance() {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	userID, err := ts.CreateUser(ctx, "user-reconcile", []byte("secret"))
+	ts.Require().NoError(err)
+
+	ts.Require().NoError(ts.CreateOrder(ctx, userID, storage.CreateOrder{
+		OrderID: "reconcile-order-1",
+		Status:  "PROCESSED",
+		Accrual: money.FromInt(100),
+	}))
+	ts.Require().NoError(ts.Withdraw(ctx, userID, storage.WithdrawBonuses{
+		Order: "reconcile-order-2",
+		Sum:   money.FromInt(30),
+	}))
+
+	userDrift := func() *storage.BalanceDrift {
+		drifts, err := ts.BalanceDrifts(ctx)
+		if err != nil {
+			ts.Require().ErrorIs(err, storage.ErrNoRecordsFound)
+		}
+		for i := range drifts {
+			if drifts[i].UserID == userID {
+				return &drifts[i]
+			}
+		}
+		return nil
+	}
+	ts.Nil(userDrift())
+
+	// кеш баланса разошелся с заказами
+	pool := ts.testStorager.(*dbStorage).pool
+	_, err = pool.Exec(ctx, `UPDATE user_balance SET current = current + 25 WHERE user_id = $1`, userID)
+	ts.Require().NoError(err)
+
+	drift := userDrift()
+	ts.Require().NotNil(drift)
+	ts.Equal(money.FromInt(95), drift.Current)
+	ts.Equal(money.FromInt(70), drift.ExpectedCurrent)
+	ts.Equal(money.FromInt(30), drift.ExpectedWithdrawn)
+
+	// исправление системой записывается без администратора
+	corrected, err := ts.CorrectBalanceDrift(ctx, userID, "")
+	ts.Require().NoError(err)
+	ts.Equal(money.FromInt(95), corrected.Current)
+
+	var systemAdjustments int
+	ts.Require().NoError(pool.QueryRow(ctx, `
+		SELECT COUNT(*) FROM balance_adjustments
+		WHERE user_id = $1 AND admin_id IS NULL AND reason = 'reconciliation'`, userID,
+	).Scan(&systemAdjustments))
+	ts.Equal(1, systemAdjustments)
+
+	balance, err := ts.UserBalance(ctx, userID)
+	ts.Require().NoError(err)
+	ts.Equal(money.FromInt(70), balance.Current)
+	ts.Nil(userDrift())
+
+	_, err = ts.CorrectBalanceDrift(ctx, userID, userID)
+	ts.ErrorIs(err, storage.ErrNoRecordsFound)
+
+	entries, err := ts.BalanceHistory(ctx, userID)
+	ts.Require().NoError(err)
+	ts.Equal("adjustment", entries[0].Type)
+	ts.Equal("reconciliation", entries[0].Reason)
+	ts.Equal(money.FromInt(-25), entries[0].Sum)
+}
+
+func (ts *PostgresTestSuite) TestPointLots() {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	userID, err := ts.CreateUser(ctx, "user-point-lots", []byte("secret"))
+	ts.Require().NoError(err)
+
+	for _, order := range []storage.CreateOrder{
+		{OrderID: "lots-order-1", Status: "PROCESSED", Accrual: money.FromInt(100)},
+		{OrderID: "lots-order-2", Status: "PROCESSED", Accrual: money.FromInt(50)},
+	} {
+		ts.Require().NoError(ts.CreateOrder(ctx, userID, order))
+	}
+
+	// первая партия уже сгорела, вторая сгорит через год
+	now := time.Now()
+	pool := ts.testStorager.(*dbStorage).pool
+	expire := func(orderID string, at time.Time) {
+		_, err := pool.Exec(ctx, `
+			UPDATE point_lots
+			SET expires_at = $2
+			WHERE entry_id IN (
+				SELECT entry_id FROM ledger_entries WHERE kind = 'accrual' AND reference = $1
+			)`, orderID, at)
+		ts.Require().NoError(err)
+	}
+	expire("lots-order-1", now.Add(-time.Hour))
+	expire("lots-order-2", now.AddDate(1, 0, 0))
+
+	// списание расходует самую старую партию
+	ts.Require().NoError(ts.Withdraw(ctx, userID, storage.WithdrawBonuses{
+		Order: "lots-order-3",
+		Sum:   money.FromInt(30),
+	}))
+
+	expiring, err := ts.ExpiringPoints(ctx, userID, now.Add(24*time.Hour))
+	ts.Require().NoError(err)
+	ts.Require().Len(expiring, 1)
+	ts.Equal(money.FromInt(70), expiring[0].Amount)
+
+	users, err := ts.UsersWithExpiredPoints(ctx, now, 100)
+	ts.Require().NoError(err)
+	ts.Contains(users, userID)
+
+	expired, err := ts.ExpirePoints(ctx, userID, now)
+	ts.Require().NoError(err)
+	ts.Equal(money.FromInt(70), expired)
+
+	_, err = ts.ExpirePoints(ctx, userID, now)
+	ts.ErrorIs(err, storage.ErrNoRecordsFound)
+
+	balance, err := ts.UserBalance(ctx, userID)
+	ts.Require().NoError(err)
+	ts.Equal(money.FromInt(50), balance.Current)
+	ts.Equal(money.FromInt(30), balance.Withdrawn)
+
+	entries, err := ts.BalanceHistory(ctx, userID)
+	ts.Require().NoError(err)
+	ts.Equal("expiry", entries[0].Type)
+	ts.Equal(money.FromInt(-70), entries[0].Sum)
+
+	// сгорание учитывается сверкой
+	drifts, err := ts.BalanceDrifts(ctx)
+	if err != nil {
+		ts.Require().ErrorIs(err, storage.ErrNoRecordsFound)
+	}
+	for _, d := range drifts {
+		ts.NotEqual(userID, d.UserID)
+	}
+}
+
+// баллы ручной корректировки и сверки не сгорают
+func (ts *PostgresTestSuite) TestAdjustmentLotsDoNotExpire() {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	// хранилище со сроком сгорания начислений за заказы
+	db := *ts.testStorager.(*dbStorage)
+	db.expiryMonths = 12
+
+	userID, err := db.CreateUser(ctx, "user-adjustment-lots", []byte("secret"))
+	ts.Require().NoError(err)
+
+	ts.Require().NoError(db.CreateOrder(ctx, userID, storage.CreateOrder{
+		OrderID: "adjustment-lots-order-1",
+		Status:  "PROCESSED",
+		Accrual: money.FromInt(100),
+	}))
+	_, err = db.AdjustBalance(ctx, storage.BalanceAdjustment{
+		UserID:  userID,
+		AdminID: userID,
+		Amount:  money.FromInt(40),
+		Reason:  "promotion",
+		Comment: "акция",
+	})
+	ts.Require().NoError(err)
+
+	// кеш баланса разошелся, сверка доначисляет баллы
+	_, err = db.pool.Exec(ctx, `UPDATE user_balance SET current = current - 15 WHERE user_id = $1`, userID)
+	ts.Require().NoError(err)
+	_, err = db.CorrectBalanceDrift(ctx, userID, userID)
+	ts.Require().NoError(err)
+
+	var withExpiry, withoutExpiry int
+	ts.Require().NoError(db.pool.QueryRow(ctx, `
+		SELECT
+			COUNT(*) FILTER (WHERE l.expires_at IS NOT NULL),
+			COUNT(*) FILTER (WHERE l.expires_at IS NULL)
+		FROM point_lots l
+			JOIN ledger_entries e ON e.entry_id = l.entry_id
+		WHERE e.user_id = $1 AND e.kind = 'adjustment'`, userID,
+	).Scan(&withExpiry, &withoutExpiry))
+	ts.Equal(0, withExpiry)
+	ts.Equal(2, withoutExpiry)
+
+	// сгорит только начисление за заказ
+	expiring, err := db.ExpiringPoints(ctx, userID, time.Now().AddDate(2, 0, 0))
+	ts.Require().NoError(err)
+	ts.Require().Len(expiring, 1)
+	ts.Equal(money.FromInt(100), expiring[0].Amount)
+}
+
+// замороженный баланс удаленного пользователя не сгорает
+func (ts *PostgresTestSuite) TestDeletedUserPointsDoNotExpire() {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	userID, err := ts.CreateUser(ctx, "user-deleted-lots", []byte("secret"))
+	ts.Require().NoError(err)
+
+	ts.Require().NoError(ts.CreateOrder(ctx, userID, storage.CreateOrder{
+		OrderID: "deleted-lots-order-1",
+		Status:  "PROCESSED",
+		Accrual: money.FromInt(100),
+	}))
+
+	now := time.Now()
+	pool := ts.testStorager.(*dbStorage).pool
+	_, err = pool.Exec(ctx, `UPDATE point_lots SET expires_at = $2 WHERE user_id = $1`, userID, now.Add(-time.Hour))
+	ts.Require().NoError(err)
+
+	// баланс заморожен, а не аннулирован
+	_, err = ts.DeleteUser(ctx, userID, false)
+	ts.Require().NoError(err)
+
+	users, err := ts.UsersWithExpiredPoints(ctx, now, 1000)
+	if err != nil {
+		ts.Require().ErrorIs(err, storage.ErrNoRecordsFound)
+	}
+	ts.NotContains(users, userID)
+
+	_, err = ts.ExpirePoints(ctx, userID, now)
+	ts.ErrorIs(err, storage.ErrNoRecordsFound)
+
+	balance, err := ts.UserBalance(ctx, userID)
+	ts.Require().NoError(err)
+	ts.Equal(money.FromInt(100), balance.Current)
+}
@@ -0,0 +1,330 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/vladislav-kr/gophermart/internal/logger"
+	"github.com/vladislav-kr/gophermart/internal/storage"
+)
+
+func (s *dbStorage) CreateRefreshToken(ctx context.Context, token storage.RefreshToken) error {
+	query := `
+		INSERT INTO
+			refresh_tokens (token_id, family_id, user_id, token_hash, expires_at)
+		VALUES
+			(@tokenID, @familyID, @userID, @tokenHash, @expiresAt)`
+
+	args := pgx.NamedArgs{
+		"tokenID":   token.TokenID,
+		"familyID":  token.FamilyID,
+		"userID":    token.UserID,
+		"tokenHash": token.TokenHash,
+		"expiresAt": token.ExpiresAt,
+	}
+
+	if _, err := s.pool.Exec(ctx, query, args); err != nil {
+		return fmt.Errorf("refresh_tokens insert %v: %w", err, storage.ErrInternal)
+	}
+
+	return nil
+}
+
+// RotateRefreshToken помечает токен использованным и выпускает новый в том же семействе.
+// Повторное использование токена отзывает всё семейство.
+func (s *dbStorage) RotateRefreshToken(
+	ctx context.Context,
+	tokenHash []byte,
+	newToken storage.RefreshToken,
+) (*storage.RefreshToken, error) {
+	type refreshToken struct {
+		TokenID   string     `db:"token_id"`
+		FamilyID  string     `db:"family_id"`
+		UserID    string     `db:"user_id"`
+		ExpiresAt time.Time  `db:"expires_at"`
+		UsedAt    *time.Time `db:"used_at"`
+		RevokedAt *time.Time `db:"revoked_at"`
+	}
+
+	tx, err := s.pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer func() {
+		if err := tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			s.log.Error("transaction rotate refresh token rollback", logger.Error(err))
+		}
+	}()
+
+	query := `
+		SELECT
+			token_id,
+			family_id,
+			user_id,
+			expires_at,
+			used_at,
+			revoked_at
+		FROM
+			refresh_tokens
+		WHERE
+			token_hash = @tokenHash
+		FOR UPDATE`
+
+	rows, err := tx.Query(ctx, query, pgx.NamedArgs{"tokenHash": tokenHash})
+	if err != nil {
+		return nil, fmt.Errorf("query refresh token %v: %w", err, storage.ErrInternal)
+	}
+
+	current, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[refreshToken])
+	if err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			return nil, storage.ErrNoRecordsFound
+		default:
+			return nil, fmt.Errorf("collect one row %v: %w", err, storage.ErrInternal)
+		}
+	}
+
+	if current.UsedAt != nil || current.RevokedAt != nil {
+		queryRevoke := `
+			UPDATE refresh_tokens
+			SET
+				revoked_at = CURRENT_TIMESTAMP
+			WHERE
+				family_id = @familyID
+				AND revoked_at IS NULL`
+
+		if _, err := tx.Exec(ctx, queryRevoke, pgx.NamedArgs{"familyID": current.FamilyID}); err != nil {
+			return nil, fmt.Errorf("revoke token family %v: %w", err, storage.ErrInternal)
+		}
+
+		querySession := `
+			UPDATE sessions
+			SET
+				revoked_at = CURRENT_TIMESTAMP
+			WHERE
+				session_id = @familyID
+				AND revoked_at IS NULL`
+
+		if _, err := tx.Exec(ctx, querySession, pgx.NamedArgs{"familyID": current.FamilyID}); err != nil {
+			return nil, fmt.Errorf("revoke session %v: %w", err, storage.ErrInternal)
+		}
+
+		if err := tx.Commit(ctx); err != nil {
+			return nil, fmt.Errorf("transaction revoke token family commit: %w", err)
+		}
+
+		return nil, storage.ErrTokenReused
+	}
+
+	if time.Now().After(current.ExpiresAt) {
+		return nil, storage.ErrTokenExpired
+	}
+
+	queryUsed := `
+		UPDATE refresh_tokens
+		SET
+			used_at = CURRENT_TIMESTAMP
+		WHERE
+			token_id = @tokenID`
+
+	if _, err := tx.Exec(ctx, queryUsed, pgx.NamedArgs{"tokenID": current.TokenID}); err != nil {
+		return nil, fmt.Errorf("refresh_tokens update %v: %w", err, storage.ErrInternal)
+	}
+
+	newToken.FamilyID = current.FamilyID
+	newToken.UserID = current.UserID
+
+	queryInsert := `
+		INSERT INTO
+			refresh_tokens (token_id, family_id, user_id, token_hash, expires_at)
+		VALUES
+			(@tokenID, @familyID, @userID, @tokenHash, @expiresAt)`
+
+	argsInsert := pgx.NamedArgs{
+		"tokenID":   newToken.TokenID,
+		"familyID":  newToken.FamilyID,
+		"userID":    newToken.UserID,
+		"tokenHash": newToken.TokenHash,
+		"expiresAt": newToken.ExpiresAt,
+	}
+
+	if _, err := tx.Exec(ctx, queryInsert, argsInsert); err != nil {
+		return nil, fmt.Errorf("refresh_tokens insert %v: %w", err, storage.ErrInternal)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("transaction rotate refresh token commit: %w", err)
+	}
+
+	return &newToken, nil
+}
+
+// RevokeRefreshToken отзывает семейство refresh токена пользователя и его сессию
+func (s *dbStorage) RevokeRefreshToken(ctx context.Context, userID string, tokenHash []byte) error {
+	query := `
+		WITH
+			family AS (
+				SELECT
+					family_id
+				FROM
+					refresh_tokens
+				WHERE
+					token_hash = @tokenHash
+					AND user_id = @userID
+			),
+			tokens AS (
+				UPDATE refresh_tokens
+				SET
+					revoked_at = CURRENT_TIMESTAMP
+				WHERE
+					family_id = (SELECT family_id FROM family)
+					AND revoked_at IS NULL
+			)
+		UPDATE sessions
+		SET
+			revoked_at = CURRENT_TIMESTAMP
+		WHERE
+			session_id = (SELECT family_id FROM family)
+			AND revoked_at IS NULL`
+
+	args := pgx.NamedArgs{
+		"userID":    userID,
+		"tokenHash": tokenHash,
+	}
+
+	if _, err := s.pool.Exec(ctx, query, args); err != nil {
+		return fmt.Errorf("revoke refresh token %v: %w", err, storage.ErrInternal)
+	}
+
+	return nil
+}
+
+// RevokeToken вносит access токен в список отозванных,
+// попутно удаляя записи о токенах с истекшим сроком действия
+func (s *dbStorage) RevokeToken(ctx context.Context, token storage.RevokedToken) error {
+	query := `
+		WITH
+			expired AS (
+				DELETE FROM revoked_tokens
+				WHERE
+					expires_at < CURRENT_TIMESTAMP
+			)
+		INSERT INTO
+			revoked_tokens (token_id, user_id, expires_at)
+		VALUES
+			(@tokenID, @userID, @expiresAt)
+		ON CONFLICT DO NOTHING`
+
+	args := pgx.NamedArgs{
+		"tokenID":   token.TokenID,
+		"userID":    token.UserID,
+		"expiresAt": token.ExpiresAt,
+	}
+
+	if _, err := s.pool.Exec(ctx, query, args); err != nil {
+		return fmt.Errorf("revoked_tokens insert %v: %w", err, storage.ErrInternal)
+	}
+
+	return nil
+}
+
+func (s *dbStorage) TokenRevoked(ctx context.Context, tokenID string) (bool, error) {
+	query := `
+		SELECT
+			EXISTS (
+				SELECT
+					1
+				FROM
+					revoked_tokens
+				WHERE
+					token_id = @tokenID
+			)`
+
+	var revoked bool
+	if err := s.pool.QueryRow(ctx, query, pgx.NamedArgs{"tokenID": tokenID}).Scan(&revoked); err != nil {
+		return false, fmt.Errorf("query revoked token %v: %w", err, storage.ErrInternal)
+	}
+
+	return revoked, nil
+}
+
+// RevokeUserTokens увеличивает поколение токенов пользователя
+// и отзывает все его refresh токены и сессии
+func (s *dbStorage) RevokeUserTokens(ctx context.Context, userID string) (int64, error) {
+	tx, err := s.pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return 0, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer func() {
+		if err := tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			s.log.Error("transaction revoke user tokens rollback", logger.Error(err))
+		}
+	}()
+
+	generation, err := revokeUserTokens(ctx, tx, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("transaction revoke user tokens commit: %w", err)
+	}
+
+	return generation, nil
+}
+
+// revokeUserTokens увеличивает поколение токенов и отзывает refresh токены
+// и сессии пользователя в транзакции вызывающего
+func revokeUserTokens(ctx context.Context, tx pgx.Tx, userID string) (int64, error) {
+	queryGeneration := `
+		UPDATE users
+		SET
+			token_generation = token_generation + 1,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE
+			user_id = @userID
+		RETURNING
+			token_generation`
+
+	args := pgx.NamedArgs{"userID": userID}
+
+	var generation int64
+	if err := tx.QueryRow(ctx, queryGeneration, args).Scan(&generation); err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			return 0, storage.ErrNoRecordsFound
+		default:
+			return 0, fmt.Errorf("users update token generation %v: %w", err, storage.ErrInternal)
+		}
+	}
+
+	queryRefresh := `
+		UPDATE refresh_tokens
+		SET
+			revoked_at = CURRENT_TIMESTAMP
+		WHERE
+			user_id = @userID
+			AND revoked_at IS NULL`
+
+	if _, err := tx.Exec(ctx, queryRefresh, args); err != nil {
+		return 0, fmt.Errorf("revoke refresh tokens %v: %w", err, storage.ErrInternal)
+	}
+
+	querySessions := `
+		UPDATE sessions
+		SET
+			revoked_at = CURRENT_TIMESTAMP
+		WHERE
+			user_id = @userID
+			AND revoked_at IS NULL`
+
+	if _, err := tx.Exec(ctx, querySessions, args); err != nil {
+		return 0, fmt.Errorf("revoke sessions %v: %w", err, storage.ErrInternal)
+	}
+
+	return generation, nil
+}
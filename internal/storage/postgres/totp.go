@@ -0,0 +1,270 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/vladislav-kr/gophermart/internal/logger"
+	"github.com/vladislav-kr/gophermart/internal/storage"
+)
+
+// SaveTOTPSecret сохраняет секрет неподтвержденной 2FA, повторный вызов заменяет секрет.
+// Вернет ErrUniqueViolation, если 2FA уже подтверждена.
+func (s *dbStorage) SaveTOTPSecret(ctx context.Context, userID, secret string) error {
+	query := `
+		INSERT INTO
+			user_totp (user_id, secret)
+		VALUES
+			(@userID, @secret)
+		ON CONFLICT (user_id) DO UPDATE
+		SET
+			secret = EXCLUDED.secret,
+			created_at = CURRENT_TIMESTAMP
+		WHERE
+			user_totp.confirmed_at IS NULL`
+
+	args := pgx.NamedArgs{
+		"userID": userID,
+		"secret": secret,
+	}
+
+	tag, err := s.pool.Exec(ctx, query, args)
+	if err != nil {
+		return fmt.Errorf("user_totp upsert %v: %w", err, storage.ErrInternal)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return storage.ErrUniqueViolation
+	}
+
+	return nil
+}
+
+func (s *dbStorage) TOTP(ctx context.Context, userID string) (*storage.TOTP, error) {
+	query := `
+		SELECT
+			user_id,
+			secret,
+			confirmed_at,
+			last_used_step
+		FROM
+			user_totp
+		WHERE
+			user_id = @userID`
+
+	rows, err := s.pool.Query(ctx, query, pgx.NamedArgs{"userID": userID})
+	if err != nil {
+		return nil, fmt.Errorf("query user totp %v: %w", err, storage.ErrInternal)
+	}
+
+	totp, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[storage.TOTP])
+	if err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			return nil, storage.ErrNoRecordsFound
+		default:
+			return nil, fmt.Errorf("collect row user totp %v: %w", err, storage.ErrInternal)
+		}
+	}
+
+	return &totp, nil
+}
+
+// ConfirmTOTP включает 2FA и заменяет коды восстановления.
+// step - шаг кода подтверждения, он не может быть использован для входа.
+func (s *dbStorage) ConfirmTOTP(ctx context.Context, userID string, step int64, recoveryCodes [][]byte) error {
+	tx, err := s.pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer func() {
+		if err := tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			s.log.Error("transaction confirm totp rollback", logger.Error(err))
+		}
+	}()
+
+	queryConfirm := `
+		UPDATE user_totp
+		SET
+			confirmed_at = CURRENT_TIMESTAMP,
+			last_used_step = @step
+		WHERE
+			user_id = @userID
+			AND confirmed_at IS NULL`
+
+	tag, err := tx.Exec(ctx, queryConfirm, pgx.NamedArgs{"userID": userID, "step": step})
+	if err != nil {
+		return fmt.Errorf("user_totp update confirmed_at %v: %w", err, storage.ErrInternal)
+	}
+	if tag.RowsAffected() == 0 {
+		return storage.ErrNoRecordsFound
+	}
+
+	queryDelete := `
+		DELETE FROM totp_recovery_codes
+		WHERE
+			user_id = @userID`
+
+	if _, err := tx.Exec(ctx, queryDelete, pgx.NamedArgs{"userID": userID}); err != nil {
+		return fmt.Errorf("totp_recovery_codes delete %v: %w", err, storage.ErrInternal)
+	}
+
+	queryInsert := `
+		INSERT INTO
+			totp_recovery_codes (code_hash, user_id)
+		SELECT
+			UNNEST(@codes::BYTEA[]),
+			@userID`
+
+	if _, err := tx.Exec(ctx, queryInsert, pgx.NamedArgs{"userID": userID, "codes": recoveryCodes}); err != nil {
+		return fmt.Errorf("totp_recovery_codes insert %v: %w", err, storage.ErrInternal)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("transaction confirm totp commit: %w", err)
+	}
+
+	return nil
+}
+
+// UseTOTPStep отмечает шаг использованным,
+// вернет ErrNoRecordsFound, если код этого или более позднего шага уже применялся
+func (s *dbStorage) UseTOTPStep(ctx context.Context, userID string, step int64) error {
+	query := `
+		UPDATE user_totp
+		SET
+			last_used_step = @step
+		WHERE
+			user_id = @userID
+			AND confirmed_at IS NOT NULL
+			AND last_used_step < @step`
+
+	tag, err := s.pool.Exec(ctx, query, pgx.NamedArgs{"userID": userID, "step": step})
+	if err != nil {
+		return fmt.Errorf("user_totp update last_used_step %v: %w", err, storage.ErrInternal)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return storage.ErrNoRecordsFound
+	}
+
+	return nil
+}
+
+// UseRecoveryCode погашает код восстановления,
+// вернет ErrNoRecordsFound, если код не найден или уже использован
+func (s *dbStorage) UseRecoveryCode(ctx context.Context, userID string, codeHash []byte) error {
+	query := `
+		UPDATE totp_recovery_codes
+		SET
+			used_at = CURRENT_TIMESTAMP
+		WHERE
+			code_hash = @codeHash
+			AND user_id = @userID
+			AND used_at IS NULL`
+
+	tag, err := s.pool.Exec(ctx, query, pgx.NamedArgs{"userID": userID, "codeHash": codeHash})
+	if err != nil {
+		return fmt.Errorf("totp_recovery_codes update used_at %v: %w", err, storage.ErrInternal)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return storage.ErrNoRecordsFound
+	}
+
+	return nil
+}
+
+func (s *dbStorage) CreateMFAChallenge(ctx context.Context, challenge storage.MFAChallenge) error {
+	query := `
+		INSERT INTO
+			mfa_challenges (token_hash, user_id, expires_at)
+		VALUES
+			(@tokenHash, @userID, @expiresAt)`
+
+	args := pgx.NamedArgs{
+		"tokenHash": challenge.TokenHash,
+		"userID":    challenge.UserID,
+		"expiresAt": challenge.ExpiresAt,
+	}
+
+	if _, err := s.pool.Exec(ctx, query, args); err != nil {
+		return fmt.Errorf("mfa_challenges insert %v: %w", err, storage.ErrInternal)
+	}
+
+	return nil
+}
+
+// MFAChallenge неиспользованный и неистекший токен второго шага входа
+func (s *dbStorage) MFAChallenge(ctx context.Context, tokenHash []byte) (*storage.MFAChallenge, error) {
+	query := `
+		SELECT
+			token_hash,
+			user_id,
+			expires_at,
+			attempts
+		FROM
+			mfa_challenges
+		WHERE
+			token_hash = @tokenHash
+			AND used_at IS NULL
+			AND expires_at > CURRENT_TIMESTAMP`
+
+	rows, err := s.pool.Query(ctx, query, pgx.NamedArgs{"tokenHash": tokenHash})
+	if err != nil {
+		return nil, fmt.Errorf("query mfa challenge %v: %w", err, storage.ErrInternal)
+	}
+
+	challenge, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[storage.MFAChallenge])
+	if err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			return nil, storage.ErrNoRecordsFound
+		default:
+			return nil, fmt.Errorf("collect row mfa challenge %v: %w", err, storage.ErrInternal)
+		}
+	}
+
+	return &challenge, nil
+}
+
+// FailMFAChallenge учитывает неверный код
+func (s *dbStorage) FailMFAChallenge(ctx context.Context, tokenHash []byte) error {
+	query := `
+		UPDATE mfa_challenges
+		SET
+			attempts = attempts + 1
+		WHERE
+			token_hash = @tokenHash`
+
+	if _, err := s.pool.Exec(ctx, query, pgx.NamedArgs{"tokenHash": tokenHash}); err != nil {
+		return fmt.Errorf("mfa_challenges update attempts %v: %w", err, storage.ErrInternal)
+	}
+
+	return nil
+}
+
+// CompleteMFAChallenge погашает токен второго шага,
+// вернет ErrNoRecordsFound, если он уже использован
+func (s *dbStorage) CompleteMFAChallenge(ctx context.Context, tokenHash []byte) error {
+	query := `
+		UPDATE mfa_challenges
+		SET
+			used_at = CURRENT_TIMESTAMP
+		WHERE
+			token_hash = @tokenHash
+			AND used_at IS NULL`
+
+	tag, err := s.pool.Exec(ctx, query, pgx.NamedArgs{"tokenHash": tokenHash})
+	if err != nil {
+		return fmt.Errorf("mfa_challenges update used_at %v: %w", err, storage.ErrInternal)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return storage.ErrNoRecordsFound
+	}
+
+	return nil
+}
@@ -4,6 +4,9 @@ import (
 	"context"
 	"errors"
 	"io"
+	"time"
+
+	"github.com/vladislav-kr/gophermart/internal/domain/money"
 )
 
 var (
@@ -14,18 +17,76 @@ var (
 
 	ErrAlreadyUploadedUser        = errors.New("already uploaded by user")
 	ErrAlreadyUploadedAnotherUser = errors.New("already uploaded by another user")
+
+	ErrWithdrawalExists = errors.New("withdrawal already exists")
+
+	ErrTokenReused  = errors.New("token reused")
+	ErrTokenExpired = errors.New("token expired")
 )
 
 type Storage interface {
 	CreateUser(ctx context.Context, login string, passwordHash []byte) (string, error)
 	User(ctx context.Context, login string) (*User, error)
+	UserByID(ctx context.Context, userID string) (*User, error)
+	RehashPassword(ctx context.Context, userID string, oldHash, newHash []byte) error
+	ChangePassword(ctx context.Context, userID string, passwordHash []byte) (int64, error)
+	CreatePasswordResetToken(ctx context.Context, token PasswordResetToken) error
+	ResetPassword(ctx context.Context, tokenHash, passwordHash []byte) (string, error)
+	SearchUsers(ctx context.Context, login string, limit uint32) ([]User, error)
+	SetUserBlocked(ctx context.Context, userID string, blocked bool) error
+	DeleteUser(ctx context.Context, userID string, forfeit bool) (money.Amount, error)
+	RecheckOrder(ctx context.Context, orderID string) error
+	AdjustBalance(ctx context.Context, adjustment BalanceAdjustment) (*Balance, error)
+	BalanceHistory(ctx context.Context, userID string) ([]BalanceEntry, error)
+	BalanceDrifts(ctx context.Context) ([]BalanceDrift, error)
+	CorrectBalanceDrift(ctx context.Context, userID, adminID string) (*BalanceDrift, error)
+	UsersWithExpiredPoints(ctx context.Context, now time.Time, limit uint32) ([]string, error)
+	ExpirePoints(ctx context.Context, userID string, now time.Time) (money.Amount, error)
+	ExpiringPoints(ctx context.Context, userID string, until time.Time) ([]ExpiringPoints, error)
+	LoginAttempts(ctx context.Context, key string) (*LoginAttempts, error)
+	IncrementLoginAttempts(ctx context.Context, key string, ttl time.Duration) (*LoginAttempts, error)
+	ResetLoginAttempts(ctx context.Context, key string) error
 	CreateOrder(ctx context.Context, userID string, order CreateOrder) error
+	CreateOrders(ctx context.Context, userID string, orderIDs []string) ([]OrderOwner, error)
 	Orders(ctx context.Context, userID string) ([]Order, error)
+	OrdersPage(ctx context.Context, filter OrdersFilter) ([]Order, error)
+	UserOrder(ctx context.Context, userID, orderID string) (*Order, error)
+	OrderEvents(ctx context.Context, orderID string) ([]OrderEvent, error)
 	UserBalance(ctx context.Context, userID string) (*Balance, error)
+	LedgerBalance(ctx context.Context, userID string, at time.Time) (*Balance, error)
 	Withdrawals(ctx context.Context, userID string) ([]WithdrawalsBonuses, error)
+	Withdrawal(ctx context.Context, userID, orderID string) (*WithdrawalsBonuses, error)
 	Withdraw(ctx context.Context, userID string, withdraw WithdrawBonuses) error
 	OrdersForUpdate(ctx context.Context, limit uint32) ([]UpdateOrderID, error)
 	BatchUpdateOrder(ctx context.Context, orders []UpdateOrder) error
+	CreateRefreshToken(ctx context.Context, token RefreshToken) error
+	RotateRefreshToken(ctx context.Context, tokenHash []byte, newToken RefreshToken) (*RefreshToken, error)
+	RevokeRefreshToken(ctx context.Context, userID string, tokenHash []byte) error
+	RevokeToken(ctx context.Context, token RevokedToken) error
+	TokenRevoked(ctx context.Context, tokenID string) (bool, error)
+	RevokeUserTokens(ctx context.Context, userID string) (int64, error)
+	SaveSession(ctx context.Context, session Session) error
+	Sessions(ctx context.Context, userID string) ([]Session, error)
+	TouchSession(ctx context.Context, sessionID string) error
+	RevokeSession(ctx context.Context, userID, sessionID string) error
+	BeginIdempotency(ctx context.Context, key IdempotencyKey, lockTimeout time.Duration) (*IdempotencyKey, error)
+	CompleteIdempotency(ctx context.Context, key IdempotencyKey) error
+	DeleteIdempotency(ctx context.Context, userID, key string) error
+	PurgeIdempotency(ctx context.Context, now time.Time, limit uint32) (int64, error)
+	CreateAPIKey(ctx context.Context, key CreateAPIKey) (*APIKey, error)
+	APIKeys(ctx context.Context, userID string) ([]APIKey, error)
+	APIKeyByHash(ctx context.Context, keyHash []byte) (*APIKey, error)
+	RevokeAPIKey(ctx context.Context, userID, keyID string) error
+	RecordAPIKeyUsage(ctx context.Context, keyID, ip string) error
+	SaveTOTPSecret(ctx context.Context, userID, secret string) error
+	TOTP(ctx context.Context, userID string) (*TOTP, error)
+	ConfirmTOTP(ctx context.Context, userID string, step int64, recoveryCodes [][]byte) error
+	UseTOTPStep(ctx context.Context, userID string, step int64) error
+	UseRecoveryCode(ctx context.Context, userID string, codeHash []byte) error
+	CreateMFAChallenge(ctx context.Context, challenge MFAChallenge) error
+	MFAChallenge(ctx context.Context, tokenHash []byte) (*MFAChallenge, error)
+	FailMFAChallenge(ctx context.Context, tokenHash []byte) error
+	CompleteMFAChallenge(ctx context.Context, tokenHash []byte) error
 	Ping(ctx context.Context) error
 	io.Closer
 }